@@ -0,0 +1,15 @@
+// Command staticsocket-vet runs the staticsocket analyzer as a vet tool:
+//
+//	go build -o staticsocket-vet ./cmd/staticsocket-vet
+//	go vet -vettool=$(pwd)/staticsocket-vet -staticsocket.type=ingress ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/yuvalk/staticsocket/pkg/staticsocketanalysis"
+)
+
+func main() {
+	unitchecker.Main(staticsocketanalysis.Analyzer)
+}
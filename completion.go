@@ -0,0 +1,291 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// fileFlags are completed with file names rather than fixed values.
+var fileFlags = map[string]bool{
+	"path":          true,
+	"output":        true,
+	"evidence":      true,
+	"lock-file":     true,
+	"manifest-file": true,
+}
+
+// subcommandArgs lists the arguments each subcommand taking a fixed one
+// accepts, for completion. diff is completed with file names.
+var subcommandArgs = map[string][]string{
+	"completion": {"bash", "zsh", "fish", "man"},
+	"manifest":   {"write", "verify"},
+	"patterns":   {"doc"},
+}
+
+// machineDefaults describes the defaults of flags that depend on the
+// machine, so that the man page is the same wherever it is built.
+var machineDefaults = map[string]string{
+	"workers": "the number of CPUs",
+}
+
+// flagValues lists the fixed values a flag accepts, for completion.
+func flagValues() map[string][]string {
+	return map[string][]string{
+		"format":   types.ExportFormats,
+		"symlinks": {"skip", "follow"},
+		"type":     {string(types.TrafficTypeIngress), string(types.TrafficTypeEgress)},
+		"layout":   layoutNames(),
+	}
+}
+
+func runCompletion(args []string, writer io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: staticsocket completion %s", strings.Join(subcommandArgs["completion"], "|"))
+	}
+
+	var opts options
+	fs := newFlagSet(&opts)
+
+	switch args[0] {
+	case "bash":
+		return writeBashCompletion(writer, fs)
+	case "zsh":
+		return writeZshCompletion(writer, fs)
+	case "fish":
+		return writeFishCompletion(writer, fs)
+	case "man":
+		return writeManPage(writer, fs)
+	default:
+		return fmt.Errorf("unsupported completion target: %s", args[0])
+	}
+}
+
+func sortedSubcommands() []string {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+func writeBashCompletion(writer io.Writer, fs *flag.FlagSet) error {
+	var words []string
+	var cases strings.Builder
+	values := flagValues()
+	fs.VisitAll(func(f *flag.Flag) {
+		words = append(words, "-"+f.Name)
+		switch {
+		case fileFlags[f.Name]:
+			fmt.Fprintf(&cases, "        -%s) COMPREPLY=( $(compgen -f -- \"$cur\") ); return ;;\n", f.Name)
+		case len(values[f.Name]) > 0:
+			fmt.Fprintf(&cases, "        -%s) COMPREPLY=( $(compgen -W %q -- \"$cur\") ); return ;;\n",
+				f.Name, strings.Join(values[f.Name], " "))
+		}
+	})
+
+	var commandCases strings.Builder
+	for _, name := range sortedSubcommands() {
+		if args := subcommandArgs[name]; len(args) > 0 {
+			fmt.Fprintf(&commandCases, "            %s) COMPREPLY=( $(compgen -W %q -- \"$cur\") ); return ;;\n",
+				name, strings.Join(args, " "))
+		}
+	}
+
+	_, err := fmt.Fprintf(writer, `# bash completion for staticsocket
+_staticsocket() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "${COMP_WORDS[1]}" in
+        diff) COMPREPLY=( $(compgen -f -- "$cur") ); return ;;
+        completion) [[ $COMP_CWORD -gt 2 ]] && return ;;
+    esac
+    if [[ $COMP_CWORD -eq 2 ]]; then
+        case "${COMP_WORDS[1]}" in
+%s        esac
+    fi
+
+    case "$prev" in
+%s    esac
+
+    if [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=( $(compgen -W %q -- "$cur") )
+        return
+    fi
+    COMPREPLY=( $(compgen -W %q -- "$cur") )
+}
+complete -F _staticsocket staticsocket
+`, commandCases.String(), cases.String(),
+		strings.Join(append(sortedSubcommands(), words...), " "),
+		strings.Join(words, " "))
+	return err
+}
+
+func writeZshCompletion(writer io.Writer, fs *flag.FlagSet) error {
+	var specs []string
+	values := flagValues()
+	fs.VisitAll(func(f *flag.Flag) {
+		spec := fmt.Sprintf("'-%s[%s]", f.Name, zshEscape(f.Usage))
+		switch {
+		case isBoolFlag(f):
+		case fileFlags[f.Name]:
+			spec += ":" + f.Name + ":_files"
+		case len(values[f.Name]) > 0:
+			spec += ":" + f.Name + ":(" + strings.Join(values[f.Name], " ") + ")"
+		default:
+			spec += ":" + f.Name + ":"
+		}
+		specs = append(specs, spec+"'")
+	})
+
+	var commands []string
+	var commandCases strings.Builder
+	for _, name := range sortedSubcommands() {
+		commands = append(commands, fmt.Sprintf("'%s:%s'", name, zshEscape(subcommands[name])))
+		if args := subcommandArgs[name]; len(args) > 0 {
+			fmt.Fprintf(&commandCases, "            %s) _values '%s argument' %s; return ;;\n", name, name, strings.Join(args, " "))
+		}
+	}
+
+	_, err := fmt.Fprintf(writer, `#compdef staticsocket
+
+_staticsocket() {
+    if [[ "${words[2]}" == "diff" ]]; then
+        _files
+        return
+    fi
+    if (( CURRENT == 3 )); then
+        case "${words[2]}" in
+%s        esac
+    fi
+    if (( CURRENT == 2 )) && [[ "${words[CURRENT]}" != -* ]]; then
+        local -a commands
+        commands=(%s)
+        _describe 'command' commands
+        return
+    fi
+    _arguments \
+        %s
+}
+
+_staticsocket "$@"
+`, commandCases.String(), strings.Join(commands, " "), strings.Join(specs, " \\\n        "))
+	return err
+}
+
+func writeFishCompletion(writer io.Writer, fs *flag.FlagSet) error {
+	var b strings.Builder
+	b.WriteString("# fish completion for staticsocket\n")
+	for _, name := range sortedSubcommands() {
+		fmt.Fprintf(&b, "complete -c staticsocket -n '__fish_use_subcommand' -f -a %s -d '%s'\n",
+			name, fishEscape(subcommands[name]))
+	}
+	for _, name := range sortedSubcommands() {
+		if args := subcommandArgs[name]; len(args) > 0 {
+			fmt.Fprintf(&b, "complete -c staticsocket -n '__fish_seen_subcommand_from %s' -f -a '%s'\n", name, strings.Join(args, " "))
+		}
+	}
+	b.WriteString("complete -c staticsocket -n '__fish_seen_subcommand_from diff' -F\n")
+
+	values := flagValues()
+	fs.VisitAll(func(f *flag.Flag) {
+		line := fmt.Sprintf("complete -c staticsocket -o %s -d '%s'", f.Name, fishEscape(f.Usage))
+		switch {
+		case isBoolFlag(f):
+		case fileFlags[f.Name]:
+			line += " -r -F"
+		case len(values[f.Name]) > 0:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(values[f.Name], " "))
+		default:
+			line += " -x"
+		}
+		b.WriteString(line + "\n")
+	})
+
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// manPageDate dates the man page from SOURCE_DATE_EPOCH, as reproducible
+// builds set it, and leaves the date out otherwise.
+func manPageDate() (string, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return "", nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC().Format("2006-01-02"), nil
+}
+
+func writeManPage(writer io.Writer, fs *flag.FlagSet) error {
+	date, err := manPageDate()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH STATICSOCKET 1 %q \"staticsocket\" \"User Commands\"\n", date)
+	b.WriteString(".SH NAME\nstaticsocket \\- identify socket creation patterns in Go source code\n")
+	b.WriteString(".SH SYNOPSIS\n.B staticsocket\n[\\fIoptions\\fR]\n.br\n.B staticsocket\n\\fIcommand\\fR [\\fIargs\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\nStatically analyzes Go source files, classifies the sockets they create as ingress or egress traffic, ")
+	b.WriteString("and exports the findings with their hosts, ports, and protocols.\n")
+
+	b.WriteString(".SH OPTIONS\n")
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(&b, ".TP\n.BR \\-%s", manEscape(f.Name))
+		if name != "" {
+			fmt.Fprintf(&b, " \" \" \\fI%s\\fR", manEscape(name))
+		}
+		b.WriteString("\n" + manEscape(usage))
+		switch {
+		case machineDefaults[f.Name] != "":
+			fmt.Fprintf(&b, " (default: %s)", manEscape(machineDefaults[f.Name]))
+		case f.DefValue != "" && !isBoolFlag(f):
+			fmt.Fprintf(&b, " (default %q)", f.DefValue)
+		}
+		b.WriteString("\n")
+	})
+
+	b.WriteString(".SH COMMANDS\n")
+	for _, name := range sortedSubcommands() {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", name, manEscape(subcommands[name]))
+	}
+
+	_, err = io.WriteString(writer, b.String())
+	return err
+}
+
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace(s)
+}
+
+func fishEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s)
+}
+
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
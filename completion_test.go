@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func completion(t *testing.T, target string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := runCompletion([]string{target}, &out); err != nil {
+		t.Fatalf("completion %s: %v", target, err)
+	}
+	return out.String()
+}
+
+func TestCompletion_SubcommandArguments(t *testing.T) {
+	for target, want := range map[string][]string{
+		"bash": {`manifest) COMPREPLY=( $(compgen -W "write verify" -- "$cur") )`, `patterns) COMPREPLY=( $(compgen -W "doc" -- "$cur") )`, `diff) COMPREPLY=( $(compgen -f -- "$cur") )`},
+		"zsh":  {"manifest) _values 'manifest argument' write verify", "patterns) _values 'patterns argument' doc", "_files"},
+		"fish": {"__fish_seen_subcommand_from manifest' -f -a 'write verify'", "__fish_seen_subcommand_from patterns' -f -a 'doc'", "__fish_seen_subcommand_from diff' -F"},
+	} {
+		script := completion(t, target)
+		for _, line := range want {
+			if !strings.Contains(script, line) {
+				t.Errorf("Expected the %s script to contain %q", target, line)
+			}
+		}
+	}
+}
+
+func TestCompletion_ManPageReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	page := completion(t, "man")
+	if !strings.HasPrefix(page, `.TH STATICSOCKET 1 "2023-11-14" "staticsocket" "User Commands"`) {
+		t.Errorf("Expected the page dated from SOURCE_DATE_EPOCH, got %q", strings.SplitN(page, "\n", 2)[0])
+	}
+	if !strings.Contains(page, "(default: the number of CPUs)") {
+		t.Error("Expected the machine-dependent -workers default described rather than printed")
+	}
+
+	os.Unsetenv("SOURCE_DATE_EPOCH")
+	if undated := completion(t, "man"); !strings.HasPrefix(undated, `.TH STATICSOCKET 1 "" `) || undated != completion(t, "man") {
+		t.Error("Expected the same undated page on every run without SOURCE_DATE_EPOCH")
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if err := runCompletion([]string{"man"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected an invalid SOURCE_DATE_EPOCH to be an error")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yuvalk/staticsocket/pkg/diff"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// runDiff compares two saved JSON or YAML results and lists the findings
+// added, removed and changed between them. It fails when there are any.
+func runDiff(args []string, output io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("usage: staticsocket diff OLD NEW")
+	}
+	old, err := readResults(args[0])
+	if err != nil {
+		return err
+	}
+	current, err := readResults(args[1])
+	if err != nil {
+		return err
+	}
+
+	result := diff.Compare(old, current)
+	if result.Empty() {
+		return nil
+	}
+	for _, socket := range result.Added {
+		fmt.Fprintf(output, "+ %s\n", diff.String(socket))
+	}
+	for _, socket := range result.Removed {
+		fmt.Fprintf(output, "- %s\n", diff.String(socket))
+	}
+	for _, change := range result.Changed {
+		fmt.Fprintf(output, "~ %s [%s]\n", diff.String(change.New), strings.Join(change.Fields, ", "))
+	}
+	return fmt.Errorf("%d added, %d removed, %d changed", len(result.Added), len(result.Removed), len(result.Changed))
+}
+
+func readResults(path string) (*types.AnalysisResults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading results: %w", err)
+	}
+	var results types.AnalysisResults
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &results)
+	default:
+		err = json.Unmarshal(data, &results)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing results %s: %w", path, err)
+	}
+	return &results, nil
+}
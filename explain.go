@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/analyzer"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// runExplain prints how the calls on the line named by target, FILE:LINE,
+// are matched and resolved.
+func runExplain(target string, a *analyzer.Analyzer, w io.Writer) error {
+	colon := strings.LastIndex(target, ":")
+	if colon <= 0 {
+		return fmt.Errorf("invalid -explain value %q: expected FILE:LINE", target)
+	}
+	line, err := strconv.Atoi(target[colon+1:])
+	if err != nil || line <= 0 {
+		return fmt.Errorf("invalid -explain value %q: expected FILE:LINE", target)
+	}
+	filePath := target[:colon]
+
+	explanations, err := a.Explain(filePath, line)
+	if err != nil {
+		return err
+	}
+	if len(explanations) == 0 {
+		fmt.Fprintf(w, "%s:%d: no calls\n", filePath, line)
+		return nil
+	}
+	for _, explanation := range explanations {
+		fmt.Fprintf(w, "%s:%d: %s\n", filePath, line, explanation.Node)
+		if explanation.Pattern != "" {
+			fmt.Fprintf(w, "  matched: %s\n", explanation.Pattern)
+		}
+		if explanation.Reason != "" {
+			fmt.Fprintf(w, "  not reported: %s\n", explanation.Reason)
+		}
+		for _, step := range explanation.Resolution {
+			fmt.Fprintf(w, "  tried: %s\n", step)
+		}
+		for _, finding := range explanation.Findings {
+			fmt.Fprintf(w, "  finding: %s\n", describeFinding(finding.Socket))
+		}
+	}
+	return nil
+}
+
+func describeFinding(socket *types.SocketInfo) string {
+	description := fmt.Sprintf("%s %s %s", socket.Type, socket.Protocol, socket.EndpointName())
+	if !socket.IsResolved {
+		description += " (unresolved)"
+	}
+	if socket.FunctionName != "" && socket.FunctionName != "unknown" {
+		description += " in " + socket.FunctionName
+	}
+	return description
+}
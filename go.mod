@@ -3,3 +3,9 @@ module github.com/yuvalk/staticsocket
 go 1.24.6
 
 require gopkg.in/yaml.v3 v3.0.1
+
+require (
+	golang.org/x/mod v0.33.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+	golang.org/x/tools v0.42.0
+)
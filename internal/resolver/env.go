@@ -0,0 +1,212 @@
+package resolver
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	socketTypes "github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// envRead is an environment variable read with os.Getenv and the literal
+// a function falls back to when it is unset.
+type envRead struct {
+	name         string
+	defaultValue string
+	hasDefault   bool
+}
+
+// resolveEnv resolves an address read from an environment variable, as in
+//
+//	port := os.Getenv("METRICS_PORT")
+//	if port == "" {
+//		port = "9090"
+//	}
+//	net.Listen("tcp", ":"+port)
+//
+// to the address the literal default gives, reporting the variable and
+// its default. An address from a variable without a default is left
+// unresolved, but names the variable. Addresses reading more than one
+// variable are left to the other strategies.
+func (r *ValueResolver) resolveEnv(socket *socketTypes.SocketInfo, expr ast.Expr, file *ast.File) bool {
+	osName := importName(file, "os")
+	body := enclosingBody(file, expr.Pos())
+	if osName == "" || body == nil {
+		return false
+	}
+
+	var reads []envRead
+	value, ok := r.fold(expr, func(name ast.Expr) (string, bool) {
+		read, isEnv := envReadOf(name, osName, body, file)
+		if !isEnv {
+			ident, isIdent := name.(*ast.Ident)
+			if isIdent && isLocal(ident, file) {
+				return "", false
+			}
+			value := r.resolveGlobal(name, file, 0)
+			return value, value != ""
+		}
+		reads = append(reads, read)
+		return read.defaultValue, read.hasDefault
+	})
+	if len(reads) != 1 {
+		return false
+	}
+	socket.EnvVar = reads[0].name
+	if !ok {
+		return false
+	}
+	socket.DefaultValue = reads[0].defaultValue
+	r.setResolvedValue(socket, value)
+	return true
+}
+
+// envReadOf reports whether expr reads an environment variable: a call of
+// os.Getenv, or a local variable holding one and, in the same function,
+// given a literal default when empty.
+func envReadOf(expr ast.Expr, osName string, body *ast.BlockStmt, file *ast.File) (envRead, bool) {
+	if name, ok := getenvName(expr, osName); ok {
+		return envRead{name: name}, true
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok || !isLocal(ident, file) {
+		return envRead{}, false
+	}
+
+	// The last assignment before the use must be the read, or the default
+	// given in an emptiness check of the variable right after it
+	var read envRead
+	isEnv := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil || n.Pos() >= ident.Pos() {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.IfStmt:
+			if isEnv && !read.hasDefault && isEmptyCheck(node.Cond, ident.Obj) && node.Else == nil {
+				if value, ok := defaultAssigned(node.Body, ident.Obj); ok {
+					read.defaultValue, read.hasDefault = value, true
+					return false
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if assignedIdent, ok := lhs.(*ast.Ident); !ok || assignedIdent.Obj != ident.Obj {
+					continue
+				}
+				read, isEnv = envRead{}, false
+				if len(node.Lhs) == len(node.Rhs) && (node.Tok == token.DEFINE || node.Tok == token.ASSIGN) {
+					read.name, isEnv = getenvName(node.Rhs[i], osName)
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if name.Obj != ident.Obj {
+					continue
+				}
+				read, isEnv = envRead{}, false
+				if len(node.Values) == len(node.Names) {
+					read.name, isEnv = getenvName(node.Values[i], osName)
+				}
+			}
+		}
+		return true
+	})
+	return read, isEnv
+}
+
+// getenvName returns the variable a call of os.Getenv with a literal
+// argument reads.
+func getenvName(expr ast.Expr, osName string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Getenv" {
+		return "", false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != osName || pkg.Obj != nil {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	name, err := strconv.Unquote(lit.Value)
+	return name, err == nil && name != ""
+}
+
+// isEmptyCheck reports whether cond is v == "" or len(v) == 0 for the
+// variable obj.
+func isEmptyCheck(cond ast.Expr, obj *ast.Object) bool {
+	binary, ok := cond.(*ast.BinaryExpr)
+	if !ok || binary.Op != token.EQL {
+		return false
+	}
+	for _, operands := range [][2]ast.Expr{{binary.X, binary.Y}, {binary.Y, binary.X}} {
+		variable, other := operands[0], operands[1]
+		if call, ok := variable.(*ast.CallExpr); ok && len(call.Args) == 1 {
+			if fun, ok := call.Fun.(*ast.Ident); ok && fun.Name == "len" && fun.Obj == nil {
+				if lit, ok := other.(*ast.BasicLit); ok && lit.Kind == token.INT && lit.Value == "0" {
+					variable, other = call.Args[0], &ast.BasicLit{Kind: token.STRING, Value: `""`}
+				}
+			}
+		}
+		ident, ok := variable.(*ast.Ident)
+		if !ok || ident.Obj != obj {
+			continue
+		}
+		if lit, ok := other.(*ast.BasicLit); ok && lit.Kind == token.STRING && (lit.Value == `""` || lit.Value == "``") {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultAssigned returns the string literal body assigns obj, when that
+// is all it assigns it.
+func defaultAssigned(body *ast.BlockStmt, obj *ast.Object) (string, bool) {
+	value, found := "", false
+	for _, stmt := range body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != len(assign.Rhs) {
+			continue
+		}
+		for i, lhs := range assign.Lhs {
+			if ident, ok := lhs.(*ast.Ident); !ok || ident.Obj != obj {
+				continue
+			}
+			lit, ok := assign.Rhs[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return "", false
+			}
+			unquoted, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return "", false
+			}
+			value, found = unquoted, true
+		}
+	}
+	return value, found
+}
+
+// importName returns the name file refers to the package importPath by,
+// or "" when it does not import it.
+func importName(file *ast.File, importPath string) string {
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err != nil || path != importPath {
+			continue
+		}
+		if spec.Name == nil {
+			return importPath[strings.LastIndex(importPath, "/")+1:]
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return ""
+		}
+		return spec.Name.Name
+	}
+	return ""
+}
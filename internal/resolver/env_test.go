@@ -0,0 +1,98 @@
+package resolver
+
+import "testing"
+
+func TestValueResolver_EnvDefault(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         string
+		resolved     bool
+		rawValue     string
+		envVar       string
+		defaultValue string
+	}{
+		{
+			name: "empty check",
+			code: `package main
+import "os"
+func connect() {
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = "5432"
+	}
+	net.Dial("tcp", "db:"+port)
+}`,
+			resolved: true, rawValue: "db:5432", envVar: "DB_PORT", defaultValue: "5432",
+		},
+		{
+			name: "length check",
+			code: `package main
+import "os"
+func connect() {
+	var addr = os.Getenv("DB_ADDR")
+	if len(addr) == 0 {
+		addr = "db:5432"
+	}
+	net.Dial("tcp", addr)
+}`,
+			resolved: true, rawValue: "db:5432", envVar: "DB_ADDR", defaultValue: "db:5432",
+		},
+		{
+			name: "renamed import",
+			code: `package main
+import sys "os"
+func connect() {
+	host := sys.Getenv("DB_HOST")
+	if "" == host {
+		host = "db"
+	}
+	net.Dial("tcp", host+":5432")
+}`,
+			resolved: true, rawValue: "db:5432", envVar: "DB_HOST", defaultValue: "db",
+		},
+		{
+			name: "no default",
+			code: `package main
+import "os"
+func connect() {
+	net.Dial("tcp", os.Getenv("DB_ADDR"))
+}`,
+			envVar: "DB_ADDR", rawValue: `os.Getenv("DB_ADDR")`,
+		},
+		{
+			name: "overwritten",
+			code: `package main
+import "os"
+func connect(override string) {
+	addr := os.Getenv("DB_ADDR")
+	if addr == "" {
+		addr = "db:5432"
+	}
+	addr = override
+	net.Dial("tcp", addr)
+}`,
+			// left to the local variable strategy
+			resolved: true, rawValue: "db:5432",
+		},
+		{
+			name: "not os",
+			code: `package main
+import "example.com/os"
+func connect() {
+	net.Dial("tcp", os.Getenv("DB_ADDR"))
+}`,
+			rawValue: `os.Getenv("DB_ADDR")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socket := resolveDial(t, tt.code)
+			if socket.IsResolved != tt.resolved || socket.RawValue != tt.rawValue {
+				t.Errorf("Expected resolved %t to %q, got %t to %q", tt.resolved, tt.rawValue, socket.IsResolved, socket.RawValue)
+			}
+			if socket.EnvVar != tt.envVar || socket.DefaultValue != tt.defaultValue {
+				t.Errorf("Expected env var %q with default %q, got %q with %q", tt.envVar, tt.defaultValue, socket.EnvVar, socket.DefaultValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package resolver
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	socketTypes "github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// maxLocalDepth bounds how far a value is chased through variables
+// assigned from other variables.
+const maxLocalDepth = 8
+
+// assignment is one value given to a variable or returned by a function,
+// in source order. value is only set when known is. callSite is the call
+// passing a parameter its value.
+type assignment struct {
+	expr     ast.Expr
+	value    string
+	known    bool
+	callSite token.Pos
+}
+
+// isLocal reports whether ident refers to a variable declared in a
+// function, including parameters and named results.
+func isLocal(ident *ast.Ident, file *ast.File) bool {
+	return ident.Obj != nil && ident.Obj.Kind == ast.Var && file.Scope.Lookup(ident.Name) != ident.Obj
+}
+
+// resolveLocal resolves a local variable to the last statically known
+// value assigned to it before it is used, as in addr := defaultAddr followed by
+// addr = override. Earlier known values become candidate values, and
+// assignments that cannot be resolved are noted, since any of them may be
+// what reaches the call. A parameter starts out with the argument of the
+// helper's only call site, whose position is returned when that argument
+// is the value reported.
+func (r *ValueResolver) resolveLocal(socket *socketTypes.SocketInfo, ident *ast.Ident, file *ast.File) (token.Pos, bool) {
+	body := enclosingBody(file, ident.Pos())
+	if body == nil {
+		return token.NoPos, false
+	}
+	values := r.assignments(ident.Obj, body, ident.Pos(), file, 0)
+	if !r.applyAssignments(socket, ident.Name, values) {
+		return token.NoPos, false
+	}
+	return values[lastKnown(values)].callSite, true
+}
+
+// resolveReturn resolves a call to a function declared in file from the
+// values it returns: explicit return values, or for bare returns what the
+// named result is assigned before them.
+func (r *ValueResolver) resolveReturn(socket *socketTypes.SocketInfo, ident *ast.Ident, file *ast.File) bool {
+	if ident.Obj == nil || ident.Obj.Kind != ast.Fun {
+		return false
+	}
+	funcDecl, ok := ident.Obj.Decl.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil || funcDecl.Type.Results == nil || funcDecl.Type.Results.NumFields() != 1 {
+		return false
+	}
+	var result *ast.Object
+	if names := funcDecl.Type.Results.List[0].Names; len(names) == 1 {
+		result = names[0].Obj
+	}
+
+	var returned []assignment
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			// Returns of a closure are not the function's
+			return false
+		case *ast.ReturnStmt:
+			if r.stopped() {
+				return false
+			}
+			switch {
+			case len(node.Results) == 1:
+				returned = append(returned, r.assigned(node.Results[0], funcDecl.Body, file, 0))
+			case len(node.Results) == 0 && result != nil:
+				returned = append(returned, r.assignments(result, funcDecl.Body, node.Pos(), file, 0)...)
+			}
+		}
+		return true
+	})
+	return r.applyAssignments(socket, ident.Name+"()", returned)
+}
+
+// applyAssignments resolves socket to the last known of the values, if
+// any, keeping the others as candidates and notes.
+func (r *ValueResolver) applyAssignments(socket *socketTypes.SocketInfo, name string, values []assignment) bool {
+	last := lastKnown(values)
+	if last < 0 {
+		return false
+	}
+	r.setResolvedValue(socket, values[last].value)
+
+	seen := map[string]bool{values[last].value: true}
+	for i, value := range values {
+		switch {
+		case !value.known:
+			socket.Notes = append(socket.Notes, socketTypes.Message(socketTypes.MsgLocalMayAlsoBe, "Name", name, "Expr", types.ExprString(value.expr)))
+		case i < last && !seen[value.value]:
+			seen[value.value] = true
+			socket.CandidateValues = append(socket.CandidateValues, value.value)
+		}
+	}
+	return true
+}
+
+func lastKnown(values []assignment) int {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i].known {
+			return i
+		}
+	}
+	return -1
+}
+
+// assignments returns what the variable obj is assigned in body before
+// pos, in source order. Declarations without a value give the zero value
+// and are left out.
+func (r *ValueResolver) assignments(obj *ast.Object, body *ast.BlockStmt, pos token.Pos, file *ast.File, depth int) []assignment {
+	var assigned []assignment
+	if argument, ok := r.argument(obj, file, depth); ok {
+		assigned = append(assigned, argument)
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil || n.Pos() >= pos || r.stopped() {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.End() > pos {
+				return true
+			}
+			for i, lhs := range node.Lhs {
+				if ident, ok := lhs.(*ast.Ident); !ok || ident.Obj != obj {
+					continue
+				}
+				switch {
+				case node.Tok != token.DEFINE && node.Tok != token.ASSIGN:
+					// addr += suffix
+					assigned = append(assigned, assignment{expr: node.Rhs[0]})
+				case len(node.Lhs) == len(node.Rhs):
+					assigned = append(assigned, r.assigned(node.Rhs[i], body, file, depth))
+				default:
+					// addr, err := lookup()
+					assigned = append(assigned, assignment{expr: node.Rhs[0]})
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range node.Names {
+				if name.Obj != obj || len(node.Values) == 0 {
+					continue
+				}
+				if len(node.Values) == len(node.Names) {
+					assigned = append(assigned, r.assigned(node.Values[i], body, file, depth))
+				} else {
+					assigned = append(assigned, assignment{expr: node.Values[0]})
+				}
+			}
+		}
+		return true
+	})
+	return assigned
+}
+
+// assigned evaluates a value assigned in body: literals, constants,
+// other local variables, by their last known value, and calls of
+// functions returning a constant.
+func (r *ValueResolver) assigned(expr ast.Expr, body *ast.BlockStmt, file *ast.File, depth int) assignment {
+	value, known := r.fold(expr, func(name ast.Expr) (string, bool) {
+		if call, ok := name.(*ast.CallExpr); ok {
+			return r.returnedConstant(call, file)
+		}
+		ident, ok := name.(*ast.Ident)
+		if !ok || !isLocal(ident, file) {
+			value := r.resolveGlobal(name, file, 0)
+			return value, value != ""
+		}
+		if depth >= maxLocalDepth || r.stopped() {
+			return "", false
+		}
+		values := r.assignments(ident.Obj, body, ident.Pos(), file, depth+1)
+		if last := lastKnown(values); last >= 0 {
+			return values[last].value, true
+		}
+		return "", false
+	})
+	return assignment{expr: expr, value: value, known: known}
+}
+
+// returnedConstant returns what a call to a function declared in file
+// returns, when the function has a single return statement of a literal
+// or constant, as in serviceURL := getServiceURL().
+func (r *ValueResolver) returnedConstant(call *ast.CallExpr, file *ast.File) (string, bool) {
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok || ident.Obj == nil || ident.Obj.Kind != ast.Fun || r.stopped() {
+		return "", false
+	}
+	funcDecl, ok := ident.Obj.Decl.(*ast.FuncDecl)
+	if !ok || funcDecl.Body == nil || funcDecl.Type.Results.NumFields() != 1 {
+		return "", false
+	}
+
+	var returns []*ast.ReturnStmt
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			returns = append(returns, node)
+		}
+		return true
+	})
+	if len(returns) != 1 || len(returns[0].Results) != 1 {
+		return "", false
+	}
+	return r.foldString(returns[0].Results[0], file)
+}
+
+// argument returns the value a parameter is passed, when obj is a
+// parameter of a function declared in file with exactly one call site and
+// no other uses, as helpers such as connect(addr string) usually are.
+func (r *ValueResolver) argument(obj *ast.Object, file *ast.File, depth int) (assignment, bool) {
+	field, ok := obj.Decl.(*ast.Field)
+	if !ok || depth >= maxLocalDepth || r.stopped() {
+		return assignment{}, false
+	}
+	if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+		return assignment{}, false
+	}
+	funcDecl, index := parameterOf(file, obj)
+	if funcDecl == nil {
+		return assignment{}, false
+	}
+
+	var call *ast.CallExpr
+	uses := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.Ident:
+			if node.Obj == funcDecl.Name.Obj && node != funcDecl.Name {
+				uses++
+			}
+		case *ast.CallExpr:
+			if ident, ok := node.Fun.(*ast.Ident); ok && ident.Obj == funcDecl.Name.Obj {
+				call = node
+			}
+		}
+		return true
+	})
+	if uses != 1 || call == nil || call.Ellipsis.IsValid() || index >= len(call.Args) {
+		return assignment{}, false
+	}
+	callerBody := enclosingBody(file, call.Pos())
+	if callerBody == nil {
+		return assignment{}, false
+	}
+	argument := r.assigned(call.Args[index], callerBody, file, depth+1)
+	argument.callSite = call.Pos()
+	return argument, true
+}
+
+// parameterOf returns the function declaration of file, other than a
+// method, that has obj as a parameter, and its index.
+func parameterOf(file *ast.File, obj *ast.Object) (*ast.FuncDecl, int) {
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv != nil || funcDecl.Name.Obj == nil {
+			continue
+		}
+		index := 0
+		for _, field := range funcDecl.Type.Params.List {
+			for _, name := range field.Names {
+				if name.Obj == obj {
+					return funcDecl, index
+				}
+				index++
+			}
+		}
+	}
+	return nil, 0
+}
+
+// enclosingBody returns the body of the function declaration containing
+// pos.
+func enclosingBody(file *ast.File, pos token.Pos) *ast.BlockStmt {
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil &&
+			funcDecl.Body.Pos() <= pos && pos < funcDecl.Body.End() {
+			return funcDecl.Body
+		}
+	}
+	return nil
+}
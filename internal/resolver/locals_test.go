@@ -0,0 +1,396 @@
+package resolver
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// parseDial parses code and finds its net.Dial call.
+func parseDial(t *testing.T, code string) (*ast.File, *ast.CallExpr) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call *ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok {
+			if sel, ok := c.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Dial" {
+				call = c
+			}
+		}
+		return true
+	})
+	if call == nil {
+		t.Fatal("no net.Dial call")
+	}
+	return file, call
+}
+
+// resolveDial resolves the net.Dial call in code.
+func resolveDial(t *testing.T, code string) *types.SocketInfo {
+	t.Helper()
+	file, call := parseDial(t, code)
+	socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+	New().ResolveValues(socket, call, file)
+	return socket
+}
+
+func TestValueResolver_LocalReassignment(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		want       string
+		candidates []string
+		notes      int
+	}{
+		{
+			name: "override",
+			code: `package main
+const defaultAddr = "db:5432"
+func connect(useReplica bool) {
+	addr := defaultAddr
+	if useReplica {
+		addr = "replica:5432"
+	}
+	net.Dial("tcp", addr)
+}`,
+			want:       "replica:5432",
+			candidates: []string{"db:5432"},
+		},
+		{
+			name: "dynamic override",
+			code: `package main
+func connect() {
+	addr := "db:5432"
+	if env := os.Getenv("DB_ADDR"); env != "" {
+		addr = env
+	}
+	net.Dial("tcp", addr)
+}`,
+			want:  "db:5432",
+			notes: 1,
+		},
+		{
+			name: "through another variable",
+			code: `package main
+func connect() {
+	host := "cache"
+	var addr string
+	addr = host + ":6379"
+	net.Dial("tcp", addr)
+	addr = "later:1"
+}`,
+			want: "cache:6379",
+		},
+		{
+			name: "shadowed package constant",
+			code: `package main
+const addr = "global:1"
+func connect() {
+	addr := "local:2"
+	net.Dial("tcp", addr)
+}`,
+			want: "local:2",
+		},
+		{
+			name: "named result",
+			code: `package main
+func dbAddr(replica bool) (addr string) {
+	addr = "db:5432"
+	if replica {
+		addr = "replica:5432"
+	}
+	return
+}
+func connect() {
+	net.Dial("tcp", dbAddr(true))
+}`,
+			want:       "replica:5432",
+			candidates: []string{"db:5432"},
+		},
+		{
+			name: "explicit returns",
+			code: `package main
+func dbAddr(replica bool) string {
+	if replica {
+		return "replica:5432"
+	}
+	return "db:5432"
+}
+func connect() {
+	net.Dial("tcp", dbAddr(false))
+}`,
+			want:       "db:5432",
+			candidates: []string{"replica:5432"},
+		},
+		{
+			name: "assigned from a call",
+			code: `package main
+const port = "5432"
+func dbAddr() string {
+	return "db:" + port
+}
+func connect() {
+	addr := dbAddr()
+	net.Dial("tcp", addr)
+}`,
+			want: "db:5432",
+		},
+		{
+			name: "assigned from a call of several returns",
+			code: `package main
+func dbAddr(replica bool) string {
+	if replica {
+		return "replica:5432"
+	}
+	return "db:5432"
+}
+func connect() {
+	addr := dbAddr(true)
+	net.Dial("tcp", addr)
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socket := resolveDial(t, tt.code)
+			if tt.want == "" {
+				if socket.IsResolved {
+					t.Fatalf("Expected the value unresolved, got %q", socket.RawValue)
+				}
+				return
+			}
+			if !socket.IsResolved || socket.RawValue != tt.want {
+				t.Fatalf("Expected %q, got %q (resolved %t)", tt.want, socket.RawValue, socket.IsResolved)
+			}
+			if !reflect.DeepEqual(socket.CandidateValues, tt.candidates) {
+				t.Errorf("Expected candidates %v, got %v", tt.candidates, socket.CandidateValues)
+			}
+			if len(socket.Notes) != tt.notes {
+				t.Errorf("Expected %d notes, got %v", tt.notes, socket.Notes)
+			}
+		})
+	}
+}
+
+func TestValueResolver_LocalUnknown(t *testing.T) {
+	socket := resolveDial(t, `package main
+const addr = "global:1"
+func connect(addr string) {
+	net.Dial("tcp", addr)
+}`)
+	if socket.IsResolved || socket.RawValue != "addr" {
+		t.Errorf("Expected the parameter unresolved, got %q (resolved %t)", socket.RawValue, socket.IsResolved)
+	}
+}
+
+func TestValueResolver_LocalListenAddress(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", `package main
+func serve(port string) {
+	addr := ":8080"
+	if port != "" {
+		addr = ":" + port
+	}
+	http.ListenAndServe(addr, nil)
+}`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call *ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok {
+			call = c
+		}
+		return call == nil
+	})
+	socket := &types.SocketInfo{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, PatternMatch: "http.ListenAndServe"}
+	New().ResolveValues(socket, call, file)
+
+	if !socket.IsResolved || socket.Listen == nil || *socket.Listen.Port != 8080 {
+		t.Errorf("Expected port 8080, got %+v", socket.Listen)
+	}
+	if len(socket.Notes) != 1 || socket.Notes[0] != `addr may also be ":" + port` {
+		t.Errorf("Expected a note on the dynamic override, got %v", socket.Notes)
+	}
+}
+
+func TestValueResolver_SingleCallerParameter(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		want     string
+		callSite bool
+	}{
+		{
+			name: "literal argument",
+			code: `package main
+func connect(addr string) {
+	net.Dial("tcp", addr)
+}
+func main() {
+	connect("db:5432")
+}`,
+			want:     "db:5432",
+			callSite: true,
+		},
+		{
+			name: "local argument",
+			code: `package main
+func connect(network, addr string) {
+	net.Dial(network, addr)
+}
+func main() {
+	host := "cache"
+	connect("tcp", host+":6379")
+}`,
+			want:     "cache:6379",
+			callSite: true,
+		},
+		{
+			name: "reassigned in the helper",
+			code: `package main
+func connect(addr string) {
+	if addr == "" {
+		addr = "localhost:5432"
+	}
+	net.Dial("tcp", addr)
+}
+func main() {
+	connect("db:5432")
+}`,
+			want: "localhost:5432",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, call := parseDial(t, tt.code)
+			socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+			callSite := New().ResolveValues(socket, call, file)
+			if !socket.IsResolved || socket.RawValue != tt.want {
+				t.Fatalf("Expected %q, got %q (resolved %t)", tt.want, socket.RawValue, socket.IsResolved)
+			}
+			if callSite.IsValid() != tt.callSite {
+				t.Errorf("Expected call site %t, got %v", tt.callSite, callSite)
+			}
+		})
+	}
+}
+
+func TestValueResolver_SeveralCallers(t *testing.T) {
+	for name, code := range map[string]string{
+		"two call sites": `package main
+func connect(addr string) {
+	net.Dial("tcp", addr)
+}
+func main() {
+	connect("db:5432")
+	connect("cache:6379")
+}`,
+		"passed as a value": `package main
+func connect(addr string) {
+	net.Dial("tcp", addr)
+}
+func main() {
+	connect("db:5432")
+	retry(connect)
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			socket := resolveDial(t, code)
+			if socket.IsResolved {
+				t.Errorf("Expected the parameter unresolved, got %q", socket.RawValue)
+			}
+		})
+	}
+}
+
+func TestValueResolver_SchemeAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		protocol types.Protocol
+		want     string
+	}{
+		{name: "tcp", value: "tcp://db.internal:5432", protocol: types.ProtocolTCP, want: "db.internal:5432"},
+		{name: "udp", value: "udp://collector:8125", protocol: types.ProtocolUDP, want: "collector:8125"},
+		{name: "unix", value: "unix:///var/run/agent.sock", protocol: types.ProtocolUnix, want: "/var/run/agent.sock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, code := range []string{
+				"package main\nconst addr = \"" + tt.value + "\"\nfunc connect() { net.Dial(\"tcp\", addr) }",
+				"package main\nfunc connect() {\n\taddr := \"" + tt.value + "\"\n\tnet.Dial(\"tcp\", addr)\n}",
+			} {
+				socket := resolveDial(t, code)
+				if !socket.IsResolved || socket.Protocol != tt.protocol || socket.Destination.String() != tt.want {
+					t.Errorf("Expected %s %s, got %s %s", tt.protocol, tt.want, socket.Protocol, socket.Destination)
+				}
+			}
+		})
+	}
+}
+
+func TestValueResolver_WithContext(t *testing.T) {
+	file, call := parseDial(t, `package main
+const defaultAddr = "db:5432"
+func connect() {
+	addr := defaultAddr
+	net.Dial("tcp", addr)
+}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := New()
+	socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+	r.WithContext(ctx).ResolveValues(socket, call, file)
+	if socket.IsResolved {
+		t.Errorf("Expected resolution to stop once the context is done, got %q", socket.RawValue)
+	}
+
+	socket = &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+	r.ResolveValues(socket, call, file)
+	if !socket.IsResolved || socket.RawValue != "db:5432" {
+		t.Errorf("Expected the resolver itself unaffected, got %q (resolved %t)", socket.RawValue, socket.IsResolved)
+	}
+}
+
+func TestValueResolver_LocalFromSampleFunction(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../../testdata/samples/http_variables.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call *ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok {
+			if sel, ok := c.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Post" {
+				call = c
+			}
+		}
+		return true
+	})
+	if call == nil {
+		t.Fatal("no http.Post call")
+	}
+
+	socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolHTTP, PatternMatch: "http.Post"}
+	New().ResolveValues(socket, call, file)
+	if !socket.IsResolved || socket.RawValue != "http://service.local:8080/api" {
+		t.Fatalf("Expected serviceURL resolved through getServiceURL, got %q (resolved %t)", socket.RawValue, socket.IsResolved)
+	}
+	if socket.Host() != "service.local" {
+		t.Errorf("Expected host service.local, got %q", socket.Host())
+	}
+	if port, ok := socket.Port(); !ok || port != 8080 {
+		t.Errorf("Expected port 8080, got %d", port)
+	}
+}
@@ -0,0 +1,229 @@
+package resolver
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// packageIndex holds the package-level constants and variables of the
+// packages resolution has looked into, read from disk on first use, so
+// that identifiers declared in another file of a package, and selectors
+// such as config.DefaultAddr naming another package of the module,
+// resolve.
+type packageIndex struct {
+	mu sync.Mutex
+	// packages by directory; nil for directories without Go files
+	packages map[string]*packageDecls
+	// module root and path by directory, "" outside modules
+	modules map[string]module
+	// directory of every file the index knows, analyzed or read by it
+	dirs sync.Map
+}
+
+type module struct {
+	root string
+	path string
+}
+
+// packageDecls are the package-level values of one package by name, with
+// the file declaring each, which their own identifiers resolve in.
+type packageDecls struct {
+	name   string
+	values map[string]packageValue
+}
+
+type packageValue struct {
+	expr ast.Expr
+	file *ast.File
+}
+
+func newPackageIndex() *packageIndex {
+	return &packageIndex{
+		packages: make(map[string]*packageDecls),
+		modules:  make(map[string]module),
+	}
+}
+
+// SetPackageDir records the directory file was read from, so identifiers
+// it does not declare itself resolve from the other files of its package
+// and from the packages of its module it imports. An empty dir forgets
+// file.
+func (r *ValueResolver) SetPackageDir(file *ast.File, dir string) {
+	if dir == "" {
+		r.packages.dirs.Delete(file)
+		return
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	r.packages.dirs.Store(file, dir)
+}
+
+// ForgetPackages drops the package-level values read so far, for files
+// that may have changed on disk since, and the directories recorded with
+// SetPackageDir.
+func (r *ValueResolver) ForgetPackages() {
+	index := r.packages
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.packages = make(map[string]*packageDecls)
+	index.modules = make(map[string]module)
+	index.dirs.Clear()
+}
+
+// dir returns the directory of file, or "" when it is not known.
+func (p *packageIndex) dir(file *ast.File) string {
+	if dir, ok := p.dirs.Load(file); ok {
+		return dir.(string)
+	}
+	return ""
+}
+
+// lookup returns the value declared for name at package level in dir.
+func (p *packageIndex) lookup(dir, name string) (packageValue, bool) {
+	decls := p.load(dir)
+	if decls == nil {
+		return packageValue{}, false
+	}
+	value, ok := decls.values[name]
+	return value, ok
+}
+
+// imported returns the directory of the package file binds to name, for
+// imports of packages in file's module or its vendor directory.
+func (p *packageIndex) imported(file *ast.File, name string) (string, bool) {
+	from := p.dir(file)
+	if from == "" {
+		return "", false
+	}
+	for _, spec := range file.Imports {
+		if spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".") {
+			continue
+		}
+		dir := p.importDir(from, strings.Trim(spec.Path.Value, `"`))
+		if dir == "" {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return dir, true
+			}
+			continue
+		}
+		if decls := p.load(dir); decls != nil && decls.name == name {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// importDir maps an import path to its directory when it is a package of
+// the module containing from, or vendored in it.
+func (p *packageIndex) importDir(from, importPath string) string {
+	mod := p.module(from)
+	if mod.root == "" {
+		return ""
+	}
+	if importPath == mod.path {
+		return mod.root
+	}
+	if rest, ok := strings.CutPrefix(importPath, mod.path+"/"); ok {
+		return filepath.Join(mod.root, filepath.FromSlash(rest))
+	}
+	vendored := filepath.Join(mod.root, "vendor", filepath.FromSlash(importPath))
+	if info, err := os.Stat(vendored); err == nil && info.IsDir() {
+		return vendored
+	}
+	return ""
+}
+
+// module returns the module of the nearest go.mod at or above dir.
+func (p *packageIndex) module(dir string) module {
+	p.mu.Lock()
+	mod, ok := p.modules[dir]
+	p.mu.Unlock()
+	if ok {
+		return mod
+	}
+
+	if path, ok := readModulePath(filepath.Join(dir, "go.mod")); ok {
+		mod = module{root: dir, path: path}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		mod = p.module(parent)
+	}
+
+	p.mu.Lock()
+	p.modules[dir] = mod
+	p.mu.Unlock()
+	return mod
+}
+
+func readModulePath(goModPath string) (string, bool) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), true
+		}
+	}
+	return "", false
+}
+
+// load parses the non-test Go files of dir once and indexes their
+// package-level constants and variables. Files of other packages, such as
+// external test packages, are skipped; the first declaration of a name
+// wins when build-constrained files declare it more than once.
+func (p *packageIndex) load(dir string) *packageDecls {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if decls, ok := p.packages[dir]; ok {
+		return decls
+	}
+
+	var decls *packageDecls
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	fileSet := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		if decls == nil {
+			decls = &packageDecls{name: file.Name.Name, values: make(map[string]packageValue)}
+		} else if file.Name.Name != decls.name {
+			continue
+		}
+		p.dirs.Store(file, dir)
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || (genDecl.Tok != token.CONST && genDecl.Tok != token.VAR) {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, name := range valueSpec.Names {
+					if _, seen := decls.values[name.Name]; !seen && i < len(valueSpec.Values) {
+						decls.values[name.Name] = packageValue{expr: valueSpec.Values[i], file: file}
+					}
+				}
+			}
+		}
+	}
+	p.packages[dir] = decls
+	return decls
+}
@@ -0,0 +1,98 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// writeModule lays out files, by slash-separated path, under a new module
+// example.com/app and returns its root.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	files["go.mod"] = "module example.com/app\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestValueResolver_PackageConstants(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"config/config.go": `package config
+const Host = "db.internal"
+const DefaultAddr = Host + ":" + port
+var CacheAddr = "cache.internal:6379"
+`,
+		"config/port.go": `package config
+const port = "5432"
+`,
+		"vendor/github.com/acme/defaults/defaults.go": `package defaults
+const Broker = "broker.acme:9092"
+`,
+		"cmd/api/upstream.go": `package main
+const upstream = "upstream.internal:8080"
+`,
+	})
+
+	tests := []struct {
+		name     string
+		imports  string
+		address  string
+		expected string
+	}{
+		{"constant of an imported package", `"example.com/app/config"`, "config.DefaultAddr", "db.internal:5432"},
+		{"variable of an imported package", `"example.com/app/config"`, "config.CacheAddr", "cache.internal:6379"},
+		{"renamed import", `cfg "example.com/app/config"`, `cfg.Host + ":5433"`, "db.internal:5433"},
+		{"vendored package", `"github.com/acme/defaults"`, "defaults.Broker", "broker.acme:9092"},
+		{"another file of the package", `"fmt"`, "upstream", "upstream.internal:8080"},
+		{"unexported name", `"example.com/app/config"`, "config.port", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, call := parseDial(t, `package main
+import (
+	"net"
+	`+tt.imports+`
+)
+func main() {
+	net.Dial("tcp", `+tt.address+`)
+}`)
+			resolver := New()
+			resolver.SetPackageDir(file, filepath.Join(root, "cmd", "api"))
+			socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+			resolver.ResolveValues(socket, call, file)
+
+			got := ""
+			if socket.IsResolved && socket.Destination != nil {
+				got = socket.Destination.String()
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q (raw value %q)", tt.expected, got, socket.RawValue)
+			}
+		})
+	}
+}
+
+func TestValueResolver_PackageDirUnknown(t *testing.T) {
+	socket := resolveDial(t, `package main
+import (
+	"net"
+	"example.com/app/config"
+)
+func main() {
+	net.Dial("tcp", config.DefaultAddr)
+}`)
+	if socket.IsResolved {
+		t.Errorf("Expected no resolution without a package directory, got %+v", socket.Destination)
+	}
+}
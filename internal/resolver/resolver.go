@@ -1,7 +1,10 @@
 package resolver
 
 import (
+	"context"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"strconv"
 	"strings"
 
@@ -9,22 +12,41 @@ import (
 )
 
 type ValueResolver struct {
-	// Future: add support for type checking and constant resolution
+	packages *packageIndex
+	// ctx stops resolution once done; nil never does
+	ctx context.Context
 }
 
 func New() *ValueResolver {
-	return &ValueResolver{}
+	return &ValueResolver{packages: newPackageIndex()}
 }
 
-func (r *ValueResolver) ResolveValues(socket *socketTypes.SocketInfo, callExpr *ast.CallExpr, file *ast.File) {
+// WithContext returns a resolver sharing r's packages that gives up, and
+// leaves what it has not resolved yet unresolved, once ctx is done.
+func (r *ValueResolver) WithContext(ctx context.Context) *ValueResolver {
+	stopping := *r
+	stopping.ctx = ctx
+	return &stopping
+}
+
+// stopped reports whether the context of r is done.
+func (r *ValueResolver) stopped() bool {
+	return r.ctx != nil && r.ctx.Err() != nil
+}
+
+// ResolveValues resolves the address of a matched call. When the address
+// is a parameter of a helper with a single caller, it returns the position
+// of the call site it was taken from, and token.NoPos otherwise.
+func (r *ValueResolver) ResolveValues(socket *socketTypes.SocketInfo, callExpr *ast.CallExpr, file *ast.File) token.Pos {
 	// If already resolved from string literals, no need to do more
 	if socket.IsResolved {
-		return
+		return token.NoPos
 	}
 
 	// Get the URL/address argument based on the pattern
 	var urlArg ast.Expr
-	if socket.PatternMatch == "http.Get" || socket.PatternMatch == "http.Post" || socket.PatternMatch == "http.PostForm" {
+	if socket.PatternMatch == "http.Get" || socket.PatternMatch == "http.Post" || socket.PatternMatch == "http.PostForm" ||
+		socket.PatternMatch == "http.ListenAndServe" || socket.PatternMatch == "http.ListenAndServeTLS" {
 		if len(callExpr.Args) > 0 {
 			urlArg = callExpr.Args[0]
 		}
@@ -36,86 +58,198 @@ func (r *ValueResolver) ResolveValues(socket *socketTypes.SocketInfo, callExpr *
 	}
 
 	if urlArg == nil {
-		return
+		return token.NoPos
+	}
+	return r.ResolveArgument(socket, urlArg, file)
+}
+
+// ResolveArgument is ResolveValues for callers that know which argument
+// of the call holds the address.
+func (r *ValueResolver) ResolveArgument(socket *socketTypes.SocketInfo, urlArg ast.Expr, file *ast.File) token.Pos {
+	return r.ResolveArgumentTraced(socket, urlArg, file, nil)
+}
+
+// ResolveArgumentTraced is ResolveArgument recording each strategy tried,
+// and its outcome, in trace.
+func (r *ValueResolver) ResolveArgumentTraced(socket *socketTypes.SocketInfo, urlArg ast.Expr, file *ast.File, trace *Trace) token.Pos {
+	if socket.IsResolved {
+		trace.add("literal %s: resolved by the pattern", types.ExprString(urlArg))
+		return token.NoPos
+	}
+
+	// Environment variables with a literal default
+	if r.resolveEnv(socket, urlArg, file) {
+		trace.add("environment variable %s: resolved to its default %q", socket.EnvVar, socket.DefaultValue)
+		return token.NoPos
+	}
+	if socket.EnvVar != "" {
+		trace.add("environment variable %s: no literal default is assigned when it is empty", socket.EnvVar)
+	}
+
+	// Local variables and parameters, with the values they are assigned
+	if ident, ok := urlArg.(*ast.Ident); ok && isLocal(ident, file) {
+		if callSite, ok := r.resolveLocal(socket, ident, file); ok {
+			trace.add("local variable %s: resolved to %q", ident.Name, socket.RawValue)
+			return callSite
+		}
+		trace.add("local variable %s: no statically known value is assigned before the call, and it is not a parameter with a single caller", ident.Name)
 	}
 
 	// Try different resolution strategies
-	if r.tryResolveArgument(socket, urlArg, file) {
-		return
+	if r.tryResolveArgument(socket, urlArg, file, trace) {
+		return token.NoPos
+	}
+
+	// Keep the source expression, e.g. cfg.Upstream.URL, so findings from
+	// the same dynamic value can be told apart and grouped.
+	if socket.RawValue == "" && socket.Type == socketTypes.TrafficTypeEgress {
+		socket.RawValue = types.ExprString(urlArg)
 	}
+	return token.NoPos
 }
 
-func (r *ValueResolver) tryResolveArgument(socket *socketTypes.SocketInfo, arg ast.Expr, file *ast.File) bool {
+func (r *ValueResolver) tryResolveArgument(socket *socketTypes.SocketInfo, arg ast.Expr, file *ast.File, trace *Trace) bool {
 	switch expr := arg.(type) {
 	case *ast.Ident:
-		// Simple identifier (variable or constant)
-		if value := r.resolveIdentifier(expr, file); value != "" {
-			r.updateSocketWithResolvedValue(socket, value)
-			return true
+		// Simple identifier (variable or constant); local variables are
+		// left to resolveLocal
+		if !isLocal(expr, file) {
+			if value := r.resolveIdentifier(expr, file); value != "" {
+				trace.add("constant %s: resolved to %q", expr.Name, value)
+				r.updateSocketWithResolvedValue(socket, value)
+				return true
+			}
+			trace.add("constant %s: %s", expr.Name, r.missingGlobal(file))
 		}
 		
 		// Check for common patterns like httptest server
 		if host, port, resolved := r.analyzeVariablePattern(expr.Name); resolved {
 			socket.IsResolved = true
-			socket.DestinationHost = &host
+			destination(socket).SetHost(host)
 			if port > 0 {
-				socket.DestinationPort = &port
+				destination(socket).Port = &port
 			}
 			socket.RawValue = expr.Name
+			trace.add("variable name %s: guessed %s", expr.Name, host)
 			return true
 		}
+		trace.add("variable name %s: matches no known naming convention", expr.Name)
 		
 	case *ast.SelectorExpr:
+		// Constants of other packages, like config.DefaultAddr
+		if value := r.resolveGlobal(expr, file, 0); value != "" {
+			trace.add("constant %s: resolved to %q", types.ExprString(expr), value)
+			r.updateSocketWithResolvedValue(socket, value)
+			return true
+		}
+		trace.add("constant %s: not a string constant or package-level variable of a package of the module", types.ExprString(expr))
+
 		// Field access like server.URL, os.Getenv(), etc.
 		varName := r.extractSelectorName(expr)
 		if host, port, resolved := r.analyzeVariablePattern(varName); resolved {
 			socket.IsResolved = true
-			socket.DestinationHost = &host
+			destination(socket).SetHost(host)
 			if port > 0 {
-				socket.DestinationPort = &port
+				destination(socket).Port = &port
 			}
 			socket.RawValue = varName
+			trace.add("field name %s: guessed %s", varName, host)
 			return true
 		}
+		trace.add("field name %s: matches no known naming convention", varName)
 		
 	case *ast.BinaryExpr:
 		// String concatenation like baseURL + endpoint
 		if r.tryResolveBinaryExpr(socket, expr, file) {
+			trace.add("concatenation %s: resolved to %q", types.ExprString(expr), socket.RawValue)
 			return true
 		}
+		trace.add("concatenation %s: not every operand is a string literal or constant", types.ExprString(expr))
 		
 	case *ast.CallExpr:
 		// Function calls like url.Parse().String(), getServiceURL()
 		if r.tryResolveCallExpr(socket, expr, file) {
+			trace.add("call %s: resolved to %q", types.ExprString(expr), socket.RawValue)
 			return true
 		}
+		trace.add("call %s: not a function of this file returning a known value", types.ExprString(expr))
+	default:
+		trace.add("%s: no resolution strategy for this kind of expression", types.ExprString(arg))
 	}
 	
 	return false
 }
 
 func (r *ValueResolver) resolveIdentifier(ident *ast.Ident, file *ast.File) string {
-	// Look for constant declarations in the file
+	return r.resolveGlobal(ident, file, 0)
+}
+
+// resolveGlobal returns the string value of a constant or package-level
+// variable: an identifier declared in file or, when file's directory is
+// known, in another file of its package, or a selector such as
+// config.DefaultAddr naming one in another package of the module.
+func (r *ValueResolver) resolveGlobal(expr ast.Expr, file *ast.File, depth int) string {
+	if depth >= maxLocalDepth || r.stopped() {
+		return ""
+	}
+	var declared packageValue
+	switch e := expr.(type) {
+	case *ast.Ident:
+		value, ok := fileValue(e.Name, file)
+		if !ok {
+			dir := r.packages.dir(file)
+			if dir == "" {
+				return ""
+			}
+			if value, ok = r.packages.lookup(dir, e.Name); !ok {
+				return ""
+			}
+		}
+		declared = value
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok || (pkg.Obj != nil && pkg.Obj.Kind != ast.Pkg) || !ast.IsExported(e.Sel.Name) {
+			return ""
+		}
+		dir, ok := r.packages.imported(file, pkg.Name)
+		if !ok {
+			return ""
+		}
+		if declared, ok = r.packages.lookup(dir, e.Sel.Name); !ok {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	value, _ := r.fold(declared.expr, func(name ast.Expr) (string, bool) {
+		value := r.resolveGlobal(name, declared.file, depth+1)
+		return value, value != ""
+	})
+	return value
+}
+
+// fileValue returns the value a constant or package-level variable named
+// name is declared with in file.
+func fileValue(name string, file *ast.File) (packageValue, bool) {
 	for _, decl := range file.Decls {
-		if genDecl, ok := decl.(*ast.GenDecl); ok {
-			for _, spec := range genDecl.Specs {
-				if valueSpec, ok := spec.(*ast.ValueSpec); ok {
-					for i, name := range valueSpec.Names {
-						if name.Name == ident.Name && i < len(valueSpec.Values) {
-							if lit, ok := valueSpec.Values[i].(*ast.BasicLit); ok {
-								if lit.Kind.String() == "STRING" {
-									if value, err := strconv.Unquote(lit.Value); err == nil {
-										return value
-									}
-								}
-							}
-						}
-					}
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, ident := range valueSpec.Names {
+				if ident.Name == name && i < len(valueSpec.Values) {
+					return packageValue{expr: valueSpec.Values[i], file: file}, true
 				}
 			}
 		}
 	}
-	return ""
+	return packageValue{}, false
 }
 
 func (r *ValueResolver) updateSocketWithResolvedValue(socket *socketTypes.SocketInfo, value string) {
@@ -131,17 +265,55 @@ func (r *ValueResolver) updateSocketWithResolvedValue(socket *socketTypes.Socket
 }
 
 func (r *ValueResolver) parseIngressValue(socket *socketTypes.SocketInfo, value string) {
-	// Reuse parsing logic from patterns package
-	// This is simplified - in practice, you'd factor out the parsing logic
-	if value != "" && value[0] == ':' {
-		if port, err := strconv.Atoi(value[1:]); err == nil {
-			socket.ListenPort = &port
-			socket.ListenInterface = "0.0.0.0"
+	if scheme, rest, ok := socketTypes.SplitScheme(value); ok {
+		socket.Protocol = socket.Protocol.WithScheme(scheme)
+		if scheme == socketTypes.ProtocolUnix {
+			socket.Listen = socketTypes.NewPathEndpoint(rest)
+			return
+		}
+		value = rest
+	}
+
+	switch socket.Protocol.Transport() {
+	case socketTypes.ProtocolUnix:
+		socket.Listen = socketTypes.NewPathEndpoint(value)
+		return
+	case socketTypes.ProtocolIP:
+		if value == "" {
+			value = "0.0.0.0"
+		}
+		socket.Listen = socketTypes.NewEndpoint(value, nil)
+		return
+	}
+
+	// host:port, [::1]:8080, or ":8080" for all interfaces
+	if host, port, ok := socketTypes.SplitHostPort(value); ok && port != nil {
+		if host == "" {
+			host = "0.0.0.0"
 		}
+		socket.Listen = socketTypes.NewEndpoint(host, port)
 	}
 }
 
 func (r *ValueResolver) parseEgressValue(socket *socketTypes.SocketInfo, value string) {
+	if scheme, rest, ok := socketTypes.SplitScheme(value); ok {
+		socket.Protocol = socket.Protocol.WithScheme(scheme)
+		if scheme == socketTypes.ProtocolUnix {
+			socket.Destination = socketTypes.NewPathEndpoint(rest)
+			return
+		}
+		value = rest
+	}
+
+	switch socket.Protocol.Transport() {
+	case socketTypes.ProtocolUnix:
+		socket.Destination = socketTypes.NewPathEndpoint(value)
+		return
+	case socketTypes.ProtocolIP:
+		destination(socket).SetHost(value)
+		return
+	}
+
 	// Parse egress addresses (host:port format)
 	if strings.Contains(value, "://") {
 		// This looks like a URL, but we only handle simple host:port here
@@ -149,15 +321,10 @@ func (r *ValueResolver) parseEgressValue(socket *socketTypes.SocketInfo, value s
 		return
 	}
 	
-	// Parse simple host:port format
-	parts := strings.Split(value, ":")
-	if len(parts) == 2 {
-		host := parts[0]
-		socket.DestinationHost = &host
-		
-		if port, err := strconv.Atoi(parts[1]); err == nil {
-			socket.DestinationPort = &port
-		}
+	// Parse host:port format, including [::1]:8080
+	if host, port, ok := socketTypes.SplitHostPort(value); ok {
+		destination(socket).SetHost(host)
+		destination(socket).Port = port
 	}
 }
 
@@ -204,6 +371,12 @@ func (r *ValueResolver) extractSelectorName(expr *ast.SelectorExpr) string {
 }
 
 func (r *ValueResolver) tryResolveBinaryExpr(socket *socketTypes.SocketInfo, expr *ast.BinaryExpr, file *ast.File) bool {
+	// Fold concatenations of literals and constants like host + ":8080"
+	if value, ok := r.foldString(expr, file); ok {
+		r.setResolvedValue(socket, value)
+		return true
+	}
+
 	// Handle string concatenation like baseURL + endpoint
 	if expr.Op.String() == "+" {
 		// Try to resolve the left side (usually the base URL)
@@ -228,7 +401,67 @@ func (r *ValueResolver) tryResolveBinaryExpr(socket *socketTypes.SocketInfo, exp
 	return false
 }
 
+// setResolvedValue resolves socket to value, an address or a URL.
+func (r *ValueResolver) setResolvedValue(socket *socketTypes.SocketInfo, value string) {
+	if _, _, socketAddress := socketTypes.SplitScheme(value); socketAddress || !strings.Contains(value, "://") {
+		r.updateSocketWithResolvedValue(socket, value)
+		return
+	}
+	socket.IsResolved = true
+	socket.RawValue = value
+	r.parseURLForSocket(socket, value)
+}
+
+// ResolveString returns the value of expr when it is a string literal, a
+// string constant or a concatenation of them, such as the network argument
+// of net.Dial.
+func (r *ValueResolver) ResolveString(expr ast.Expr, file *ast.File) (string, bool) {
+	return r.foldString(expr, file)
+}
+
+// foldString returns the value of a concatenation of string literals and
+// string constants, declared in file, its package or a package it imports.
+func (r *ValueResolver) foldString(expr ast.Expr, file *ast.File) (string, bool) {
+	return r.fold(expr, func(name ast.Expr) (string, bool) {
+		value := r.resolveGlobal(name, file, 0)
+		return value, value != ""
+	})
+}
+
+// fold is foldString with identifiers, package-qualified names and calls
+// looked up by lookup.
+func (r *ValueResolver) fold(expr ast.Expr, lookup func(ast.Expr) (string, bool)) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			value, err := strconv.Unquote(e.Value)
+			return value, err == nil
+		}
+	case *ast.ParenExpr:
+		return r.fold(e.X, lookup)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := r.fold(e.X, lookup)
+		if !ok {
+			return "", false
+		}
+		y, ok := r.fold(e.Y, lookup)
+		return x + y, ok
+	case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr:
+		return lookup(e)
+	}
+	return "", false
+}
+
 func (r *ValueResolver) tryResolveCallExpr(socket *socketTypes.SocketInfo, expr *ast.CallExpr, file *ast.File) bool {
+	// Functions of the file returning a known value, e.g. through a named
+	// result
+	if ident, ok := expr.Fun.(*ast.Ident); ok && r.resolveReturn(socket, ident, file) {
+		return true
+	}
+
 	// Handle function calls that return URLs
 	if sel, ok := expr.Fun.(*ast.SelectorExpr); ok {
 		funcName := r.extractSelectorName(sel)
@@ -239,14 +472,14 @@ func (r *ValueResolver) tryResolveCallExpr(socket *socketTypes.SocketInfo, expr
 			// url.Parse().String() pattern
 			socket.IsResolved = true
 			socket.RawValue = "parsed-url"
-			socket.DestinationHost = stringPtr("parsed-url-host")
+			destination(socket).SetHost("parsed-url-host")
 			return true
 			
 		case strings.Contains(funcName, "getURL") || strings.Contains(funcName, "GetURL"):
 			// Functions that return URLs
 			socket.IsResolved = true
 			socket.RawValue = funcName + "()"
-			socket.DestinationHost = stringPtr("dynamic-url")
+			destination(socket).SetHost("dynamic-url")
 			return true
 		}
 	}
@@ -260,33 +493,30 @@ func (r *ValueResolver) parseURLForSocket(socket *socketTypes.SocketInfo, url st
 		socket.Protocol = socketTypes.ProtocolHTTPS
 		url = url[8:]
 		port := 443
-		socket.DestinationPort = &port
+		destination(socket).Port = &port
 	} else if strings.HasPrefix(url, "http://") {
 		socket.Protocol = socketTypes.ProtocolHTTP
 		url = url[7:]
 		port := 80
-		socket.DestinationPort = &port
+		destination(socket).Port = &port
 	}
 	
 	// Extract host
 	parts := strings.Split(url, "/")
 	if len(parts) > 0 && parts[0] != "" {
-		hostPort := parts[0]
-		if strings.Contains(hostPort, ":") {
-			hostPortParts := strings.Split(hostPort, ":")
-			if len(hostPortParts) >= 2 {
-				host := hostPortParts[0]
-				socket.DestinationHost = &host
-				if port, err := strconv.Atoi(hostPortParts[1]); err == nil {
-					socket.DestinationPort = &port
-				}
-			}
-		} else {
-			socket.DestinationHost = &hostPort
+		host, port := socketTypes.SplitURLHost(parts[0])
+		destination(socket).SetHost(host)
+		if port != nil {
+			destination(socket).Port = port
 		}
 	}
 }
 
-func stringPtr(s string) *string {
-	return &s
+// destination returns the socket's destination, adding an empty one first
+// if it has none.
+func destination(socket *socketTypes.SocketInfo) *socketTypes.Endpoint {
+	if socket.Destination == nil {
+		socket.Destination = &socketTypes.Endpoint{}
+	}
+	return socket.Destination
 }
\ No newline at end of file
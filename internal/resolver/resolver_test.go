@@ -117,8 +117,8 @@ func makeRequest() {
 		t.Error("Expected socket to be resolved for constant URL")
 	}
 
-	if socket.DestinationHost == nil || *socket.DestinationHost != "api.example.com" {
-		t.Errorf("Expected host to be api.example.com, got %v", socket.DestinationHost)
+	if socket.Destination == nil || socket.Destination.Host != "api.example.com" {
+		t.Errorf("Expected host to be api.example.com, got %v", socket.Destination)
 	}
 }
 
@@ -0,0 +1,27 @@
+package resolver
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// Trace collects the resolution strategies tried for an address and their
+// outcomes, in order, to explain a result. A nil *Trace collects nothing.
+type Trace struct {
+	Steps []string
+}
+
+func (t *Trace) add(format string, args ...any) {
+	if t != nil {
+		t.Steps = append(t.Steps, fmt.Sprintf(format, args...))
+	}
+}
+
+// missingGlobal says where an identifier that is not a resolvable
+// constant of file was looked for.
+func (r *ValueResolver) missingGlobal(file *ast.File) string {
+	if r.packages.dir(file) == "" {
+		return "not a string constant or package-level variable of this file; other files of the package are not known"
+	}
+	return "not a string constant or package-level variable of this package"
+}
@@ -0,0 +1,65 @@
+package resolver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestValueResolver_ResolveArgumentTraced(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "constant",
+			code: `package main
+import "net"
+const addr = "db:5432"
+func main() { net.Dial("tcp", addr) }`,
+			want: []string{`constant addr: resolved to "db:5432"`},
+		},
+		{
+			name: "unknown selector",
+			code: `package main
+import "net"
+func main() { net.Dial("tcp", cfg.Addr) }`,
+			want: []string{
+				"constant cfg.Addr: not a string constant or package-level variable of a package of the module",
+				"field name cfg.Addr: matches no known naming convention",
+			},
+		},
+		{
+			name: "local variable",
+			code: `package main
+import "net"
+func main(addr string) { net.Dial("tcp", addr) }`,
+			want: []string{"local variable addr: no statically known value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, call := parseDial(t, tt.code)
+			socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+			var trace Trace
+			New().ResolveArgumentTraced(socket, call.Args[1], file, &trace)
+			if len(trace.Steps) < len(tt.want) {
+				t.Fatalf("Expected at least %d steps, got %q", len(tt.want), trace.Steps)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(trace.Steps[i], want) {
+					t.Errorf("Expected step %d to start with %q, got %q", i, want, trace.Steps[i])
+				}
+			}
+		})
+	}
+
+	// A nil trace records nothing
+	file, call := parseDial(t, `package main
+import "net"
+func main() { net.Dial("tcp", addr) }`)
+	socket := &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, PatternMatch: "net.Dial"}
+	New().ResolveArgumentTraced(socket, call.Args[1], file, nil)
+}
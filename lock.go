@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/analyzer"
+)
+
+const defaultLockFile = "staticsocket.lock"
+
+// lockFile pins the effective configuration and rule set of a scan, so a
+// later run with -locked can prove it used the approved setup.
+type lockFile struct {
+	ToolVersion    string     `json:"tool_version"`
+	Config         lockConfig `json:"config"`
+	ConfigHash     string     `json:"config_hash"`
+	PatternSetHash string     `json:"pattern_set_hash"`
+	Rules          []string   `json:"rules"`
+}
+
+// lockConfig holds the flags that go into the configuration hash, so a
+// mismatch can be told apart. lock_test.go checks that every flag changing
+// the hash is here.
+type lockConfig struct {
+	Symlinks           string `json:"symlinks"`
+	MaxDepth           int    `json:"max_depth"`
+	MaxFileSize        int64  `json:"max_file_size"`
+	MaxFiles           int    `json:"max_files"`
+	MaxFindings        int    `json:"max_findings"`
+	ExcludeTests       bool   `json:"exclude_tests,omitempty"`
+	NoIgnoreFiles      bool   `json:"no_ignore_files,omitempty"`
+	Type               string `json:"type,omitempty"`
+	Layout             string `json:"layout"`
+	GeneratedRoots     string `json:"generated_roots,omitempty"`
+	ExternalRoots      string `json:"external_roots,omitempty"`
+	DNSSearch          string `json:"dns_search,omitempty"`
+	CollapseUnresolved bool   `json:"collapse_unresolved,omitempty"`
+	Templates          bool   `json:"templates,omitempty"`
+	Typed              bool   `json:"typed,omitempty"`
+	Symbol             string `json:"symbol,omitempty"`
+	CallGraph          bool   `json:"call_graph,omitempty"`
+	FindingBudget      string `json:"finding_budget,omitempty"`
+	FileBudget         string `json:"file_budget,omitempty"`
+	Plugin             string `json:"plugin,omitempty"`
+}
+
+func newLockFile(opts options, a *analyzer.Analyzer) lockFile {
+	return lockFile{
+		ToolVersion: version,
+		Config: lockConfig{
+			Symlinks:           opts.symlinks,
+			MaxDepth:           opts.maxDepth,
+			MaxFileSize:        opts.maxFileSize,
+			MaxFiles:           opts.maxFiles,
+			MaxFindings:        opts.maxFindings,
+			ExcludeTests:       !opts.tests,
+			NoIgnoreFiles:      !opts.ignoreFiles,
+			Type:               opts.trafficType,
+			Layout:             opts.layout,
+			GeneratedRoots:     opts.generatedRoots,
+			ExternalRoots:      opts.externalRoots,
+			DNSSearch:          opts.dnsSearch,
+			CollapseUnresolved: opts.collapseUnresolved,
+			Templates:          opts.templates,
+			Typed:              opts.typed,
+			Symbol:             opts.symbol,
+			CallGraph:          opts.callGraph,
+			FindingBudget:      budgetString(opts.findingBudget),
+			FileBudget:         budgetString(opts.fileBudget),
+			Plugin:             opts.plugin,
+		},
+		ConfigHash:     a.ConfigHash(),
+		PatternSetHash: a.PatternSetHash(),
+		Rules:          a.Rules(),
+	}
+}
+
+// budgetString renders a resolution budget, or "" when there is none.
+func budgetString(budget time.Duration) string {
+	if budget <= 0 {
+		return ""
+	}
+	return budget.String()
+}
+
+func writeLock(opts options, a *analyzer.Analyzer) error {
+	data, err := json.MarshalIndent(newLockFile(opts, a), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(opts.lockFile, append(data, '\n'), 0644)
+}
+
+// checkLock compares the effective configuration and rules against the
+// lock file. A different tool version is fine as long as both match.
+func checkLock(opts options, a *analyzer.Analyzer) error {
+	data, err := os.ReadFile(opts.lockFile)
+	if err != nil {
+		return fmt.Errorf("reading lock file: %w", err)
+	}
+	var locked lockFile
+	if err := json.Unmarshal(data, &locked); err != nil {
+		return fmt.Errorf("parsing lock file %s: %w", opts.lockFile, err)
+	}
+
+	current := newLockFile(opts, a)
+	var mismatches []string
+	if current.ConfigHash != locked.ConfigHash {
+		mismatches = append(mismatches, fmt.Sprintf("configuration %+v does not match locked %+v", current.Config, locked.Config))
+	}
+	if current.PatternSetHash != locked.PatternSetHash {
+		mismatches = append(mismatches, "rule set differs from the locked one"+ruleDiff(locked.Rules, current.Rules))
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%s is out of date: %s", opts.lockFile, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// ruleDiff summarizes rules added and removed since the lock was written.
+func ruleDiff(locked, current []string) string {
+	inLock := make(map[string]bool, len(locked))
+	for _, rule := range locked {
+		inLock[rule] = true
+	}
+	inCurrent := make(map[string]bool, len(current))
+	added := 0
+	for _, rule := range current {
+		inCurrent[rule] = true
+		if !inLock[rule] {
+			added++
+		}
+	}
+	removed := 0
+	for _, rule := range locked {
+		if !inCurrent[rule] {
+			removed++
+		}
+	}
+	return fmt.Sprintf(" (%d added, %d removed)", added, removed)
+}
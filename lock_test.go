@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+// lockedFlags gives each flag that changes the configuration hash a value
+// other than its default, after the base arguments it needs to matter.
+var lockedFlags = map[string]struct{ base, args []string }{
+	"symlinks":            {args: []string{"-symlinks=follow"}},
+	"max-depth":           {args: []string{"-max-depth=3"}},
+	"max-file-size":       {args: []string{"-max-file-size=1"}},
+	"max-files":           {args: []string{"-max-files=1"}},
+	"max-findings":        {args: []string{"-max-findings=1"}},
+	"include-tests":       {args: []string{"-include-tests=false"}},
+	"ignore-files":        {args: []string{"-ignore-files=false"}},
+	"type":                {args: []string{"-type=egress"}},
+	"layout":              {args: []string{"-layout=bazel"}},
+	"generated-roots":     {args: []string{"-generated-roots=gen"}},
+	"external-roots":      {args: []string{"-external-roots=third_party"}},
+	"dns-search":          {args: []string{"-dns-search=corp.internal"}},
+	"collapse-unresolved": {args: []string{"-collapse-unresolved"}},
+	"templates":           {args: []string{"-templates"}},
+	"typed":               {args: []string{"-typed"}},
+	"symbol":              {args: []string{"-symbol=main.run"}},
+	"call-graph":          {base: []string{"-symbol=main.run"}, args: []string{"-call-graph"}},
+	"finding-budget":      {args: []string{"-finding-budget=1s"}},
+	"file-budget":         {args: []string{"-file-budget=1s"}},
+	"plugin":              {args: []string{"-plugin=enrich --cmdb"}},
+}
+
+// unlockedFlags change what is done with the results, or the rule set
+// pinned by the pattern set hash, but not the configuration hash.
+var unlockedFlags = []string{
+	"path", "output", "format", "verbose", "evidence", "lock-file", "locked", "any", "header",
+	"workers", "manifest-file", "explain", "import-aliases", "patterns", "checks", "policy",
+	"messages", "group-by-endpoint", "snippets",
+}
+
+func lockFor(t *testing.T, args []string) lockFile {
+	t.Helper()
+	var opts options
+	if err := newFlagSet(&opts).Parse(args); err != nil {
+		t.Fatalf("Parsing %v: %v", args, err)
+	}
+	a, err := newAnalyzer(opts)
+	if err != nil {
+		t.Fatalf("newAnalyzer(%v): %v", args, err)
+	}
+	return newLockFile(opts, a)
+}
+
+func TestLockFile_CoversConfigHash(t *testing.T) {
+	unlocked := make(map[string]bool)
+	for _, name := range unlockedFlags {
+		unlocked[name] = true
+	}
+	newFlagSet(&options{}).VisitAll(func(f *flag.Flag) {
+		if _, ok := lockedFlags[f.Name]; !ok && !unlocked[f.Name] {
+			t.Errorf("Flag -%s is neither in the lock file nor known to leave the configuration hash alone", f.Name)
+		}
+	})
+
+	for name, flags := range lockedFlags {
+		base := lockFor(t, flags.base)
+		changed := lockFor(t, append(append([]string(nil), flags.base...), flags.args...))
+		if changed.ConfigHash == base.ConfigHash {
+			t.Errorf("Expected -%s to change the configuration hash", name)
+		}
+		if changed.Config == base.Config {
+			t.Errorf("Expected -%s to be recorded in the lock file", name)
+		}
+	}
+
+	base := lockFor(t, nil)
+	for _, args := range [][]string{{"-any"}, {"-workers=3"}, {"-snippets"}, {"-group-by-endpoint"}, {"-checks=all"}} {
+		if lockFor(t, args).ConfigHash != base.ConfigHash {
+			t.Errorf("Expected %v to leave the configuration hash alone", args)
+		}
+	}
+}
@@ -1,40 +1,253 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/yuvalk/staticsocket/pkg/analyzer"
+	"github.com/yuvalk/staticsocket/pkg/evidence"
+	"github.com/yuvalk/staticsocket/pkg/manifest"
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/policy"
+	"github.com/yuvalk/staticsocket/pkg/types"
 )
 
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+type options struct {
+	targetPath  string
+	outputFile  string
+	format      string
+	verbose     bool
+	symlinks    string
+	maxDepth    int
+	maxFileSize int64
+	maxFiles    int
+	maxFindings int
+	tests       bool
+	ignoreFiles bool
+	evidence    string
+	lockFile    string
+	locked      bool
+	trafficType string
+	any         bool
+	header      bool
+	workers     int
+
+	findingBudget time.Duration
+	fileBudget    time.Duration
+
+	manifestFile string
+
+	layout             string
+	generatedRoots     string
+	externalRoots      string
+	importAliases      string
+	patterns           string
+	checks             string
+	policy             string
+	messages           string
+	plugin             string
+	dnsSearch          string
+	groupByEndpoint    bool
+	collapseUnresolved bool
+	templates          bool
+	snippets           bool
+	typed              bool
+	symbol             string
+	callGraph          bool
+
+	explain string
+}
+
+// subcommands maps each subcommand to its one-line description. Running the
+// binary without a subcommand performs an analysis.
+var subcommands = map[string]string{
+	"completion": "Generate shell completion scripts or a man page",
+	"diff":       "Compare two saved JSON or YAML results (diff OLD NEW)",
+	"lock":       "Write a lock file pinning the analysis configuration and rule set",
+	"manifest":   "Write or verify the committed network manifest (manifest write|verify)",
+	"messages":   "Write the message catalog of finding notes and report text, for translation",
+	"patterns":   "Write the documentation of the rules as Markdown or HTML (patterns doc)",
+}
+
+func newFlagSet(opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("staticsocket", flag.ExitOnError)
+	fs.StringVar(&opts.targetPath, "path", ".", "Path to analyze (file or directory)")
+	fs.StringVar(&opts.outputFile, "output", "", "Output file (default: stdout)")
+	fs.StringVar(&opts.format, "format", "json", "Output format: "+strings.Join(types.ExportFormats, ", "))
+	fs.BoolVar(&opts.verbose, "verbose", false, "Enable verbose output")
+	fs.StringVar(&opts.symlinks, "symlinks", "skip", "Symlinked directories during directory walks: skip, follow; symlinked files are always analyzed")
+	fs.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum directory depth to descend into (0 = unlimited)")
+	fs.Int64Var(&opts.maxFileSize, "max-file-size", analyzer.DefaultMaxFileSize, "Skip files larger than this many bytes (0 = unlimited)")
+	fs.IntVar(&opts.maxFiles, "max-files", analyzer.DefaultMaxFiles, "Abort after analyzing this many files (0 = unlimited)")
+	fs.IntVar(&opts.maxFindings, "max-findings", analyzer.DefaultMaxFindings, "List at most this many findings, marking the results truncated (0 = unlimited)")
+	fs.BoolVar(&opts.tests, "include-tests", true, "Analyze _test.go files; -include-tests=false keeps test servers and fake destinations out of the results")
+	fs.BoolVar(&opts.ignoreFiles, "ignore-files", true, "Skip files and directories ignored by .gitignore and .staticsocketignore files; -ignore-files=false analyzes them")
+	fs.StringVar(&opts.evidence, "evidence", "", "Also write a zip bundle of the results and the source snippets behind each finding")
+	fs.StringVar(&opts.lockFile, "lock-file", defaultLockFile, "Lock file written by the lock command and checked by -locked")
+	fs.BoolVar(&opts.locked, "locked", false, "Refuse to run unless the configuration and rules match the lock file")
+	fs.StringVar(&opts.trafficType, "type", "", "Only report findings of this traffic type: ingress, egress")
+	fs.BoolVar(&opts.any, "any", false, "Stop at the first finding and exit 0 if one exists, 1 if none, 2 on error")
+	fs.StringVar(&opts.layout, "layout", "go", "Source layout preset: "+strings.Join(layoutNames(), ", "))
+	fs.StringVar(&opts.generatedRoots, "generated-roots", "", "Comma-separated generated-source roots to strip from paths, added to the layout preset")
+	fs.StringVar(&opts.externalRoots, "external-roots", "", "Comma-separated external dependency roots, added to the layout preset")
+	fs.StringVar(&opts.importAliases, "import-aliases", "", "Comma-separated fork=original import paths; the fork matches the original package's patterns")
+	fs.StringVar(&opts.patterns, "patterns", "", "YAML or JSON file of additional ingress and egress patterns, such as internal wrappers of net.Listen")
+	fs.StringVar(&opts.policy, "policy", "", "YAML or JSON policy of allowed and denied listeners and destinations; exit 1 if a finding violates it")
+	fs.StringVar(&opts.checks, "checks", "", "Comma-separated rules to enable or disable by code or name, applied in order: all, -SS1003, SS16*")
+	fs.StringVar(&opts.messages, "messages", "", "YAML or JSON message catalog rephrasing or translating finding notes and report text")
+	fs.StringVar(&opts.plugin, "plugin", "", "Command run on the results before export, such as to add owners from a CMDB: it reads them as JSON on stdin and writes them back on stdout; quote words with spaces as in a shell")
+	fs.StringVar(&opts.dnsSearch, "dns-search", "", "Comma-separated DNS search domains used to list fully-qualified candidates of unqualified destinations, or \"kubernetes\"")
+	fs.BoolVar(&opts.groupByEndpoint, "group-by-endpoint", false, "Add findings grouped by listener or destination, with every source location, to json and yaml output")
+	fs.BoolVar(&opts.collapseUnresolved, "collapse-unresolved", false, "Report unresolved findings sharing a source expression once, with an occurrence count")
+	fs.BoolVar(&opts.templates, "templates", false, "Also analyze Go source templates ("+strings.Join(analyzer.TemplateSuffixes, ", ")+"), best effort")
+	fs.BoolVar(&opts.snippets, "snippets", false, "Record the source of the call or literal behind each finding as its code_snippet")
+	fs.BoolVar(&opts.typed, "typed", false, "Type-check packages with the Go toolchain so calls match by callee, e.g. methods of *http.Client; needs module dependencies")
+	fs.StringVar(&opts.symbol, "symbol", "", "Only report findings in this function or method: pkg.Func, pkg.Type.Method or pkg.(*Type).Method")
+	fs.BoolVar(&opts.callGraph, "call-graph", false, "With -symbol, also report findings in every function the symbol calls or refers to, directly or indirectly")
+	fs.StringVar(&opts.manifestFile, "manifest-file", "", "Network manifest used by the manifest command (default: "+manifest.DefaultFile+" in the analyzed directory)")
+	fs.IntVar(&opts.workers, "workers", runtime.NumCPU(), "Number of files analyzed concurrently; results are identical for any value")
+	fs.DurationVar(&opts.findingBudget, "finding-budget", 0, "Time allowed to resolve one finding before it is reported unresolved, making results depend on machine speed (0 = unlimited)")
+	fs.DurationVar(&opts.fileBudget, "file-budget", 0, "Time allowed to resolve all findings of one file, making results depend on machine speed (0 = unlimited)")
+	fs.StringVar(&opts.explain, "explain", "", "Instead of analyzing, explain how the calls on FILE:LINE are matched and resolved, or why they are not")
+	fs.BoolVar(&opts.header, "header", false, "Prepend a \"Code generated ... DO NOT EDIT.\" header to yaml, csv, threagile, endpoints, networkpolicy, dot, backstage, squid, envoy, envoy-clusters and nginx output")
+	return fs
+}
+
 func main() {
-	var (
-		targetPath = flag.String("path", ".", "Path to analyze (file or directory)")
-		outputFile = flag.String("output", "", "Output file (default: stdout)")
-		format     = flag.String("format", "json", "Output format: json, yaml, csv")
-		verbose    = flag.Bool("verbose", false, "Enable verbose output")
-	)
-	flag.Parse()
-
-	if *verbose {
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		if err := runCompletion(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating completion: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "messages" {
+		if err := runMessages(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "patterns" {
+		if err := runPatterns(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	command, action := "", ""
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == "lock" || args[0] == "manifest") {
+		command, args = args[0], args[1:]
+	}
+	if command == "manifest" {
+		if len(args) == 0 || (args[0] != "write" && args[0] != "verify") {
+			fmt.Fprintln(os.Stderr, "usage: staticsocket manifest write|verify [options]")
+			os.Exit(1)
+		}
+		action, args = args[0], args[1:]
+	}
+
+	var opts options
+	fs := newFlagSet(&opts)
+	_ = fs.Parse(args)
+
+	if opts.verbose {
 		log.SetOutput(os.Stderr)
 	} else {
 		log.SetOutput(io.Discard)
 	}
 
-	analyzer := analyzer.New()
-	results, err := analyzer.Analyze(*targetPath)
+	errorStatus := 1
+	if opts.any || opts.policy != "" {
+		errorStatus = 2
+	}
+
+	analyzer, err := newAnalyzer(opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error analyzing path %s: %v\n", *targetPath, err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(errorStatus)
+	}
+
+	if command == "lock" {
+		if err := writeLock(opts, analyzer); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing lock file: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if opts.explain != "" {
+		if err := runExplain(opts.explain, analyzer, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if opts.locked {
+		if err := checkLock(opts, analyzer); err != nil {
+			fmt.Fprintf(os.Stderr, "Refusing to run: %v\n", err)
+			os.Exit(errorStatus)
+		}
+	}
+	var socketPolicy *policy.Policy
+	if opts.policy != "" {
+		if socketPolicy, err = readPolicy(opts.policy); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(errorStatus)
+		}
+	}
+
+	results, err := analyzer.Analyze(opts.targetPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error analyzing path %s: %v\n", opts.targetPath, err)
+		os.Exit(errorStatus)
+	}
+	results.Scan.ToolVersion = version
+	if results.Truncated {
+		fmt.Fprintf(os.Stderr, "Warning: results truncated to %d of %d findings; raise -max-findings to list them all\n", results.Truncation.MaxFindings, results.TotalCount)
+	}
+
+	if command == "manifest" {
+		if err := runManifest(action, opts, results); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.any {
+		if len(results.Sockets) == 0 {
+			os.Exit(1)
+		}
+		socket := results.Sockets[0]
+		fmt.Printf("%s:%d: %s %s %s\n", socket.SourceFile, socket.SourceLine, socket.Type, socket.Protocol, socket.PatternMatch)
+		return
 	}
 
 	output := os.Stdout
-	if *outputFile != "" {
-		file, err := os.Create(*outputFile)
+	if opts.outputFile != "" {
+		file, err := os.Create(opts.outputFile)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
 			os.Exit(1)
@@ -43,8 +256,240 @@ func main() {
 		output = file
 	}
 
-	if err := results.Export(output, *format); err != nil {
+	if opts.header {
+		header := types.GeneratedHeader{
+			GeneratedBy: "staticsocket " + version,
+			CommandLine: commandLine(os.Args[1:]),
+		}
+		if err := types.WriteGeneratedHeader(output, opts.format, header); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing header: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := results.Export(output, opts.format); err != nil {
 		fmt.Fprintf(os.Stderr, "Error exporting results: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+
+	if opts.evidence != "" {
+		if err := writeEvidence(opts.evidence, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing evidence bundle: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if socketPolicy != nil {
+		violations, err := checkPolicy(socketPolicy, results, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(errorStatus)
+		}
+		if violations > 0 {
+			fmt.Fprintf(os.Stderr, "%d findings violate the policy %s\n", violations, opts.policy)
+			os.Exit(1)
+		}
+	}
+}
+
+func layoutNames() []string {
+	names := make([]string, 0, len(analyzer.LayoutPresets))
+	for name := range analyzer.LayoutPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// commandLine renders args as a shell command, independent of where the
+// binary was installed.
+func commandLine(args []string) string {
+	quoted := []string{"staticsocket"}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'$") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func newAnalyzer(opts options) (*analyzer.Analyzer, error) {
+	symlinkPolicy, ok := analyzer.ParseSymlinkPolicy(opts.symlinks)
+	if !ok {
+		return nil, fmt.Errorf("invalid -symlinks value %q: expected skip or follow", opts.symlinks)
+	}
+
+	trafficType := types.TrafficType(opts.trafficType)
+	switch trafficType {
+	case "", types.TrafficTypeIngress, types.TrafficTypeEgress:
+	default:
+		return nil, fmt.Errorf("invalid -type value %q: expected ingress or egress", opts.trafficType)
+	}
+
+	layout, ok := analyzer.LayoutPresets[opts.layout]
+	if !ok {
+		return nil, fmt.Errorf("invalid -layout value %q: expected %s", opts.layout, strings.Join(layoutNames(), ", "))
+	}
+	layout.GeneratedRoots = append(append([]string(nil), layout.GeneratedRoots...), splitList(opts.generatedRoots)...)
+	layout.ExternalRoots = append(append([]string(nil), layout.ExternalRoots...), splitList(opts.externalRoots)...)
+
+	// notes are rendered as findings are made, so the catalog comes first
+	if opts.messages != "" {
+		if err := loadMessages(opts.messages); err != nil {
+			return nil, err
+		}
+	}
+
+	config := analyzer.DefaultOptions()
+	config.SymlinkPolicy = symlinkPolicy
+	config.Layout = layout
+	config.MaxDepth = opts.maxDepth
+	config.MaxFileSize = opts.maxFileSize
+	config.MaxFiles = opts.maxFiles
+	config.MaxFindings = opts.maxFindings
+	config.IncludeTests = opts.tests
+	config.IgnoreFiles = opts.ignoreFiles
+	config.TrafficType = trafficType
+	config.StopAtFirst = opts.any
+	config.GroupByEndpoint = opts.groupByEndpoint
+	config.CollapseUnresolved = opts.collapseUnresolved
+	config.ScanTemplates = opts.templates
+	config.TypeCheck = opts.typed
+	config.CodeSnippets = opts.snippets
+	config.Symbol, config.CallGraph = opts.symbol, opts.callGraph
+	config.Workers = opts.workers
+	if opts.verbose {
+		config.PipelineStats = func(stats analyzer.PipelineStats) {
+			fmt.Fprintln(os.Stderr, stats)
+		}
+	}
+	config.FindingBudget, config.FileBudget = opts.findingBudget, opts.fileBudget
+	if opts.dnsSearch == "kubernetes" {
+		config.DNSSearch = analyzer.KubernetesDNSSearch
+	} else {
+		config.DNSSearch = splitList(opts.dnsSearch)
+	}
+
+	config.ImportAliases = make(map[string]string)
+	for _, alias := range splitList(opts.importAliases) {
+		fork, original, ok := strings.Cut(alias, "=")
+		if !ok || fork == "" || original == "" {
+			return nil, fmt.Errorf("invalid -import-aliases entry %q: expected fork=original", alias)
+		}
+		config.ImportAliases[fork] = original
+	}
+	if opts.patterns != "" {
+		custom, err := readPatterns(opts.patterns)
+		if err != nil {
+			return nil, err
+		}
+		config.Patterns = custom
+	}
+	config.Checks = splitList(opts.checks)
+	if opts.plugin != "" {
+		command, err := splitCommand(opts.plugin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -plugin %q: %w", opts.plugin, err)
+		}
+		config.Plugin = command
+	}
+
+	a, err := analyzer.NewWithOptions(config)
+	switch {
+	case errors.Is(err, patterns.ErrUnknownCheck):
+		return nil, fmt.Errorf("invalid -checks: %w", err)
+	case err != nil && opts.patterns != "":
+		return nil, fmt.Errorf("invalid -patterns file %s: %w", opts.patterns, err)
+	}
+	return a, err
+}
+
+// splitCommand splits a command line into words as a POSIX shell does,
+// without expansions: words are separated by blanks, which single or
+// double quotes or a backslash keep within one, as in
+// "/opt/my tools/enrich" --cmdb https://cmdb.internal.
+func splitCommand(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func readPatterns(path string) ([]patterns.CustomPattern, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading patterns: %w", err)
+	}
+	defer file.Close()
+	custom, err := patterns.ReadCustomPatterns(file)
+	if err != nil {
+		return nil, fmt.Errorf("parsing patterns file %s: %w", path, err)
+	}
+	return custom, nil
+}
+
+func writeEvidence(path string, results *types.AnalysisResults) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := evidence.Write(file, results); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
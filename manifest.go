@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yuvalk/staticsocket/pkg/manifest"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// runManifest writes the canonical manifest for results, or verifies that
+// the committed one still matches them.
+func runManifest(action string, opts options, results *types.AnalysisResults) error {
+	root := opts.targetPath
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	path := opts.manifestFile
+	if path == "" {
+		path = filepath.Join(root, manifest.DefaultFile)
+	}
+	if results.Truncated {
+		return fmt.Errorf("results are truncated to %d findings; rerun with a higher -max-findings", results.Truncation.MaxFindings)
+	}
+	current := manifest.Build(results, root)
+
+	if action == "write" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating manifest: %w", err)
+		}
+		header := types.GeneratedHeader{
+			GeneratedBy: "staticsocket " + version,
+			CommandLine: commandLine(os.Args[1:]),
+		}
+		if err := manifest.Write(file, current, header); err != nil {
+			file.Close()
+			return fmt.Errorf("writing manifest: %w", err)
+		}
+		return file.Close()
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+	defer file.Close()
+	committed, err := manifest.Read(file)
+	if err != nil {
+		return fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+
+	added, removed := manifest.Diff(committed, current)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	for _, entry := range added {
+		fmt.Fprintf(os.Stderr, "+ %s\n", entry)
+	}
+	for _, entry := range removed {
+		fmt.Fprintf(os.Stderr, "- %s\n", entry)
+	}
+	return errors.New(path + " is out of date; run 'staticsocket manifest write' and commit the result")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// runMessages handles "messages", which writes the message catalog, with
+// the templates of a -messages file applied, as a starting point for a
+// translation.
+func runMessages(args []string, output io.Writer) error {
+	fs := flag.NewFlagSet("staticsocket messages", flag.ExitOnError)
+	messageFile := fs.String("messages", "", "YAML or JSON message catalog to start from")
+	_ = fs.Parse(args)
+
+	if *messageFile != "" {
+		if err := loadMessages(*messageFile); err != nil {
+			return err
+		}
+	}
+	return types.WriteMessageCatalog(output)
+}
+
+// loadMessages applies the message catalog at path.
+func loadMessages(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading messages: %w", err)
+	}
+	defer file.Close()
+	messages, err := types.ReadMessageCatalog(file)
+	if err != nil {
+		return fmt.Errorf("parsing messages file %s: %w", path, err)
+	}
+	if err := types.SetMessageCatalog(messages); err != nil {
+		return fmt.Errorf("invalid -messages file %s: %w", path, err)
+	}
+	return nil
+}
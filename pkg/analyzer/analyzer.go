@@ -1,3 +1,7 @@
+// Package analyzer finds the sockets a Go codebase opens by walking its
+// files and matching them with package patterns. New, with functional
+// options, and NewWithOptions create an Analyzer; Analyze, AnalyzeSource
+// and Reanalyze return results as package types defines them.
 package analyzer
 
 import (
@@ -6,29 +10,96 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/yuvalk/staticsocket/internal/parser/patterns"
-	"github.com/yuvalk/staticsocket/internal/resolver"
+	"github.com/yuvalk/staticsocket/pkg/patterns"
 	"github.com/yuvalk/staticsocket/pkg/types"
 )
 
+// Analyzer is safe for use by multiple goroutines once configured: Analyze,
+// AnalyzeSource, Reanalyze, Explain and Invalidate take turns, and each
+// call returns results of its own that later calls leave untouched.
 type Analyzer struct {
-	fileSet   *token.FileSet
-	patterns  *patterns.PatternMatcher
-	resolver  *resolver.ValueResolver
-	results   *types.AnalysisResults
+	// Positions of the files parsed by the analysis in progress, in a set
+	// of their own unless one was shared with WithFileSet
+	fileSet       *token.FileSet
+	sharedFileSet bool
+	patterns      *patterns.PatternMatcher
+	// Serializes analyses, which share the state below
+	mu sync.Mutex
+	// The results of the analysis in progress
+	results *types.AnalysisResults
+
+	symlinkPolicy      SymlinkPolicy
+	maxDepth           int
+	excludeTests       bool
+	exclude            []string
+	noIgnoreFiles      bool
+	maxFileSize        int64
+	maxFiles           int
+	maxFindings        int
+	goVersion          string
+	trafficType        types.TrafficType
+	stopAtFirst        bool
+	layout             Layout
+	root               string
+	workers            int
+	pipelineStats      func(PipelineStats)
+	dnsSearch          []string
+	groupByEndpoint    bool
+	collapseUnresolved bool
+	scanTemplates      bool
+	codeSnippets       bool
+	// Template actions behind the placeholders of each parsed template,
+	// from parse until resolve
+	templateActions sync.Map
+	typeCheck       bool
+	// Type-checked files by absolute path, from Analyze until parsed
+	typedFiles sync.Map
+
+	// Rules of the ignore files read during the analysis, by directory
+	ignoreRules map[string]ignoreRules
+
+	// The symbol findings are restricted to and the byte ranges of the
+	// functions kept, by absolute path; a nil scope keeps everything
+	symbol    string
+	callGraph bool
+	scope     map[string][]span
+
+	// The last analyzed path and what each of its files contributed, in
+	// walk order, for Reanalyze
+	target      string
+	targetIsDir bool
+	files       map[string]*fileResult
+	order       []string
+	stale       map[string]bool
+
+	findingBudget  time.Duration
+	fileBudget     time.Duration
+	budgetFindings atomic.Int64
+	budgetFiles    atomic.Int64
+
+	// Hooks run on the results of each analysis, and the commands of the
+	// plugins among them
+	postProcess []PostProcess
+	plugins     [][]string
 }
 
-func New() *Analyzer {
-	return &Analyzer{
-		fileSet:  token.NewFileSet(),
-		patterns: patterns.NewPatternMatcher(),
-		resolver: resolver.New(),
-		results: &types.AnalysisResults{
-			Sockets: make([]types.SocketInfo, 0),
-		},
+// New returns an Analyzer with the default settings, changed by opts in
+// order.
+func New(opts ...Option) *Analyzer {
+	a := &Analyzer{
+		patterns:    patterns.NewPatternMatcher(),
+		maxFileSize: DefaultMaxFileSize,
+		maxFiles:    DefaultMaxFiles,
+		maxFindings: DefaultMaxFindings,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 func (a *Analyzer) Analyze(targetPath string) (*types.AnalysisResults, error) {
@@ -37,61 +108,249 @@ func (a *Analyzer) Analyze(targetPath string) (*types.AnalysisResults, error) {
 		return nil, err
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results = newResults()
+	a.budgetFindings.Store(0)
+	a.budgetFiles.Store(0)
+	a.newFileSet()
+
+	a.target, a.targetIsDir = targetPath, info.IsDir()
+	a.files, a.order, a.stale = nil, nil, nil
+	a.ignoreRules = nil
+	a.patterns.ForgetPackages()
+	a.root = targetPath
+	if !info.IsDir() {
+		a.root = filepath.Dir(targetPath)
+	}
+	a.goVersion = moduleGoVersion(targetPath)
+	a.results.GoVersion = a.goVersion
+	a.results.VCS = vcsInfo(targetPath)
+	a.results.Scan = a.scanInfo()
+	a.results.Scan.Root = filepath.ToSlash(a.root)
+	if a.symbol != "" {
+		paths, err := a.scopePaths()
+		if err != nil {
+			return nil, err
+		}
+		if err := a.restrict(paths, os.ReadFile); err != nil {
+			return nil, err
+		}
+	}
+	if a.typeCheck {
+		a.loadTypes(targetPath, info.IsDir())
+		defer a.typedFiles.Clear()
+	}
+
+	analyze := a.analyzeFile
 	if info.IsDir() {
-		return a.analyzeDirectory(targetPath)
+		analyze = a.analyzeDirectory
 	}
-	return a.analyzeFile(targetPath)
+	results, err := analyze(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	return a.postProcessed(results)
 }
 
 func (a *Analyzer) analyzeDirectory(dirPath string) (*types.AnalysisResults, error) {
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !strings.HasSuffix(path, ".go") || strings.Contains(path, "vendor/") {
-			return nil
-		}
+	if err := a.runPipeline(dirPath); err != nil {
+		return nil, err
+	}
+	a.results.Sockets = mergeVariants(a.results.Sockets)
+	if a.collapseUnresolved {
+		a.results.Sockets = collapseUnresolved(a.results.Sockets)
+	}
 
-		_, err = a.analyzeFile(path)
-		return err
-	})
+	a.updateCounts()
+	return a.results, nil
+}
 
+// AnalyzeSource analyzes a single Go file held in memory, such as a snippet
+// under test or the post-image of a patch. Nothing is read from disk;
+// findings have an empty SourceFile.
+func (a *Analyzer) AnalyzeSource(src []byte) (*types.AnalysisResults, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results = newResults()
+	a.budgetFindings.Store(0)
+	a.budgetFiles.Store(0)
+	a.newFileSet()
+	a.results.Scan = a.scanInfo()
+	read := func(string) ([]byte, error) { return src, nil }
+	if err := a.restrict([]string{""}, read); err != nil {
+		return nil, err
+	}
+	results, err := a.collect(a.matchSource("", src))
 	if err != nil {
 		return nil, err
 	}
+	return a.postProcessed(results)
+}
+
+// newFileSet starts a file set for the analysis about to run, so that the
+// files of earlier ones are not kept around.
+func (a *Analyzer) newFileSet() {
+	if !a.sharedFileSet {
+		a.fileSet = token.NewFileSet()
+	}
+}
+
+func newResults() *types.AnalysisResults {
+	return &types.AnalysisResults{Sockets: make([]types.SocketInfo, 0)}
+}
 
+func (a *Analyzer) analyzeFile(filePath string) (*types.AnalysisResults, error) {
+	sockets, err := a.matchFile(filePath)
+	if err == nil {
+		a.record(filePath, &fileResult{sockets: sockets})
+	}
+	return a.collect(sockets, err)
+}
+
+func (a *Analyzer) collect(sockets []types.SocketInfo, err error) (*types.AnalysisResults, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !a.stopped() {
+		a.results.Sockets = append(a.results.Sockets, sockets...)
+	}
+	if a.collapseUnresolved {
+		a.results.Sockets = collapseUnresolved(a.results.Sockets)
+	}
 	a.updateCounts()
 	return a.results, nil
 }
 
-func (a *Analyzer) analyzeFile(filePath string) (*types.AnalysisResults, error) {
-	src, err := os.ReadFile(filePath)
+func (a *Analyzer) matchFile(filePath string) ([]types.SocketInfo, error) {
+	file, err := a.parseFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	return a.resolve(filePath, file, a.match(file)), nil
+}
 
+// matchSource returns the wanted findings of one file without touching the
+// shared results, so files can be matched concurrently.
+func (a *Analyzer) matchSource(filePath string, src []byte) ([]types.SocketInfo, error) {
 	file, err := parser.ParseFile(a.fileSet, filePath, src, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
+	return a.resolve(filePath, file, a.match(file)), nil
+}
 
-	visitor := &astVisitor{
-		analyzer: a,
-		file:     file,
-		filePath: filePath,
+func (a *Analyzer) parseFile(filePath string) (*ast.File, error) {
+	if file, ok := a.typed(filePath); ok {
+		return file, nil
 	}
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if isTemplate(filePath) {
+		var actions []string
+		src, actions = expandTemplate(src)
+		a.templateActions.Store(filePath, actions)
+	}
+	return parser.ParseFile(a.fileSet, filePath, src, parser.ParseComments)
+}
 
+// match returns the wanted findings of file with their addresses still
+// unresolved, stopping at the first one in stop-at-first mode.
+func (a *Analyzer) match(file *ast.File) []patterns.Finding {
+	visitor := &astVisitor{analyzer: a, file: file}
 	ast.Walk(visitor, file)
-	
-	a.updateCounts()
-	return a.results, nil
+
+	for _, finding := range a.patterns.MatchCgoPreamble(file) {
+		visitor.add(finding)
+	}
+	for _, finding := range a.patterns.MatchDeclarations(file) {
+		visitor.add(finding)
+	}
+	return visitor.findings
+}
+
+// resolve completes the findings of file: addresses, listener hand-off,
+// fallback ports, TLS verification, shutdown paths, socket options, DNS search candidates, source position
+// and extent (honoring //line directives), code snippet, process name and logical path.
+func (a *Analyzer) resolve(filePath string, file *ast.File, findings []patterns.Finding) []types.SocketInfo {
+	actions, template := a.templateActions.LoadAndDelete(filePath)
+	defer a.patterns.SetTypesInfo(file, nil)
+	if len(findings) == 0 {
+		return nil
+	}
+	if filePath != "" {
+		a.patterns.SetPackageDir(file, filepath.Dir(filePath))
+		defer a.patterns.SetPackageDir(file, "")
+	}
+	consumers := a.patterns.ListenerConsumers(file)
+	fallbacks := a.patterns.FallbackPorts(file)
+	verification := a.patterns.TLSVerification(file)
+	shutdowns := a.patterns.ShutdownPaths(file)
+	options := a.patterns.SocketOptions(file)
+	mapPaths := filePath != "" && (len(a.layout.GeneratedRoots) > 0 || len(a.layout.ExternalRoots) > 0)
+	buildLine := buildConstraint(filePath, file)
+	budget := a.newResolveBudget()
+
+	sockets := make([]types.SocketInfo, 0, len(findings))
+	for i := range findings {
+		finding := &findings[i]
+		budget.resolve(finding, file)
+		socket := finding.Socket
+		patterns.ApplyListenerUse(socket, consumers[finding.Pos])
+		patterns.ApplyFallbackPorts(socket, fallbacks[finding.Pos])
+		patterns.ApplyTLSVerification(socket, verification)
+		patterns.ApplyShutdown(socket, shutdowns[finding.Pos])
+		patterns.ApplySocketOptions(socket, options[finding.Pos])
+		a.qualify(socket)
+
+		// Report the position in the original source a //line directive
+		// names, keeping the generated one
+		generated := a.fileSet.PositionFor(finding.Pos, false)
+		socket.SourceFile, socket.SourceLine, socket.SourceColumn = filePath, generated.Line, generated.Column
+		adjusted := false
+		if origin := a.fileSet.PositionFor(finding.Pos, true); origin.Filename != generated.Filename || origin.Line != generated.Line {
+			socket.Generated = &types.SourcePosition{File: filePath, Line: generated.Line}
+			socket.SourceLine, socket.SourceColumn = origin.Line, origin.Column
+			adjusted = true
+			// "//line :40" only moves the line
+			if origin.Filename != "" {
+				socket.SourceFile = origin.Filename
+			}
+		}
+		if finding.End.IsValid() {
+			end := a.fileSet.PositionFor(finding.End, adjusted)
+			socket.EndLine, socket.EndColumn = end.Line, end.Column
+		}
+		if a.codeSnippets {
+			socket.CodeSnippet = a.snippet(file, *finding)
+		}
+		if finding.CallSite.IsValid() {
+			socket.ResolvedFrom = &types.SourcePosition{File: socket.SourceFile, Line: a.fileSet.Position(finding.CallSite).Line}
+		}
+		if socket.ProcessName == "" {
+			socket.ProcessName = processName(file, filePath)
+		}
+		if mapPaths {
+			socket.LogicalPath, socket.Module = a.mapPath(filePath)
+		}
+		if buildLine != "" {
+			socket.Variants = []types.BuildVariant{{Constraint: buildLine, SourceFile: filePath, SourceLine: generated.Line}}
+		}
+		if template {
+			markTemplate(socket, actions.([]string))
+		}
+		sockets = append(sockets, *socket)
+	}
+	return sockets
 }
 
 func (a *Analyzer) updateCounts() {
 	a.results.TotalCount = len(a.results.Sockets)
 	a.results.IngressCount = 0
 	a.results.EgressCount = 0
+	a.results.UnknownProtocolCount = 0
 
 	for _, socket := range a.results.Sockets {
 		switch socket.Type {
@@ -100,42 +359,57 @@ func (a *Analyzer) updateCounts() {
 		case types.TrafficTypeEgress:
 			a.results.EgressCount++
 		}
+		if socket.Protocol == types.ProtocolUnknown {
+			a.results.UnknownProtocolCount++
+		}
+	}
+
+	a.results.AttackSurface = types.ComputeAttackSurface(a.results.Sockets)
+	a.results.ResolveBudget = a.budgetSummary()
+	a.truncate()
+	if a.groupByEndpoint {
+		a.results.Endpoints = types.GroupByEndpoint(a.results.Sockets)
 	}
 }
 
 type astVisitor struct {
-	analyzer *Analyzer
-	file     *ast.File
-	filePath string
+	analyzer  *Analyzer
+	file      *ast.File
+	findings  []patterns.Finding
+	functions patterns.FunctionScope
+}
+
+// done reports whether a stop-at-first analysis has what it needs from
+// this file.
+func (v *astVisitor) done() bool {
+	return v.analyzer.stopAtFirst && len(v.findings) > 0
 }
 
 func (v *astVisitor) Visit(node ast.Node) ast.Visitor {
-	callExpr, ok := node.(*ast.CallExpr)
-	if !ok {
-		return v
+	if v.done() {
+		return nil
 	}
+	v.functions.Visit(node)
 
-	position := v.analyzer.fileSet.Position(callExpr.Pos())
-	
-	if socket := v.analyzer.patterns.MatchSocketPattern(callExpr, v.file); socket != nil {
-		socket.SourceFile = v.filePath
-		socket.SourceLine = position.Line
-		
-		if socket.ProcessName == "" {
-			socket.ProcessName = v.deriveProcessName()
-		}
-
-		v.analyzer.resolver.ResolveValues(socket, callExpr, v.file)
-		v.analyzer.results.Sockets = append(v.analyzer.results.Sockets, *socket)
+	for _, finding := range v.analyzer.patterns.MatchUnresolved(node, v.file) {
+		v.functions.Apply(finding.Socket)
+		v.add(finding)
 	}
 
 	return v
 }
 
-func (v *astVisitor) deriveProcessName() string {
-	packageName := v.file.Name.Name
-	if packageName == "main" {
-		return filepath.Base(filepath.Dir(v.filePath))
+func (v *astVisitor) add(finding patterns.Finding) {
+	if !v.analyzer.wanted(finding.Socket) || v.done() || !v.analyzer.inScope(finding.Pos) {
+		return
+	}
+	v.findings = append(v.findings, finding)
+}
+
+func processName(file *ast.File, filePath string) string {
+	packageName := file.Name.Name
+	if packageName == "main" && filePath != "" {
+		return filepath.Base(filepath.Dir(filePath))
 	}
 	return packageName
-}
\ No newline at end of file
+}
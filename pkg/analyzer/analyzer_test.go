@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/yuvalk/staticsocket/pkg/types"
@@ -77,8 +79,8 @@ func main() {
 		if httpServer.Type != types.TrafficTypeIngress {
 			t.Error("HTTP server should be ingress traffic")
 		}
-		if httpServer.ListenPort == nil || *httpServer.ListenPort != 3000 {
-			t.Errorf("Expected HTTP server port 3000, got %v", httpServer.ListenPort)
+		if httpServer.Listen == nil || httpServer.Listen.Port == nil || *httpServer.Listen.Port != 3000 {
+			t.Errorf("Expected HTTP server port 3000, got %v", httpServer.Listen)
 		}
 	}
 
@@ -97,8 +99,8 @@ func main() {
 		if httpClient.Type != types.TrafficTypeEgress {
 			t.Error("HTTP client should be egress traffic")
 		}
-		if httpClient.DestinationHost == nil || *httpClient.DestinationHost != "api.example.com" {
-			t.Errorf("Expected destination host api.example.com, got %v", httpClient.DestinationHost)
+		if httpClient.Destination == nil || httpClient.Destination.Host != "api.example.com" {
+			t.Errorf("Expected destination host api.example.com, got %v", httpClient.Destination)
 		}
 	}
 }
@@ -193,6 +195,24 @@ func TestAnalyzer_IntegrationWithTestData(t *testing.T) {
 	t.Logf("JSON output:\n%s", jsonData)
 }
 
+func TestAnalyzer_VariableURLGuesses(t *testing.T) {
+	results, err := New().Analyze("../../testdata/samples/http_variables.go")
+	if err != nil {
+		t.Fatalf("Failed to analyze sample: %v", err)
+	}
+	for _, socket := range results.Sockets {
+		if socket.SourceLine != 25 {
+			continue
+		}
+		// http.Get(apiURL), with apiURL read from the environment
+		if socket.RawValue != "apiURL" || socket.Destination == nil || socket.Destination.Host != "external-service" || socket.Destination.Port != nil {
+			t.Errorf("Expected the guessed external service, not a URL parsed from the variable name, got %+v", socket.Destination)
+		}
+		return
+	}
+	t.Error("Expected a finding at line 25")
+}
+
 func TestAnalyzer_EmptyFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "empty.go")
@@ -258,4 +278,327 @@ func TestDeriveProcessName(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestAnalyzer_SkipsUnparsableFilesInDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/svc\n\ngo 1.99\n",
+		"server.go": "package main\nimport \"net/http\"\nfunc main() { http.ListenAndServe(\":8080\", nil) }",
+		"future.go": "package main\nfunc f() { this is not go syntax {{{ }",
+	}
+	for filename, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, filename), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", filename, err)
+		}
+	}
+
+	analyzer := New()
+	results, err := analyzer.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected unparsable file to be skipped, got error: %v", err)
+	}
+
+	if results.TotalCount != 1 {
+		t.Errorf("Expected 1 socket from the parsable file, got %d", results.TotalCount)
+	}
+	if results.GoVersion != "go1.99" {
+		t.Errorf("Expected module go version go1.99, got %q", results.GoVersion)
+	}
+	if len(results.Errors) != 1 {
+		t.Fatalf("Expected 1 error entry, got %d", len(results.Errors))
+	}
+	if results.Errors[0].File != filepath.Join(tmpDir, "future.go") {
+		t.Errorf("Expected error for future.go, got %s", results.Errors[0].File)
+	}
+	if !strings.Contains(results.Errors[0].Message, "module declares go1.99") {
+		t.Errorf("Expected toolchain mismatch hint, got %q", results.Errors[0].Message)
+	}
+}
+
+func TestAnalyzer_AnalyzeSource(t *testing.T) {
+	src := []byte(`package main
+
+import "net/http"
+
+func main() {
+	http.ListenAndServe(":8080", nil)
+}`)
+
+	results, err := New().AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if results.TotalCount != 1 || results.IngressCount != 1 {
+		t.Fatalf("Expected 1 ingress finding, got %d", results.TotalCount)
+	}
+
+	socket := results.Sockets[0]
+	if socket.SourceFile != "" || socket.SourceLine != 6 || socket.ProcessName != "main" {
+		t.Errorf("Unexpected in-memory finding: %+v", socket)
+	}
+
+	if _, err := New().AnalyzeSource([]byte("package main\nfunc {")); err == nil {
+		t.Error("Expected a parse error for invalid source")
+	}
+}
+
+// TestAnalyzer_NegativeCorpus guards against false positives: nothing in
+// testdata/negative opens a socket, so every finding there is a regression.
+func TestAnalyzer_NegativeCorpus(t *testing.T) {
+	analyzer := New()
+	results, err := analyzer.Analyze("../../testdata/negative")
+	if err != nil {
+		t.Fatalf("Failed to analyze negative corpus: %v", err)
+	}
+
+	for _, e := range results.Errors {
+		t.Errorf("Negative corpus file could not be analyzed: %+v", e)
+	}
+	for _, socket := range results.Sockets {
+		t.Errorf("False positive at %s:%d: %s %s (%s)",
+			socket.SourceFile, socket.SourceLine, socket.Type, socket.Protocol, socket.PatternMatch)
+	}
+}
+
+func TestAnalyzer_LineDirectives(t *testing.T) {
+	dir := t.TempDir()
+	code := `// Code generated by protoc-gen-foo. DO NOT EDIT.
+
+package gen
+
+import "net"
+
+//line api/service.proto:12
+func serve() { net.Listen("tcp", ":9090") }
+
+//line :40
+func dial() { net.Dial("tcp", "db:5432") }
+
+func direct() { net.Dial("tcp", "cache:6379") }
+`
+	path := filepath.Join(dir, "service.pb.go")
+	if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New().Analyze(path)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(results.Sockets))
+	}
+
+	expected := []struct {
+		file          string
+		line          int
+		generatedLine int
+	}{
+		{filepath.Join(dir, "api/service.proto"), 12, 8},
+		{path, 40, 11},
+		// The directive applies until the next one
+		{path, 42, 13},
+	}
+	for i, want := range expected {
+		socket := results.Sockets[i]
+		if socket.SourceFile != want.file || socket.SourceLine != want.line {
+			t.Errorf("Finding %d: expected %s:%d, got %s:%d", i, want.file, want.line, socket.SourceFile, socket.SourceLine)
+		}
+		if socket.Generated == nil || socket.Generated.File != path || socket.Generated.Line != want.generatedLine {
+			t.Errorf("Finding %d: expected generated position %s:%d, got %+v", i, path, want.generatedLine, socket.Generated)
+		}
+	}
+}
+
+func TestAnalyzer_ResolvedFromCallSite(t *testing.T) {
+	src := []byte(`package main
+
+import "net"
+
+func connect(addr string) (net.Conn, error) {
+	return net.Dial("tcp", addr)
+}
+
+func main() {
+	connect("db.internal:5432")
+}
+`)
+	results, err := New().AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Fatalf("Expected 1 finding, got %d", results.TotalCount)
+	}
+	socket := results.Sockets[0]
+	if !socket.IsResolved || socket.Destination.Host != "db.internal" || *socket.Destination.Port != 5432 {
+		t.Errorf("Expected db.internal:5432, got %+v", socket.Destination)
+	}
+	if socket.SourceLine != 6 || socket.ResolvedFrom == nil || socket.ResolvedFrom.Line != 10 {
+		t.Errorf("Expected the finding on line 6 resolved from line 10, got %d from %+v", socket.SourceLine, socket.ResolvedFrom)
+	}
+}
+
+func TestAnalyzer_FunctionName(t *testing.T) {
+	src := []byte(`package main
+
+import (
+	"net"
+	"net/http"
+)
+
+type Server struct{}
+
+func (s *Server) Start() {
+	http.ListenAndServe(":8080", nil)
+	go func() {
+		net.Dial("tcp", "db.internal:5432")
+	}()
+}
+
+func main() {
+	net.Listen("tcp", ":9090")
+}
+`)
+	results, err := New().AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	want := []string{"(*Server).Start", "(*Server).Start.func1", "main"}
+	if len(results.Sockets) != len(want) {
+		t.Fatalf("Expected %d findings, got %d", len(want), len(results.Sockets))
+	}
+	for i, name := range want {
+		if got := results.Sockets[i].FunctionName; got != name {
+			t.Errorf("Finding %d: expected function %q, got %q", i, name, got)
+		}
+	}
+}
+
+func TestAnalyzer_PackageConstants(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n",
+		"config/config.go": `package config
+
+const ListenAddr = ":8443"
+const Database = "db.internal:5432"
+`,
+		"main.go": `package main
+
+import (
+	"net"
+	"net/http"
+
+	"example.com/app/config"
+)
+
+func main() {
+	net.Dial("tcp", config.Database)
+	http.ListenAndServe(config.ListenAddr, nil)
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := New().Analyze(root)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 2 {
+		t.Fatalf("Expected 2 findings, got %d", results.TotalCount)
+	}
+	for _, socket := range results.Sockets {
+		if !socket.IsResolved {
+			t.Errorf("Expected %s to resolve from the config package, got raw value %q", socket.PatternMatch, socket.RawValue)
+		}
+	}
+	if dial := results.Sockets[0]; dial.Destination == nil || dial.Destination.String() != "db.internal:5432" {
+		t.Errorf("Expected db.internal:5432, got %+v", dial.Destination)
+	}
+	if listen := results.Sockets[1]; listen.Listen == nil || *listen.Listen.Port != 8443 {
+		t.Errorf("Expected a listener on 8443, got %+v", listen.Listen)
+	}
+}
+
+func TestAnalyzer_FreshResultsPerCall(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"server.go": "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":8080\") }\n",
+	})
+
+	analyzer := New()
+	first, err := analyzer.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	second, err := analyzer.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if first == second || first.TotalCount != 1 || second.TotalCount != 1 {
+		t.Errorf("Expected independent results of one finding each, got %d and %d", first.TotalCount, second.TotalCount)
+	}
+
+	source, err := analyzer.AnalyzeSource([]byte("package main\nimport \"net\"\nfunc main() { net.Dial(\"tcp\", \"db:5432\") }\n"))
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if source.TotalCount != 1 || source.EgressCount != 1 || second.TotalCount != 1 {
+		t.Errorf("Expected AnalyzeSource to start from empty results, got %d findings", source.TotalCount)
+	}
+}
+
+func TestAnalyzer_ConcurrentAnalyze(t *testing.T) {
+	analyzer := New()
+	src := []byte("package main\nimport \"net\"\nfunc main() {\n\tnet.Listen(\"tcp\", \":8080\")\n\tnet.Dial(\"tcp\", \"db:5432\")\n}\n")
+
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := analyzer.AnalyzeSource(src)
+			if err != nil {
+				t.Errorf("AnalyzeSource failed: %v", err)
+				return
+			}
+			counts[i] = results.TotalCount
+		}()
+	}
+	wg.Wait()
+	for i, count := range counts {
+		if count != 2 {
+			t.Errorf("Call %d: expected 2 findings, got %d", i, count)
+		}
+	}
+}
+
+func TestAnalyzer_UnknownProtocolCount(t *testing.T) {
+	src := []byte(`package main
+
+import "net"
+
+func dial(network string) {
+	net.Dial(network, "collector:8125")
+	net.Dial("udp", "collector:8125")
+}`)
+
+	results, err := New().AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if results.EgressCount != 2 || results.UnknownProtocolCount != 1 {
+		t.Errorf("Expected 2 egress findings, 1 of unknown protocol, got %d and %d", results.EgressCount, results.UnknownProtocolCount)
+	}
+}
@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"context"
+	"go/ast"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// now is the clock budgets are measured against.
+var now = time.Now
+
+// SetResolveBudget bounds how long resolving one finding, and all findings
+// of one file, may take. Findings out of time are reported unresolved with
+// reason budget-exceeded instead of stalling the scan. Zero or a negative
+// value disables a bound, as New does for both: which findings run out of
+// time depends on the machine, so bounded results are not reproducible.
+func (a *Analyzer) SetResolveBudget(perFinding, perFile time.Duration) {
+	a.findingBudget = perFinding
+	a.fileBudget = perFile
+}
+
+// resolveBudget tracks the resolution time left for one file.
+type resolveBudget struct {
+	analyzer *Analyzer
+	deadline time.Time
+	exceeded bool
+}
+
+func (a *Analyzer) newResolveBudget() *resolveBudget {
+	b := &resolveBudget{analyzer: a}
+	if a.fileBudget > 0 {
+		b.deadline = now().Add(a.fileBudget)
+	}
+	return b
+}
+
+// resolve resolves finding within the budget, degrading it to unresolved
+// when time runs out.
+func (b *resolveBudget) resolve(finding *patterns.Finding, file *ast.File) {
+	a := b.analyzer
+	if !finding.NeedsResolve() || finding.Socket.IsResolved {
+		a.patterns.Resolve(finding, file)
+		return
+	}
+
+	limit := a.findingBudget
+	if !b.deadline.IsZero() {
+		left := b.deadline.Sub(now())
+		if left <= 0 {
+			b.exceed(finding, file)
+			return
+		}
+		if limit <= 0 || left < limit {
+			limit = left
+		}
+	}
+	if limit <= 0 {
+		a.patterns.Resolve(finding, file)
+		return
+	}
+
+	// Resolve a copy, so that a resolution cut short leaves nothing of
+	// what it got to in the reported finding.
+	resolved := *finding
+	resolved.Socket = cloneSocket(finding.Socket)
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+	a.patterns.ResolveContext(ctx, &resolved, file)
+	if ctx.Err() == nil {
+		*finding = resolved
+		return
+	}
+	a.budgetFindings.Add(1)
+	degrade(finding.Socket)
+	log.Printf("Resolution budget of %s exceeded for %s", limit, finding.Socket.PatternMatch)
+}
+
+// exceed degrades a finding left unresolved because the file ran out of
+// time, counting the file once.
+func (b *resolveBudget) exceed(finding *patterns.Finding, file *ast.File) {
+	if !b.exceeded {
+		b.exceeded = true
+		b.analyzer.budgetFiles.Add(1)
+		log.Printf("Resolution budget of %s exceeded for %s", b.analyzer.fileBudget, b.analyzer.fileSet.Position(file.Pos()).Filename)
+	}
+	b.analyzer.budgetFindings.Add(1)
+	degrade(finding.Socket)
+}
+
+func degrade(socket *types.SocketInfo) {
+	socket.IsResolved = false
+	socket.UnresolvedReason = types.UnresolvedBudgetExceeded
+}
+
+// budgetSummary reports the budget counters, or nil if no budget ran out.
+func (a *Analyzer) budgetSummary() *types.BudgetSummary {
+	findings, files := a.budgetFindings.Load(), a.budgetFiles.Load()
+	if findings == 0 && files == 0 {
+		return nil
+	}
+	return &types.BudgetSummary{FindingsExceeded: int(findings), FilesExceeded: int(files)}
+}
+
+// cloneSocket returns a deep copy of socket, which shares nothing with it.
+func cloneSocket(socket *types.SocketInfo) *types.SocketInfo {
+	clone := *socket
+	clone.Listen = cloneEndpoint(socket.Listen)
+	clone.Destination = cloneEndpoint(socket.Destination)
+	clone.Generated = clonePointer(socket.Generated)
+	clone.ResolvedFrom = clonePointer(socket.ResolvedFrom)
+	clone.VerifiesTLS = clonePointer(socket.VerifiesTLS)
+	clone.CandidatePorts = slices.Clone(socket.CandidatePorts)
+	clone.CandidateValues = slices.Clone(socket.CandidateValues)
+	clone.Tags = slices.Clone(socket.Tags)
+	clone.Notes = slices.Clone(socket.Notes)
+	clone.ConsumedBy = slices.Clone(socket.ConsumedBy)
+	clone.Facets = slices.Clone(socket.Facets)
+	clone.ShutdownSignals = slices.Clone(socket.ShutdownSignals)
+	clone.SocketOptions = slices.Clone(socket.SocketOptions)
+	clone.Variants = slices.Clone(socket.Variants)
+	return &clone
+}
+
+func cloneEndpoint(endpoint *types.Endpoint) *types.Endpoint {
+	if endpoint == nil {
+		return nil
+	}
+	clone := *endpoint
+	clone.Port = clonePointer(endpoint.Port)
+	clone.PortRange = clonePointer(endpoint.PortRange)
+	clone.FQDNCandidates = slices.Clone(endpoint.FQDNCandidates)
+	return &clone
+}
+
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	return &clone
+}
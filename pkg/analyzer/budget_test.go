@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+const budgetSource = `package main
+
+import "net"
+
+func main() {
+	net.Listen("tcp", ":8080")
+	net.Dial("tcp", peerAddress())
+	net.Dial("tcp", backupAddress())
+}
+`
+
+// tickingClock advances a minute every time it is read.
+func tickingClock(t *testing.T) {
+	t.Helper()
+	current := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		current = current.Add(time.Minute)
+		return current
+	}
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestAnalyzer_FileBudgetExceeded(t *testing.T) {
+	tickingClock(t)
+
+	analyzer := New(WithResolveBudget(2*time.Second, 30*time.Second))
+	results, err := analyzer.AnalyzeSource([]byte(budgetSource))
+	if err != nil {
+		t.Fatalf("Failed to analyze source: %v", err)
+	}
+
+	if results.TotalCount != 3 {
+		t.Fatalf("Expected 3 findings, got %d", results.TotalCount)
+	}
+	if listener := results.Sockets[0]; !listener.IsResolved || listener.UnresolvedReason != "" {
+		t.Errorf("Literal listener should stay resolved, got %+v", listener)
+	}
+	for _, socket := range results.Sockets[1:] {
+		if socket.IsResolved || socket.UnresolvedReason != types.UnresolvedBudgetExceeded {
+			t.Errorf("Expected %s at line %d to be degraded, got resolved=%t reason=%q",
+				socket.PatternMatch, socket.SourceLine, socket.IsResolved, socket.UnresolvedReason)
+		}
+	}
+
+	want := types.BudgetSummary{FindingsExceeded: 2, FilesExceeded: 1}
+	if results.ResolveBudget == nil || *results.ResolveBudget != want {
+		t.Errorf("Expected budget summary %+v, got %+v", want, results.ResolveBudget)
+	}
+}
+
+func TestAnalyzer_ResolveBudgetDisabled(t *testing.T) {
+	tickingClock(t)
+
+	// Budgets are off unless set, keeping results reproducible
+	analyzer := New()
+	results, err := analyzer.AnalyzeSource([]byte(budgetSource))
+	if err != nil {
+		t.Fatalf("Failed to analyze source: %v", err)
+	}
+
+	for _, socket := range results.Sockets {
+		if socket.UnresolvedReason != "" {
+			t.Errorf("Expected no budget with budgets disabled, got %q at line %d", socket.UnresolvedReason, socket.SourceLine)
+		}
+	}
+	if results.ResolveBudget != nil {
+		t.Errorf("Expected no budget summary, got %+v", results.ResolveBudget)
+	}
+}
+
+func TestAnalyzer_ResolveBudgetConfigHash(t *testing.T) {
+	unbounded := New().ConfigHash()
+	if New(WithResolveBudget(0, 0)).ConfigHash() != unbounded {
+		t.Error("Expected disabled budgets to keep the configuration hash")
+	}
+	finding := New(WithResolveBudget(time.Second, 0)).ConfigHash()
+	file := New(WithResolveBudget(0, time.Second)).ConfigHash()
+	if finding == unbounded || file == unbounded || finding == file {
+		t.Error("Expected each budget to change the configuration hash")
+	}
+}
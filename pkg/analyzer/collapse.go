@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// SetCollapseUnresolved reports unresolved findings that share a source
+// expression, such as every dial of cfg.Upstream.URL, once with the number
+// of occurrences, so dynamic endpoints do not dominate large reports.
+func (a *Analyzer) SetCollapseUnresolved(collapse bool) {
+	a.collapseUnresolved = collapse
+}
+
+// collapseUnresolved folds unresolved findings with the same traffic type,
+// protocol, pattern and raw expression into the first of them, counting
+// the occurrences. Resolved findings are left alone.
+func collapseUnresolved(sockets []types.SocketInfo) []types.SocketInfo {
+	type key struct {
+		trafficType types.TrafficType
+		protocol    types.Protocol
+		pattern     string
+		raw         string
+	}
+	collapsed := make([]types.SocketInfo, 0, len(sockets))
+	first := make(map[key]int)
+	for _, socket := range sockets {
+		if socket.IsResolved || socket.RawValue == "" {
+			collapsed = append(collapsed, socket)
+			continue
+		}
+
+		k := key{socket.Type, socket.Protocol, socket.PatternMatch, socket.RawValue}
+		i, ok := first[k]
+		if !ok {
+			first[k] = len(collapsed)
+			collapsed = append(collapsed, socket)
+			continue
+		}
+		collapsed[i].Occurrences = collapsed[i].OccurrenceCount() + socket.OccurrenceCount()
+	}
+	return collapsed
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzer_CollapseUnresolved(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go": "package a\nimport \"net/http\"\nfunc f() { http.Get(cfg.Upstream.URL); http.Get(cfg.Upstream.URL) }\n",
+		"b.go": "package a\nimport \"net/http\"\nfunc g() { http.Get(cfg.Upstream.URL); http.Get(\"http://example.com\"); http.Get(\"http://example.com\") }\n",
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := New()
+	a.SetCollapseUnresolved(true)
+	if a.ConfigHash() == New().ConfigHash() {
+		t.Error("Expected collapsing to change the configuration hash")
+	}
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 3 {
+		t.Fatalf("Expected 1 collapsed and 2 resolved findings, got %d", len(results.Sockets))
+	}
+	dynamic := results.Sockets[0]
+	if dynamic.IsResolved || dynamic.RawValue != "cfg.Upstream.URL" || dynamic.Occurrences != 3 {
+		t.Errorf("Expected cfg.Upstream.URL with 3 occurrences, got %q with %d", dynamic.RawValue, dynamic.Occurrences)
+	}
+	for _, socket := range results.Sockets[1:] {
+		if !socket.IsResolved || socket.Occurrences != 0 {
+			t.Errorf("Expected resolved findings to be kept as is, got %+v", socket)
+		}
+	}
+}
+
+func TestAnalyzer_CollapseUnresolvedGroupByEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	code := "package a\nimport \"net/http\"\nfunc f() { http.Get(cfg.Upstream.URL); http.Get(cfg.Upstream.URL); http.Get(\"http://example.com\") }\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New()
+	a.SetCollapseUnresolved(true)
+	a.SetGroupByEndpoint(true)
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, group := range results.Endpoints {
+		counts[group.Endpoint] = group.Count
+	}
+	if counts["cfg.upstream.url"] != 2 || counts["example.com:80"] != 1 {
+		t.Errorf("Expected 2 occurrences of cfg.Upstream.URL and 1 of example.com:80, got %v", counts)
+	}
+}
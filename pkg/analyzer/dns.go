@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// KubernetesDNSSearch is the search path of a pod in the default
+// namespace.
+var KubernetesDNSSearch = []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"}
+
+// SetDNSSearch sets the DNS search path of the environment the analyzed
+// code runs in. Unqualified destinations such as "payments" keep their raw
+// host and list one fully-qualified candidate per search domain.
+func (a *Analyzer) SetDNSSearch(domains []string) {
+	a.dnsSearch = domains
+}
+
+// qualify lists the fully-qualified candidates of an unqualified
+// destination host. Like a resolver with the default ndots:1, only names
+// without a dot are completed.
+func (a *Analyzer) qualify(socket *types.SocketInfo) {
+	destination := socket.Destination
+	if len(a.dnsSearch) == 0 || destination == nil || destination.Kind != types.EndpointKindHostname {
+		return
+	}
+	host := destination.Host
+	if strings.Contains(host, ".") || strings.EqualFold(host, "localhost") {
+		return
+	}
+	destination.FQDNCandidates = nil
+	for _, domain := range a.dnsSearch {
+		if domain = strings.Trim(domain, "."); domain != "" {
+			destination.FQDNCandidates = append(destination.FQDNCandidates, host+"."+domain)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzer_DNSSearch(t *testing.T) {
+	src := []byte(`package main
+
+import "net"
+
+func main() {
+	net.Dial("tcp", "payments:8080")
+	net.Dial("tcp", "ledger.finance:5432")
+	net.Dial("tcp", "10.0.0.7:6379")
+	net.Dial("tcp", "localhost:9000")
+}
+`)
+
+	analyzer := New()
+	analyzer.SetDNSSearch(KubernetesDNSSearch)
+	results, err := analyzer.AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("Failed to analyze source: %v", err)
+	}
+	if results.TotalCount != 4 {
+		t.Fatalf("Expected 4 findings, got %d", results.TotalCount)
+	}
+
+	payments := results.Sockets[0].Destination
+	want := []string{"payments.default.svc.cluster.local", "payments.svc.cluster.local", "payments.cluster.local"}
+	if payments.Host != "payments" || !reflect.DeepEqual(payments.FQDNCandidates, want) {
+		t.Errorf("Expected raw host payments with candidates %v, got %s %v", want, payments.Host, payments.FQDNCandidates)
+	}
+	for _, socket := range results.Sockets[1:] {
+		if socket.Destination.FQDNCandidates != nil {
+			t.Errorf("Expected no candidates for %s, got %v", socket.Destination.Host, socket.Destination.FQDNCandidates)
+		}
+	}
+}
+
+func TestAnalyzer_NoDNSSearch(t *testing.T) {
+	results, err := New().AnalyzeSource([]byte("package main\nimport \"net\"\nfunc f() { net.Dial(\"tcp\", \"payments:8080\") }\n"))
+	if err != nil {
+		t.Fatalf("Failed to analyze source: %v", err)
+	}
+	if candidates := results.Sockets[0].Destination.FQDNCandidates; candidates != nil {
+		t.Errorf("Expected no candidates without a search path, got %v", candidates)
+	}
+}
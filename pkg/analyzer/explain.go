@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+)
+
+// Explain says how each call on one line of a file is treated: the
+// pattern it matched and how its address was resolved, or why no pattern
+// matched it. Literals and assignments on the line are included when they
+// match. Findings the traffic type filter drops are left out, and said so.
+func (a *Analyzer) Explain(filePath string, line int) ([]patterns.Explanation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.newFileSet()
+	file, err := a.parseFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	a.patterns.SetPackageDir(file, filepath.Dir(filePath))
+	defer a.patterns.SetPackageDir(file, "")
+
+	var explanations []patterns.Explanation
+	var scope patterns.FunctionScope
+	ast.Inspect(file, func(node ast.Node) bool {
+		scope.Visit(node)
+		if node == nil || a.fileSet.PositionFor(node.Pos(), false).Line != line {
+			return true
+		}
+		explanation, ok := a.patterns.Explain(node, file)
+		if !ok {
+			return true
+		}
+		for _, finding := range explanation.Findings {
+			scope.Apply(finding.Socket)
+		}
+		explanations = append(explanations, explanation)
+		return true
+	})
+
+	for i := range explanations {
+		explanation := &explanations[i]
+		var wanted []patterns.Finding
+		for _, finding := range explanation.Findings {
+			if a.wanted(finding.Socket) {
+				wanted = append(wanted, finding)
+			}
+		}
+		if len(wanted) == 0 && len(explanation.Findings) > 0 {
+			explanation.Reason = fmt.Sprintf("%s is %s, which the traffic type filter drops", explanation.Pattern, explanation.Findings[0].Socket.Type)
+		}
+		explanation.Findings = wanted
+		a.resolve(filePath, file, wanted)
+	}
+	return explanations, nil
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestAnalyzer_Explain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	code := `package main
+
+import (
+	"net"
+	"net/http"
+)
+
+func main() {
+	net.Listen("tcp", addr); http.Get("https://api.example.com")
+}
+`
+	if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "config.go"), []byte("package main\n\nconst addr = \":9090\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	explanations, err := New().Explain(path, 9)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(explanations) != 2 {
+		t.Fatalf("Expected 2 explanations, got %+v", explanations)
+	}
+	listen := explanations[0]
+	if listen.Node != "net.Listen" || len(listen.Findings) != 1 {
+		t.Fatalf("Expected net.Listen with one finding, got %+v", listen)
+	}
+	socket := listen.Findings[0].Socket
+	if !socket.IsResolved || socket.EndpointName() != "0.0.0.0:9090" || socket.FunctionName != "main" || socket.SourceLine != 9 {
+		t.Errorf("Expected the listener resolved from the package constant, got %+v", socket)
+	}
+
+	a := New()
+	a.SetTrafficType(types.TrafficTypeEgress)
+	explanations, err = a.Explain(path, 9)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(explanations[0].Findings) != 0 || !strings.Contains(explanations[0].Reason, "traffic type filter") {
+		t.Errorf("Expected the ingress finding to be filtered out, got %+v", explanations[0])
+	}
+	if len(explanations[1].Findings) != 1 || explanations[1].Findings[0].Socket.VerifiesTLS == nil {
+		t.Errorf("Expected the https egress with TLS verification recorded, got %+v", explanations[1])
+	}
+
+	if explanations, _ := New().Explain(path, 1); len(explanations) != 0 {
+		t.Errorf("Expected no explanations for the package clause, got %+v", explanations)
+	}
+	if _, err := New().Explain(filepath.Join(filepath.Dir(path), "missing.go"), 1); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
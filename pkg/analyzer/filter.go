@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// SetTrafficType restricts findings to one traffic type. The empty value
+// keeps both ingress and egress.
+func (a *Analyzer) SetTrafficType(trafficType types.TrafficType) {
+	a.trafficType = trafficType
+}
+
+// SetStopAtFirst makes Analyze return as soon as one finding is recorded,
+// for presence checks on large trees. Results then hold that one finding.
+func (a *Analyzer) SetStopAtFirst(stop bool) {
+	a.stopAtFirst = stop
+}
+
+// wanted reports whether a finding passes the traffic type filter.
+func (a *Analyzer) wanted(socket *types.SocketInfo) bool {
+	return a.trafficType == "" || socket.Type == a.trafficType
+}
+
+// stopped reports whether a stop-at-first analysis already has its finding.
+func (a *Analyzer) stopped() bool {
+	return a.stopAtFirst && len(a.results.Sockets) > 0
+}
+
+// SetGroupByEndpoint adds the findings grouped by listener or destination
+// to the results, each group listing every source location that references
+// it.
+func (a *Analyzer) SetGroupByEndpoint(group bool) {
+	a.groupByEndpoint = group
+}
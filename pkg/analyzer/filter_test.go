@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func writeFilterFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/client.go": "package a\nimport \"net/http\"\nfunc f() { http.Get(\"http://example.com\") }\n",
+		"b/server.go": "package b\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":80\"); net.Listen(\"tcp\", \":81\") }\n",
+		"c/server.go": "package c\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":82\") }\n",
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAnalyzer_TrafficTypeFilter(t *testing.T) {
+	a := New()
+	a.SetTrafficType(types.TrafficTypeIngress)
+	results, err := a.Analyze(writeFilterFixture(t))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 3 || results.EgressCount != 0 {
+		t.Errorf("Expected only the 3 listeners, got %d findings (%d egress)", results.TotalCount, results.EgressCount)
+	}
+}
+
+func TestAnalyzer_StopAtFirst(t *testing.T) {
+	a := New()
+	a.SetTrafficType(types.TrafficTypeIngress)
+	a.SetStopAtFirst(true)
+	results, err := a.Analyze(writeFilterFixture(t))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 1 {
+		t.Fatalf("Expected a single finding, got %d", len(results.Sockets))
+	}
+	if socket := results.Sockets[0]; filepath.Base(filepath.Dir(socket.SourceFile)) != "b" || *socket.Listen.Port != 80 {
+		t.Errorf("Expected the first listener in walk order, got %s:%d", socket.SourceFile, socket.SourceLine)
+	}
+
+	a = New()
+	a.SetTrafficType(types.TrafficTypeEgress)
+	a.SetStopAtFirst(true)
+	results, err = a.Analyze(filepath.Join(writeFilterFixture(t), "c"))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 0 {
+		t.Errorf("Expected no egress in c/, got %d", len(results.Sockets))
+	}
+}
+
+func TestAnalyzer_GroupByEndpoint(t *testing.T) {
+	a := New()
+	results, err := a.Analyze(writeFilterFixture(t))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.Endpoints != nil {
+		t.Errorf("Expected no endpoint groups by default, got %d", len(results.Endpoints))
+	}
+
+	a = New()
+	a.SetGroupByEndpoint(true)
+	results, err = a.Analyze(writeFilterFixture(t))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Endpoints) != 4 {
+		t.Errorf("Expected 4 endpoint groups, got %d", len(results.Endpoints))
+	}
+}
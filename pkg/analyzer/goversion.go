@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/version"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// moduleGoVersion returns the go directive of the nearest go.mod at or above
+// path, in toolchain syntax ("go1.22"), or "" when there is none.
+func moduleGoVersion(path string) string {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		if goVersion, ok := readGoDirective(filepath.Join(dir, "go.mod")); ok {
+			return goVersion
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func readGoDirective(goModPath string) (string, bool) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "go" {
+			return "go" + fields[1], true
+		}
+	}
+	return "", true
+}
+
+// skippedFileError converts a syntax error into an entry for the results'
+// Errors section, so one file using syntax the analyzer cannot parse does
+// not fail the whole scan. It returns nil for any other kind of error.
+func (a *Analyzer) skippedFileError(path string, err error) *types.AnalysisError {
+	var syntaxErrs scanner.ErrorList
+	if !errors.As(err, &syntaxErrs) {
+		return nil
+	}
+
+	message := fmt.Sprintf("skipped: %v", err)
+	toolchain := runtime.Version()
+	if a.goVersion != "" && version.IsValid(toolchain) && version.Compare(a.goVersion, toolchain) > 0 {
+		message += fmt.Sprintf(" (module declares %s, analyzer was built with %s and may not support its syntax)",
+			a.goVersion, toolchain)
+	}
+	return &types.AnalysisError{File: path, Message: message}
+}
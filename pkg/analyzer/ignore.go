@@ -0,0 +1,210 @@
+package analyzer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFiles are the files whose patterns directory walks honor, in the
+// order they apply within a directory.
+var IgnoreFiles = []string{".gitignore", ".staticsocketignore"}
+
+// SetIgnoreFiles selects whether directory walks skip the files and
+// directories matched by the .gitignore and .staticsocketignore files found
+// in the analyzed directory and below, which they do by default, so build
+// output and experiments kept out of git are kept out of the results too.
+// Patterns follow gitignore syntax, including negation with "!"; a file in
+// an ignored directory cannot be re-included.
+func (a *Analyzer) SetIgnoreFiles(honor bool) {
+	a.noIgnoreFiles = !honor
+}
+
+// ignoreRule is one pattern of an ignore file.
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules are the rules of the ignore files of one directory, matched
+// against paths relative to it.
+type ignoreRules []ignoreRule
+
+// readIgnoreRules reads the ignore files of dir; a directory without any
+// has no rules.
+func readIgnoreRules(dir string) ignoreRules {
+	var rules ignoreRules
+	for _, name := range IgnoreFiles {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		file.Close()
+	}
+	return rules
+}
+
+// parseIgnoreRule converts a line of gitignore syntax into a rule,
+// reporting false for blank lines and comments.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(line, `\`) {
+		// a trailing "\ " escapes the space trimmed above
+		line += " "
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// A pattern with a slash other than at its end is relative to the
+	// directory of its file; others match a name at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			expr.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			expr.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	pattern, err := regexp.Compile(expr.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// match reports whether the rules decide rel, a slash-separated path
+// relative to their directory, and whether they ignore it: the last
+// matching rule wins.
+func (rules ignoreRules) match(rel string, isDir bool) (ignored, matched bool) {
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(rel) {
+			ignored, matched = !rule.negate, true
+		}
+	}
+	return ignored, matched
+}
+
+// ignoreRulesOf returns the rules of the ignore files of dir, read once
+// per analysis.
+func (a *Analyzer) ignoreRulesOf(dir string) ignoreRules {
+	if a.ignoreRules == nil {
+		a.ignoreRules = make(map[string]ignoreRules)
+	}
+	rules, ok := a.ignoreRules[dir]
+	if !ok {
+		rules = readIgnoreRules(dir)
+		a.ignoreRules[dir] = rules
+	}
+	return rules
+}
+
+// ignoredEntry reports whether the ignore files of the directories from
+// the analyzed root down to the parent of path ignore it.
+func (a *Analyzer) ignoredEntry(path string, isDir bool) bool {
+	if a.noIgnoreFiles {
+		return false
+	}
+	rel, err := filepath.Rel(a.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	ignored := false
+	dir, below := a.root, rel
+	for {
+		if decided, matched := a.ignoreRulesOf(dir).match(below, isDir); matched {
+			ignored = decided
+		}
+		next, rest, ok := strings.Cut(below, "/")
+		if !ok {
+			return ignored
+		}
+		dir, below = filepath.Join(dir, next), rest
+	}
+}
+
+// ignored reports whether path, a file below the analyzed root, is ignored
+// itself or lies in an ignored directory.
+func (a *Analyzer) ignored(path string) bool {
+	if a.noIgnoreFiles {
+		return false
+	}
+	rel, err := filepath.Rel(a.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	dir := a.root
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if a.ignoredEntry(dir, true) {
+			return true
+		}
+	}
+	return a.ignoredEntry(path, false)
+}
@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line    string
+		path    string
+		isDir   bool
+		ignored bool
+		matched bool
+	}{
+		{"build/", "build", true, true, true},
+		{"build/", "build", false, false, false},
+		{"build/", "cmd/build", true, true, true},
+		{"/gen", "gen", true, true, true},
+		{"/gen", "pkg/gen", true, false, false},
+		{"*.pb.go", "api/v1/service.pb.go", false, true, true},
+		{"docs/*.go", "docs/example.go", false, true, true},
+		{"docs/*.go", "docs/sub/example.go", false, false, false},
+		{"**/mocks", "internal/x/mocks", true, true, true},
+		{"exp/**", "exp/a/b.go", false, true, true},
+		{"a/**/b.go", "a/x/y/b.go", false, true, true},
+		{"a/**/b.go", "a/b.go", false, true, true},
+		{"!keep.go", "keep.go", false, false, true},
+		{"file[0-9].go", "file7.go", false, true, true},
+		{`\#hash.go`, "#hash.go", false, true, true},
+		{"# comment", "comment", false, false, false},
+	}
+	for _, tt := range tests {
+		var rules ignoreRules
+		if rule, ok := parseIgnoreRule(tt.line); ok {
+			rules = append(rules, rule)
+		}
+		ignored, matched := rules.match(tt.path, tt.isDir)
+		if ignored != tt.ignored || matched != tt.matched {
+			t.Errorf("%q on %s: got ignored %t matched %t, want %t %t", tt.line, tt.path, ignored, matched, tt.ignored, tt.matched)
+		}
+	}
+}
+
+func TestAnalyzer_IgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	listen := func(port string) string {
+		return "package main\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":" + port + "\") }\n"
+	}
+	writeFiles(t, dir, map[string]string{
+		".gitignore":              "dist/\n*_gen.go\n!keep_gen.go\n",
+		".staticsocketignore":     "/experiments\n",
+		"main.go":                 listen("1000"),
+		"dist/main.go":            listen("2000"),
+		"server_gen.go":           listen("3000"),
+		"keep_gen.go":             listen("4000"),
+		"experiments/try.go":      listen("5000"),
+		"pkg/.gitignore":          "local.go\n",
+		"pkg/local.go":            listen("6000"),
+		"pkg/server.go":           listen("7000"),
+		"pkg/experiments/main.go": listen("8000"),
+	})
+
+	a := New()
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	ports := make(map[int]bool)
+	for _, socket := range results.Sockets {
+		ports[*socket.Listen.Port] = true
+	}
+	for port, want := range map[int]bool{1000: true, 2000: false, 3000: false, 4000: true, 5000: false, 6000: false, 7000: true, 8000: true} {
+		if ports[port] != want {
+			t.Errorf("Port %d: expected found %t, got %v", port, want, ports)
+		}
+	}
+
+	// Files in ignored directories stay out of incremental updates
+	writeFiles(t, dir, map[string]string{"dist/new.go": listen("9000")})
+	a.Invalidate(filepath.Join(dir, "dist/new.go"))
+	results, err = a.Reanalyze()
+	if err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+	if results.TotalCount != 4 {
+		t.Errorf("Expected ignored new files left out, got %d findings", results.TotalCount)
+	}
+
+	all, err := New(WithIgnoreFiles(false)).Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if all.TotalCount != 9 {
+		t.Errorf("Expected every file analyzed with ignore files off, got %d findings", all.TotalCount)
+	}
+}
@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// ErrNotAnalyzed is returned by Reanalyze before Analyze has run.
+var ErrNotAnalyzed = errors.New("nothing analyzed yet")
+
+// fileResult is what one file contributed to the results, kept so that
+// Reanalyze can replace it without touching other files.
+type fileResult struct {
+	sockets []types.SocketInfo
+	err     *types.AnalysisError
+}
+
+// record remembers the contribution of path, in walk order.
+func (a *Analyzer) record(path string, result *fileResult) {
+	if a.files == nil {
+		a.files = make(map[string]*fileResult)
+	}
+	if _, ok := a.files[path]; !ok {
+		a.order = append(a.order, path)
+	}
+	a.files[path] = result
+}
+
+// Invalidate marks files edited, created or deleted since the last
+// Analyze, for long-lived embedders such as editor plugins. Paths may be
+// absolute or relative to the working directory; nothing is read until
+// Reanalyze.
+func (a *Analyzer) Invalidate(paths ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stale == nil {
+		a.stale = make(map[string]bool)
+	}
+	for _, path := range paths {
+		a.stale[path] = true
+	}
+}
+
+// Reanalyze brings the results of the last Analyze up to date with the
+// files passed to Invalidate since. Only those files are read: edited
+// files are matched again, deleted ones drop out and new Go files under
+// the analyzed directory are added where a full walk would put them. The
+// results returned by earlier calls are left as they were.
+func (a *Analyzer) Reanalyze() (*types.AnalysisResults, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.target == "" {
+		return nil, ErrNotAnalyzed
+	}
+	a.newFileSet()
+
+	stale := make([]string, 0, len(a.stale))
+	for path := range a.stale {
+		stale = append(stale, path)
+	}
+	sort.Strings(stale)
+	a.stale = nil
+	a.ignoreRules = nil
+	a.patterns.ForgetPackages()
+	if a.files == nil {
+		a.files = make(map[string]*fileResult)
+	}
+
+	// An edit may change which functions the symbol reaches, and with it
+	// the findings of files left as they were
+	if a.symbol != "" && len(stale) > 0 {
+		previous := a.scope
+		paths, err := a.scopePaths()
+		if err != nil {
+			return nil, err
+		}
+		if err := a.restrict(paths, os.ReadFile); err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(previous, a.scope) {
+			stale = append(stale, a.order...)
+		}
+	}
+
+	for _, invalidated := range stale {
+		path, ok := a.walkPath(invalidated)
+		if !ok {
+			continue
+		}
+		result, err := a.reanalyzeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			a.forget(path)
+			continue
+		}
+		if _, ok := a.files[path]; !ok {
+			a.insertInWalkOrder(path)
+		}
+		a.files[path] = result
+	}
+
+	results := *a.results
+	results.Sockets, results.Errors, results.Endpoints = make([]types.SocketInfo, 0), nil, nil
+	a.results = &results
+	for _, path := range a.order {
+		result := a.files[path]
+		if result.err != nil {
+			a.results.Errors = append(a.results.Errors, *result.err)
+		}
+		a.results.Sockets = append(a.results.Sockets, result.sockets...)
+	}
+	if a.targetIsDir {
+		a.results.Sockets = mergeVariants(a.results.Sockets)
+	}
+	if a.collapseUnresolved {
+		a.results.Sockets = collapseUnresolved(a.results.Sockets)
+	}
+	a.updateCounts()
+	return a.postProcessed(a.results)
+}
+
+// walkPath converts an invalidated path to the form the walk of the
+// analyzed target produces, reporting false for paths outside it.
+func (a *Analyzer) walkPath(path string) (string, bool) {
+	absTarget, err := filepath.Abs(a.target)
+	if err != nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	if !a.targetIsDir {
+		return a.target, absPath == absTarget
+	}
+	rel, err := filepath.Rel(absTarget, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(a.target, rel), true
+}
+
+// reanalyzeFile returns the current contribution of path, or nil if it no
+// longer exists or would no longer be walked.
+func (a *Analyzer) reanalyzeFile(path string) (*fileResult, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a.targetIsDir && !a.walked(path, info) {
+		return nil, nil
+	}
+
+	sockets, err := a.matchFile(path)
+	if err != nil {
+		skipped := a.skippedFileError(path, err)
+		if skipped == nil {
+			return nil, err
+		}
+		return &fileResult{err: skipped}, nil
+	}
+	return &fileResult{sockets: sockets}, nil
+}
+
+// walked reports whether a directory walk of the target would analyze
+// path, applying the same filters and limits.
+func (a *Analyzer) walked(path string, info fs.FileInfo) bool {
+	if !a.analyzable(path) || strings.Contains(path, "vendor/") {
+		return false
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		var ok bool
+		if info, ok = a.followSymlink(path); !ok {
+			return false
+		}
+	}
+	if info.IsDir() || (a.maxFileSize > 0 && info.Size() > a.maxFileSize) {
+		return false
+	}
+	rel, err := filepath.Rel(a.target, path)
+	if err != nil {
+		return false
+	}
+	return a.maxDepth <= 0 || strings.Count(rel, string(filepath.Separator)) <= a.maxDepth
+}
+
+func (a *Analyzer) forget(path string) {
+	if _, ok := a.files[path]; !ok {
+		return
+	}
+	delete(a.files, path)
+	for i, recorded := range a.order {
+		if recorded == path {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (a *Analyzer) insertInWalkOrder(path string) {
+	i := sort.Search(len(a.order), func(i int) bool { return walkLess(path, a.order[i]) })
+	a.order = append(a.order, "")
+	copy(a.order[i+1:], a.order[i:])
+	a.order[i] = path
+}
+
+// walkLess orders paths the way the directory walk visits them: by name
+// within each directory, depth first.
+func walkLess(a, b string) bool {
+	aParts := strings.Split(a, string(filepath.Separator))
+	bParts := strings.Split(b, string(filepath.Separator))
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] != bParts[i] {
+			return aParts[i] < bParts[i]
+		}
+	}
+	return len(aParts) < len(bParts)
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAnalyzer_Reanalyze(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/client.go": "package a\nimport \"net/http\"\nfunc f() { http.Get(\"http://a.example.com\") }\n",
+		"b/server.go": "package b\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":80\") }\n",
+		"c/server.go": "package c\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":82\") }\n",
+	})
+
+	a := New()
+	before, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	// Edit one file, delete another, add one between them and one that
+	// does not parse yet.
+	writeFiles(t, dir, map[string]string{
+		"a/client.go":   "package a\nimport \"net/http\"\nfunc f() {\n\thttp.Get(\"http://b.example.com\")\n}\n",
+		"a-b/dial.go":   "package ab\nimport \"net\"\nfunc f() { net.Dial(\"tcp\", \"db:5432\") }\n",
+		"b/sub/main.go": "package main\nimport \"net\"\nfunc main() { net.Listen(\"udp\", \":53\") }\n",
+		"d/broken.go":   "package d\nfunc f() {",
+	})
+	if err := os.Remove(filepath.Join(dir, "c/server.go")); err != nil {
+		t.Fatal(err)
+	}
+	a.Invalidate(
+		filepath.Join(dir, "a/client.go"),
+		filepath.Join(dir, "a-b/dial.go"),
+		filepath.Join(dir, "b/sub/main.go"),
+		filepath.Join(dir, "c/server.go"),
+		filepath.Join(dir, "d/broken.go"),
+		"/elsewhere/outside.go",
+	)
+	results, err := a.Reanalyze()
+	if err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+
+	fresh, err := New().Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if !reflect.DeepEqual(results.Sockets, fresh.Sockets) || !reflect.DeepEqual(results.Errors, fresh.Errors) {
+		t.Errorf("Expected the same results as a fresh analysis.\nReanalyzed: %+v\nFresh: %+v", results.Sockets, fresh.Sockets)
+	}
+	if results.TotalCount != 4 || len(results.Errors) != 1 {
+		t.Errorf("Expected 4 findings and 1 skipped file, got %d and %d", results.TotalCount, len(results.Errors))
+	}
+	if before.TotalCount != 3 || len(before.Sockets) != 3 || len(before.Errors) != 0 {
+		t.Errorf("Expected the results of Analyze left untouched, got %+v", before)
+	}
+}
+
+func TestAnalyzer_ReanalyzeBeforeAnalyze(t *testing.T) {
+	if _, err := New().Reanalyze(); !errors.Is(err, ErrNotAnalyzed) {
+		t.Errorf("Expected ErrNotAnalyzed, got %v", err)
+	}
+}
+
+func TestWalkLess(t *testing.T) {
+	paths := []string{"root/a/x.go", "root/a-b/y.go", "root/a.go", "root/b.go"}
+	for i := 1; i < len(paths); i++ {
+		if !walkLess(paths[i-1], paths[i]) {
+			t.Errorf("Expected %s to be walked before %s", paths[i-1], paths[i])
+		}
+	}
+}
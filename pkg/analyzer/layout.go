@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Layout describes where a build system puts generated sources and
+// external dependencies, so findings in them are attributed correctly.
+// Roots are slash-separated paths relative to the analyzed directory and
+// may contain path.Match wildcards, e.g. "bazel-out/*/bin".
+type Layout struct {
+	// GeneratedRoots are stripped from paths: bazel-bin/pkg/x.go is
+	// reported with logical path pkg/x.go.
+	GeneratedRoots []string
+	// ExternalRoots hold one directory per external dependency: files in
+	// external/com_github_lib_pq/... belong to module com_github_lib_pq.
+	ExternalRoots []string
+}
+
+// LayoutPresets are the layouts selectable with -layout. Build outputs are
+// usually symlinked into the workspace, so they are only walked with
+// SymlinkFollow.
+var LayoutPresets = map[string]Layout{
+	"go": {},
+	"bazel": {
+		GeneratedRoots: []string{"bazel-bin", "bazel-out/*/bin", "bazel-genfiles"},
+		ExternalRoots:  []string{"external", "bazel-*/external"},
+	},
+	"please": {
+		GeneratedRoots: []string{"plz-out/gen", "plz-out/bin"},
+		ExternalRoots:  []string{"third_party/go"},
+	},
+}
+
+// SetLayout configures generated-source and external dependency roots.
+func (a *Analyzer) SetLayout(layout Layout) {
+	a.layout = layout
+}
+
+// mapPath returns the logical, root-relative path of filePath and the
+// external module it belongs to, if any.
+func (a *Analyzer) mapPath(filePath string) (logical, module string) {
+	rel, err := filepath.Rel(a.root, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", ""
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+
+	for _, root := range a.layout.GeneratedRoots {
+		if n := matchRoot(segments, root); n > 0 {
+			segments = segments[n:]
+			break
+		}
+	}
+	for _, root := range a.layout.ExternalRoots {
+		if n := matchRoot(segments, root); n > 0 && n < len(segments)-1 {
+			module = segments[n]
+			break
+		}
+	}
+	return path.Join(segments...), module
+}
+
+// matchRoot reports how many leading segments match the root pattern, or 0.
+func matchRoot(segments []string, root string) int {
+	patterns := strings.Split(strings.Trim(root, "/"), "/")
+	if len(patterns) > len(segments) {
+		return 0
+	}
+	for i, pattern := range patterns {
+		if ok, err := path.Match(pattern, segments[i]); err != nil || !ok {
+			return 0
+		}
+	}
+	return len(patterns)
+}
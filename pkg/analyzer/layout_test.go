@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzer_BazelLayout(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"svc/main.go":                           "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":80\") }\n",
+		"bazel-out/k8-fastbuild/bin/svc/gen.go": "package main\nimport \"net\"\nfunc gen() { net.Listen(\"tcp\", \":81\") }\n",
+		"external/com_github_lib_pq/conn.go":    "package pq\nimport \"net\"\nfunc dial() { net.Dial(\"tcp\", \"db:5432\") }\n",
+	}
+	for name, code := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := New()
+	a.SetLayout(LayoutPresets["bazel"])
+	results, err := a.Analyze(root)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	byPort := make(map[string]string)
+	for _, socket := range results.Sockets {
+		byPort[socket.RawValue] = socket.LogicalPath + "|" + socket.Module
+	}
+
+	expected := map[string]string{
+		":80":     "svc/main.go|",
+		":81":     "svc/gen.go|",
+		"db:5432": "external/com_github_lib_pq/conn.go|com_github_lib_pq",
+	}
+	for raw, want := range expected {
+		if got := byPort[raw]; got != want {
+			t.Errorf("%s: expected %q, got %q", raw, want, got)
+		}
+	}
+}
+
+func TestAnalyzer_DefaultLayoutLeavesPaths(t *testing.T) {
+	results, err := New().AnalyzeSource([]byte("package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":80\") }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if socket := results.Sockets[0]; socket.LogicalPath != "" || socket.Module != "" {
+		t.Errorf("Expected no layout mapping by default, got %+v", socket)
+	}
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+const (
+	// DefaultMaxFileSize skips Go files larger than 10 MiB, which are almost
+	// always generated code or test fixtures rather than hand-written sockets.
+	DefaultMaxFileSize int64 = 10 << 20
+	// DefaultMaxFiles aborts directory scans that would analyze more files
+	// than any single service tree reasonably contains.
+	DefaultMaxFiles = 100000
+	// DefaultMaxFindings keeps the results of pathological trees, such as
+	// generated clients with thousands of destinations, to a size the
+	// consumers of the output can still load.
+	DefaultMaxFindings = 50000
+)
+
+// ErrMaxFilesExceeded is returned when a directory scan reaches the file limit.
+var ErrMaxFilesExceeded = errors.New("maximum number of files exceeded")
+
+// SetMaxFileSize sets the size in bytes above which files are skipped.
+// Zero or a negative value disables the guard.
+func (a *Analyzer) SetMaxFileSize(size int64) {
+	a.maxFileSize = size
+}
+
+// SetMaxFiles sets how many files a directory scan may analyze before it is
+// aborted with ErrMaxFilesExceeded. Zero or a negative value disables the guard.
+func (a *Analyzer) SetMaxFiles(count int) {
+	a.maxFiles = count
+}
+
+// SetMaxFindings sets how many findings results list. Findings past the
+// limit, in walk order, are left out of Sockets, and the results are marked
+// Truncated with a summary of what was left out; the counts still cover
+// every finding. Zero or a negative value disables the guard.
+func (a *Analyzer) SetMaxFindings(count int) {
+	a.maxFindings = count
+}
+
+// truncate applies the findings limit to the results, once their counts
+// cover every finding.
+func (a *Analyzer) truncate() {
+	a.results.Truncated, a.results.Truncation = false, nil
+	if a.maxFindings <= 0 || len(a.results.Sockets) <= a.maxFindings {
+		return
+	}
+	summary := &types.TruncationSummary{
+		MaxFindings: a.maxFindings,
+		Omitted:     len(a.results.Sockets) - a.maxFindings,
+		ByProtocol:  make(map[types.Protocol]int),
+	}
+	for _, socket := range a.results.Sockets {
+		summary.ByProtocol[socket.Protocol]++
+	}
+	a.results.Sockets = a.results.Sockets[:a.maxFindings:a.maxFindings]
+	a.results.Truncated, a.results.Truncation = true, summary
+}
+
+// withinLimits reports whether path should be analyzed, counting it toward
+// the file limit. Oversized files are skipped rather than failing the run.
+func (a *Analyzer) withinLimits(path string, fileCount *int) (bool, error) {
+	if a.maxFileSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if info.Size() > a.maxFileSize {
+			log.Printf("Skipping %s: %d bytes exceeds max file size %d", path, info.Size(), a.maxFileSize)
+			return false, nil
+		}
+	}
+
+	*fileCount++
+	if a.maxFiles > 0 && *fileCount > a.maxFiles {
+		return false, fmt.Errorf("%w: limit is %d", ErrMaxFilesExceeded, a.maxFiles)
+	}
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeListenerFiles(t *testing.T, dir string, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		content := "package main\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":8080\") }\n"
+		name := filepath.Join(dir, "file"+strings.Repeat("x", i)+".go")
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+}
+
+func TestAnalyzer_MaxFileSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeListenerFiles(t, tmpDir, 1)
+
+	big := "package main\nimport \"net\"\nfunc g() { net.Listen(\"tcp\", \":9090\") }\n// " +
+		strings.Repeat("x", 4096) + "\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "generated.go"), []byte(big), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	analyzer := New()
+	analyzer.SetMaxFileSize(1024)
+	results, err := analyzer.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Errorf("Expected oversized file to be skipped, got %d sockets", results.TotalCount)
+	}
+
+	unlimited := New()
+	unlimited.SetMaxFileSize(0)
+	results, err = unlimited.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if results.TotalCount != 2 {
+		t.Errorf("Expected 2 sockets with size guard disabled, got %d", results.TotalCount)
+	}
+}
+
+func TestAnalyzer_MaxFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeListenerFiles(t, tmpDir, 3)
+
+	analyzer := New()
+	analyzer.SetMaxFiles(2)
+	_, err := analyzer.Analyze(tmpDir)
+	if !errors.Is(err, ErrMaxFilesExceeded) {
+		t.Errorf("Expected ErrMaxFilesExceeded, got %v", err)
+	}
+
+	analyzer = New()
+	analyzer.SetMaxFiles(3)
+	if _, err := analyzer.Analyze(tmpDir); err != nil {
+		t.Errorf("Expected scan within limit to succeed, got %v", err)
+	}
+}
+
+func TestAnalyzer_MaxFindings(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeListenerFiles(t, tmpDir, 3)
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\nimport \"net\"\nfunc g() { net.Dial(\"udp\", \"dns:53\") }\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	analyzer := New(WithMaxFindings(2))
+	results, err := analyzer.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if !results.Truncated || len(results.Sockets) != 2 {
+		t.Fatalf("Expected 2 findings listed and results marked truncated, got %d, truncated %t", len(results.Sockets), results.Truncated)
+	}
+	if results.TotalCount != 4 || results.IngressCount != 3 || results.EgressCount != 1 {
+		t.Errorf("Expected counts of every finding, got total %d, ingress %d, egress %d", results.TotalCount, results.IngressCount, results.EgressCount)
+	}
+	summary := results.Truncation
+	if summary == nil || summary.MaxFindings != 2 || summary.Omitted != 2 || summary.ByProtocol["tcp"] != 3 || summary.ByProtocol["udp"] != 1 {
+		t.Errorf("Unexpected truncation summary %+v", summary)
+	}
+	if analyzer.ConfigHash() == New().ConfigHash() {
+		t.Error("Expected the findings limit to change the config hash")
+	}
+
+	if err := os.Remove(filepath.Join(tmpDir, "filexx.go")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	analyzer.Invalidate(filepath.Join(tmpDir, "filexx.go"))
+	if results, err = analyzer.Reanalyze(); err != nil {
+		t.Fatalf("Failed to reanalyze: %v", err)
+	}
+	if !results.Truncated || len(results.Sockets) != 2 || results.Truncation.Omitted != 1 {
+		t.Errorf("Expected reanalyzed results truncated again, got %d findings, %+v", len(results.Sockets), results.Truncation)
+	}
+
+	results, err = New(WithMaxFindings(4)).Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if results.Truncated || results.Truncation != nil || len(results.Sockets) != 3 {
+		t.Errorf("Expected results within the limit untouched, got %d findings, truncated %t", len(results.Sockets), results.Truncated)
+	}
+}
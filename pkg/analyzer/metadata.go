@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// scanInfo records how these results were produced. The tool version is
+// left for the caller, which knows its own build.
+func (a *Analyzer) scanInfo() *types.ScanInfo {
+	return &types.ScanInfo{
+		Timestamp:      time.Now().UTC(),
+		ConfigHash:     a.ConfigHash(),
+		PatternSetHash: a.PatternSetHash(),
+	}
+}
+
+// ConfigHash hashes the settings that change which files are analyzed,
+// which findings are kept or run out of resolution time and how plugins
+// rewrite them. Hooks added with AddPostProcess are counted, but cannot be
+// told apart.
+func (a *Analyzer) ConfigHash() string {
+	config := fmt.Sprintf("symlinks=%d max-depth=%d max-file-size=%d max-files=%d type=%s generated=%q external=%q",
+		a.symlinkPolicy, a.maxDepth, a.maxFileSize, a.maxFiles, a.trafficType, a.layout.GeneratedRoots, a.layout.ExternalRoots)
+	if len(a.dnsSearch) > 0 {
+		config += fmt.Sprintf(" dns-search=%q", a.dnsSearch)
+	}
+	if a.excludeTests {
+		config += " exclude-tests"
+	}
+	if a.maxFindings != DefaultMaxFindings {
+		config += fmt.Sprintf(" max-findings=%d", a.maxFindings)
+	}
+	if a.collapseUnresolved {
+		config += " collapse-unresolved"
+	}
+	if a.noIgnoreFiles {
+		config += " no-ignore-files"
+	}
+	if len(a.exclude) > 0 {
+		config += fmt.Sprintf(" exclude=%q", a.exclude)
+	}
+	if a.symbol != "" {
+		config += fmt.Sprintf(" symbol=%q call-graph=%t", a.symbol, a.callGraph)
+	}
+	if a.scanTemplates {
+		config += " templates"
+	}
+	if a.typeCheck {
+		config += " typed"
+	}
+	if a.findingBudget > 0 || a.fileBudget > 0 {
+		config += fmt.Sprintf(" finding-budget=%s file-budget=%s", max(a.findingBudget, 0), max(a.fileBudget, 0))
+	}
+	for _, command := range a.plugins {
+		config += fmt.Sprintf(" plugin=%q", command)
+	}
+	if hooks := len(a.postProcess) - len(a.plugins); hooks > 0 {
+		config += fmt.Sprintf(" post-process=%d", hooks)
+	}
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:])
+}
+
+// PatternSetHash identifies the rules the analyzer applies.
+func (a *Analyzer) PatternSetHash() string {
+	return a.patterns.Fingerprint()
+}
+
+// Rules lists the rules the analyzer applies, one per line, sorted.
+func (a *Analyzer) Rules() []string {
+	return a.patterns.Rules()
+}
+
+// AddImportAlias makes files importing fork match the patterns of the
+// package at original, such as an internal fork of net/http.
+func (a *Analyzer) AddImportAlias(fork, original string) {
+	a.patterns.AddImportAlias(fork, original)
+}
+
+// AddPatterns adds custom positional patterns, such as internal wrappers
+// of net.Listen, to the built-in ones. They are part of the rule set and
+// of PatternSetHash.
+func (a *Analyzer) AddPatterns(custom ...patterns.CustomPattern) error {
+	for _, pattern := range custom {
+		if err := a.patterns.AddCustomPattern(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetChecks enables and disables rules with a checks list such as
+// "all,-SS1003", naming rules by code or pattern_match, as
+// patterns.PatternMatcher.SetChecks describes. Custom patterns must be
+// added first. The disabled rules are part of PatternSetHash.
+func (a *Analyzer) SetChecks(checks []string) error {
+	return a.patterns.SetChecks(checks)
+}
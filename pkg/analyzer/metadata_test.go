@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestAnalyzer_ScanInfo(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New().Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	scan := results.Scan
+	if scan == nil || scan.Timestamp.IsZero() || scan.ConfigHash == "" || scan.PatternSetHash == "" {
+		t.Fatalf("Expected scan metadata, got %+v", scan)
+	}
+
+	limited := New()
+	limited.SetMaxDepth(2)
+	if limited.ConfigHash() == New().ConfigHash() {
+		t.Error("Expected a different configuration to change the config hash")
+	}
+	if limited.scanInfo().PatternSetHash != scan.PatternSetHash {
+		t.Error("Expected the pattern set hash to be independent of walk settings")
+	}
+}
+
+func TestAnalyzer_AddPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	code := `package main
+import "example.com/platform/netutil"
+func main() { netutil.ListenSecure(":9443") }
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New()
+	builtIn := a.PatternSetHash()
+	err := a.AddPatterns(patterns.CustomPattern{
+		Function: "netutil.ListenSecure",
+		Package:  "example.com/platform/netutil",
+		Type:     types.TrafficTypeIngress,
+		Protocol: types.ProtocolHTTPS,
+		PortOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("AddPatterns failed: %v", err)
+	}
+	if a.PatternSetHash() == builtIn {
+		t.Error("Expected custom patterns to change the pattern set hash")
+	}
+
+	results, err := a.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 1 || results.Sockets[0].Protocol != types.ProtocolHTTPS || *results.Sockets[0].Listen.Port != 9443 {
+		t.Errorf("Expected the custom listener on 9443, got %+v", results.Sockets)
+	}
+
+	if err := New().AddPatterns(patterns.CustomPattern{Function: "net.Listen", Type: types.TrafficTypeIngress, Protocol: types.ProtocolTCP}); err == nil {
+		t.Error("Expected a pattern replacing a built-in one to be rejected")
+	}
+}
+
+func TestAnalyzer_SetChecks(t *testing.T) {
+	a := New()
+	builtIn := a.PatternSetHash()
+	if err := a.SetChecks([]string{"all", "-net.ListenUDP"}); err != nil {
+		t.Fatalf("SetChecks failed: %v", err)
+	}
+	if a.PatternSetHash() == builtIn {
+		t.Error("Expected disabled rules to change the pattern set hash")
+	}
+
+	results, err := a.AnalyzeSource([]byte(`package main
+import "net"
+func main() {
+	net.Listen("tcp", ":8080")
+	net.ListenUDP("udp", &net.UDPAddr{Port: 53})
+}
+`))
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if len(results.Sockets) != 1 || results.Sockets[0].PatternMatch != "net.Listen" {
+		t.Errorf("Expected only the net.Listen finding, got %+v", results.Sockets)
+	}
+}
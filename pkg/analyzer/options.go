@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Options is the whole configuration of an Analyzer in one value, for
+// embedders that keep their settings in a struct of their own. Each field
+// does what the setter of the same name does. Start from DefaultOptions,
+// which holds the settings of New: the zero Options has no file size or
+// file count limits, no resolution budget, and skips test files.
+type Options struct {
+	SymlinkPolicy SymlinkPolicy
+	// MaxDepth limits the directory levels descended into; 0 is no limit.
+	MaxDepth int
+	// MaxFileSize skips larger files; 0 is no limit.
+	MaxFileSize int64
+	// MaxFiles aborts the walk after this many files; 0 is no limit.
+	MaxFiles int
+	// MaxFindings truncates results past this many findings; 0 is no limit.
+	MaxFindings int
+	// IncludeTests analyzes _test.go files found by directory walks.
+	IncludeTests bool
+	// Exclude skips the files and directories matching these globs, as
+	// SetExclude does.
+	Exclude []string
+	// IgnoreFiles skips what .gitignore and .staticsocketignore files
+	// ignore.
+	IgnoreFiles bool
+	// TrafficType keeps only ingress or egress findings; "" keeps both.
+	TrafficType types.TrafficType
+	StopAtFirst bool
+	Layout      Layout
+	Workers     int
+	DNSSearch   []string
+
+	// Symbol restricts findings to one function, as SetSymbol does, and
+	// CallGraph to the functions it reaches.
+	Symbol    string
+	CallGraph bool
+
+	GroupByEndpoint    bool
+	CollapseUnresolved bool
+	ScanTemplates      bool
+	TypeCheck          bool
+	CodeSnippets       bool
+
+	// FindingBudget and FileBudget bound address resolution; 0 is no bound.
+	FindingBudget time.Duration
+	FileBudget    time.Duration
+
+	// ImportAliases maps forks to the import path whose patterns they
+	// match, as AddImportAlias does.
+	ImportAliases map[string]string
+	// Patterns are added to the built-in ones, as AddPatterns does.
+	Patterns []patterns.CustomPattern
+	// Checks enables and disables rules, as SetChecks does.
+	Checks []string
+	// PipelineStats receives the stats of each directory analysis, as
+	// SetPipelineStats does.
+	PipelineStats func(PipelineStats)
+	// PostProcess hooks run on the results, as AddPostProcess does.
+	PostProcess []PostProcess
+	// Plugin is the command of an external plugin run on the results
+	// after them, as AddPlugin does.
+	Plugin []string
+}
+
+// DefaultOptions returns the settings an Analyzer from New starts with.
+func DefaultOptions() Options {
+	return Options{
+		MaxFileSize:  DefaultMaxFileSize,
+		MaxFiles:     DefaultMaxFiles,
+		MaxFindings:  DefaultMaxFindings,
+		IncludeTests: true,
+		IgnoreFiles:  true,
+	}
+}
+
+// NewWithOptions returns an Analyzer configured with opts. It fails on a
+// traffic type other than ingress or egress, on invalid patterns and on
+// checks naming no rule.
+func NewWithOptions(opts Options) (*Analyzer, error) {
+	switch opts.TrafficType {
+	case "", types.TrafficTypeIngress, types.TrafficTypeEgress:
+	default:
+		return nil, fmt.Errorf("invalid traffic type %q: expected ingress or egress", opts.TrafficType)
+	}
+	if opts.Symbol != "" {
+		if _, _, err := parseSymbol(opts.Symbol); err != nil {
+			return nil, err
+		}
+	}
+	for _, glob := range opts.Exclude {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude glob %q: %w", glob, err)
+		}
+	}
+
+	a := New()
+	forks := make([]string, 0, len(opts.ImportAliases))
+	for fork := range opts.ImportAliases {
+		forks = append(forks, fork)
+	}
+	sort.Strings(forks)
+	for _, fork := range forks {
+		a.AddImportAlias(fork, opts.ImportAliases[fork])
+	}
+	if err := a.AddPatterns(opts.Patterns...); err != nil {
+		return nil, err
+	}
+	if err := a.SetChecks(opts.Checks); err != nil {
+		return nil, err
+	}
+
+	a.SetSymlinkPolicy(opts.SymlinkPolicy)
+	a.SetMaxDepth(opts.MaxDepth)
+	a.SetMaxFileSize(opts.MaxFileSize)
+	a.SetMaxFiles(opts.MaxFiles)
+	a.SetMaxFindings(opts.MaxFindings)
+	a.SetIncludeTests(opts.IncludeTests)
+	a.SetExclude(opts.Exclude)
+	a.SetIgnoreFiles(opts.IgnoreFiles)
+	a.SetTrafficType(opts.TrafficType)
+	a.SetStopAtFirst(opts.StopAtFirst)
+	a.SetLayout(opts.Layout)
+	a.SetSymbol(opts.Symbol)
+	a.SetCallGraph(opts.CallGraph)
+	a.SetWorkers(opts.Workers)
+	a.SetPipelineStats(opts.PipelineStats)
+	a.SetDNSSearch(opts.DNSSearch)
+	a.SetGroupByEndpoint(opts.GroupByEndpoint)
+	a.SetCollapseUnresolved(opts.CollapseUnresolved)
+	a.SetScanTemplates(opts.ScanTemplates)
+	a.SetTypeCheck(opts.TypeCheck)
+	a.SetCodeSnippets(opts.CodeSnippets)
+	a.SetResolveBudget(opts.FindingBudget, opts.FileBudget)
+	a.AddPostProcess(opts.PostProcess...)
+	a.AddPlugin(opts.Plugin)
+	return a, nil
+}
+
+// Option changes one setting of an Analyzer from New, as the setter of the
+// same name does.
+type Option func(*Analyzer)
+
+// WithExclude skips the files and directories matching globs.
+func WithExclude(globs ...string) Option {
+	return func(a *Analyzer) { a.SetExclude(globs) }
+}
+
+// WithPatternMatcher replaces the built-in rules with pm, such as a matcher
+// from patterns.NewPatternMatcher with custom patterns and import aliases
+// added. Its rules are those of PatternSetHash.
+func WithPatternMatcher(pm *patterns.PatternMatcher) Option {
+	return func(a *Analyzer) { a.patterns = pm }
+}
+
+// WithFileSet records the positions of parsed files in fset instead of a
+// new set for each analysis, so that a tool can map the syntax trees it
+// shares with the analyzer back to source. fset grows with every file
+// each analysis parses.
+func WithFileSet(fset *token.FileSet) Option {
+	return func(a *Analyzer) { a.fileSet, a.sharedFileSet = fset, true }
+}
+
+func WithSymlinkPolicy(policy SymlinkPolicy) Option {
+	return func(a *Analyzer) { a.SetSymlinkPolicy(policy) }
+}
+
+func WithMaxDepth(depth int) Option {
+	return func(a *Analyzer) { a.SetMaxDepth(depth) }
+}
+
+func WithMaxFileSize(size int64) Option {
+	return func(a *Analyzer) { a.SetMaxFileSize(size) }
+}
+
+func WithMaxFiles(count int) Option {
+	return func(a *Analyzer) { a.SetMaxFiles(count) }
+}
+
+func WithSymbol(symbol string) Option {
+	return func(a *Analyzer) { a.SetSymbol(symbol) }
+}
+
+func WithCallGraph(callGraph bool) Option {
+	return func(a *Analyzer) { a.SetCallGraph(callGraph) }
+}
+
+func WithMaxFindings(count int) Option {
+	return func(a *Analyzer) { a.SetMaxFindings(count) }
+}
+
+func WithIncludeTests(include bool) Option {
+	return func(a *Analyzer) { a.SetIncludeTests(include) }
+}
+
+func WithIgnoreFiles(honor bool) Option {
+	return func(a *Analyzer) { a.SetIgnoreFiles(honor) }
+}
+
+func WithWorkers(workers int) Option {
+	return func(a *Analyzer) { a.SetWorkers(workers) }
+}
+
+func WithResolveBudget(perFinding, perFile time.Duration) Option {
+	return func(a *Analyzer) { a.SetResolveBudget(perFinding, perFile) }
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	defaults, err := NewWithOptions(DefaultOptions())
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	if defaults.ConfigHash() != New().ConfigHash() || defaults.PatternSetHash() != New().PatternSetHash() {
+		t.Error("Expected DefaultOptions to configure the analyzer New returns")
+	}
+
+	opts := DefaultOptions()
+	opts.TrafficType = types.TrafficTypeEgress
+	opts.MaxDepth = 3
+	opts.Patterns = []patterns.CustomPattern{{
+		Function: "netutil.Dial",
+		Package:  "example.com/platform/netutil",
+		Type:     types.TrafficTypeEgress,
+		Protocol: types.ProtocolTCP,
+		Arg:      1,
+	}}
+	a, err := NewWithOptions(opts)
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	if a.trafficType != types.TrafficTypeEgress || a.maxDepth != 3 {
+		t.Errorf("Expected the options to be applied, got type %q and depth %d", a.trafficType, a.maxDepth)
+	}
+	if a.PatternSetHash() == New().PatternSetHash() {
+		t.Error("Expected the custom pattern in the rule set")
+	}
+
+	results, err := a.AnalyzeSource([]byte(`package main
+import (
+	"net"
+	"example.com/platform/netutil"
+)
+func main() {
+	net.Listen("tcp", ":8080")
+	netutil.Dial("tcp", "db.internal:5432")
+}`))
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if results.TotalCount != 1 || results.Sockets[0].PatternMatch != "netutil.Dial" {
+		t.Errorf("Expected only the custom egress finding, got %+v", results.Sockets)
+	}
+}
+
+func TestNewWithOptions_Invalid(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TrafficType = "sideways"
+	if _, err := NewWithOptions(opts); err == nil {
+		t.Error("Expected an error for an unknown traffic type")
+	}
+
+	opts = DefaultOptions()
+	opts.Patterns = []patterns.CustomPattern{{Function: "net.Dial", Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP}}
+	if _, err := NewWithOptions(opts); err == nil {
+		t.Error("Expected an error for a pattern replacing a built-in one")
+	}
+
+	opts = DefaultOptions()
+	opts.Checks = []string{"all", "-SS0000"}
+	if _, err := NewWithOptions(opts); !errors.Is(err, patterns.ErrUnknownCheck) {
+		t.Errorf("Expected ErrUnknownCheck for a check naming no rule, got %v", err)
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	pm := patterns.NewPatternMatcher()
+	if err := pm.AddCustomPattern(patterns.CustomPattern{
+		Function: "netutil.Dial",
+		Package:  "example.com/platform/netutil",
+		Type:     types.TrafficTypeEgress,
+		Protocol: types.ProtocolTCP,
+		Arg:      1,
+	}); err != nil {
+		t.Fatalf("AddCustomPattern failed: %v", err)
+	}
+	fset := token.NewFileSet()
+	a := New(
+		WithPatternMatcher(pm),
+		WithFileSet(fset),
+		WithExclude("testdata"),
+		WithMaxFileSize(1<<10),
+		WithSymlinkPolicy(SymlinkFollow),
+	)
+	if a.maxFileSize != 1<<10 || a.symlinkPolicy != SymlinkFollow || len(a.exclude) != 1 {
+		t.Errorf("Expected the options to be applied, got %+v", a)
+	}
+	if a.PatternSetHash() != pm.Fingerprint() {
+		t.Error("Expected the rules of the given pattern matcher")
+	}
+
+	results, err := a.AnalyzeSource([]byte(`package main
+import "example.com/platform/netutil"
+func main() {
+	netutil.Dial("tcp", "db.internal:5432")
+}`))
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if results.TotalCount != 1 || results.Sockets[0].PatternMatch != "netutil.Dial" {
+		t.Errorf("Expected the custom egress finding, got %+v", results.Sockets)
+	}
+	if fset.Base() == token.NewFileSet().Base() {
+		t.Error("Expected the source parsed into the given file set")
+	}
+}
+
+func TestAnalyzer_FileSetPerAnalysis(t *testing.T) {
+	src := []byte("package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":8080\") }\n")
+	a := New()
+	if _, err := a.AnalyzeSource(src); err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	first := a.fileSet.Base()
+	for range 3 {
+		if _, err := a.AnalyzeSource(src); err != nil {
+			t.Fatalf("AnalyzeSource failed: %v", err)
+		}
+	}
+	if a.fileSet.Base() != first {
+		t.Errorf("Expected each analysis to start a file set of its own, base grew from %d to %d", first, a.fileSet.Base())
+	}
+
+	fset := token.NewFileSet()
+	shared := New(WithFileSet(fset))
+	for range 2 {
+		if _, err := shared.AnalyzeSource(src); err != nil {
+			t.Fatalf("AnalyzeSource failed: %v", err)
+		}
+	}
+	if shared.fileSet != fset || fset.Base() <= first {
+		t.Error("Expected every analysis to use the given file set")
+	}
+}
+
+func TestNewWithOptions_InvalidExclude(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Exclude = []string{"[unterminated"}
+	if _, err := NewWithOptions(opts); err == nil {
+		t.Error("Expected an error for a malformed exclude glob")
+	}
+}
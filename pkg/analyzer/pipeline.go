@@ -0,0 +1,299 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// filesPerWorker bounds how many files may be in flight between the walk
+// and the sink per worker. Together with the bounded channels between
+// stages it keeps memory flat however large the tree is.
+const filesPerWorker = 4
+
+var errStopped = errors.New("analysis stopped")
+
+// SetWorkers sets how many files each pipeline stage processes
+// concurrently. Values below one mean one. Results do not depend on the
+// worker count.
+func (a *Analyzer) SetWorkers(workers int) {
+	a.workers = workers
+}
+
+type stage int
+
+const (
+	stageWalk stage = iota
+	stageParse
+	stageMatch
+	stageResolve
+	stageSink
+	numStages
+)
+
+var stageNames = [numStages]string{"walk", "parse", "match", "resolve", "sink"}
+
+// stageTimings holds the time spent working in each stage, summed over
+// its workers. Time blocked on a neighbouring stage is not counted.
+type stageTimings [numStages]atomic.Int64
+
+func (t *stageTimings) add(s stage, d time.Duration) {
+	t[s].Add(int64(d))
+}
+
+// PipelineStats tells how a directory analysis went: how many files it
+// analyzed in how long, with how many workers per stage, and the time each
+// stage spent working, summed over its workers.
+type PipelineStats struct {
+	Files   int
+	Elapsed time.Duration
+	Workers int
+	Stages  []StageTiming
+}
+
+// StageTiming is the time one pipeline stage spent working.
+type StageTiming struct {
+	Stage string
+	Busy  time.Duration
+}
+
+func (s PipelineStats) String() string {
+	parts := make([]string, len(s.Stages))
+	for i, stage := range s.Stages {
+		parts[i] = fmt.Sprintf("%s %s", stage.Stage, stage.Busy.Round(time.Microsecond))
+	}
+	return fmt.Sprintf("Analyzed %d files in %s with %d workers: %s",
+		s.Files, s.Elapsed.Round(time.Microsecond), s.Workers, strings.Join(parts, ", "))
+}
+
+// SetPipelineStats hands the stats of each directory analysis to report,
+// such as to print them in a verbose mode. By default they are dropped.
+func (a *Analyzer) SetPipelineStats(report func(PipelineStats)) {
+	a.pipelineStats = report
+}
+
+// WithPipelineStats hands the stats of each directory analysis to report.
+func WithPipelineStats(report func(PipelineStats)) Option {
+	return func(a *Analyzer) { a.SetPipelineStats(report) }
+}
+
+func (t *stageTimings) stats() []StageTiming {
+	stages := make([]StageTiming, numStages)
+	for s := range numStages {
+		stages[s] = StageTiming{Stage: stageNames[s], Busy: time.Duration(t[s].Load())}
+	}
+	return stages
+}
+
+// fileJob carries one file through the pipeline. Each stage fills in its
+// part; the AST is dropped once resolved.
+type fileJob struct {
+	index    int
+	path     string
+	file     *ast.File
+	findings []patterns.Finding
+	sockets  []types.SocketInfo
+	err      error
+}
+
+type pipeline struct {
+	analyzer *Analyzer
+	workers  int
+	timings  stageTimings
+
+	// window holds one token per file between the walk and the sink.
+	window   chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	// firstHit is the lowest index with a finding in stop-at-first mode.
+	firstHit atomic.Int64
+}
+
+func (p *pipeline) halt() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// skip reports whether job can no longer affect the results: the analysis
+// was halted, or an earlier file already has the first finding.
+func (p *pipeline) skip(job *fileJob) bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+	}
+	return job.err != nil || int64(job.index) > p.firstHit.Load()
+}
+
+// runPipeline analyzes the Go files under dirPath in stages connected by
+// bounded channels:
+//
+//	walk → parse → match → resolve → sink
+//
+// Parse, match and resolve each run on the configured number of workers.
+// The sink merges files in walk order, so the results do not depend on
+// which worker finishes first.
+func (a *Analyzer) runPipeline(dirPath string) error {
+	p := &pipeline{
+		analyzer: a,
+		workers:  max(a.workers, 1),
+		stop:     make(chan struct{}),
+	}
+	p.window = make(chan struct{}, p.workers*filesPerWorker)
+	p.firstHit.Store(math.MaxInt64)
+	start := time.Now()
+
+	var walkErr error
+	var files int
+	paths := make(chan *fileJob, p.workers)
+	go func() {
+		defer close(paths)
+		walkErr = p.walk(dirPath, paths, &files)
+	}()
+
+	parsed := p.stage(stageParse, paths, func(job *fileJob) {
+		job.file, job.err = a.parseFile(job.path)
+	})
+	matched := p.stage(stageMatch, parsed, func(job *fileJob) {
+		job.findings = a.match(job.file)
+		if a.stopAtFirst && len(job.findings) > 0 {
+			p.recordHit(job.index)
+		}
+	})
+	resolved := p.stage(stageResolve, matched, func(job *fileJob) {
+		job.sockets = a.resolve(job.path, job.file, job.findings)
+		job.file, job.findings = nil, nil
+	})
+
+	sinkErr := p.sink(resolved)
+
+	if a.pipelineStats != nil {
+		a.pipelineStats(PipelineStats{Files: files, Elapsed: time.Since(start), Workers: p.workers, Stages: p.timings.stats()})
+	}
+
+	if walkErr != nil && !errors.Is(walkErr, errStopped) {
+		return walkErr
+	}
+	return sinkErr
+}
+
+func (p *pipeline) walk(dirPath string, out chan<- *fileJob, files *int) error {
+	a := p.analyzer
+	start := time.Now()
+	var blocked time.Duration
+	defer func() { p.timings.add(stageWalk, time.Since(start)-blocked) }()
+
+	fileCount := 0
+	return a.walkDirectory(dirPath, func(path string) error {
+		if !a.analyzable(path) || strings.Contains(path, "vendor/") {
+			return nil
+		}
+
+		ok, err := a.withinLimits(path, &fileCount)
+		if err != nil || !ok {
+			return err
+		}
+
+		waitStart := time.Now()
+		select {
+		case p.window <- struct{}{}:
+		case <-p.stop:
+			return errStopped
+		}
+		out <- &fileJob{index: *files, path: path}
+		blocked += time.Since(waitStart)
+		*files++
+		return nil
+	})
+}
+
+// stage starts the workers of one stage and returns its output channel,
+// which is closed once in is drained. Skipped jobs and jobs that already
+// failed pass through untouched so the sink still sees every index.
+func (p *pipeline) stage(s stage, in <-chan *fileJob, work func(*fileJob)) <-chan *fileJob {
+	out := make(chan *fileJob, p.workers)
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range in {
+				if !p.skip(job) {
+					start := time.Now()
+					work(job)
+					p.timings.add(s, time.Since(start))
+				}
+				out <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+func (p *pipeline) recordHit(index int) {
+	for {
+		hit := p.firstHit.Load()
+		if int64(index) >= hit || p.firstHit.CompareAndSwap(hit, int64(index)) {
+			return
+		}
+	}
+}
+
+// sink merges jobs into the results in walk order, releasing each file's
+// window slot once it is merged. It drains in even after halting so no
+// stage is left blocked.
+func (p *pipeline) sink(in <-chan *fileJob) error {
+	a := p.analyzer
+	var err error
+	pending := make(map[int]*fileJob)
+	next := 0
+	for job := range in {
+		pending[job.index] = job
+		for {
+			job, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-p.window
+
+			if err != nil || a.stopped() {
+				continue
+			}
+			start := time.Now()
+			err = a.merge(job)
+			p.timings.add(stageSink, time.Since(start))
+			if err != nil || a.stopped() {
+				p.halt()
+			}
+		}
+	}
+	return err
+}
+
+func (a *Analyzer) merge(job *fileJob) error {
+	if job.err != nil {
+		skipped := a.skippedFileError(job.path, job.err)
+		if skipped == nil {
+			return job.err
+		}
+		a.results.Errors = append(a.results.Errors, *skipped)
+		a.record(job.path, &fileResult{err: skipped})
+		return nil
+	}
+	a.results.Sockets = append(a.results.Sockets, job.sockets...)
+	a.record(job.path, &fileResult{sockets: job.sockets})
+	return nil
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func exportWithWorkers(t *testing.T, root string, workers int) []byte {
+	t.Helper()
+	a := New()
+	a.SetWorkers(workers)
+	results, err := a.Analyze(root)
+	if err != nil {
+		t.Fatalf("Analyze with %d workers failed: %v", workers, err)
+	}
+	results.Scan = nil // carries the scan time
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyzer_WorkersDeterministic(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 40; i++ {
+		code := fmt.Sprintf("package p%d\nimport \"net\"\nfunc f() {\n\tnet.Listen(\"tcp\", \":%d\")\n\tnet.Dial(\"tcp\", \"svc%d:80\")\n}\n", i, 8000+i, i)
+		if i%7 == 3 {
+			code = "package broken\nfunc {"
+		}
+		path := filepath.Join(root, fmt.Sprintf("d%d", i%5), fmt.Sprintf("f%02d.go", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sequential := exportWithWorkers(t, root, 1)
+	for _, workers := range []int{2, 8, 32} {
+		for run := 0; run < 3; run++ {
+			if parallel := exportWithWorkers(t, root, workers); !bytes.Equal(sequential, parallel) {
+				t.Fatalf("Output with %d workers differs from sequential output", workers)
+			}
+		}
+	}
+}
+
+func TestAnalyzer_WorkersStopAtFirst(t *testing.T) {
+	root := writeFilterFixture(t)
+	for _, workers := range []int{1, 4} {
+		a := New()
+		a.SetWorkers(workers)
+		a.SetStopAtFirst(true)
+		results, err := a.Analyze(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Sockets) != 1 || filepath.Base(filepath.Dir(results.Sockets[0].SourceFile)) != "a" {
+			t.Errorf("%d workers: expected the first finding in walk order, got %+v", workers, results.Sockets)
+		}
+	}
+}
+
+func TestAnalyzer_PipelineStageTimings(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var reports []PipelineStats
+	a := New()
+	a.SetWorkers(2)
+	if _, err := a.Analyze(writeFilterFixture(t)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(logs.String(), "Analyzed") {
+		t.Errorf("Expected no timings without a stats hook, got %q", logs.String())
+	}
+
+	a.SetPipelineStats(func(stats PipelineStats) { reports = append(reports, stats) })
+	if _, err := a.Analyze(writeFilterFixture(t)); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected one stats report, got %d", len(reports))
+	}
+	stats := reports[0]
+	if stats.Files != 3 || stats.Workers != 2 || len(stats.Stages) != int(numStages) {
+		t.Errorf("Expected 3 files, 2 workers and %d stages, got %+v", numStages, stats)
+	}
+	out := stats.String()
+	for _, want := range []string{"Analyzed 3 files", "2 workers", "walk ", "parse ", "match ", "resolve ", "sink "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected the stats to contain %q, got %q", want, out)
+		}
+	}
+}
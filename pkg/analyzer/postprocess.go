@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// PostProcess enriches or rewrites the results of an analysis before they
+// are returned, such as by looking up the owner of each destination in a
+// CMDB. It may change the results in place; an error fails the analysis.
+type PostProcess func(*types.AnalysisResults) error
+
+// AddPostProcess adds hooks run, in order, on the results of each Analyze,
+// AnalyzeSource and Reanalyze, after counting and truncation. Each call
+// runs them on a copy of its own, so that Reanalyze starts from the
+// results as analyzed rather than as post-processed.
+func (a *Analyzer) AddPostProcess(hooks ...PostProcess) {
+	a.postProcess = append(a.postProcess, hooks...)
+}
+
+// WithPostProcess adds hooks run on the results of each analysis.
+func WithPostProcess(hooks ...PostProcess) Option {
+	return func(a *Analyzer) { a.AddPostProcess(hooks...) }
+}
+
+// AddPlugin adds a hook running the external plugin command, its name
+// followed by its arguments, as CommandPostProcess does. Unlike a hook
+// added with AddPostProcess, the command is part of ConfigHash.
+func (a *Analyzer) AddPlugin(command []string) {
+	if len(command) == 0 {
+		return
+	}
+	a.plugins = append(a.plugins, command)
+	a.AddPostProcess(CommandPostProcess(command[0], command[1:]...))
+}
+
+// WithPlugin adds a hook running the external plugin command.
+func WithPlugin(command ...string) Option {
+	return func(a *Analyzer) { a.AddPlugin(command) }
+}
+
+// postProcessed returns a copy of results passed through the
+// post-processing hooks, or results themselves when there are none. The
+// sockets are copied deeply, so that hooks changing them in place leave
+// the results Reanalyze starts from as they were.
+func (a *Analyzer) postProcessed(results *types.AnalysisResults) (*types.AnalysisResults, error) {
+	if len(a.postProcess) == 0 {
+		return results, nil
+	}
+	processed := *results
+	processed.Sockets = make([]types.SocketInfo, len(results.Sockets))
+	for i := range results.Sockets {
+		processed.Sockets[i] = *cloneSocket(&results.Sockets[i])
+	}
+	processed.Errors = slices.Clone(results.Errors)
+	processed.Endpoints = slices.Clone(results.Endpoints)
+	processed.Scan = clonePointer(results.Scan)
+	processed.VCS = clonePointer(results.VCS)
+	for _, hook := range a.postProcess {
+		if err := hook(&processed); err != nil {
+			return nil, fmt.Errorf("post-processing results: %w", err)
+		}
+	}
+	return &processed, nil
+}
+
+// CommandPostProcess returns a hook running an external plugin: the
+// command name with args, which reads the results as JSON on its standard
+// input and writes them back, enriched, as JSON on its standard output.
+// The command failing, or writing anything but results, fails the hook.
+func CommandPostProcess(name string, args ...string) PostProcess {
+	return func(results *types.AnalysisResults) error {
+		input, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return fmt.Errorf("%s: %w: %s", name, err, message)
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		var processed types.AnalysisResults
+		if err := json.Unmarshal(output, &processed); err != nil {
+			return fmt.Errorf("%s: invalid results: %w", name, err)
+		}
+		*results = processed
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestAnalyzer_PostProcess(t *testing.T) {
+	var calls []string
+	owner := func(results *types.AnalysisResults) error {
+		calls = append(calls, "owner")
+		for i := range results.Sockets {
+			results.Sockets[i].ProcessName = "payments"
+		}
+		return nil
+	}
+	drop := func(results *types.AnalysisResults) error {
+		calls = append(calls, "drop")
+		results.Sockets = results.Sockets[:1]
+		return nil
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\nimport \"net\"\nfunc main() {\n\tnet.Listen(\"tcp\", \":8080\")\n\tnet.Dial(\"tcp\", \"db:5432\")\n}\n",
+	})
+	a := New(WithPostProcess(owner), WithPostProcess(drop))
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if strings.Join(calls, ",") != "owner,drop" {
+		t.Errorf("Expected the hooks to run in order, got %v", calls)
+	}
+	if len(results.Sockets) != 1 || results.Sockets[0].ProcessName != "payments" {
+		t.Errorf("Expected one finding of process payments, got %+v", results.Sockets)
+	}
+
+	// Reanalyze starts from the results as analyzed
+	a.Invalidate(filepath.Join(dir, "main.go"))
+	calls = nil
+	results, err = a.Reanalyze()
+	if err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+	if strings.Join(calls, ",") != "owner,drop" || len(results.Sockets) != 1 {
+		t.Errorf("Expected the hooks to run again on both findings, got %v and %+v", calls, results.Sockets)
+	}
+
+	failing := New(WithPostProcess(func(*types.AnalysisResults) error { return errors.New("CMDB unavailable") }))
+	if _, err := failing.AnalyzeSource([]byte("package main\n")); err == nil || !strings.Contains(err.Error(), "CMDB unavailable") {
+		t.Errorf("Expected the hook's error, got %v", err)
+	}
+}
+
+func TestAnalyzer_PostProcessReanalyze(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package a\nimport \"net\"\nfunc f() { net.Dial(\"tcp\", \"a.internal:5432\") }\n",
+		"b.go": "package a\nimport \"net\"\nfunc g() { net.Dial(\"tcp\", \"b.internal:6379\") }\n",
+	})
+	var seen []string
+	rewrite := func(results *types.AnalysisResults) error {
+		for i := range results.Sockets {
+			socket := &results.Sockets[i]
+			seen = append(seen, fmt.Sprintf("%s %v", socket.EndpointName(), socket.Tags))
+			socket.Destination.Host = "proxy.internal"
+			*socket.Destination.Port = 3128
+			socket.Tags = append(socket.Tags, "proxied")
+		}
+		return nil
+	}
+	a := New(WithPostProcess(rewrite))
+	if _, err := a.Analyze(dir); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	// b.go is not read again: its finding comes from the cache
+	seen = nil
+	a.Invalidate(filepath.Join(dir, "a.go"))
+	results, err := a.Reanalyze()
+	if err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+	if want := "[a.internal:5432 [] b.internal:6379 []]"; fmt.Sprint(seen) != want {
+		t.Errorf("Expected the hook to see the findings as analyzed, %s, got %s", want, seen)
+	}
+	if results.Sockets[1].EndpointName() != "proxy.internal:3128" {
+		t.Errorf("Expected the rewritten destination returned, got %s", results.Sockets[1].EndpointName())
+	}
+}
+
+func TestAnalyzer_PluginConfigHash(t *testing.T) {
+	plain := New().ConfigHash()
+	enrich := New(WithPlugin("/opt/my tools/enrich", "--cmdb")).ConfigHash()
+	other := New(WithPlugin("/opt/my tools/enrich", "--owners")).ConfigHash()
+	if enrich == plain || enrich == other {
+		t.Error("Expected each plugin command to change the configuration hash")
+	}
+	if New(WithPostProcess(func(*types.AnalysisResults) error { return nil })).ConfigHash() == plain {
+		t.Error("Expected a post-processing hook to change the configuration hash")
+	}
+}
+
+func TestCommandPostProcess(t *testing.T) {
+	plugin := func(mode string) PostProcess {
+		t.Setenv("STATICSOCKET_TEST_PLUGIN", mode)
+		return CommandPostProcess(os.Args[0], "-test.run=TestHelperPlugin")
+	}
+	src := []byte("package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":8080\") }\n")
+
+	opts := DefaultOptions()
+	opts.PostProcess = []PostProcess{plugin("enrich")}
+	a, err := NewWithOptions(opts)
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	results, err := a.AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if len(results.Sockets) != 1 || results.Sockets[0].EndpointName() != "0.0.0.0:8080" || !slices.Contains(results.Sockets[0].Tags, "owner:payments") {
+		t.Errorf("Expected the listener tagged by the plugin, got %+v", results.Sockets)
+	}
+
+	for mode, want := range map[string]string{
+		"fail":    "exit status 3: CMDB unavailable",
+		"garbage": "invalid results",
+	} {
+		a := New(WithPostProcess(plugin(mode)))
+		if _, err := a.AnalyzeSource(src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", mode, want, err)
+		}
+	}
+}
+
+// TestHelperPlugin is the plugin run by TestCommandPostProcess, not a test
+// of its own.
+func TestHelperPlugin(t *testing.T) {
+	switch os.Getenv("STATICSOCKET_TEST_PLUGIN") {
+	case "enrich":
+		var results types.AnalysisResults
+		if err := json.NewDecoder(os.Stdin).Decode(&results); err != nil {
+			os.Exit(2)
+		}
+		for i := range results.Sockets {
+			results.Sockets[i].Tags = append(results.Sockets[i].Tags, "owner:payments")
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(results)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "CMDB unavailable")
+		os.Exit(3)
+	case "garbage":
+		fmt.Println("not json")
+	default:
+		return
+	}
+	os.Exit(0)
+}
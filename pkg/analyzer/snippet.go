@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/printer"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+)
+
+// maxSnippetLines bounds the code snippet of a finding, such as a
+// configuration literal spanning a screen.
+const maxSnippetLines = 10
+
+// SetCodeSnippets records the source of the call or literal behind each
+// finding as its CodeSnippet, for reports and editors that show the code
+// without the file at hand. Snippets longer than ten lines are cut short.
+func (a *Analyzer) SetCodeSnippets(capture bool) {
+	a.codeSnippets = capture
+}
+
+// WithCodeSnippets records the source behind each finding.
+func WithCodeSnippets(capture bool) Option {
+	return func(a *Analyzer) { a.SetCodeSnippets(capture) }
+}
+
+// snippet returns the source of the node, or comment, finding covers in
+// file, as gofmt prints it, or "" when it covers none.
+func (a *Analyzer) snippet(file *ast.File, finding patterns.Finding) string {
+	if !finding.End.IsValid() {
+		return ""
+	}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if comment.Slash == finding.Pos && comment.End() == finding.End {
+				return comment.Text
+			}
+		}
+	}
+
+	var node ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || node != nil || n.Pos() > finding.Pos || n.End() < finding.End {
+			return false
+		}
+		if n.Pos() == finding.Pos && n.End() == finding.End {
+			node = n
+		}
+		return node == nil
+	})
+	if node == nil {
+		return ""
+	}
+	var code strings.Builder
+	if err := printer.Fprint(&code, a.fileSet, node); err != nil {
+		return ""
+	}
+	lines := strings.Split(code.String(), "\n")
+	if len(lines) > maxSnippetLines {
+		lines = append(lines[:maxSnippetLines], "...")
+	}
+	return strings.Join(lines, "\n")
+}
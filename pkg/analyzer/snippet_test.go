@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzer_CodeSnippets(t *testing.T) {
+	src := []byte(`package main
+
+import (
+	"net"
+	"net/http"
+)
+
+//staticsocket:declare ingress udp :6081 reason=XDP
+func main() {
+	ln, _ := net.Listen("tcp", ":8080")
+	srv := &http.Server{
+		Addr: ":8443",
+	}
+	http.Get("http://a.example.com/" +
+		"v1")
+	_, _ = ln, srv
+}
+`)
+	tests := []struct {
+		line, column, endLine, endColumn int
+		snippet                          string
+	}{
+		{10, 11, 10, 37, `net.Listen("tcp", ":8080")`},
+		{11, 10, 13, 3, "http.Server{\n\tAddr: \":8443\",\n}"},
+		{14, 2, 15, 8, "http.Get(\"http://a.example.com/\" +\n\t\"v1\")"},
+		{8, 1, 8, 52, "//staticsocket:declare ingress udp :6081 reason=XDP"},
+	}
+
+	a := New()
+	results, err := a.AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	for _, socket := range results.Sockets {
+		if socket.CodeSnippet != "" {
+			t.Errorf("Expected no snippets unless enabled, got %q", socket.CodeSnippet)
+		}
+	}
+
+	a.SetCodeSnippets(true)
+	results, err = a.AnalyzeSource(src)
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if len(results.Sockets) != len(tests) {
+		t.Fatalf("Expected %d findings, got %+v", len(tests), results.Sockets)
+	}
+	for i, tt := range tests {
+		socket := results.Sockets[i]
+		if socket.SourceLine != tt.line || socket.SourceColumn != tt.column || socket.EndLine != tt.endLine || socket.EndColumn != tt.endColumn {
+			t.Errorf("Finding %d: expected %d:%d-%d:%d, got %d:%d-%d:%d", i, tt.line, tt.column, tt.endLine, tt.endColumn,
+				socket.SourceLine, socket.SourceColumn, socket.EndLine, socket.EndColumn)
+		}
+		if socket.CodeSnippet != tt.snippet {
+			t.Errorf("Finding %d: expected snippet %q, got %q", i, tt.snippet, socket.CodeSnippet)
+		}
+	}
+}
+
+func TestAnalyzer_CodeSnippetsTruncated(t *testing.T) {
+	var fields strings.Builder
+	for range 20 {
+		fields.WriteString("\t\tReadTimeout: 0,\n")
+	}
+	src := "package main\nimport \"net/http\"\nvar srv = &http.Server{\n\t\tAddr: \":8080\",\n" + fields.String() + "}\n"
+
+	a := New(WithCodeSnippets(true))
+	results, err := a.AnalyzeSource([]byte(src))
+	if err != nil {
+		t.Fatalf("AnalyzeSource failed: %v", err)
+	}
+	if len(results.Sockets) != 1 {
+		t.Fatalf("Expected one finding, got %+v", results.Sockets)
+	}
+	lines := strings.Split(results.Sockets[0].CodeSnippet, "\n")
+	if len(lines) != maxSnippetLines+1 || lines[maxSnippetLines] != "..." {
+		t.Errorf("Expected the snippet cut after %d lines, got %q", maxSnippetLines, results.Sockets[0].CodeSnippet)
+	}
+}
@@ -0,0 +1,346 @@
+package analyzer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrSymbolNotFound is returned when no function of the analyzed code is
+// the symbol set with SetSymbol.
+var ErrSymbolNotFound = errors.New("symbol not found")
+
+// SetSymbol restricts findings to one function or method, named
+// pkg.Func, pkg.Type.Method or pkg.(*Type).Method, for audits of a single
+// entry point. pkg is the name in the package clause, the directory
+// relative to the analyzed root, such as cmd/server, or the import path;
+// every package it names is searched. Function literals inside the function are included.
+// The empty symbol analyzes everything.
+func (a *Analyzer) SetSymbol(symbol string) {
+	a.symbol = symbol
+}
+
+// SetCallGraph extends a symbol restriction to every function of the
+// analyzed code the symbol reaches, by calls or by references such as
+// handlers passed to http.HandleFunc. The call graph is syntactic: a call
+// of a method reaches every method of that name, since the receiver's
+// type is not known, so it errs towards including too much.
+func (a *Analyzer) SetCallGraph(callGraph bool) {
+	a.callGraph = callGraph
+}
+
+// parseSymbol splits a symbol into its package and its function name,
+// with methods named Type.Method.
+func parseSymbol(symbol string) (string, string, error) {
+	prefix, tail := "", symbol
+	if i := strings.LastIndex(symbol, "/"); i >= 0 {
+		prefix, tail = symbol[:i+1], symbol[i+1:]
+	}
+	tail = strings.NewReplacer("(*", "", ")", "").Replace(tail)
+	parts := strings.Split(tail, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", "", fmt.Errorf("invalid symbol %q: expected pkg.Func or pkg.Type.Method", symbol)
+	}
+	for _, part := range parts {
+		if !token.IsIdentifier(part) {
+			return "", "", fmt.Errorf("invalid symbol %q: expected pkg.Func or pkg.Type.Method", symbol)
+		}
+	}
+	return prefix + parts[0], strings.Join(parts[1:], "."), nil
+}
+
+// funcKey identifies a function or method by its package directory and
+// its name, with methods named Type.Method.
+type funcKey struct {
+	dir  string
+	name string
+}
+
+// funcNode is a function declaration of the call graph and what its body
+// refers to.
+type funcNode struct {
+	file       string
+	start, end int
+	// Names of functions of its own package, of functions of other
+	// packages of the module and of methods it refers to
+	locals    []string
+	qualified []funcKey
+	methods   []string
+}
+
+// span is the byte range of a function declaration in its file.
+type span struct {
+	start, end int
+}
+
+// callGraph is the function declarations of the analyzed code. Unrelated
+// packages sharing a directory, as in testdata, may declare a function of
+// the same name; both are kept under its key.
+type callGraph struct {
+	funcs   map[funcKey][]*funcNode
+	methods map[string][]funcKey
+	// Package names, root-relative directories and import paths of each
+	// directory
+	packages map[string][]string
+}
+
+// symbolScope returns the byte ranges of the functions findings are kept
+// from, by absolute file path, for the files in paths read with read.
+func (a *Analyzer) symbolScope(paths []string, read func(string) ([]byte, error)) (map[string][]span, error) {
+	pkg, name, err := parseSymbol(a.symbol)
+	if err != nil {
+		return nil, err
+	}
+	graph := a.buildCallGraph(paths, read)
+
+	var queue []funcKey
+	for dir, names := range graph.packages {
+		for _, pkgName := range names {
+			if pkgName == pkg {
+				queue = append(queue, funcKey{dir: dir, name: name})
+				break
+			}
+		}
+	}
+	scope := make(map[string][]span)
+	seen := make(map[funcKey]bool)
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for _, node := range graph.funcs[key] {
+			scope[node.file] = append(scope[node.file], span{node.start, node.end})
+			if !a.callGraph {
+				continue
+			}
+			for _, local := range node.locals {
+				queue = append(queue, funcKey{dir: key.dir, name: local})
+			}
+			queue = append(queue, node.qualified...)
+			for _, method := range node.methods {
+				queue = append(queue, graph.methods[method]...)
+			}
+		}
+	}
+	if len(scope) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, a.symbol)
+	}
+	return scope, nil
+}
+
+// buildCallGraph parses the files in paths for their function
+// declarations. Files that fail to parse are left out.
+func (a *Analyzer) buildCallGraph(paths []string, read func(string) ([]byte, error)) *callGraph {
+	graph := &callGraph{
+		funcs:    make(map[funcKey][]*funcNode),
+		methods:  make(map[string][]funcKey),
+		packages: make(map[string][]string),
+	}
+	modulePath, moduleRoot := findModule(a.root)
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		src, err := read(path)
+		if err != nil {
+			continue
+		}
+		if isTemplate(path) {
+			src, _ = expandTemplate(src)
+		}
+		file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Dir(absPath)
+		if _, ok := graph.packages[dir]; !ok {
+			if rel, err := filepath.Rel(a.absRoot(), dir); err == nil && rel != "." {
+				graph.packages[dir] = append(graph.packages[dir], filepath.ToSlash(rel))
+			}
+			if rel, err := filepath.Rel(moduleRoot, dir); err == nil && modulePath != "" && !strings.HasPrefix(rel, "..") {
+				graph.packages[dir] = append(graph.packages[dir], strings.TrimSuffix(modulePath+"/"+filepath.ToSlash(rel), "/."))
+			}
+		}
+		// A directory may hold a main package among others, or in testdata
+		// several unrelated ones
+		if !slices.Contains(graph.packages[dir], file.Name.Name) {
+			graph.packages[dir] = append(graph.packages[dir], file.Name.Name)
+		}
+
+		imports := make(map[string]string)
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || modulePath == "" || (importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/")) {
+				continue
+			}
+			name := importPath[strings.LastIndex(importPath, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = filepath.Join(moduleRoot, strings.TrimPrefix(importPath, modulePath))
+		}
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+			key := funcKey{dir: dir, name: declName(funcDecl)}
+			node := &funcNode{
+				file:  absPath,
+				start: fset.Position(funcDecl.Pos()).Offset,
+				end:   fset.Position(funcDecl.End()).Offset,
+			}
+			selected := make(map[*ast.Ident]bool)
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				switch e := n.(type) {
+				case *ast.SelectorExpr:
+					selected[e.Sel] = true
+					if pkg, ok := e.X.(*ast.Ident); ok {
+						if importDir, ok := imports[pkg.Name]; ok {
+							node.qualified = append(node.qualified, funcKey{dir: importDir, name: e.Sel.Name})
+							return false
+						}
+					}
+					node.methods = append(node.methods, e.Sel.Name)
+				case *ast.Ident:
+					if !selected[e] {
+						node.locals = append(node.locals, e.Name)
+					}
+				}
+				return true
+			})
+			graph.funcs[key] = append(graph.funcs[key], node)
+			if funcDecl.Recv != nil {
+				graph.methods[funcDecl.Name.Name] = append(graph.methods[funcDecl.Name.Name], key)
+			}
+		}
+	}
+	return graph
+}
+
+// declName names a function declaration Func, or Type.Method for methods.
+func declName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+	recv := funcDecl.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.ParenExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + funcDecl.Name.Name
+		}
+		return funcDecl.Name.Name
+	}
+}
+
+// inScope reports whether the finding at pos lies in a function the
+// symbol restriction keeps.
+func (a *Analyzer) inScope(pos token.Pos) bool {
+	if a.scope == nil {
+		return true
+	}
+	position := a.fileSet.PositionFor(pos, false)
+	path, err := filepath.Abs(position.Filename)
+	if err != nil {
+		return false
+	}
+	for _, s := range a.scope[path] {
+		if s.start <= position.Offset && position.Offset < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// restrict computes the symbol restriction for the files in paths, if a
+// symbol is set.
+func (a *Analyzer) restrict(paths []string, read func(string) ([]byte, error)) error {
+	a.scope = nil
+	if a.symbol == "" {
+		return nil
+	}
+	scope, err := a.symbolScope(paths, read)
+	a.scope = scope
+	return err
+}
+
+// scopePaths lists the files a walk of the target analyzes, applying the
+// same filters but not the file limit.
+func (a *Analyzer) scopePaths() ([]string, error) {
+	if !a.targetIsDir {
+		return []string{a.target}, nil
+	}
+	var paths []string
+	err := a.walkDirectory(a.target, func(path string) error {
+		if a.analyzable(path) && !strings.Contains(path, "vendor/") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func (a *Analyzer) absRoot() string {
+	root, err := filepath.Abs(a.root)
+	if err != nil {
+		return a.root
+	}
+	return root
+}
+
+// findModule returns the module path and directory of the nearest go.mod
+// at or above dir, or empty strings when there is none.
+func findModule(dir string) (string, string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if file, err := os.Open(filepath.Join(dir, "go.mod")); err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) == 2 && fields[0] == "module" {
+					modulePath, err := strconv.Unquote(fields[1])
+					if err != nil {
+						modulePath = fields[1]
+					}
+					return modulePath, dir
+				}
+			}
+			return "", ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		pkg    string
+		name   string
+		err    bool
+	}{
+		{symbol: "main.main", pkg: "main", name: "main"},
+		{symbol: "server.Server.Start", pkg: "server", name: "Server.Start"},
+		{symbol: "server.(*Server).Start", pkg: "server", name: "Server.Start"},
+		{symbol: "cmd/api.run", pkg: "cmd/api", name: "run"},
+		{symbol: "example.com/app/server.(*Server).Start", pkg: "example.com/app/server", name: "Server.Start"},
+		{symbol: "main", err: true},
+		{symbol: "a.b.c.d", err: true},
+		{symbol: "server.", err: true},
+	}
+	for _, tt := range tests {
+		pkg, name, err := parseSymbol(tt.symbol)
+		if (err != nil) != tt.err || pkg != tt.pkg || name != tt.name {
+			t.Errorf("parseSymbol(%q) = %q, %q, %v", tt.symbol, pkg, name, err)
+		}
+	}
+}
+
+func TestAnalyzer_Symbol(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n",
+		"cmd/api/main.go": `package main
+
+import (
+	"net"
+	"net/http"
+
+	"example.com/app/store"
+)
+
+type server struct{}
+
+func (s *server) Start() {
+	net.Listen("tcp", ":8000")
+	http.HandleFunc("/", handle)
+	go func() { net.Listen("tcp", ":8001") }()
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	store.Open()
+}
+
+func main() {
+	s := &server{}
+	s.Start()
+	net.Listen("tcp", ":8002")
+}
+
+func unused() {
+	net.Listen("tcp", ":8003")
+}
+`,
+		"store/store.go": `package store
+
+import "net"
+
+func Open() {
+	net.Dial("tcp", "db:5432")
+}
+
+func Close() {
+	net.Dial("tcp", "db:5433")
+}
+`,
+	})
+
+	endpoints := func(opts ...Option) ([]string, error) {
+		results, err := New(opts...).Analyze(dir)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, socket := range results.Sockets {
+			names = append(names, socket.EndpointName())
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	tests := []struct {
+		name      string
+		symbol    string
+		callGraph bool
+		want      []string
+	}{
+		{name: "method", symbol: "main.(*server).Start", want: []string{"0.0.0.0:8000", "0.0.0.0:8001"}},
+		{name: "directory", symbol: "cmd/api.unused", want: []string{"0.0.0.0:8003"}},
+		{name: "import path", symbol: "example.com/app/store.Open", want: []string{"db:5432"}},
+		{name: "call graph", symbol: "main.main", callGraph: true, want: []string{"0.0.0.0:8000", "0.0.0.0:8001", "0.0.0.0:8002", "db:5432"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := endpoints(WithSymbol(tt.symbol), WithCallGraph(tt.callGraph))
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+
+	if _, err := endpoints(WithSymbol("main.missing")); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("Expected ErrSymbolNotFound, got %v", err)
+	}
+	if _, err := NewWithOptions(Options{Symbol: "main"}); err == nil {
+		t.Error("Expected an invalid symbol to be rejected")
+	}
+}
+
+func TestAnalyzer_SymbolReanalyze(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\nfunc main() {}\n",
+		"dial.go": "package main\nimport \"net\"\nfunc dial() { net.Dial(\"tcp\", \"db:5432\") }\n",
+	})
+
+	a := New(WithSymbol("main.main"), WithCallGraph(true))
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 0 {
+		t.Fatalf("Expected no findings reachable from main, got %d", results.TotalCount)
+	}
+
+	// Only main.go changes, but it now reaches the dial of dial.go
+	writeFiles(t, dir, map[string]string{"main.go": "package main\nfunc main() { dial() }\n"})
+	a.Invalidate(filepath.Join(dir, "main.go"))
+	if results, err = a.Reanalyze(); err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Errorf("Expected the newly reached dial, got %d findings", results.TotalCount)
+	}
+}
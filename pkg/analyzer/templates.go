@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// TemplateSuffixes are the file name suffixes of Go source templates, such
+// as those of scaffolding generators, analyzed with SetScanTemplates.
+var TemplateSuffixes = []string{".go.tmpl", ".go.tpl", ".gotmpl"}
+
+var templatePlaceholder = regexp.MustCompile(`__tmpl[0-9]+__`)
+
+// SetScanTemplates also analyzes Go source templates. Template actions
+// are substituted before parsing, so matching is best effort: findings are
+// tagged "template", and values that depend on an action are reported
+// unresolved with the action as their raw value.
+func (a *Analyzer) SetScanTemplates(scan bool) {
+	a.scanTemplates = scan
+}
+
+// analyzable reports whether the walk should analyze path.
+func (a *Analyzer) analyzable(path string) bool {
+	if a.excludeTests && strings.HasSuffix(path, "_test.go") {
+		return false
+	}
+	if a.excluded(path) || a.ignored(path) {
+		return false
+	}
+	return strings.HasSuffix(path, ".go") || (a.scanTemplates && isTemplate(path))
+}
+
+func isTemplate(path string) bool {
+	for _, suffix := range TemplateSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTemplate turns a text/template of Go source into parseable Go.
+// Actions alone on their line, such as {{if .TLS}} or {{end}}, are
+// dropped; other actions become placeholder identifiers, returned in
+// order. Line numbers are kept.
+func expandTemplate(src []byte) ([]byte, []string) {
+	text := string(src)
+	var out strings.Builder
+	var actions []string
+	for i := 0; i < len(text); {
+		start := strings.Index(text[i:], "{{")
+		if start < 0 {
+			out.WriteString(text[i:])
+			break
+		}
+		start += i
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			out.WriteString(text[i:])
+			break
+		}
+		end += start + 2
+
+		out.WriteString(text[i:start])
+		action := text[start:end]
+		lineStart := strings.LastIndex(text[:start], "\n") + 1
+		lineEnd := strings.Index(text[end:], "\n")
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += end
+		}
+		if !onlyActions(text[lineStart:start]) || !onlyActions(text[end:lineEnd]) {
+			fmt.Fprintf(&out, "__tmpl%d__", len(actions))
+			actions = append(actions, action)
+		}
+		out.WriteString(strings.Repeat("\n", strings.Count(action, "\n")))
+		i = end
+	}
+	return []byte(out.String()), actions
+}
+
+// onlyActions reports whether s holds nothing but template actions and
+// white space.
+func onlyActions(s string) bool {
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			break
+		}
+		s = s[:start] + s[start+end+2:]
+	}
+	return strings.TrimSpace(s) == ""
+}
+
+// markTemplate tags the findings of a template and restores the actions
+// behind placeholders. A value built from an action is only known once
+// the generator runs.
+func markTemplate(socket *types.SocketInfo, actions []string) {
+	socket.Tags = append(socket.Tags, types.TagTemplate)
+	restore := func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			var n int
+			fmt.Sscanf(placeholder, "__tmpl%d__", &n)
+			if n < len(actions) {
+				return actions[n]
+			}
+			return placeholder
+		})
+	}
+	if !templatePlaceholder.MatchString(socket.RawValue) {
+		return
+	}
+	socket.RawValue = restore(socket.RawValue)
+	for _, endpoint := range []*types.Endpoint{socket.Listen, socket.Destination} {
+		if endpoint != nil && templatePlaceholder.MatchString(endpoint.Host) {
+			endpoint.SetHost(restore(endpoint.Host))
+		}
+	}
+	socket.IsResolved = false
+	socket.UnresolvedReason = types.UnresolvedTemplateValue
+}
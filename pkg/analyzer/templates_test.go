@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	src := "func main() {\n\t{{- if .TLS }}\n\tserve(\":{{ .Port }}\", {{ .Handler\n}})\n\t{{ end }}\n}\n"
+	expanded, actions := expandTemplate([]byte(src))
+
+	want := "func main() {\n\t\n\tserve(\":__tmpl0__\", __tmpl1__\n)\n\t\n}\n"
+	if string(expanded) != want {
+		t.Errorf("Expected %q, got %q", want, expanded)
+	}
+	if len(actions) != 2 || actions[0] != "{{ .Port }}" || actions[1] != "{{ .Handler\n}}" {
+		t.Errorf("Expected the inline actions, got %q", actions)
+	}
+	if strings.Count(string(expanded), "\n") != strings.Count(src, "\n") {
+		t.Error("Expected line numbers to be kept")
+	}
+}
+
+func TestAnalyzer_ScanTemplates(t *testing.T) {
+	dir := t.TempDir()
+	code := `package main
+
+import "net"
+
+func main() {
+	{{- if .Admin }}
+	net.Listen("tcp", ":9000")
+	{{- end }}
+	net.Listen("tcp", ":{{ .Port }}")
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.go.tmpl"), []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := New().Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 0 {
+		t.Errorf("Expected templates to be skipped by default, got %d findings", results.TotalCount)
+	}
+
+	a := New()
+	a.SetScanTemplates(true)
+	results, err = a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(results.Sockets) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(results.Sockets))
+	}
+	admin, service := results.Sockets[0], results.Sockets[1]
+	if !admin.IsResolved || *admin.Listen.Port != 9000 || admin.SourceLine != 7 || !containsString(admin.Tags, types.TagTemplate) {
+		t.Errorf("Expected a resolved template listener on 9000 at line 7, got %+v", admin)
+	}
+	if service.IsResolved || service.RawValue != ":{{ .Port }}" || service.UnresolvedReason != types.UnresolvedTemplateValue {
+		t.Errorf("Expected the templated port to be unresolved, got %+v", service)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
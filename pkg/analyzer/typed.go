@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"log"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// typedFile is a file parsed and type-checked by go/packages.
+type typedFile struct {
+	file *ast.File
+	info *gotypes.Info
+}
+
+// SetTypeCheck loads the analyzed code with go/packages before matching,
+// so that calls are matched by their callee: a user package named net no
+// longer matches net.Dial, dot and renamed imports do, and so do methods
+// such as Get on an *http.Client. It takes a Go toolchain and the
+// module's dependencies, and holds the loaded packages in memory for the
+// whole analysis. Files that fail to load are matched without types.
+func (a *Analyzer) SetTypeCheck(typeCheck bool) {
+	a.typeCheck = typeCheck
+}
+
+// loadTypes type-checks the packages under dir, or the package of the file
+// targetPath when it is not a directory, for parseFile to pick up.
+func (a *Analyzer) loadTypes(targetPath string, isDir bool) {
+	dir, pattern := targetPath, "./..."
+	if !isDir {
+		dir, pattern = filepath.Dir(targetPath), "."
+	}
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   dir,
+		Fset:  a.fileSet,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		log.Printf("Matching without types: %v", err)
+		return
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			filename := a.fileSet.Position(file.Package).Filename
+			// Test variants repeat the files of their package
+			a.typedFiles.LoadOrStore(filename, typedFile{file: file, info: pkg.TypesInfo})
+		}
+	}
+}
+
+// typed returns the type-checked syntax of filePath, if it was loaded, and
+// registers its types for matching. Each file is handed out once, so that
+// Reanalyze parses edited files afresh.
+func (a *Analyzer) typed(filePath string) (*ast.File, bool) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, false
+	}
+	value, ok := a.typedFiles.LoadAndDelete(absPath)
+	if !ok {
+		return nil, false
+	}
+	typed := value.(typedFile)
+	a.patterns.SetTypesInfo(typed.file, typed.info)
+	return typed.file, true
+}
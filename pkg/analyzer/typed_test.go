@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestAnalyzer_TypeCheck(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.21\n",
+		"net/net.go": "package net\n\nfunc Dial(network, address string) error { return nil }\n",
+		"main.go": `package main
+
+import (
+	"net/http"
+
+	"example.com/app/net"
+)
+
+type api struct{ client *http.Client }
+
+func (a *api) fetch() {
+	a.client.Get("http://api.example.com/v1")
+}
+
+func main() {
+	net.Dial("tcp", "db:5432")
+}
+`,
+	})
+
+	results, err := New().Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 0 {
+		t.Errorf("Expected no findings without types, got %+v", results.Sockets)
+	}
+
+	a := New()
+	a.SetTypeCheck(true)
+	results, err = a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Fatalf("Expected only the client call, got %+v", results.Sockets)
+	}
+	socket := results.Sockets[0]
+	if socket.PatternMatch != "http.Get" || socket.Destination == nil || socket.Destination.Host != "api.example.com" {
+		t.Errorf("Expected http.Get to api.example.com, got %+v", socket)
+	}
+	if socket.SourceLine != 12 {
+		t.Errorf("Expected line 12, got %d", socket.SourceLine)
+	}
+}
+
+func TestAnalyzer_TypeCheckFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":80\") }\n",
+	})
+
+	a := New()
+	a.SetTypeCheck(true)
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Errorf("Expected the listener without a module, got %+v", results.Sockets)
+	}
+}
@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values go/build recognizes
+// as file name suffixes.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// buildConstraint returns the constraint under which file is built, from
+// its _GOOS/_GOARCH name suffix and //go:build line, or "" if it is built
+// everywhere.
+func buildConstraint(filePath string, file *ast.File) string {
+	var terms []string
+	_, osTag, archTag := splitVariantName(filepath.Base(filePath))
+	if osTag != "" {
+		terms = append(terms, osTag)
+	}
+	if archTag != "" {
+		terms = append(terms, archTag)
+	}
+	if expr := goBuildExpr(file); expr != nil {
+		line := expr.String()
+		if _, isOr := expr.(*constraint.OrExpr); isOr && len(terms) > 0 {
+			line = "(" + line + ")"
+		}
+		terms = append(terms, line)
+	}
+	return strings.Join(terms, " && ")
+}
+
+func goBuildExpr(file *ast.File) constraint.Expr {
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(comment.Text); err == nil {
+				return expr
+			}
+		}
+	}
+	return nil
+}
+
+// splitVariantName splits a file name into its stem and the GOOS and
+// GOARCH suffixes go/build honors: foo_linux_amd64.go is stem "foo".
+// Test files keep _test in the stem.
+func splitVariantName(name string) (stem, osTag, archTag string) {
+	stem = strings.TrimSuffix(name, ".go")
+	test := strings.HasSuffix(stem, "_test")
+	stem = strings.TrimSuffix(stem, "_test")
+	defer func() {
+		if test {
+			stem += "_test"
+		}
+	}()
+
+	parts := strings.Split(stem, "_")
+	n := len(parts)
+	if n >= 3 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return strings.Join(parts[:n-2], "_"), parts[n-2], parts[n-1]
+	}
+	if n >= 2 && knownOS[parts[n-1]] {
+		return strings.Join(parts[:n-1], "_"), parts[n-1], ""
+	}
+	if n >= 2 && knownArch[parts[n-1]] {
+		return strings.Join(parts[:n-1], "_"), "", parts[n-1]
+	}
+	return stem, "", ""
+}
+
+// variantStem returns the path identifying a file across its variants.
+// Besides the GOOS and GOARCH suffixes, a last name segment that is a tag
+// of the build constraint is dropped too, so foo_unix.go built with
+// //go:build unix pairs up with foo_windows.go.
+func variantStem(filePath, buildLine string) string {
+	stem, _, _ := splitVariantName(filepath.Base(filePath))
+	if expr, err := constraint.Parse("//go:build " + buildLine); err == nil {
+		tags := make(map[string]bool)
+		expr.Eval(func(tag string) bool {
+			tags[tag] = true
+			return true
+		})
+		if i := strings.LastIndex(stem, "_"); i > 0 && tags[stem[i+1:]] {
+			stem = stem[:i]
+		}
+	}
+	return filepath.Join(filepath.Dir(filePath), stem)
+}
+
+// mergeVariants folds findings that occur identically in several variants
+// of one file into the first of them, listing every variant, so
+// cross-platform code is counted once. Findings repeated within a single
+// file are left alone.
+func mergeVariants(sockets []types.SocketInfo) []types.SocketInfo {
+	merged := make([]types.SocketInfo, 0, len(sockets))
+	first := make(map[string]int)
+	for _, socket := range sockets {
+		if len(socket.Variants) != 1 {
+			merged = append(merged, socket)
+			continue
+		}
+
+		key := variantKey(socket)
+		if i, ok := first[key]; ok && !hasVariantFile(merged[i], socket.SourceFile) {
+			merged[i].Variants = append(merged[i].Variants, socket.Variants[0])
+			continue
+		}
+		if _, ok := first[key]; !ok {
+			first[key] = len(merged)
+		}
+		merged = append(merged, socket)
+	}
+	return merged
+}
+
+// variantKey identifies a finding independently of which variant file and
+// line it was found at.
+func variantKey(socket types.SocketInfo) string {
+	stem := variantStem(socket.Variants[0].SourceFile, socket.Variants[0].Constraint)
+	socket.SourceFile, socket.SourceLine, socket.LogicalPath, socket.Variants, socket.Generated = "", 0, "", nil, nil
+	socket.SourceColumn, socket.EndLine, socket.EndColumn = 0, 0, 0
+	socket.ResolvedFrom = nil
+	data, _ := json.Marshal(socket)
+	return stem + "\x00" + string(data)
+}
+
+func hasVariantFile(socket types.SocketInfo, file string) bool {
+	for _, variant := range socket.Variants {
+		if variant.SourceFile == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitVariantName(t *testing.T) {
+	tests := []struct {
+		name, stem, os, arch string
+	}{
+		{"server.go", "server", "", ""},
+		{"server_linux.go", "server", "linux", ""},
+		{"server_linux_amd64.go", "server", "linux", "amd64"},
+		{"server_arm64.go", "server", "", "arm64"},
+		{"server_windows_test.go", "server_test", "windows", ""},
+		{"linux.go", "linux", "", ""},
+		{"read_config.go", "read_config", "", ""},
+	}
+	for _, tt := range tests {
+		stem, osTag, archTag := splitVariantName(tt.name)
+		if stem != tt.stem || osTag != tt.os || archTag != tt.arch {
+			t.Errorf("splitVariantName(%q) = %q, %q, %q; want %q, %q, %q",
+				tt.name, stem, osTag, archTag, tt.stem, tt.os, tt.arch)
+		}
+	}
+}
+
+func TestBuildConstraint(t *testing.T) {
+	tests := []struct {
+		path, code, want string
+	}{
+		{"a/server.go", "package a", ""},
+		{"a/server_linux.go", "package a", "linux"},
+		{"a/server_linux_amd64.go", "package a", "linux && amd64"},
+		{"a/server_unix.go", "//go:build unix\n\npackage a", "unix"},
+		{"a/server_linux.go", "//go:build cgo || netgo\n\npackage a", "linux && (cgo || netgo)"},
+		{"a/server.go", "package a\n\n//go:build linux\n", ""},
+	}
+	for _, tt := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), tt.path, tt.code, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buildConstraint(tt.path, file); got != tt.want {
+			t.Errorf("buildConstraint(%q, %q) = %q, want %q", tt.path, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzer_MergesBuildVariants(t *testing.T) {
+	dir := t.TempDir()
+	listen := "package srv\nimport \"net\"\nfunc listen() { net.Listen(\"tcp\", \":8080\") }\n"
+	files := map[string]string{
+		"srv/listen_linux.go":   listen,
+		"srv/listen_unix.go":    "//go:build unix && !linux\n\n" + listen,
+		"srv/listen_windows.go": "package srv\nimport \"net\"\n\nfunc listen() {\n\tnet.Listen(\"tcp\", \":8080\")\n}\n",
+		"srv/listen_plan9.go":   "package srv\nimport \"net\"\nfunc listen() { net.Listen(\"tcp\", \":9090\") }\n",
+		"srv/dial.go":           "package srv\nimport \"net\"\nfunc dial() { net.Dial(\"tcp\", \"db:5432\") }\n",
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := New().Analyze(dir)
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if results.TotalCount != 3 || results.IngressCount != 2 {
+		t.Fatalf("Expected 3 findings (2 ingress), got %d (%d ingress): %+v", results.TotalCount, results.IngressCount, results.Sockets)
+	}
+
+	for _, socket := range results.Sockets {
+		switch {
+		case socket.Type == "egress":
+			if socket.Variants != nil {
+				t.Errorf("Expected no variants for an unconstrained file, got %+v", socket.Variants)
+			}
+		case *socket.Listen.Port == 8080:
+			if len(socket.Variants) != 3 {
+				t.Fatalf("Expected :8080 merged across 3 variants, got %+v", socket.Variants)
+			}
+			want := []string{"linux", "unix && !linux", "windows"}
+			for i, variant := range socket.Variants {
+				if variant.Constraint != want[i] {
+					t.Errorf("Expected variant %d to be %q, got %q", i, want[i], variant.Constraint)
+				}
+			}
+			if filepath.Base(socket.SourceFile) != "listen_linux.go" || socket.Variants[2].SourceLine != 5 {
+				t.Errorf("Expected the first variant's position and each variant's own line, got %+v", socket)
+			}
+		case *socket.Listen.Port == 9090:
+			if len(socket.Variants) != 1 || socket.Variants[0].Constraint != "plan9" {
+				t.Errorf("Expected a single plan9 variant, got %+v", socket.Variants)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// SymlinkPolicy controls how the directory walker treats symbolic links.
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip does not descend into linked directories, as
+	// filepath.Walk does not; linked files are analyzed like others.
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkFollow descends into linked directories and analyzes linked
+	// files, visiting each real path at most once so link cycles and
+	// symlink farms do not cause duplicate or runaway scans.
+	SymlinkFollow
+)
+
+// ParseSymlinkPolicy converts a CLI value ("skip" or "follow") into a policy.
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, bool) {
+	switch value {
+	case "skip":
+		return SymlinkSkip, true
+	case "follow":
+		return SymlinkFollow, true
+	}
+	return SymlinkSkip, false
+}
+
+// SetSymlinkPolicy selects how symbolic links are handled during directory walks.
+func (a *Analyzer) SetSymlinkPolicy(policy SymlinkPolicy) {
+	a.symlinkPolicy = policy
+}
+
+// SetMaxDepth limits how many directory levels below the analyzed root are
+// descended into. Zero means no limit.
+func (a *Analyzer) SetMaxDepth(depth int) {
+	a.maxDepth = depth
+}
+
+// SetIncludeTests selects whether directory walks analyze _test.go files,
+// which they do by default. Excluding them keeps test servers and fake
+// destinations out of reports of production traffic. A test file passed
+// to Analyze itself is always analyzed.
+func (a *Analyzer) SetIncludeTests(include bool) {
+	a.excludeTests = !include
+}
+
+// SetExclude skips the files and directories whose path relative to the
+// analyzed root, or whose name, matches one of globs, in the syntax of
+// path.Match: "testdata" skips every testdata directory and "cmd/*/mock_*.go"
+// the mocks of each command. Malformed globs match nothing; NewWithOptions
+// rejects them.
+func (a *Analyzer) SetExclude(globs []string) {
+	a.exclude = globs
+}
+
+// excluded reports whether path matches an exclusion glob.
+func (a *Analyzer) excluded(name string) bool {
+	if len(a.exclude) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(a.root, name)
+	if err != nil {
+		rel = name
+	}
+	rel = filepath.ToSlash(rel)
+	for _, glob := range a.exclude {
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analyzer) walkDirectory(root string, visit func(path string) error) error {
+	return a.walk(root, 0, make(map[string]bool), visit)
+}
+
+func (a *Analyzer) walk(dir string, depth int, visited map[string]bool, visit func(path string) error) error {
+	if realPath, err := filepath.EvalSymlinks(dir); err == nil {
+		if visited[realPath] {
+			log.Printf("Skipping %s: already visited as %s", dir, realPath)
+			return nil
+		}
+		visited[realPath] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, ok := a.followSymlink(path)
+			if !ok {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if isDir {
+			if a.excluded(path) {
+				log.Printf("Skipping %s: excluded", path)
+				continue
+			}
+			if a.ignoredEntry(path, true) {
+				log.Printf("Skipping %s: ignored", path)
+				continue
+			}
+			if a.maxDepth > 0 && depth+1 > a.maxDepth {
+				log.Printf("Skipping %s: exceeds max depth %d", path, a.maxDepth)
+				continue
+			}
+			if err := a.walk(path, depth+1, visited, visit); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if a.symlinkPolicy == SymlinkFollow {
+			if realPath, err := filepath.EvalSymlinks(path); err == nil {
+				if visited[realPath] {
+					continue
+				}
+				visited[realPath] = true
+			}
+		}
+
+		if err := visit(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// followSymlink returns what the symlink at path points to, and whether a
+// walk goes on to it: dangling links are skipped, and so are linked
+// directories under SymlinkSkip, while linked files are always analyzed.
+func (a *Analyzer) followSymlink(path string) (fs.FileInfo, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Printf("Skipping dangling symlink %s: %v", path, err)
+		return nil, false
+	}
+	if info.IsDir() && a.symlinkPolicy == SymlinkSkip {
+		log.Printf("Skipping symlinked directory %s", path)
+		return nil, false
+	}
+	return info, true
+}
@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzer_SymlinkHandling(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	server := `package main
+import "net/http"
+func main() {
+	http.ListenAndServe(":8080", nil)
+}`
+	if err := os.WriteFile(filepath.Join(srcDir, "server.go"), []byte(server), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// A link back to the root creates a cycle; a second link duplicates src.
+	if err := os.Symlink(tmpDir, filepath.Join(srcDir, "loop")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(srcDir, filepath.Join(tmpDir, "farm")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   SymlinkPolicy
+		expected int
+	}{
+		{name: "skip", policy: SymlinkSkip, expected: 1},
+		{name: "follow", policy: SymlinkFollow, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := New()
+			analyzer.SetSymlinkPolicy(tt.policy)
+			results, err := analyzer.Analyze(tmpDir)
+			if err != nil {
+				t.Fatalf("Failed to analyze directory: %v", err)
+			}
+			if results.TotalCount != tt.expected {
+				t.Errorf("Expected %d sockets, got %d", tt.expected, results.TotalCount)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_SymlinkedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "shared", "metrics.go.txt")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":9090\") }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmpDir, "app")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(dir, "m.go")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	// Like filepath.Walk, the default walk analyzes linked files
+	a := New()
+	results, err := a.Analyze(dir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if results.TotalCount != 1 || results.Sockets[0].EndpointName() != "0.0.0.0:9090" {
+		t.Errorf("Expected the listener of the linked file, got %+v", results.Sockets)
+	}
+
+	// and so does Reanalyze once the link is invalidated
+	a.Invalidate(filepath.Join(dir, "m.go"))
+	results, err = a.Reanalyze()
+	if err != nil {
+		t.Fatalf("Reanalyze failed: %v", err)
+	}
+	if results.TotalCount != 1 || results.Sockets[0].EndpointName() != "0.0.0.0:9090" {
+		t.Errorf("Expected Reanalyze to keep the listener of the linked file, got %+v", results.Sockets)
+	}
+}
+
+func TestAnalyzer_MaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"top.go":        "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":1000\") }",
+		"a/mid.go":      "package a\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":2000\") }",
+		"a/b/bottom.go": "package b\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":3000\") }",
+	}
+	for filename, content := range files {
+		filePath := filepath.Join(tmpDir, filename)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", filename, err)
+		}
+	}
+
+	for depth, expected := range map[int]int{0: 3, 1: 2, 2: 3} {
+		analyzer := New()
+		analyzer.SetMaxDepth(depth)
+		results, err := analyzer.Analyze(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to analyze directory: %v", err)
+		}
+		if results.TotalCount != expected {
+			t.Errorf("max depth %d: expected %d sockets, got %d", depth, expected, results.TotalCount)
+		}
+	}
+}
+
+func TestAnalyzer_IncludeTests(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"server.go":      "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":8080\") }",
+		"server_test.go": "package main\nimport \"net/http\"\nfunc TestServer() { http.Get(\"http://fake.example.com\") }",
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(code), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	for _, include := range []bool{true, false} {
+		analyzer := New()
+		analyzer.SetIncludeTests(include)
+		results, err := analyzer.Analyze(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to analyze directory: %v", err)
+		}
+		if want := map[bool]int{true: 2, false: 1}[include]; results.TotalCount != want {
+			t.Errorf("include tests %t: expected %d sockets, got %d", include, want, results.TotalCount)
+		}
+	}
+
+	// A test file named explicitly is analyzed anyway
+	analyzer := New()
+	analyzer.SetIncludeTests(false)
+	results, err := analyzer.Analyze(filepath.Join(tmpDir, "server_test.go"))
+	if err != nil {
+		t.Fatalf("Failed to analyze file: %v", err)
+	}
+	if results.TotalCount != 1 {
+		t.Errorf("Expected the named test file analyzed, got %d sockets", results.TotalCount)
+	}
+	if analyzer.ConfigHash() == New().ConfigHash() {
+		t.Error("Expected excluding tests to change the configuration hash")
+	}
+}
+
+func TestAnalyzer_Exclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":                  "package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":1000\") }",
+		"testdata/fixture.go":      "package fixture\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":2000\") }",
+		"cmd/api/mock_client.go":   "package main\nimport \"net\"\nfunc f() { net.Dial(\"tcp\", \"fake:1\") }",
+		"cmd/api/server.go":        "package main\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":3000\") }",
+		"pkg/testdata/fixtures.go": "package fixture\nimport \"net\"\nfunc f() { net.Listen(\"tcp\", \":4000\") }",
+	}
+	for filename, content := range files {
+		filePath := filepath.Join(tmpDir, filename)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", filename, err)
+		}
+	}
+
+	analyzer := New()
+	analyzer.SetExclude([]string{"testdata", "cmd/*/mock_*.go"})
+	results, err := analyzer.Analyze(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze directory: %v", err)
+	}
+	if results.TotalCount != 2 {
+		t.Errorf("Expected main.go and cmd/api/server.go analyzed, got %+v", results.Sockets)
+	}
+	if analyzer.ConfigHash() == New().ConfigHash() {
+		t.Error("Expected exclusions to change the configuration hash")
+	}
+}
@@ -0,0 +1,151 @@
+// Package diff compares the results of two analysis runs, such as the scan
+// of a release and of the change on top of it.
+package diff
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Result lists how the findings of a new run differ from an old one.
+type Result struct {
+	Added   []types.SocketInfo `json:"added" yaml:"added"`
+	Removed []types.SocketInfo `json:"removed" yaml:"removed"`
+	Changed []Change           `json:"changed" yaml:"changed"`
+}
+
+// Change is a finding present in both runs whose details differ.
+type Change struct {
+	Old types.SocketInfo `json:"old" yaml:"old"`
+	New types.SocketInfo `json:"new" yaml:"new"`
+	// Fields names what differs, e.g. "endpoint" or "is_resolved"
+	Fields []string `json:"fields" yaml:"fields"`
+}
+
+// Empty reports whether the runs have the same findings.
+func (r *Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// Fingerprint identifies a finding across runs: what it is and where it is
+// opened, but not the line, which moves with unrelated edits, nor the
+// address, which a change may edit. Its file is the logical path, or the
+// source file as recorded; Compare uses the path relative to the root of
+// each run instead, so that checkouts in different directories compare.
+func Fingerprint(socket types.SocketInfo) string {
+	return fingerprint(socket, "")
+}
+
+// fingerprint is Fingerprint with the source file relative to root, when
+// it is under it. The process name is left out: that of a main package is
+// the name of its directory, which differs between checkouts too.
+func fingerprint(socket types.SocketInfo, root string) string {
+	file := socket.LogicalPath
+	if file == "" {
+		file = filepath.ToSlash(socket.SourceFile)
+		if rel, err := filepath.Rel(filepath.FromSlash(root), socket.SourceFile); root != "" && err == nil && filepath.IsLocal(rel) {
+			file = filepath.ToSlash(rel)
+		}
+	}
+	return strings.Join([]string{
+		string(socket.Type), string(socket.Protocol),
+		file, socket.FunctionName, socket.PatternMatch,
+	}, "\x00")
+}
+
+// Compare matches the findings of old and new by fingerprint. Findings
+// sharing a fingerprint are paired in line order, so a second dial added
+// in the same function shows up as one addition. Either run may be nil.
+func Compare(old, new *types.AnalysisResults) *Result {
+	oldGroups := groupByFingerprint(old)
+	newGroups := groupByFingerprint(new)
+
+	result := &Result{}
+	for _, fingerprint := range sortedKeys(oldGroups, newGroups) {
+		before, after := oldGroups[fingerprint], newGroups[fingerprint]
+		for i := 0; i < len(before) || i < len(after); i++ {
+			switch {
+			case i >= len(after):
+				result.Removed = append(result.Removed, before[i])
+			case i >= len(before):
+				result.Added = append(result.Added, after[i])
+			default:
+				if fields := changedFields(before[i], after[i]); len(fields) > 0 {
+					result.Changed = append(result.Changed, Change{Old: before[i], New: after[i], Fields: fields})
+				}
+			}
+		}
+	}
+	return result
+}
+
+func groupByFingerprint(results *types.AnalysisResults) map[string][]types.SocketInfo {
+	groups := make(map[string][]types.SocketInfo)
+	if results == nil {
+		return groups
+	}
+	var root string
+	if results.Scan != nil {
+		root = results.Scan.Root
+	}
+	for _, socket := range results.Sockets {
+		key := fingerprint(socket, root)
+		groups[key] = append(groups[key], socket)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].SourceLine < group[j].SourceLine })
+	}
+	return groups
+}
+
+func sortedKeys(a, b map[string][]types.SocketInfo) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// changedFields lists the details that differ between two occurrences of
+// a finding. Positions are not compared.
+func changedFields(old, new types.SocketInfo) []string {
+	var fields []string
+	compare := func(name string, a, b any) {
+		if !reflect.DeepEqual(a, b) {
+			fields = append(fields, name)
+		}
+	}
+	compare("endpoint", old.EndpointName(), new.EndpointName())
+	compare("is_resolved", old.IsResolved, new.IsResolved)
+	compare("raw_value", old.RawValue, new.RawValue)
+	compare("unresolved_reason", old.UnresolvedReason, new.UnresolvedReason)
+	compare("candidate_values", old.CandidateValues, new.CandidateValues)
+	compare("env_var", old.EnvVar, new.EnvVar)
+	compare("default_value", old.DefaultValue, new.DefaultValue)
+	compare("candidate_ports", old.CandidatePorts, new.CandidatePorts)
+	compare("tags", old.Tags, new.Tags)
+	compare("consumed_by", old.ConsumedBy, new.ConsumedBy)
+	compare("facets", old.Facets, new.Facets)
+	compare("verifies_tls", old.VerifiesTLS, new.VerifiesTLS)
+	compare("closed_by", old.ClosedBy, new.ClosedBy)
+	compare("shutdown_signals", old.ShutdownSignals, new.ShutdownSignals)
+	compare("socket_options", old.SocketOptions, new.SocketOptions)
+	return fields
+}
+
+// String renders a finding for a one-line diff listing.
+func String(socket types.SocketInfo) string {
+	return fmt.Sprintf("%s %s %s %s (%s in %s:%d)", socket.Type, socket.Protocol, socket.ProcessName,
+		socket.EndpointName(), socket.PatternMatch, socket.SourceFile, socket.SourceLine)
+}
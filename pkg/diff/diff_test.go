@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/analyzer"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func port(p int) *int {
+	return &p
+}
+
+func TestCompare(t *testing.T) {
+	old := &types.AnalysisResults{Sockets: []types.SocketInfo{
+		{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, SourceFile: "main.go", SourceLine: 10, FunctionName: "main",
+			PatternMatch: "http.ListenAndServe", Listen: types.NewEndpoint("0.0.0.0", port(8080)), IsResolved: true},
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolHTTP, SourceFile: "client.go", SourceLine: 5, FunctionName: "fetch",
+			PatternMatch: "http.Get", Destination: types.NewEndpoint("api.example.com", port(80)), IsResolved: true},
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, SourceFile: "legacy.go", SourceLine: 3, FunctionName: "dial",
+			PatternMatch: "net.Dial", Destination: types.NewEndpoint("old.example.com", port(9000)), IsResolved: true},
+	}}
+	current := &types.AnalysisResults{Sockets: []types.SocketInfo{
+		// Moved down by an unrelated edit
+		{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, SourceFile: "main.go", SourceLine: 14, FunctionName: "main",
+			PatternMatch: "http.ListenAndServe", Listen: types.NewEndpoint("0.0.0.0", port(8080)), IsResolved: true},
+		// Now connects elsewhere
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolHTTP, SourceFile: "client.go", SourceLine: 5, FunctionName: "fetch",
+			PatternMatch: "http.Get", Destination: types.NewEndpoint("api.internal", port(80)), IsResolved: true},
+		// A second call in the same function
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolHTTP, SourceFile: "client.go", SourceLine: 9, FunctionName: "fetch",
+			PatternMatch: "http.Get", RawValue: "url"},
+	}}
+
+	result := Compare(old, current)
+	if len(result.Added) != 1 || result.Added[0].SourceLine != 9 {
+		t.Errorf("Expected the second http.Get as added, got %+v", result.Added)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].PatternMatch != "net.Dial" {
+		t.Errorf("Expected net.Dial as removed, got %+v", result.Removed)
+	}
+	if len(result.Changed) != 1 {
+		t.Fatalf("Expected 1 changed finding, got %d", len(result.Changed))
+	}
+	change := result.Changed[0]
+	if change.New.Destination.Host != "api.internal" || len(change.Fields) != 1 || change.Fields[0] != "endpoint" {
+		t.Errorf("Expected the http.Get endpoint change, got %+v", change)
+	}
+}
+
+func TestCompare_Identical(t *testing.T) {
+	results := &types.AnalysisResults{Sockets: []types.SocketInfo{
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, SourceFile: "a.go", PatternMatch: "net.Dial", RawValue: "addr"},
+	}}
+	if result := Compare(results, results); !result.Empty() {
+		t.Errorf("Expected no differences, got %+v", result)
+	}
+	if result := Compare(nil, results); len(result.Added) != 1 {
+		t.Errorf("Expected every finding added against a nil run, got %+v", result)
+	}
+}
+
+func TestCompare_Checkouts(t *testing.T) {
+	code := []byte("package main\nimport \"net\"\nfunc main() { net.Listen(\"tcp\", \":9090\") }\n")
+	var runs []*types.AnalysisResults
+	for _, checkout := range []string{"base", "head"} {
+		dir := filepath.Join(t.TempDir(), checkout)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "m.go"), code, 0644); err != nil {
+			t.Fatal(err)
+		}
+		results, err := analyzer.New().Analyze(dir)
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+		runs = append(runs, results)
+	}
+	if runs[0].Sockets[0].SourceFile == runs[1].Sockets[0].SourceFile || runs[0].Sockets[0].ProcessName == runs[1].Sockets[0].ProcessName {
+		t.Fatalf("Expected the checkouts to differ in path and process name, got %+v", runs)
+	}
+	if result := Compare(runs[0], runs[1]); !result.Empty() {
+		t.Errorf("Expected no differences between checkouts, got %+v", result)
+	}
+}
@@ -0,0 +1,134 @@
+// Package evidence packages analysis results together with the source
+// they were derived from, so findings can be audited after the code changes.
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// ContextLines is the number of lines kept on each side of a finding.
+const ContextLines = 3
+
+// Manifest indexes the bundle contents.
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	// Commit is the revision of the analyzed tree, if it is a checkout.
+	Commit   string    `json:"commit,omitempty"`
+	Results  string    `json:"results"`
+	Findings []Finding `json:"findings"`
+}
+
+// Finding ties one result entry to the snippet supporting it.
+type Finding struct {
+	Index      int    `json:"index"`
+	SourceFile string `json:"source_file"`
+	SourceLine int    `json:"source_line"`
+	// FileSHA256 is the hash of the whole source file at analysis time.
+	FileSHA256 string `json:"file_sha256,omitempty"`
+	// Snippet is the bundle path of the source excerpt, named by its hash.
+	Snippet   string `json:"snippet,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Write produces a zip bundle with results.json, one content-addressed
+// snippet per distinct source excerpt under snippets/, and manifest.json.
+func Write(w io.Writer, results *types.AnalysisResults) error {
+	archive := zip.NewWriter(w)
+
+	var resultsJSON bytes.Buffer
+	if err := results.Export(&resultsJSON, "json"); err != nil {
+		return err
+	}
+	if err := writeEntry(archive, "results.json", resultsJSON.Bytes()); err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		CreatedAt: time.Now().UTC(),
+		Results:   "results.json",
+		Findings:  make([]Finding, 0, len(results.Sockets)),
+	}
+	if results.VCS != nil {
+		manifest.Commit = results.VCS.Commit
+	}
+
+	sources := make(map[string][]byte)
+	written := make(map[string]bool)
+	for i, socket := range results.Sockets {
+		finding := Finding{Index: i, SourceFile: socket.SourceFile, SourceLine: socket.SourceLine}
+
+		src, ok := sources[socket.SourceFile]
+		if !ok {
+			var err error
+			if src, err = os.ReadFile(socket.SourceFile); err != nil {
+				finding.Error = err.Error()
+				manifest.Findings = append(manifest.Findings, finding)
+				continue
+			}
+			sources[socket.SourceFile] = src
+		}
+
+		fileSum := sha256.Sum256(src)
+		finding.FileSHA256 = hex.EncodeToString(fileSum[:])
+
+		snippet, start := excerpt(src, socket.SourceLine)
+		snippetSum := sha256.Sum256(snippet)
+		finding.Snippet = "snippets/" + hex.EncodeToString(snippetSum[:]) + ".txt"
+		finding.StartLine = start
+		if !written[finding.Snippet] {
+			if err := writeEntry(archive, finding.Snippet, snippet); err != nil {
+				return err
+			}
+			written[finding.Snippet] = true
+		}
+		manifest.Findings = append(manifest.Findings, finding)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(archive, "manifest.json", data); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeEntry(archive *zip.Writer, name string, data []byte) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("adding %s to evidence bundle: %w", name, err)
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
+// excerpt returns the lines around line (1-based) and the number of the
+// first line returned.
+func excerpt(src []byte, line int) ([]byte, int) {
+	lines := strings.SplitAfter(string(src), "\n")
+	start := line - ContextLines
+	if start < 1 {
+		start = 1
+	}
+	end := line + ContextLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return nil, start
+	}
+	return []byte(strings.Join(lines[start-1:end], "")), start
+}
@@ -0,0 +1,76 @@
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	code := "package main\n\nimport \"net/http\"\n\nfunc main() {\n\thttp.ListenAndServe(\":8080\", nil)\n\thttp.ListenAndServe(\":8081\", nil)\n}\n"
+	if err := os.WriteFile(source, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := &types.AnalysisResults{Sockets: []types.SocketInfo{
+		{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, SourceFile: source, SourceLine: 6},
+		{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, SourceFile: filepath.Join(dir, "gone.go"), SourceLine: 1},
+	}, VCS: &types.VCSInfo{System: "git", Commit: "0123abc"}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, results); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Bundle is not a valid zip: %v", err)
+	}
+	entries := make(map[string][]byte)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = data
+	}
+
+	if _, ok := entries["results.json"]; !ok {
+		t.Error("Expected results.json in bundle")
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(entries["manifest.json"], &manifest); err != nil {
+		t.Fatalf("Invalid manifest: %v", err)
+	}
+	if manifest.Commit != "0123abc" {
+		t.Errorf("Expected manifest commit from VCS metadata, got %q", manifest.Commit)
+	}
+	if len(manifest.Findings) != 2 {
+		t.Fatalf("Expected 2 findings in manifest, got %d", len(manifest.Findings))
+	}
+
+	found := manifest.Findings[0]
+	if found.FileSHA256 == "" || found.StartLine != 3 {
+		t.Errorf("Unexpected finding entry: %+v", found)
+	}
+	snippet := string(entries[found.Snippet])
+	if !strings.Contains(snippet, `":8080"`) || !strings.HasPrefix(snippet, "import") {
+		t.Errorf("Unexpected snippet %q", snippet)
+	}
+
+	if manifest.Findings[1].Error == "" || manifest.Findings[1].Snippet != "" {
+		t.Errorf("Expected missing source to be recorded as an error, got %+v", manifest.Findings[1])
+	}
+}
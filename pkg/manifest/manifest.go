@@ -0,0 +1,132 @@
+// Package manifest maintains a canonical, committed description of a
+// codebase's network surface that CI can verify against fresh results.
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// DefaultFile is the manifest location relative to the analyzed root.
+const DefaultFile = "network-manifest.yaml"
+
+// Manifest lists the distinct sockets of a codebase. It deliberately omits
+// line numbers, timestamps and absolute paths so that it only changes when
+// network behavior does.
+type Manifest struct {
+	SchemaVersion string  `yaml:"schema_version"`
+	Entries       []Entry `yaml:"entries"`
+}
+
+// Entry is one distinct socket.
+type Entry struct {
+	Type     types.TrafficType `yaml:"type"`
+	Protocol types.Protocol    `yaml:"protocol"`
+	Process  string            `yaml:"process"`
+	File     string            `yaml:"file"`
+	Endpoint string            `yaml:"endpoint"`
+	Pattern  string            `yaml:"pattern"`
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %s %s %s (%s in %s)", e.Type, e.Protocol, e.Process, e.Endpoint, e.Pattern, e.File)
+}
+
+func (e Entry) less(other Entry) bool {
+	a := []string{e.File, string(e.Type), string(e.Protocol), e.Endpoint, e.Pattern, e.Process}
+	b := []string{other.File, string(other.Type), string(other.Protocol), other.Endpoint, other.Pattern, other.Process}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// Build derives the canonical manifest from results. File paths are made
+// relative to root and use forward slashes.
+func Build(results *types.AnalysisResults, root string) *Manifest {
+	seen := make(map[Entry]bool)
+	m := &Manifest{SchemaVersion: types.SchemaVersion, Entries: []Entry{}}
+	for _, socket := range results.Sockets {
+		entry := Entry{
+			Type:     socket.Type,
+			Protocol: socket.Protocol,
+			Process:  socket.ProcessName,
+			File:     sourcePath(root, socket),
+			Endpoint: socket.EndpointName(),
+			Pattern:  socket.PatternMatch,
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			m.Entries = append(m.Entries, entry)
+		}
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].less(m.Entries[j]) })
+	return m
+}
+
+// Write encodes m as YAML below a generated-code header.
+func Write(writer io.Writer, m *Manifest, header types.GeneratedHeader) error {
+	if err := types.WriteGeneratedHeader(writer, "yaml", header); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Read decodes a manifest written by Write.
+func Read(reader io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.NewDecoder(reader).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.SchemaVersion != types.SchemaVersion {
+		return nil, fmt.Errorf("manifest schema version %q is not supported (expected %q)", m.SchemaVersion, types.SchemaVersion)
+	}
+	return &m, nil
+}
+
+// Diff returns the entries current has that committed lacks, and the
+// reverse. Both are empty when the manifest is up to date.
+func Diff(committed, current *Manifest) (added, removed []Entry) {
+	inCommitted := make(map[Entry]bool, len(committed.Entries))
+	for _, entry := range committed.Entries {
+		inCommitted[entry] = true
+	}
+	inCurrent := make(map[Entry]bool, len(current.Entries))
+	for _, entry := range current.Entries {
+		inCurrent[entry] = true
+		if !inCommitted[entry] {
+			added = append(added, entry)
+		}
+	}
+	for _, entry := range committed.Entries {
+		if !inCurrent[entry] {
+			removed = append(removed, entry)
+		}
+	}
+	return added, removed
+}
+
+// sourcePath prefers the logical path set for build-system layouts.
+func sourcePath(root string, socket types.SocketInfo) string {
+	if socket.LogicalPath != "" {
+		return socket.LogicalPath
+	}
+	path := socket.SourceFile
+	if rel, err := filepath.Rel(root, path); err == nil {
+		path = rel
+	}
+	return filepath.ToSlash(path)
+}
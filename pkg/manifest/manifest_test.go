@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func intPtr(i int) *int { return &i }
+
+func sampleResults(root string) *types.AnalysisResults {
+	return &types.AnalysisResults{Sockets: []types.SocketInfo{
+		{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, ProcessName: "api",
+			SourceFile: filepath.Join(root, "cmd/api/main.go"), SourceLine: 12,
+			Listen: types.NewEndpoint("0.0.0.0", intPtr(8080)), PatternMatch: "http.ListenAndServe"},
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, ProcessName: "api",
+			SourceFile: filepath.Join(root, "cmd/api/db.go"), SourceLine: 40,
+			Destination: types.NewEndpoint("db", intPtr(5432)), PatternMatch: "net.Dial"},
+		// Same socket on another line: one manifest entry.
+		{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, ProcessName: "api",
+			SourceFile: filepath.Join(root, "cmd/api/db.go"), SourceLine: 55,
+			Destination: types.NewEndpoint("db", intPtr(5432)), PatternMatch: "net.Dial"},
+	}}
+}
+
+func TestBuild(t *testing.T) {
+	m := Build(sampleResults("/repo"), "/repo")
+	if len(m.Entries) != 2 {
+		t.Fatalf("Expected duplicates to collapse into 2 entries, got %d", len(m.Entries))
+	}
+	if m.Entries[0].File != "cmd/api/db.go" || m.Entries[0].Endpoint != "db:5432" {
+		t.Errorf("Expected sorted, root-relative entries, got %+v", m.Entries[0])
+	}
+	if m.Entries[1].Endpoint != "0.0.0.0:8080" {
+		t.Errorf("Unexpected listener endpoint %q", m.Entries[1].Endpoint)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	m := Build(sampleResults("/repo"), "/repo")
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m, types.GeneratedHeader{GeneratedBy: "staticsocket test"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "# Code generated") {
+		t.Errorf("Expected generated header, got:\n%s", buf.String())
+	}
+
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if added, removed := Diff(read, m); len(added) != 0 || len(removed) != 0 {
+		t.Errorf("Expected round trip without differences, got +%v -%v", added, removed)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	committed := Build(sampleResults("/repo"), "/repo")
+
+	// Checked out elsewhere, with the listener moved to another port.
+	results := sampleResults("/ci/work")
+	results.Sockets[0].Listen.Port = intPtr(9090)
+	current := Build(results, "/ci/work")
+
+	added, removed := Diff(committed, current)
+	if len(added) != 1 || added[0].Endpoint != "0.0.0.0:9090" {
+		t.Errorf("Expected the new port to be added, got %v", added)
+	}
+	if len(removed) != 1 || removed[0].Endpoint != "0.0.0.0:8080" {
+		t.Errorf("Expected the old port to be removed, got %v", removed)
+	}
+}
+
+func TestRead_RejectsUnknownSchema(t *testing.T) {
+	if _, err := Read(strings.NewReader("schema_version: \"99\"\nentries: []\n")); err == nil {
+		t.Error("Expected an unsupported schema version to be rejected")
+	}
+}
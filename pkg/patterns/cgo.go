@@ -0,0 +1,199 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags attached to findings that static Go-level analysis cannot fully see.
+const (
+	TagOpaqueNetworking = "opaque-networking"
+	TagCgo              = "cgo"
+)
+
+var cgoRules = ruleFamily{
+	name:        "cgo",
+	code:        2400,
+	description: "Calls to connect, bind, listen and accept in the C preamble of a file importing \"C\". Their addresses live in C structs, so the findings are unresolved and tagged opaque-networking.",
+	rationale:   "Sockets opened in C are invisible to Go-level analysis; the finding tells a reviewer that the package needs a manual look.",
+	example: `// #include <sys/socket.h>
+// int open_socket(struct sockaddr *addr, socklen_t len) {
+//     int fd = socket(AF_INET, SOCK_STREAM, 0);
+//     return connect(fd, addr, len);
+// }
+import "C"`,
+	rules: []string{"cgo:connect", "cgo:bind", "cgo:listen", "cgo:accept", "cgo:accept4"},
+}
+
+// cgoSocketCall matches socket-related libc calls in C source text, and
+// their first argument.
+var cgoSocketCall = regexp.MustCompile(`\b(connect|bind|listen|accept4?)\s*\(\s*([^,()]*)`)
+
+// MatchCgoPreamble reports libc socket calls made from the cgo preamble of
+// a file that imports "C", leaving out C comments and string literals. The
+// addresses live in C structs, so the findings are unresolved and tagged as
+// opaque networking: they tell auditors that Go-level analysis of this
+// package is incomplete. Accepting connections on a socket the preamble
+// listens on is the listener already reported. The file must have been
+// parsed with parser.ParseComments.
+func (pm *PatternMatcher) MatchCgoPreamble(file *ast.File) []Finding {
+	preamble := cgoPreamble(file)
+	if preamble == nil {
+		return nil
+	}
+
+	source := newCgoSource(preamble)
+	code := source.code()
+	protocol := cgoProtocol(code)
+	matches := cgoSocketCall.FindAllStringSubmatchIndex(code, -1)
+	listened := make(map[string]bool)
+	for _, match := range matches {
+		if code[match[2]:match[3]] == "listen" {
+			listened[strings.TrimSpace(code[match[4]:match[5]])] = true
+		}
+	}
+
+	var findings []Finding
+	for _, match := range matches {
+		function := code[match[2]:match[3]]
+		if strings.HasPrefix(function, "accept") && listened[strings.TrimSpace(code[match[4]:match[5]])] {
+			continue
+		}
+		trafficType := types.TrafficTypeIngress
+		if function == "connect" {
+			trafficType = types.TrafficTypeEgress
+		}
+
+		findings = append(findings, Finding{
+			Socket: &types.SocketInfo{
+				Type:         trafficType,
+				Protocol:     protocol,
+				RawValue:     cgoSourceLine(source.text, match[0]),
+				PatternMatch: "cgo:" + function,
+				FunctionName: "unknown",
+				Tags:         []string{TagOpaqueNetworking, TagCgo},
+			},
+			Pos: source.pos(match[0]),
+		})
+	}
+	return pm.enabledFindings(findings)
+}
+
+// cgoSource is the C source of a cgo preamble: the text of its comments
+// without the Go comment markers, one after the other.
+type cgoSource struct {
+	text string
+	// starts holds the offset in text, and positions the position in the
+	// file, at which the text of each comment starts
+	starts    []int
+	positions []token.Pos
+}
+
+func newCgoSource(preamble *ast.CommentGroup) *cgoSource {
+	source := &cgoSource{}
+	var text strings.Builder
+	for _, comment := range preamble.List {
+		body := comment.Text[2:]
+		if comment.Text[1] == '*' {
+			body = strings.TrimSuffix(body, "*/")
+		}
+		source.starts = append(source.starts, text.Len())
+		source.positions = append(source.positions, comment.Slash+2)
+		text.WriteString(body)
+		text.WriteByte('\n')
+	}
+	source.text = text.String()
+	return source
+}
+
+// pos returns the position in the file of the text at offset.
+func (s *cgoSource) pos(offset int) token.Pos {
+	i := len(s.starts) - 1
+	for i > 0 && s.starts[i] > offset {
+		i--
+	}
+	return s.positions[i] + token.Pos(offset-s.starts[i])
+}
+
+// code returns the text with C comments and string and character literals
+// blanked out, keeping offsets and line breaks.
+func (s *cgoSource) code() string {
+	code := []byte(s.text)
+	for i := 0; i < len(code); i++ {
+		end := i
+		switch {
+		case strings.HasPrefix(s.text[i:], "//"):
+			end = i + strings.IndexByte(s.text[i:], '\n')
+		case strings.HasPrefix(s.text[i:], "/*"):
+			if n := strings.Index(s.text[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			} else {
+				end = len(code)
+			}
+		case code[i] == '"' || code[i] == '\'':
+			end = i + 1
+			for end < len(code) && code[end] != code[i] && code[end] != '\n' {
+				if code[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(code))
+		default:
+			continue
+		}
+		for j := i; j < end; j++ {
+			if code[j] != '\n' {
+				code[j] = ' '
+			}
+		}
+		i = end - 1
+	}
+	return string(code)
+}
+
+func cgoPreamble(file *ast.File) *ast.CommentGroup {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			importSpec, ok := spec.(*ast.ImportSpec)
+			if !ok || importSpec.Path.Value != `"C"` {
+				continue
+			}
+			if importSpec.Doc != nil {
+				return importSpec.Doc
+			}
+			return genDecl.Doc
+		}
+	}
+	return nil
+}
+
+func cgoProtocol(preamble string) types.Protocol {
+	switch {
+	case strings.Contains(preamble, "AF_UNIX") || strings.Contains(preamble, "AF_LOCAL"):
+		return types.ProtocolUnix
+	case strings.Contains(preamble, "SOCK_DGRAM"):
+		return types.ProtocolUDP
+	default:
+		return types.ProtocolTCP
+	}
+}
+
+func cgoSourceLine(text string, offset int) string {
+	start := strings.LastIndex(text[:offset], "\n") + 1
+	end := strings.Index(text[offset:], "\n")
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += offset
+	}
+	return strings.TrimSpace(text[start:end])
+}
@@ -0,0 +1,147 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_MatchCgoPreamble(t *testing.T) {
+	code := `package netwrap
+
+/*
+#include <sys/socket.h>
+
+int open_conn(struct sockaddr *addr, socklen_t len) {
+	int fd = socket(AF_INET, SOCK_STREAM, 0);
+	return connect(fd, addr, len);
+}
+
+int serve(int fd, struct sockaddr *addr, socklen_t len) {
+	bind(fd, addr, len);
+	return listen(fd, 16);
+}
+*/
+import "C"
+
+func Open() { C.open_conn(nil, 0) }
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "wrap.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	findings := NewPatternMatcher().MatchCgoPreamble(file)
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 cgo findings, got %d", len(findings))
+	}
+
+	expected := []struct {
+		pattern     string
+		trafficType types.TrafficType
+		line        int
+	}{
+		{"cgo:connect", types.TrafficTypeEgress, 8},
+		{"cgo:bind", types.TrafficTypeIngress, 12},
+		{"cgo:listen", types.TrafficTypeIngress, 13},
+	}
+
+	for i, want := range expected {
+		socket := findings[i].Socket
+		if socket.PatternMatch != want.pattern {
+			t.Errorf("Finding %d: expected pattern %s, got %s", i, want.pattern, socket.PatternMatch)
+		}
+		if socket.Type != want.trafficType {
+			t.Errorf("Finding %d: expected type %s, got %s", i, want.trafficType, socket.Type)
+		}
+		if line := fset.Position(findings[i].Pos).Line; line != want.line {
+			t.Errorf("Finding %d: expected line %d, got %d", i, want.line, line)
+		}
+		if socket.IsResolved {
+			t.Errorf("Finding %d: cgo findings must be unresolved", i)
+		}
+		if len(socket.Tags) == 0 || socket.Tags[0] != TagOpaqueNetworking {
+			t.Errorf("Finding %d: expected opaque-networking tag, got %v", i, socket.Tags)
+		}
+	}
+
+	if findings[0].Socket.RawValue != "return connect(fd, addr, len);" {
+		t.Errorf("Unexpected raw value: %q", findings[0].Socket.RawValue)
+	}
+}
+
+func TestPatternMatcher_MatchCgoPreambleCommentsAndAccept(t *testing.T) {
+	code := `package netwrap
+
+// #include <stdio.h>
+// #include <sys/socket.h>
+//
+// /* connect(fd, addr, len) is left to Go,
+//    and so is bind(fd, addr, len) */
+// int serve(int fd) {
+//     // listen(other, 1) used to be here
+//     puts("listen(fd, 16) failed; accept(fd) again");
+//     char c = '(';
+//     listen(fd, 16);
+//     return accept(fd, NULL, NULL);
+// }
+//
+// int handoff(int conn) { return accept4(conn, NULL, NULL, 0); }
+import "C"
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "wrap.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	findings := NewPatternMatcher().MatchCgoPreamble(file)
+	expected := []struct {
+		pattern string
+		line    int
+		column  int
+		raw     string
+	}{
+		{"cgo:listen", 12, 8, "listen(fd, 16);"},
+		{"cgo:accept4", 16, 35, "int handoff(int conn) { return accept4(conn, NULL, NULL, 0); }"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d cgo findings, got %d", len(expected), len(findings))
+	}
+	for i, want := range expected {
+		socket := findings[i].Socket
+		position := fset.Position(findings[i].Pos)
+		if socket.PatternMatch != want.pattern || position.Line != want.line || position.Column != want.column {
+			t.Errorf("Finding %d: expected %s at %d:%d, got %s at %d:%d", i, want.pattern, want.line, want.column,
+				socket.PatternMatch, position.Line, position.Column)
+		}
+		if socket.RawValue != want.raw {
+			t.Errorf("Finding %d: expected raw value %q, got %q", i, want.raw, socket.RawValue)
+		}
+	}
+}
+
+func TestPatternMatcher_MatchCgoPreambleWithoutCgo(t *testing.T) {
+	code := `package main
+
+// connect(fd) in a regular comment is not a cgo preamble
+import "net"
+
+func main() { net.Dial("tcp", "example.com:80") }
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	if findings := NewPatternMatcher().MatchCgoPreamble(file); len(findings) != 0 {
+		t.Errorf("Expected no cgo findings, got %d", len(findings))
+	}
+}
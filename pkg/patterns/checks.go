@@ -0,0 +1,124 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownCheck is returned by SetChecks for an entry naming no rule.
+var ErrUnknownCheck = errors.New("no rule has this code or name")
+
+// ruleCode returns the code of the i-th rule of family, such as SS1003.
+func ruleCode(family *ruleFamily, i int) string {
+	return fmt.Sprintf("SS%d", family.code+i+1)
+}
+
+// RuleCode returns the code of the built-in rule name, such as SS1001 for
+// net.Listen.
+func RuleCode(name string) (string, bool) {
+	for _, family := range ruleFamilies {
+		if i := slices.Index(family.rules, name); i >= 0 {
+			return ruleCode(family, i), true
+		}
+	}
+	return "", false
+}
+
+// SetChecks enables and disables rules with a checks list in the style of
+// staticcheck's. Entries apply in order: "all" enables every rule, an
+// entry prefixed with "-" disables the rules it names instead, and a
+// trailing "*" names every rule starting with the rest, such as SS16* for
+// the Kubernetes rules. Rules are named by code or by name, the
+// pattern_match of their findings, which also names custom patterns.
+// Every rule starts enabled, so "-SS1003" alone is "all,-SS1003". An entry
+// naming no rule is an error and leaves the rules as they were.
+func (pm *PatternMatcher) SetChecks(checks []string) error {
+	names := pm.ruleNames()
+	disabled := make(map[string]bool)
+	for _, check := range checks {
+		check = strings.TrimSpace(check)
+		if check == "" {
+			continue
+		}
+		enable := !strings.HasPrefix(check, "-")
+		selector := strings.TrimPrefix(check, "-")
+		matched := selectRules(selector, names)
+		if len(matched) == 0 {
+			return fmt.Errorf("invalid check %q: %w", check, ErrUnknownCheck)
+		}
+		for _, name := range matched {
+			if enable {
+				delete(disabled, name)
+			} else {
+				disabled[name] = true
+			}
+		}
+	}
+	pm.disabled = disabled
+	return nil
+}
+
+// RuleEnabled reports whether the checks list leaves the rule name
+// enabled. Rules are enabled unless SetChecks disabled them.
+func (pm *PatternMatcher) RuleEnabled(name string) bool {
+	return !pm.disabled[name]
+}
+
+// selectRules returns the names of the rules selector names, by code or
+// by name.
+func selectRules(selector string, names []string) []string {
+	if selector == "all" {
+		return names
+	}
+	prefix, wildcard := strings.CutSuffix(selector, "*")
+	selects := func(key string) bool {
+		if wildcard {
+			return strings.HasPrefix(key, prefix)
+		}
+		return key == selector
+	}
+	var matched []string
+	for _, name := range names {
+		code, _ := RuleCode(name)
+		if selects(name) || (code != "" && selects(code)) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
+
+// ruleNames returns the names of every rule of pm, built-in and custom,
+// sorted.
+func (pm *PatternMatcher) ruleNames() []string {
+	names := pm.positionalNames()
+	for _, family := range ruleFamilies {
+		names = append(names, family.rules...)
+	}
+	for name := range pm.callMatchers {
+		names = append(names, name)
+	}
+	for name := range pm.callListMatchers {
+		names = append(names, name)
+	}
+	for name := range pm.literalMatchers {
+		names = append(names, name)
+	}
+	for name := range pm.fieldMatchers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// enabledFindings drops the findings of the rules the checks list
+// disabled.
+func (pm *PatternMatcher) enabledFindings(findings []Finding) []Finding {
+	if len(pm.disabled) == 0 {
+		return findings
+	}
+	return slices.DeleteFunc(findings, func(finding Finding) bool {
+		return !pm.RuleEnabled(finding.Socket.PatternMatch)
+	})
+}
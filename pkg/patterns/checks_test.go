@@ -0,0 +1,97 @@
+package patterns
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+)
+
+func TestRuleCode(t *testing.T) {
+	tests := map[string]string{
+		"net.Listen":    "SS1001",
+		"net.ListenUDP": "SS1003",
+		"cgo:connect":   "SS2401",
+	}
+	for name, want := range tests {
+		if code, ok := RuleCode(name); !ok || code != want {
+			t.Errorf("RuleCode(%q) = %q, %t; expected %q", name, code, ok, want)
+		}
+	}
+	if code, ok := RuleCode("netutil.ListenSecure"); ok {
+		t.Errorf("Expected no code for a custom pattern, got %q", code)
+	}
+}
+
+func TestPatternMatcher_SetChecks(t *testing.T) {
+	code := `package main
+// #include <sys/socket.h>
+// int dial(int fd) { return connect(fd, 0, 0); }
+import "C"
+import (
+	"net"
+	"net/http"
+)
+func main() {
+	net.Listen("tcp", ":8080")
+	net.ListenUDP("udp", nil)
+	http.Get("https://api.example.com")
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := func(pm *PatternMatcher) []string {
+		var names []string
+		ast.Inspect(file, func(n ast.Node) bool {
+			for _, finding := range pm.MatchUnresolved(n, file) {
+				names = append(names, finding.Socket.PatternMatch)
+			}
+			return true
+		})
+		for _, finding := range pm.MatchCgoPreamble(file) {
+			names = append(names, finding.Socket.PatternMatch)
+		}
+		return names
+	}
+
+	tests := []struct {
+		checks []string
+		want   []string
+	}{
+		{nil, []string{"net.Listen", "net.ListenUDP", "http.Get", "cgo:connect"}},
+		{[]string{"all", "-SS1003"}, []string{"net.Listen", "http.Get", "cgo:connect"}},
+		{[]string{"-SS1003"}, []string{"net.Listen", "http.Get", "cgo:connect"}},
+		{[]string{"-all", "SS1001"}, []string{"net.Listen"}},
+		{[]string{"-SS10*", "SS1003"}, []string{"net.ListenUDP", "cgo:connect"}},
+		{[]string{"-http.Get", " -cgo:* "}, []string{"net.Listen", "net.ListenUDP"}},
+	}
+	for _, tt := range tests {
+		pm := NewPatternMatcher()
+		if err := pm.SetChecks(tt.checks); err != nil {
+			t.Fatalf("SetChecks(%q) failed: %v", tt.checks, err)
+		}
+		if got := matched(pm); !slices.Equal(got, tt.want) {
+			t.Errorf("SetChecks(%q): expected %v, got %v", tt.checks, tt.want, got)
+		}
+	}
+
+	pm := NewPatternMatcher()
+	builtIn := pm.Fingerprint()
+	if err := pm.SetChecks([]string{"-SS1003"}); err != nil {
+		t.Fatal(err)
+	}
+	if pm.Fingerprint() == builtIn || pm.RuleEnabled("net.ListenUDP") {
+		t.Error("Expected the disabled rule to change the fingerprint")
+	}
+	if err := pm.SetChecks([]string{"-SS1001", "SS9999"}); !errors.Is(err, ErrUnknownCheck) {
+		t.Errorf("Expected ErrUnknownCheck, got %v", err)
+	}
+	if pm.RuleEnabled("net.ListenUDP") || !pm.RuleEnabled("net.Listen") {
+		t.Error("Expected a failed SetChecks to leave the rules as they were")
+	}
+}
@@ -0,0 +1,282 @@
+package patterns
+
+import (
+	"go/ast"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for the listeners a controller-runtime manager opens on behalf of
+// an operator.
+const (
+	TagWebhook        = "webhook"
+	TagMetrics        = "metrics"
+	TagHealthProbe    = "health-probe"
+	TagLeaderElection = "leader-election"
+)
+
+// Defaults controller-runtime applies when an address is not configured.
+const (
+	webhookDefaultPort    = 9443
+	metricsDefaultAddress = ":8080"
+)
+
+var (
+	managerImports       = []string{"sigs.k8s.io/controller-runtime", "sigs.k8s.io/controller-runtime/pkg/manager"}
+	webhookImports       = []string{"sigs.k8s.io/controller-runtime/pkg/webhook"}
+	metricsServerImports = []string{"sigs.k8s.io/controller-runtime/pkg/metrics/server"}
+)
+
+var controllerRuntimeRules = ruleFamily{
+	name:        "controller-runtime managers",
+	code:        1700,
+	description: "The listeners a controller-runtime manager opens for an operator: the webhook server, metrics and health probes, at the addresses set in the manager options or the library defaults; and the API server egress leader election adds.",
+	rationale:   "Operators open these ports without a single Listen call in their code, and the defaults change between controller-runtime releases.",
+	example: `mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+	Metrics:                metricsserver.Options{BindAddress: ":8443"},
+	HealthProbeBindAddress: ":8081",
+})`,
+	rules: []string{
+		"ctrl.Options", "manager.Options", "webhook.Server", "webhook.Options",
+		"metricsserver.Options", "server.Options",
+		"MetricsBindAddress", "HealthProbeBindAddress", "LeaderElection",
+	},
+}
+
+func (pm *PatternMatcher) initializeControllerRuntimePatterns() {
+	for name, imports := range map[string][]string{
+		"ctrl.Options":    managerImports,
+		"manager.Options": managerImports,
+	} {
+		pm.literalMatchers[name] = matchManagerOptions
+		pm.requiredImports[name] = imports
+	}
+	for _, name := range []string{"webhook.Server", "webhook.Options"} {
+		pm.literalMatchers[name] = matchWebhookServer
+		pm.requiredImports[name] = webhookImports
+	}
+	for _, name := range []string{"metricsserver.Options", "server.Options"} {
+		pm.literalMatchers[name] = matchMetricsServerOptions
+		pm.requiredImports[name] = metricsServerImports
+	}
+	for _, field := range []string{"MetricsBindAddress", "HealthProbeBindAddress", "LeaderElection"} {
+		pm.fieldMatchers[field] = matchManagerOptionField
+		pm.requiredImports[field] = managerImports
+	}
+}
+
+func newManagerListener(typeName string, protocol types.Protocol, tag string) *types.SocketInfo {
+	return &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     protocol,
+		PatternMatch: typeName,
+		FunctionName: "unknown",
+		Tags:         []string{TagKubernetes, tag},
+	}
+}
+
+// matchManagerOptions reports the listeners configured directly on manager
+// options: the webhook server (Host/Port), metrics and health probes, plus
+// the API server dependency leader election adds. Nested
+// metricsserver.Options and webhook.Options literals are matched on their
+// own, and so are fields the file also assigns, as in an option func, which
+// override the literal. The default metrics listener, when nothing sets
+// one, carries a note saying so.
+func matchManagerOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	var webhook *types.SocketInfo
+	webhookHost := ""
+	metricsConfigured := false
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+
+		switch key.Name {
+		case "MetricsBindAddress", "HealthProbeBindAddress", "LeaderElection":
+			metricsConfigured = metricsConfigured || key.Name == "MetricsBindAddress"
+			if assignsField(file, key.Name) {
+				continue
+			}
+			if socket := matchManagerOptionField(pm, kv.Value, file, key.Name); socket != nil {
+				socket.PatternMatch = typeName
+				sockets = append(sockets, socket)
+			}
+		case "Metrics":
+			metricsConfigured = true
+		case "Port":
+			webhook = newManagerListener(typeName, types.ProtocolHTTPS, TagWebhook)
+			pm.setListenPort(webhook, kv.Value, file)
+		case "Host":
+			webhookHost, _ = pm.resolveConstant(kv.Value, file)
+		}
+	}
+
+	if !metricsConfigured && !assignsField(file, "MetricsBindAddress") {
+		socket := newManagerListener(typeName, types.ProtocolHTTP, TagMetrics)
+		socket.RawValue = metricsDefaultAddress
+		pm.parseIngressAddress(socket, metricsDefaultAddress, true)
+		socket.Notes = append(socket.Notes, types.Message(types.MsgManagerDefaultMetrics, "Address", metricsDefaultAddress))
+		sockets = append(sockets, socket)
+	}
+
+	if webhook != nil {
+		if webhook.IsResolved && webhookHost != "" {
+			webhook.Listen.SetHost(webhookHost)
+		}
+		sockets = append(sockets, webhook)
+	}
+	return sockets
+}
+
+// matchManagerOptionField handles a manager option set either in the
+// options literal or by assignment, e.g. in an option func:
+// func(o *ctrl.Options) { o.MetricsBindAddress = ":8080" }.
+func matchManagerOptionField(pm *PatternMatcher, value ast.Expr, file *ast.File, field string) *types.SocketInfo {
+	switch field {
+	case "MetricsBindAddress":
+		return pm.managerBindAddress(value, file, field, types.ProtocolHTTP, TagMetrics)
+	case "HealthProbeBindAddress":
+		return pm.managerBindAddress(value, file, field, types.ProtocolHTTP, TagHealthProbe)
+	case "LeaderElection":
+		// Leader election is usually toggled by a flag; only a literal
+		// false rules out the lease traffic.
+		if ident, ok := value.(*ast.Ident); ok && ident.Name == "false" {
+			return nil
+		}
+		socket := newAPIServerSocket(field, "lease")
+		socket.Tags = append(socket.Tags, TagLeaderElection)
+		return socket
+	}
+	return nil
+}
+
+// assignsField reports whether file assigns to a field of the given name.
+func assignsField(file *ast.File, field string) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || found {
+			return !found
+		}
+		for _, lhs := range assign.Lhs {
+			if sel, ok := lhs.(*ast.SelectorExpr); ok && sel.Sel.Name == field {
+				found = true
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// matchWebhookServer handles webhook.Server{...} and webhook.Options{...}.
+// An unset port means the default 9443 on all interfaces.
+func matchWebhookServer(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	socket := newManagerListener(typeName, types.ProtocolHTTPS, TagWebhook)
+	port := webhookDefaultPort
+	socket.Listen = types.NewEndpoint("0.0.0.0", &port)
+	socket.RawValue = strconv.Itoa(port)
+	socket.IsResolved = true
+	host := ""
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "Port":
+			pm.setListenPort(socket, kv.Value, file)
+		case "Host":
+			host, _ = pm.resolveConstant(kv.Value, file)
+		}
+	}
+
+	if socket.IsResolved && host != "" {
+		socket.Listen.SetHost(host)
+	}
+	return []*types.SocketInfo{socket}
+}
+
+// matchMetricsServerOptions handles metricsserver.Options{BindAddress: ...}
+// from controller-runtime v0.16 onwards.
+func matchMetricsServerOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var bindAddress ast.Expr
+	protocol := types.ProtocolHTTP
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "BindAddress":
+			bindAddress = kv.Value
+		case "SecureServing":
+			if ident, ok := kv.Value.(*ast.Ident); ok && ident.Name == "true" {
+				protocol = types.ProtocolHTTPS
+			}
+		}
+	}
+
+	if bindAddress == nil {
+		return nil
+	}
+	if socket := pm.managerBindAddress(bindAddress, file, typeName, protocol, TagMetrics); socket != nil {
+		return []*types.SocketInfo{socket}
+	}
+	return nil
+}
+
+// managerBindAddress builds a listener from a bind address field, which
+// may be a literal, a constant or a flag default. The values "" and "0"
+// disable the server and yield no finding.
+func (pm *PatternMatcher) managerBindAddress(expr ast.Expr, file *ast.File, patternMatch string, protocol types.Protocol, tag string) *types.SocketInfo {
+	socket := newManagerListener(patternMatch, protocol, tag)
+	address, ok := pm.resolveConstant(expr, file)
+	if !ok {
+		return socket
+	}
+	if address == "" || address == "0" {
+		return nil
+	}
+
+	socket.RawValue = address
+	pm.parseIngressAddress(socket, address, true)
+	return socket
+}
+
+// setListenPort fills the listen port from an integer literal, constant or
+// flag default; any other expression leaves the socket unresolved.
+func (pm *PatternMatcher) setListenPort(socket *types.SocketInfo, expr ast.Expr, file *ast.File) {
+	socket.Listen = nil
+	socket.IsResolved = false
+	socket.RawValue = ""
+
+	value, ok := pm.resolveConstant(expr, file)
+	if !ok {
+		return
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	socket.RawValue = value
+	socket.Listen = types.NewEndpoint("0.0.0.0", &port)
+	socket.IsResolved = true
+}
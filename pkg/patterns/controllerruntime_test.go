@@ -0,0 +1,192 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_ManagerOptions(t *testing.T) {
+	sockets := matchAll(t, `package main
+import ctrl "sigs.k8s.io/controller-runtime"
+func main() {
+	ctrl.NewManager(cfg, ctrl.Options{
+		MetricsBindAddress:     "127.0.0.1:8080",
+		HealthProbeBindAddress: ":8081",
+		Port:                   9443,
+		LeaderElection:         true,
+	})
+}`)
+
+	if len(sockets) != 4 {
+		t.Fatalf("Expected 3 manager listeners and leader election, got %d", len(sockets))
+	}
+
+	lease := sockets[2]
+	if lease.Type != types.TrafficTypeEgress || lease.Tags[len(lease.Tags)-1] != TagLeaderElection {
+		t.Errorf("Expected leader election egress, got %+v", lease)
+	}
+	sockets = append(sockets[:2], sockets[3])
+
+	expected := []struct {
+		tag      string
+		iface    string
+		port     int
+		protocol types.Protocol
+	}{
+		{TagMetrics, "127.0.0.1", 8080, types.ProtocolHTTP},
+		{TagHealthProbe, "0.0.0.0", 8081, types.ProtocolHTTP},
+		{TagWebhook, "0.0.0.0", 9443, types.ProtocolHTTPS},
+	}
+	for i, want := range expected {
+		socket := sockets[i]
+		if socket.Type != types.TrafficTypeIngress || socket.Tags[1] != want.tag {
+			t.Errorf("Socket %d: expected %s ingress, got %s %v", i, want.tag, socket.Type, socket.Tags)
+		}
+		if !socket.IsResolved || socket.Listen.Host != want.iface || *socket.Listen.Port != want.port {
+			t.Errorf("Socket %d: expected %s:%d, got %+v", i, want.iface, want.port, socket)
+		}
+		if socket.Protocol != want.protocol {
+			t.Errorf("Socket %d: expected protocol %s, got %s", i, want.protocol, socket.Protocol)
+		}
+	}
+}
+
+func TestPatternMatcher_ManagerOptionsDisabled(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "sigs.k8s.io/controller-runtime/pkg/manager"
+var opts = manager.Options{MetricsBindAddress: "0", HealthProbeBindAddress: ""}`)
+
+	if len(sockets) != 0 {
+		t.Errorf("Expected disabled servers to produce no findings, got %d", len(sockets))
+	}
+}
+
+func TestPatternMatcher_WebhookAndMetricsServer(t *testing.T) {
+	sockets := matchAll(t, `package main
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+var opts = ctrl.Options{
+	Metrics:       metricsserver.Options{BindAddress: ":8443", SecureServing: true},
+	WebhookServer: webhook.NewServer(webhook.Options{}),
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected metrics and webhook listeners, got %d", len(sockets))
+	}
+
+	metrics := sockets[0]
+	if metrics.Protocol != types.ProtocolHTTPS || *metrics.Listen.Port != 8443 || metrics.Tags[1] != TagMetrics {
+		t.Errorf("Unexpected metrics listener: %+v", metrics)
+	}
+
+	webhook := sockets[1]
+	if webhook.PatternMatch != "webhook.Options" || *webhook.Listen.Port != webhookDefaultPort {
+		t.Errorf("Expected webhook default port, got %+v", webhook)
+	}
+}
+
+func TestPatternMatcher_ManagerOptionsRequiresImport(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "example.com/ctrl"
+var opts = ctrl.Options{MetricsBindAddress: ":8080"}`)
+
+	if len(sockets) != 0 {
+		t.Errorf("Expected no findings without controller-runtime import, got %d", len(sockets))
+	}
+}
+
+func TestPatternMatcher_ManagerOptionsFromFlags(t *testing.T) {
+	sockets := matchAll(t, `package main
+import (
+	"flag"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+const webhookPort = 9444
+func main() {
+	var metricsAddr string
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "metrics address")
+	probeAddr := flag.String("health-probe-bind-address", ":8081", "probe address")
+	flag.Parse()
+
+	ctrl.NewManager(cfg, ctrl.Options{
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: *probeAddr,
+		Port:                   webhookPort,
+	})
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 manager listeners, got %d", len(sockets))
+	}
+	for i, port := range []int{8080, 8081, 9444} {
+		if !sockets[i].IsResolved || *sockets[i].Listen.Port != port {
+			t.Errorf("Socket %d: expected port %d from flag or constant, got %+v", i, port, sockets[i])
+		}
+	}
+}
+
+func TestPatternMatcher_ManagerOptionFuncs(t *testing.T) {
+	sockets := matchAll(t, `package main
+import ctrl "sigs.k8s.io/controller-runtime"
+func withProbes(o *ctrl.Options) {
+	o.HealthProbeBindAddress = ":9440"
+	o.MetricsBindAddress = "127.0.0.1:9090"
+}
+var opts = ctrl.Options{}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 listeners from the option func, got %d", len(sockets))
+	}
+	if sockets[0].PatternMatch != "HealthProbeBindAddress" || *sockets[0].Listen.Port != 9440 {
+		t.Errorf("Unexpected probe listener: %+v", sockets[0])
+	}
+	if sockets[1].Listen.Host != "127.0.0.1" || *sockets[1].Listen.Port != 9090 {
+		t.Errorf("Unexpected metrics listener: %+v", sockets[1])
+	}
+}
+
+func TestPatternMatcher_ManagerOptionsDefaultMetrics(t *testing.T) {
+	sockets := matchAll(t, `package main
+import ctrl "sigs.k8s.io/controller-runtime"
+var opts = ctrl.Options{LeaderElection: false}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected only the default metrics listener, got %d", len(sockets))
+	}
+	if sockets[0].Tags[1] != TagMetrics || *sockets[0].Listen.Port != 8080 {
+		t.Errorf("Expected default metrics on :8080, got %+v", sockets[0])
+	}
+	if want := types.Message(types.MsgManagerDefaultMetrics, "Address", ":8080"); len(sockets[0].Notes) != 1 || sockets[0].Notes[0] != want {
+		t.Errorf("Expected a note that the default is assumed, got %v", sockets[0].Notes)
+	}
+}
+
+func TestPatternMatcher_ManagerOptionsOverridden(t *testing.T) {
+	sockets := matchAll(t, `package main
+import ctrl "sigs.k8s.io/controller-runtime"
+func main() {
+	opts := ctrl.Options{
+		MetricsBindAddress:     ":8080",
+		HealthProbeBindAddress: ":8081",
+	}
+	if secure {
+		opts.MetricsBindAddress = "127.0.0.1:8443"
+	}
+	ctrl.NewManager(cfg, opts)
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected one metrics and one probe listener, got %d: %+v", len(sockets), sockets)
+	}
+	if sockets[0].Tags[1] != TagHealthProbe || *sockets[0].Listen.Port != 8081 {
+		t.Errorf("Unexpected probe listener: %+v", sockets[0])
+	}
+	if sockets[1].PatternMatch != "MetricsBindAddress" || sockets[1].Listen.Host != "127.0.0.1" || *sockets[1].Listen.Port != 8443 {
+		t.Errorf("Expected the assigned metrics address, got %+v", sockets[1])
+	}
+}
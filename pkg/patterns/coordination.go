@@ -0,0 +1,142 @@
+package patterns
+
+import (
+	"go/ast"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for control-plane dependencies used to coordinate replicas.
+const (
+	TagEtcd         = "etcd"
+	TagCoordination = "coordination"
+)
+
+var (
+	leaderElectionImports = []string{"k8s.io/client-go/tools/leaderelection"}
+	etcdClientImports     = []string{"go.etcd.io/etcd/client/v3", "go.etcd.io/etcd/clientv3"}
+	etcdConcurrency       = []string{"go.etcd.io/etcd/client/v3/concurrency", "go.etcd.io/etcd/clientv3/concurrency"}
+)
+
+// etcdCoordinationTags maps etcd concurrency primitives to the tag they add
+// to the file's etcd egress.
+var etcdCoordinationTags = map[string]string{
+	"concurrency.NewElection": TagLeaderElection,
+	"concurrency.NewMutex":    TagCoordination,
+	"concurrency.NewLocker":   TagCoordination,
+}
+
+var coordinationRules = ruleFamily{
+	name:        "Coordination",
+	code:        1800,
+	description: "Dependencies replicas use to coordinate: client-go leader election, which renews a Lease through the API server, and etcd clients, tagged as coordination when the file runs an election or takes a lock.",
+	rationale:   "Losing the path to the coordination store stops a leader-elected service, so it belongs in availability reviews as well as in network policy.",
+	example:     `cli, err := clientv3.New(clientv3.Config{Endpoints: []string{"etcd-0:2379", "etcd-1:2379"}})`,
+	rules:       []string{"leaderelection.RunOrDie", "leaderelection.NewLeaderElector", "clientv3.Config"},
+}
+
+func (pm *PatternMatcher) initializeCoordinationPatterns() {
+	for _, name := range []string{"leaderelection.RunOrDie", "leaderelection.NewLeaderElector"} {
+		pm.callMatchers[name] = matchLeaderElector
+		pm.requiredImports[name] = leaderElectionImports
+	}
+
+	pm.literalMatchers["clientv3.Config"] = matchEtcdConfig
+	pm.requiredImports["clientv3.Config"] = etcdClientImports
+}
+
+// matchLeaderElector reports the Lease traffic of client-go leader election
+// as API server egress.
+func matchLeaderElector(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	socket := newAPIServerSocket(funcName, "lease")
+	socket.Tags = append(socket.Tags, TagLeaderElection)
+	return socket
+}
+
+// matchEtcdConfig reports one egress per etcd endpoint. When the file also
+// runs an election or takes a lock through the concurrency package, the
+// endpoints are tagged as coordination dependencies.
+func matchEtcdConfig(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	tags := []string{TagEtcd}
+	tags = append(tags, pm.etcdCoordinationUses(file)...)
+
+	var sockets []*types.SocketInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != "Endpoints" {
+			continue
+		}
+
+		newSocket := func(raw string) *types.SocketInfo {
+			return &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolGRPC,
+				RawValue:     raw,
+				PatternMatch: typeName,
+				FunctionName: "unknown",
+				Tags:         append([]string(nil), tags...),
+			}
+		}
+
+		endpoints, ok := kv.Value.(*ast.CompositeLit)
+		if !ok {
+			sockets = append(sockets, newSocket(""))
+			continue
+		}
+		for _, endpointExpr := range endpoints.Elts {
+			endpoint, _ := pm.resolveConstant(endpointExpr, file)
+			socket := newSocket(endpoint)
+			parseEtcdEndpoint(socket, endpoint)
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+// etcdCoordinationUses returns the coordination tags implied by the
+// concurrency primitives the file uses.
+func (pm *PatternMatcher) etcdCoordinationUses(file *ast.File) []string {
+	if !importsAny(file, etcdConcurrency) {
+		return nil
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if tag, ok := etcdCoordinationTags[pm.extractFunctionName(call)]; ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		return true
+	})
+	return tags
+}
+
+// parseEtcdEndpoint accepts both "etcd-0:2379" and "https://etcd-0:2379".
+func parseEtcdEndpoint(socket *types.SocketInfo, endpoint string) {
+	hostPort := endpoint
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+		hostPort = parsed.Host
+	}
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return
+	}
+	socket.Destination = types.NewEndpoint(host, &port)
+	socket.IsResolved = true
+}
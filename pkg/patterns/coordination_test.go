@@ -0,0 +1,63 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_LeaderElector(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "k8s.io/client-go/tools/leaderelection"
+func run() {
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{Lock: lock})
+}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 leader election finding, got %d", len(sockets))
+	}
+	socket := sockets[0]
+	if socket.Type != types.TrafficTypeEgress || socket.Tags[len(socket.Tags)-1] != TagLeaderElection {
+		t.Errorf("Expected leader-election tagged egress, got %+v", socket)
+	}
+}
+
+func TestPatternMatcher_EtcdElection(t *testing.T) {
+	sockets := matchAll(t, `package main
+import (
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+const peer = "https://etcd-1.internal:2379"
+func elect() {
+	cli, _ := clientv3.New(clientv3.Config{Endpoints: []string{"etcd-0.internal:2379", peer}})
+	session, _ := concurrency.NewSession(cli)
+	concurrency.NewElection(session, "/leader")
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 etcd endpoints, got %d", len(sockets))
+	}
+	for i, host := range []string{"etcd-0.internal", "etcd-1.internal"} {
+		socket := sockets[i]
+		if !socket.IsResolved || socket.Destination.Host != host || *socket.Destination.Port != 2379 {
+			t.Errorf("Endpoint %d: expected %s:2379, got %+v", i, host, socket)
+		}
+		if len(socket.Tags) != 2 || socket.Tags[0] != TagEtcd || socket.Tags[1] != TagLeaderElection {
+			t.Errorf("Endpoint %d: expected etcd and leader-election tags, got %v", i, socket.Tags)
+		}
+	}
+}
+
+func TestPatternMatcher_EtcdWithoutCoordination(t *testing.T) {
+	sockets := matchAll(t, `package main
+import clientv3 "go.etcd.io/etcd/client/v3"
+var cfg = clientv3.Config{Endpoints: endpoints}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 unresolved etcd finding, got %d", len(sockets))
+	}
+	if sockets[0].IsResolved || len(sockets[0].Tags) != 1 {
+		t.Errorf("Expected unresolved etcd egress without coordination tags, got %+v", sockets[0])
+	}
+}
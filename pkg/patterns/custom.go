@@ -0,0 +1,104 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// CustomPattern describes a function outside the built-in table that
+// listens or connects on the address passed in one of its arguments, such
+// as an internal wrapper around net.Listen.
+type CustomPattern struct {
+	// Function is the call as written with the package's usual name, e.g.
+	// netutil.ListenSecure.
+	Function string `yaml:"function" json:"function"`
+	// Package is the import path of the function's package. Without it,
+	// any package imported under the qualifier of Function matches.
+	Package  string            `yaml:"package,omitempty" json:"package,omitempty"`
+	Type     types.TrafficType `yaml:"type" json:"type"`
+	Protocol types.Protocol    `yaml:"protocol" json:"protocol"`
+	// Arg is the index of the argument holding the address.
+	Arg int `yaml:"arg" json:"arg"`
+	// PortOnly marks ingress addresses that may be just a port, e.g. ":8080".
+	PortOnly bool `yaml:"port_only,omitempty" json:"port_only,omitempty"`
+	// URL marks egress arguments that are URLs rather than host:port.
+	URL bool `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
+// patternFile is the layout of a custom pattern file.
+type patternFile struct {
+	Patterns []CustomPattern `yaml:"patterns"`
+}
+
+// ReadCustomPatterns decodes a pattern file, in YAML or JSON:
+//
+//	patterns:
+//	  - function: netutil.ListenSecure
+//	    package: example.com/platform/netutil
+//	    type: ingress
+//	    protocol: https
+//	    arg: 0
+func ReadCustomPatterns(reader io.Reader) ([]CustomPattern, error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	var file patternFile
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return file.Patterns, nil
+}
+
+// AddCustomPattern adds p to the positional patterns. Protocols that are
+// not registered yet are registered as carried over tcp. A pattern may
+// not replace a built-in one, nor give a built-in qualifier another
+// import path.
+func (pm *PatternMatcher) AddCustomPattern(p CustomPattern) error {
+	qualifier, function, ok := strings.Cut(p.Function, ".")
+	if !ok || !token.IsIdentifier(qualifier) || !token.IsIdentifier(function) {
+		return fmt.Errorf("pattern %q: function must be package.Function", p.Function)
+	}
+	if _, exists := pm.ingressPatterns[p.Function]; exists {
+		return fmt.Errorf("pattern %s: already defined", p.Function)
+	}
+	if _, exists := pm.egressPatterns[p.Function]; exists {
+		return fmt.Errorf("pattern %s: already defined", p.Function)
+	}
+	if p.Arg < 0 {
+		return fmt.Errorf("pattern %s: negative argument index %d", p.Function, p.Arg)
+	}
+	if p.Package != "" {
+		if path, exists := pm.packagePaths[qualifier]; exists && path != p.Package {
+			return fmt.Errorf("pattern %s: %s already stands for %s", p.Function, qualifier, path)
+		}
+	}
+	switch {
+	case p.Type != types.TrafficTypeIngress && p.Type != types.TrafficTypeEgress:
+		return fmt.Errorf("pattern %s: invalid type %q: expected ingress or egress", p.Function, p.Type)
+	case p.URL && p.Type != types.TrafficTypeEgress:
+		return fmt.Errorf("pattern %s: url only applies to egress patterns", p.Function)
+	case p.PortOnly && p.Type != types.TrafficTypeIngress:
+		return fmt.Errorf("pattern %s: port_only only applies to ingress patterns", p.Function)
+	}
+	if !p.Protocol.Registered() {
+		if err := types.RegisterProtocol(types.ProtocolInfo{Name: p.Protocol}); err != nil {
+			return fmt.Errorf("pattern %s: %w", p.Function, err)
+		}
+	}
+
+	if p.Type == types.TrafficTypeIngress {
+		pm.ingressPatterns[p.Function] = IngressPattern{Protocol: p.Protocol, AddressArg: p.Arg, PortOnly: p.PortOnly}
+	} else {
+		pm.egressPatterns[p.Function] = EgressPattern{Protocol: p.Protocol, AddressArg: p.Arg, URLArg: p.Arg, URL: p.URL}
+	}
+	if p.Package != "" {
+		pm.packagePaths[qualifier] = p.Package
+	}
+	return nil
+}
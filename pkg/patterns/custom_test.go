@@ -0,0 +1,141 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+const customPatterns = `
+patterns:
+  - function: netutil.ListenSecure
+    package: example.com/platform/netutil
+    type: ingress
+    protocol: https
+    arg: 0
+    port_only: true
+  - function: rpc.Call
+    type: egress
+    protocol: platform-rpc
+    arg: 1
+    url: true
+`
+
+func TestReadCustomPatterns(t *testing.T) {
+	custom, err := ReadCustomPatterns(strings.NewReader(customPatterns))
+	if err != nil {
+		t.Fatalf("ReadCustomPatterns failed: %v", err)
+	}
+	if len(custom) != 2 || custom[0].Package != "example.com/platform/netutil" || !custom[0].PortOnly || !custom[1].URL || custom[1].Arg != 1 {
+		t.Fatalf("Unexpected patterns: %+v", custom)
+	}
+
+	asJSON, err := ReadCustomPatterns(strings.NewReader(`{"patterns": [{"function": "rpc.Call", "type": "egress", "protocol": "platform-rpc", "arg": 1, "url": true}]}`))
+	if err != nil || len(asJSON) != 1 || asJSON[0] != custom[1] {
+		t.Errorf("Expected JSON to decode like YAML, got %+v, %v", asJSON, err)
+	}
+
+	if _, err := ReadCustomPatterns(strings.NewReader("patterns:\n  - function: rpc.Call\n    argument: 1\n")); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+	if empty, err := ReadCustomPatterns(strings.NewReader("")); err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty file to hold no patterns, got %+v, %v", empty, err)
+	}
+}
+
+func TestPatternMatcher_AddCustomPattern(t *testing.T) {
+	custom, err := ReadCustomPatterns(strings.NewReader(customPatterns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm := NewPatternMatcher()
+	for _, pattern := range custom {
+		if err := pm.AddCustomPattern(pattern); err != nil {
+			t.Fatalf("AddCustomPattern(%s) failed: %v", pattern.Function, err)
+		}
+	}
+	if !types.Protocol("platform-rpc").Registered() {
+		t.Error("Expected the pattern's protocol to be registered")
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", `package main
+import (
+	secure "example.com/platform/netutil"
+	"example.com/other/netutil"
+	"example.com/platform/rpc"
+)
+const billing = "https://billing.internal:8443/v1"
+func main() {
+	secure.ListenSecure(":9443", nil)
+	netutil.ListenSecure(":9444", nil)
+	rpc.Call(ctx, billing)
+}`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := pm.MatchFile(file)
+	if len(findings) != 2 {
+		t.Fatalf("Expected the platform listener and the rpc call, got %d findings", len(findings))
+	}
+
+	listener := findings[0].Socket
+	if listener.Type != types.TrafficTypeIngress || listener.Protocol != types.ProtocolHTTPS ||
+		listener.PatternMatch != "netutil.ListenSecure" || listener.Listen == nil || *listener.Listen.Port != 9443 {
+		t.Errorf("Unexpected listener: %+v", listener)
+	}
+	call := findings[1].Socket
+	if call.Type != types.TrafficTypeEgress || !call.IsResolved || call.Destination.Host != "billing.internal" || *call.Destination.Port != 8443 {
+		t.Errorf("Unexpected rpc call: %+v", call)
+	}
+}
+
+func TestPatternMatcher_AddCustomPatternInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern CustomPattern
+		want    string
+	}{
+		{
+			name:    "not qualified",
+			pattern: CustomPattern{Function: "ListenSecure", Type: types.TrafficTypeIngress, Protocol: types.ProtocolTCP},
+			want:    "package.Function",
+		},
+		{
+			name:    "built-in",
+			pattern: CustomPattern{Function: "net.Listen", Type: types.TrafficTypeIngress, Protocol: types.ProtocolTCP},
+			want:    "already defined",
+		},
+		{
+			name:    "built-in qualifier",
+			pattern: CustomPattern{Function: "http.Serve", Package: "example.com/http", Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP},
+			want:    "already stands for net/http",
+		},
+		{
+			name:    "type",
+			pattern: CustomPattern{Function: "rpc.Call", Type: "both", Protocol: types.ProtocolTCP},
+			want:    "invalid type",
+		},
+		{
+			name:    "ingress url",
+			pattern: CustomPattern{Function: "rpc.Serve", Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTP, URL: true},
+			want:    "only applies to egress",
+		},
+		{
+			name:    "protocol",
+			pattern: CustomPattern{Function: "rpc.Call", Type: types.TrafficTypeEgress, Protocol: "Platform RPC"},
+			want:    "invalid protocol name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPatternMatcher().AddCustomPattern(tt.pattern)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
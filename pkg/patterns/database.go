@@ -0,0 +1,384 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for database connections and their SQL dialects.
+const (
+	TagDatabase  = "database"
+	TagPostgres  = "postgres"
+	TagMySQL     = "mysql"
+	TagSQLServer = "sqlserver"
+)
+
+// Default ports of the database servers.
+const (
+	postgresDefaultPort  = 5432
+	mysqlDefaultPort     = 3306
+	sqlServerDefaultPort = 1433
+)
+
+// driverDialects maps database/sql driver names to the dialect of their
+// DSNs. Drivers of embedded databases map to "", as they open files rather
+// than connections.
+var driverDialects = map[string]string{
+	"postgres":   TagPostgres,
+	"postgresql": TagPostgres,
+	"pgx":        TagPostgres,
+	"pgx/v5":     TagPostgres,
+	"mysql":      TagMySQL,
+	"sqlserver":  TagSQLServer,
+	"mssql":      TagSQLServer,
+	"sqlite":     "",
+	"sqlite3":    "",
+}
+
+// databaseOpener describes a function connecting to a database from a
+// DSN: either named by a driver argument, or of a fixed dialect.
+type databaseOpener struct {
+	imports   []string
+	driverArg int // -1 when the dialect is fixed
+	dialect   string
+	dsnArg    int
+}
+
+var (
+	sqlxImports    = []string{"github.com/jmoiron/sqlx"}
+	pgxImports     = []string{"github.com/jackc/pgx/v5", "github.com/jackc/pgx/v4", "github.com/jackc/pgx"}
+	pgxpoolImports = []string{"github.com/jackc/pgx/v5/pgxpool", "github.com/jackc/pgx/v4/pgxpool"}
+	gormImports    = []string{"gorm.io/gorm", "github.com/jinzhu/gorm"}
+)
+
+var databaseOpeners = map[string]databaseOpener{
+	"sql.Open":         {imports: []string{"database/sql"}, driverArg: 0, dsnArg: 1},
+	"sqlx.Open":        {imports: sqlxImports, driverArg: 0, dsnArg: 1},
+	"sqlx.Connect":     {imports: sqlxImports, driverArg: 0, dsnArg: 1},
+	"sqlx.MustOpen":    {imports: sqlxImports, driverArg: 0, dsnArg: 1},
+	"sqlx.MustConnect": {imports: sqlxImports, driverArg: 0, dsnArg: 1},
+	"pgx.Connect":      {imports: pgxImports, driverArg: -1, dialect: TagPostgres, dsnArg: 1},
+	"pgxpool.New":      {imports: pgxpoolImports, driverArg: -1, dialect: TagPostgres, dsnArg: 1},
+	"pgxpool.Connect":  {imports: pgxpoolImports, driverArg: -1, dialect: TagPostgres, dsnArg: 1},
+	"gorm.Open":        {imports: gormImports, driverArg: 0, dsnArg: 1},
+}
+
+var databaseRules = ruleFamily{
+	name:        "Databases",
+	code:        2100,
+	description: "SQL database connections opened from a DSN: database/sql and sqlx with a postgres, pgx, mysql, sqlserver or mssql driver, pgx and pgxpool, and gorm with the postgres, mysql or sqlserver dialector. Host and port are parsed from URL, keyword/value, MySQL and ADO DSNs; passwords are redacted from raw_value. SQLite files are not reported.",
+	rationale:   "Database connections are the most sensitive egress of most services, and DSNs hide their destination from simpler tools.",
+	example:     `db, err := sql.Open("postgres", "postgres://app@db.internal:5432/orders?sslmode=verify-full")`,
+	rules: []string{
+		"sql.Open", "sqlx.Open", "sqlx.Connect", "sqlx.MustOpen", "sqlx.MustConnect",
+		"pgx.Connect", "pgxpool.New", "pgxpool.Connect", "gorm.Open",
+	},
+}
+
+func (pm *PatternMatcher) initializeDatabasePatterns() {
+	for name, opener := range databaseOpeners {
+		pm.callListMatchers[name] = opener.match
+		pm.requiredImports[name] = opener.imports
+	}
+}
+
+// match reports one egress per host the DSN of the call names. gorm.Open
+// takes its DSN from a dialector such as postgres.Open(dsn), named after
+// the gorm.io/driver package; gorm v1 takes a driver name and a DSN.
+func (o databaseOpener) match(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) []*types.SocketInfo {
+	args := callExpr.Args
+	dialect, known := o.dialect, o.driverArg < 0
+	dsnArg := o.dsnArg
+	if funcName == "gorm.Open" && len(args) > 0 {
+		if dialector, ok := args[0].(*ast.CallExpr); ok {
+			sel, ok := dialector.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Open" || len(dialector.Args) != 1 {
+				return []*types.SocketInfo{newDatabaseSocket(funcName, "", gotypes.ExprString(args[0]))}
+			}
+			dialect, known = driverDialects[gotypes.ExprString(sel.X)]
+			args, dsnArg = dialector.Args, 0
+		}
+	}
+	if !known && o.driverArg >= 0 && o.driverArg < len(args) {
+		if driver, ok := pm.resolveConstant(args[o.driverArg], file); ok {
+			dialect, known = driverDialects[driver]
+			if !known {
+				// Tag other drivers by name, such as clickhouse
+				dialect = driver
+			}
+		}
+	}
+	if (known && dialect == "") || dsnArg >= len(args) {
+		return nil
+	}
+
+	dsn, ok := pm.resolveConstant(args[dsnArg], file)
+	if !ok {
+		return []*types.SocketInfo{newDatabaseSocket(funcName, dialect, gotypes.ExprString(args[dsnArg]))}
+	}
+	info := parseDSN(dialect, dsn)
+	if len(info.addresses) == 0 {
+		return []*types.SocketInfo{newDatabaseSocket(funcName, dialect, info.redacted)}
+	}
+	var sockets []*types.SocketInfo
+	for _, address := range info.addresses {
+		socket := newDatabaseSocket(funcName, dialect, info.redacted)
+		if info.plaintext {
+			socket.Tags = append(socket.Tags, TagPlaintext)
+		}
+		if strings.HasPrefix(address, "/") {
+			socket.Protocol = types.ProtocolUnix
+			socket.Destination = types.NewPathEndpoint(address)
+			socket.IsResolved = true
+		} else {
+			pm.parseOptionEndpoint(socket, address)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+func newDatabaseSocket(funcName, dialect, raw string) *types.SocketInfo {
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     types.ProtocolTCP,
+		RawValue:     raw,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+		Tags:         []string{TagDatabase},
+	}
+	if dialect != "" {
+		socket.Tags = append(socket.Tags, dialect)
+	}
+	return socket
+}
+
+// dsnInfo is what a DSN says about the connection: the host:port
+// addresses or unix socket paths it connects to, whether it turns TLS
+// off, and the DSN with its password redacted.
+type dsnInfo struct {
+	addresses []string
+	plaintext bool
+	redacted  string
+}
+
+// parseDSN parses a DSN of dialect. DSNs of unknown dialects are only
+// redacted.
+func parseDSN(dialect, dsn string) dsnInfo {
+	info := dsnInfo{redacted: redactDSN(dsn)}
+	switch dialect {
+	case TagPostgres:
+		info.addresses, info.plaintext = parsePostgresDSN(dsn)
+	case TagMySQL:
+		info.addresses, info.plaintext = parseMySQLDSN(dsn)
+		info.redacted = redactMySQLDSN(dsn)
+	case TagSQLServer:
+		info.addresses, info.plaintext = parseSQLServerDSN(dsn)
+	}
+	return info
+}
+
+// parsePostgresDSN parses a libpq URL, postgres://user@host1:5432,host2/db,
+// or keyword/value string, host=db port=5432 sslmode=disable. Hosts
+// starting with a slash are socket directories.
+func parsePostgresDSN(dsn string) ([]string, bool) {
+	var hosts, ports string
+	var params map[string]string
+	if rest, ok := cutScheme(dsn, "postgres", "postgresql"); ok {
+		authority, query := splitURL(rest)
+		hosts, params = authority, queryParams(query)
+		if host, ok := params["host"]; ok {
+			hosts = host
+		}
+		if port, ok := params["port"]; ok {
+			ports = port
+		}
+	} else {
+		params = keywordParams(dsn)
+		hosts, ports = params["host"], params["port"]
+	}
+
+	var addresses []string
+	portList := strings.Split(ports, ",")
+	for i, host := range strings.Split(hosts, ",") {
+		port := strconv.Itoa(postgresDefaultPort)
+		if i < len(portList) && portList[i] != "" {
+			port = portList[i]
+		} else if len(portList) == 1 && portList[0] != "" {
+			port = portList[0]
+		}
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		}
+		switch {
+		case host == "":
+			host = "localhost"
+		case strings.HasPrefix(host, "/"):
+			addresses = append(addresses, path.Join(host, ".s.PGSQL."+port))
+			continue
+		}
+		addresses = append(addresses, net.JoinHostPort(host, port))
+	}
+	return addresses, params["sslmode"] == "disable"
+}
+
+// parseMySQLDSN parses a go-sql-driver/mysql DSN,
+// user:password@tcp(host:3306)/db?tls=true. Without tls, or with
+// tls=false, the connection is plaintext, as is the driver's default.
+func parseMySQLDSN(dsn string) ([]string, bool) {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return nil, false
+	}
+	_, query, _ := strings.Cut(dsn[slash:], "?")
+	params := queryParams(query)
+	tls := params["tls"]
+	plaintext := tls == "" || tls == "false"
+
+	address := dsn[:slash]
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		address = address[at+1:]
+	}
+	network, addr, _ := strings.Cut(address, "(")
+	addr = strings.TrimSuffix(addr, ")")
+	switch network {
+	case "unix":
+		if addr == "" {
+			addr = "/tmp/mysql.sock"
+		}
+		return []string{addr}, plaintext
+	case "", "tcp", "tcp6":
+		if addr == "" {
+			addr = "127.0.0.1"
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, strconv.Itoa(mysqlDefaultPort))
+		}
+		return []string{addr}, plaintext
+	}
+	return nil, plaintext
+}
+
+// parseSQLServerDSN parses a go-mssqldb URL, sqlserver://user@host:1433,
+// or ADO string, server=host,1433;user id=app;encrypt=disable.
+func parseSQLServerDSN(dsn string) ([]string, bool) {
+	var host, port string
+	var params map[string]string
+	if rest, ok := cutScheme(dsn, "sqlserver"); ok {
+		authority, query := splitURL(rest)
+		params = queryParams(query)
+		host, port = authority, params["port"]
+		if h, p, err := net.SplitHostPort(authority); err == nil {
+			host, port = h, p
+		}
+	} else {
+		params = make(map[string]string)
+		for _, field := range strings.Split(dsn, ";") {
+			key, value, _ := strings.Cut(field, "=")
+			params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+		host = params["server"]
+		if host == "" {
+			host = params["data source"]
+		}
+		host = strings.TrimPrefix(host, "tcp:")
+		host, port, _ = strings.Cut(host, ",")
+		if port == "" {
+			port = params["port"]
+		}
+	}
+	// An instance name, host\SQLEXPRESS, is looked up through the browser
+	// service; the host is what is connected to
+	host, _, _ = strings.Cut(host, `\`)
+	host, _, _ = strings.Cut(host, "/")
+	if host == "" || host == "." {
+		host = "localhost"
+	}
+	if port == "" {
+		port = strconv.Itoa(sqlServerDefaultPort)
+	}
+	return []string{net.JoinHostPort(host, port)}, params["encrypt"] == "disable"
+}
+
+// cutScheme returns dsn without its scheme if it is one of schemes.
+func cutScheme(dsn string, schemes ...string) (string, bool) {
+	for _, scheme := range schemes {
+		if rest, ok := strings.CutPrefix(dsn, scheme+"://"); ok {
+			return rest, true
+		}
+	}
+	return dsn, false
+}
+
+// splitURL splits the rest of a URL after its scheme into the host list,
+// without user info, and the query.
+func splitURL(rest string) (authority, query string) {
+	rest, query, _ = strings.Cut(rest, "?")
+	authority, _, _ = strings.Cut(rest, "/")
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		authority = authority[at+1:]
+	}
+	return authority, query
+}
+
+func queryParams(query string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(query, "&") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// keywordParams parses libpq keyword/value pairs, which may be quoted.
+func keywordParams(dsn string) map[string]string {
+	params := make(map[string]string)
+	for _, match := range keywordPair.FindAllStringSubmatch(dsn, -1) {
+		value := match[2]
+		if strings.HasPrefix(value, "'") {
+			value = strings.Trim(value, "'")
+		}
+		params[match[1]] = value
+	}
+	return params
+}
+
+var (
+	keywordPair     = regexp.MustCompile(`(\w+)\s*=\s*('[^']*'|\S*)`)
+	passwordPair    = regexp.MustCompile(`(?i)\b(password|pwd)(\s*=\s*)('[^']*'|[^\s;&]*)`)
+	urlUserPassword = regexp.MustCompile(`^(\w+://[^:/@]*:)[^@/]*@`)
+)
+
+// redactDSN replaces the password of a DSN with xxxxx, in URL user info
+// or a password keyword.
+func redactDSN(dsn string) string {
+	dsn = passwordPair.ReplaceAllString(dsn, "${1}${2}xxxxx")
+	return urlUserPassword.ReplaceAllString(dsn, "${1}xxxxx@")
+}
+
+// redactMySQLDSN replaces the password of user:password@ with xxxxx. The
+// credentials end at the last @ before the database name, as the driver
+// reads them.
+func redactMySQLDSN(dsn string) string {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	user, _, ok := strings.Cut(dsn[:at], ":")
+	if !ok {
+		return dsn
+	}
+	return user + ":xxxxx" + dsn[at:]
+}
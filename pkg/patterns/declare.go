@@ -0,0 +1,159 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// TagDeclared marks findings declared in a comment rather than found in
+// code.
+const TagDeclared = "declared"
+
+// declareDirective starts a comment declaring a socket, such as
+// //staticsocket:declare ingress udp :6081 reason=XDP.
+const declareDirective = "//staticsocket:declare"
+
+var declarationRules = ruleFamily{
+	name:        "Declarations",
+	code:        2600,
+	description: "Sockets declared in a //staticsocket:declare comment, with their direction, protocol and address, followed by optional reason=... and process=... settings. Quote values with spaces in Go syntax. A comment that does not parse is reported unresolved with the error in its notes.",
+	rationale:   "Some sockets are opened where the analyzer cannot see them, such as by eBPF/XDP programs or sidecars; declaring them next to the code that relies on them keeps the network manifest complete.",
+	example: `//staticsocket:declare ingress udp :6081 reason=XDP
+//staticsocket:declare egress tcp envoy.internal:15001 reason="outbound sidecar" process=envoy`,
+	rules: []string{"staticsocket:declare"},
+}
+
+// MatchDeclarations reports the sockets declared in the
+// //staticsocket:declare comments of file, which must have been parsed
+// with parser.ParseComments.
+func (pm *PatternMatcher) MatchDeclarations(file *ast.File) []Finding {
+	var findings []Finding
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			rest, ok := strings.CutPrefix(comment.Text, declareDirective)
+			if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+				continue
+			}
+			findings = append(findings, Finding{Socket: pm.parseDeclaration(rest), Pos: comment.Slash, End: comment.End()})
+		}
+	}
+	return pm.enabledFindings(findings)
+}
+
+// parseDeclaration returns the socket a declaration declares: its
+// direction, protocol and address, and key=value settings.
+func (pm *PatternMatcher) parseDeclaration(declaration string) *types.SocketInfo {
+	// The whole declaration, until its address is known
+	socket := &types.SocketInfo{
+		RawValue:     strings.TrimSpace(declaration),
+		PatternMatch: "staticsocket:declare",
+		FunctionName: "unknown",
+		Tags:         []string{TagDeclared},
+	}
+	reason, err := pm.declare(socket, declaration)
+	if err != nil {
+		if socket.Type == "" {
+			socket.Type = types.TrafficTypeIngress
+		}
+		socket.Listen, socket.Destination = nil, nil
+		socket.RawValue = strings.TrimSpace(declaration)
+		socket.IsResolved = false
+		socket.UnresolvedReason = types.UnresolvedInvalidDeclaration
+		socket.Notes = append(socket.Notes, types.Message(types.MsgInvalidDeclaration, "Error", err.Error()))
+		return socket
+	}
+	socket.Notes = append(socket.Notes, types.Message(types.MsgDeclared, "Reason", reason))
+	return socket
+}
+
+// declare fills in socket from the fields of a declaration, returning its
+// reason.
+func (pm *PatternMatcher) declare(socket *types.SocketInfo, declaration string) (string, error) {
+	fields, err := declarationFields(declaration)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) < 3 {
+		return "", errors.New("expected ingress or egress, a protocol and an address")
+	}
+
+	switch types.TrafficType(fields[0]) {
+	case types.TrafficTypeIngress, types.TrafficTypeEgress:
+		socket.Type = types.TrafficType(fields[0])
+	default:
+		return "", fmt.Errorf("invalid direction %q: expected ingress or egress", fields[0])
+	}
+	socket.Protocol = types.Protocol(fields[1])
+	if !socket.Protocol.Registered() {
+		return "", fmt.Errorf("unknown protocol %q", fields[1])
+	}
+
+	address := fields[2]
+	socket.RawValue = address
+	if socket.Type == types.TrafficTypeIngress {
+		pm.parseIngressAddress(socket, address, true)
+		if socket.Listen == nil {
+			return "", fmt.Errorf("invalid listen address %q", address)
+		}
+	} else {
+		if strings.Contains(address, "://") && !strings.HasPrefix(address, "unix://") {
+			protocol := socket.Protocol
+			pm.parseEgressURL(socket, address)
+			// The declared protocol wins over the scheme's
+			socket.Protocol = protocol
+		} else {
+			pm.parseEgressAddress(socket, address)
+		}
+		if socket.Destination == nil {
+			return "", fmt.Errorf("invalid destination %q", address)
+		}
+	}
+
+	var reason string
+	for _, field := range fields[3:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid setting %q: expected key=value", field)
+		}
+		switch key {
+		case "reason":
+			reason = value
+		case "process":
+			socket.ProcessName = value
+		default:
+			return "", fmt.Errorf("unknown setting %q: expected reason or process", key)
+		}
+	}
+	return reason, nil
+}
+
+// declarationFields splits a declaration at spaces, except within the
+// double-quoted value of a key="..." setting, which is unquoted.
+func declarationFields(declaration string) ([]string, error) {
+	var fields []string
+	rest := strings.TrimSpace(declaration)
+	for rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		field := rest[:end]
+		if key, value, ok := strings.Cut(field, "="); ok && strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest[len(key)+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value of %s", key)
+			}
+			unquoted, _ := strconv.Unquote(quoted)
+			field = key + "=" + unquoted
+			end = len(key) + 1 + len(quoted)
+		}
+		fields = append(fields, field)
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return fields, nil
+}
@@ -0,0 +1,88 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_MatchDeclarations(t *testing.T) {
+	code := `package main
+
+// Geneve traffic is terminated by the XDP program loaded below.
+//staticsocket:declare ingress udp :6081 reason=XDP
+//staticsocket:declare egress tcp envoy.internal:15001 reason="outbound sidecar" process=envoy
+//staticsocket:declare egress https https://telemetry.example.com/v1
+
+//staticsocket:declared ingress tcp :1
+// staticsocket:declare ingress tcp :2
+func main() {
+	//staticsocket:declare ingress unix /run/agent.sock
+	load()
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := NewPatternMatcher().MatchDeclarations(file)
+
+	tests := []struct {
+		kind     types.TrafficType
+		protocol types.Protocol
+		endpoint string
+		process  string
+		note     string
+	}{
+		{types.TrafficTypeIngress, types.ProtocolUDP, "0.0.0.0:6081", "", "declared in a staticsocket:declare comment: XDP"},
+		{types.TrafficTypeEgress, types.ProtocolTCP, "envoy.internal:15001", "envoy", "declared in a staticsocket:declare comment: outbound sidecar"},
+		{types.TrafficTypeEgress, types.ProtocolHTTPS, "telemetry.example.com:443", "", "declared in a staticsocket:declare comment"},
+		{types.TrafficTypeIngress, types.ProtocolUnix, "/run/agent.sock", "", "declared in a staticsocket:declare comment"},
+	}
+	if len(findings) != len(tests) {
+		t.Fatalf("Expected %d findings, got %d", len(tests), len(findings))
+	}
+	for i, tt := range tests {
+		socket := findings[i].Socket
+		if socket.Type != tt.kind || socket.Protocol != tt.protocol || socket.EndpointName() != tt.endpoint || socket.ProcessName != tt.process || !socket.IsResolved {
+			t.Errorf("Finding %d: expected %s %s %s for %q, got %s %s %s for %q", i, tt.kind, tt.protocol, tt.endpoint, tt.process,
+				socket.Type, socket.Protocol, socket.EndpointName(), socket.ProcessName)
+		}
+		if !reflect.DeepEqual(socket.Notes, []string{tt.note}) || !reflect.DeepEqual(socket.Tags, []string{TagDeclared}) {
+			t.Errorf("Finding %d: expected note %q and tag declared, got %v and %v", i, tt.note, socket.Notes, socket.Tags)
+		}
+	}
+	if line := fset.Position(findings[0].Pos).Line; line != 4 {
+		t.Errorf("Expected the first declaration on line 4, got %d", line)
+	}
+}
+
+func TestPatternMatcher_InvalidDeclarations(t *testing.T) {
+	tests := map[string]string{
+		"ingress udp":                         "expected ingress or egress, a protocol and an address",
+		"inbound udp :6081":                   `invalid direction "inbound"`,
+		"ingress sctp :6081":                  `unknown protocol "sctp"`,
+		"ingress udp :xdp":                    `invalid listen address ":xdp"`,
+		"egress tcp db":                       `invalid destination "db"`,
+		"ingress udp :6081 XDP":               `invalid setting "XDP"`,
+		"ingress udp :6081 owner=net":         `unknown setting "owner"`,
+		`ingress udp :6081 reason="XDP`:       "invalid quoted value of reason",
+		`egress tcp db:5432 reason="a b" c=d`: `unknown setting "c"`,
+	}
+	pm := NewPatternMatcher()
+	for declaration, want := range tests {
+		socket := pm.parseDeclaration(" " + declaration)
+		if socket.IsResolved || socket.UnresolvedReason != types.UnresolvedInvalidDeclaration || socket.Listen != nil || socket.Destination != nil {
+			t.Errorf("%q: expected an unresolved invalid declaration, got %+v", declaration, socket)
+			continue
+		}
+		if len(socket.Notes) != 1 || !strings.Contains(socket.Notes[0], want) {
+			t.Errorf("%q: expected a note containing %q, got %v", declaration, want, socket.Notes)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package patterns
+
+import (
+	"go/ast"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for findings produced by container tooling rather than the service itself.
+const (
+	TagDocker         = "docker"
+	TagTestcontainers = "testcontainers"
+)
+
+// dockerDefaultHost is where the Docker SDK connects when DOCKER_HOST is unset.
+const dockerDefaultHost = "unix:///var/run/docker.sock"
+
+var (
+	dockerClientImports   = []string{"github.com/docker/docker/client", "github.com/moby/moby/client"}
+	dockerNatImports      = []string{"github.com/docker/go-connections/nat"}
+	testcontainersImports = []string{"github.com/testcontainers/testcontainers-go"}
+)
+
+var dockerRules = ruleFamily{
+	name:        "Docker and testcontainers",
+	code:        1500,
+	description: "Docker SDK clients, connecting to the daemon at DOCKER_HOST, client.WithHost or the default socket; ports published with a nat.PortMap; and ports exposed by testcontainers requests.",
+	rationale:   "Access to the Docker daemon is equivalent to root on its host, and published ports are ingress on that host, not on the service.",
+	example:     `cli, err := client.NewClientWithOpts(client.WithHost("tcp://build-host:2375"))`,
+	rules: []string{
+		"client.NewClientWithOpts", "client.NewClient", "client.NewEnvClient",
+		"testcontainers.ContainerRequest", "nat.PortMap",
+	},
+}
+
+func (pm *PatternMatcher) initializeDockerPatterns() {
+	for name, matcher := range map[string]callMatcher{
+		"client.NewClientWithOpts": matchDockerClientWithOpts,
+		"client.NewClient":         matchDockerClient,
+		"client.NewEnvClient":      matchDockerEnvClient,
+	} {
+		pm.callMatchers[name] = matcher
+		pm.requiredImports[name] = dockerClientImports
+	}
+
+	pm.literalMatchers["testcontainers.ContainerRequest"] = matchContainerRequest
+	pm.requiredImports["testcontainers.ContainerRequest"] = testcontainersImports
+	pm.literalMatchers["nat.PortMap"] = matchDockerPortMap
+	pm.requiredImports["nat.PortMap"] = dockerNatImports
+}
+
+func newDockerClientSocket(funcName string) *types.SocketInfo {
+	return &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     types.ProtocolUnix,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+		Tags:         []string{TagDocker},
+	}
+}
+
+// matchDockerClientWithOpts handles client.NewClientWithOpts(opts...), where
+// the daemon address comes from client.WithHost or client.FromEnv.
+func matchDockerClientWithOpts(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	socket := newDockerClientSocket(funcName)
+	host := dockerDefaultHost
+	fromEnv := false
+
+	for _, arg := range callExpr.Args {
+		switch option := arg.(type) {
+		case *ast.SelectorExpr:
+			if option.Sel.Name == "FromEnv" {
+				fromEnv = true
+			}
+		case *ast.CallExpr:
+			name := pm.extractFunctionName(option)
+			if name == "client.WithHost" && len(option.Args) == 1 {
+				host = pm.extractStringLiteral(option.Args[0])
+			} else if name == "client.WithHostFromEnv" {
+				fromEnv = true
+			}
+		}
+	}
+
+	if fromEnv {
+		// DOCKER_HOST may override any host configured before it.
+		socket.RawValue = "$DOCKER_HOST"
+		return socket
+	}
+	if host != "" {
+		parseDockerHost(socket, host)
+	}
+	return socket
+}
+
+// matchDockerClient handles the legacy client.NewClient(host, version, ...).
+func matchDockerClient(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	if len(callExpr.Args) == 0 {
+		return nil
+	}
+	socket := newDockerClientSocket(funcName)
+	if host := pm.extractStringLiteral(callExpr.Args[0]); host != "" {
+		parseDockerHost(socket, host)
+	}
+	return socket
+}
+
+func matchDockerEnvClient(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	socket := newDockerClientSocket(funcName)
+	socket.RawValue = "$DOCKER_HOST"
+	return socket
+}
+
+// parseDockerHost fills the destination from a Docker host string such as
+// "tcp://build-host:2375" or "unix:///var/run/docker.sock".
+func parseDockerHost(socket *types.SocketInfo, host string) {
+	socket.RawValue = host
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return
+	}
+
+	switch parsed.Scheme {
+	case "unix", "npipe":
+		path := parsed.Path
+		socket.Protocol = types.ProtocolUnix
+		socket.Destination = types.NewPathEndpoint(path)
+		socket.IsResolved = true
+	case "tcp", "http", "https":
+		socket.Protocol = types.ProtocolTCP
+		socket.Destination = types.NewEndpoint(parsed.Hostname(), nil)
+		if port, err := strconv.Atoi(parsed.Port()); err == nil {
+			socket.Destination.Port = &port
+		}
+		socket.IsResolved = true
+	}
+}
+
+// matchContainerRequest reports each port a testcontainers request exposes.
+// The test process reaches these through a dynamically mapped host port, so
+// the finding records the container-side port.
+func matchContainerRequest(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != "ExposedPorts" {
+			continue
+		}
+		ports, ok := kv.Value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+
+		for _, portExpr := range ports.Elts {
+			raw := pm.extractStringLiteral(portExpr)
+			socket := &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolTCP,
+				RawValue:     raw,
+				PatternMatch: typeName,
+				FunctionName: "unknown",
+				Tags:         []string{TagTestcontainers},
+			}
+			if port, protocol, ok := parseContainerPort(raw); ok {
+				socket.Destination = types.NewEndpoint("container", &port)
+				socket.Protocol = protocol
+				socket.IsResolved = true
+			}
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+// matchDockerPortMap reports host ports published through a Docker SDK
+// nat.PortMap, e.g. {"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}}}.
+func matchDockerPortMap(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+
+		keyExpr := kv.Key
+		if call, ok := keyExpr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+			keyExpr = call.Args[0] // nat.Port("80/tcp")
+		}
+		_, protocol, ok := parseContainerPort(pm.extractStringLiteral(keyExpr))
+		if !ok {
+			protocol = types.ProtocolTCP
+		}
+
+		bindings, ok := kv.Value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		for _, bindingExpr := range bindings.Elts {
+			binding, ok := bindingExpr.(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			sockets = append(sockets, pm.dockerPortBinding(binding, protocol, typeName))
+		}
+	}
+	return sockets
+}
+
+func (pm *PatternMatcher) dockerPortBinding(binding *ast.CompositeLit, protocol types.Protocol, typeName string) *types.SocketInfo {
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     protocol,
+		PatternMatch: typeName,
+		FunctionName: "unknown",
+		Tags:         []string{TagDocker},
+	}
+
+	hostIP := ""
+	for _, field := range binding.Elts {
+		kv, ok := field.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "HostIP":
+			hostIP = pm.extractStringLiteral(kv.Value)
+		case "HostPort":
+			socket.RawValue = pm.extractStringLiteral(kv.Value)
+		}
+	}
+
+	if hostIP == "" {
+		hostIP = "0.0.0.0"
+	}
+	if port, err := strconv.Atoi(socket.RawValue); err == nil {
+		socket.Listen = types.NewEndpoint(hostIP, &port)
+		socket.IsResolved = true
+	} else if ports, ok := parsePortRange(socket.RawValue); ok {
+		socket.Listen = types.NewEndpoint(hostIP, nil)
+		socket.Listen.PortRange = ports
+		socket.IsResolved = true
+	}
+	return socket
+}
+
+// parsePortRange parses a published port range such as "8000-8010".
+func parsePortRange(spec string) (*types.PortRange, bool) {
+	startPart, endPart, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, false
+	}
+	start, err := strconv.Atoi(startPart)
+	if err != nil {
+		return nil, false
+	}
+	end, err := strconv.Atoi(endPart)
+	if err != nil || end < start {
+		return nil, false
+	}
+	return &types.PortRange{Start: start, End: end}, true
+}
+
+// parseContainerPort parses Docker port specs such as "5432", "5432/tcp"
+// or "53/udp".
+func parseContainerPort(spec string) (int, types.Protocol, bool) {
+	portPart, protoPart, _ := strings.Cut(spec, "/")
+	port, err := strconv.Atoi(portPart)
+	if err != nil {
+		return 0, "", false
+	}
+
+	protocol := types.ProtocolTCP
+	if protoPart == "udp" {
+		protocol = types.ProtocolUDP
+	}
+	return port, protocol, true
+}
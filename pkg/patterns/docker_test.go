@@ -0,0 +1,129 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func matchAll(t *testing.T, code string) []*types.SocketInfo {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	pm := NewPatternMatcher()
+	var sockets []*types.SocketInfo
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if socket := pm.MatchSocketPattern(node, file); socket != nil {
+				sockets = append(sockets, socket)
+			}
+		case *ast.CompositeLit:
+			sockets = append(sockets, pm.MatchCompositeLiteral(node, file)...)
+		case *ast.AssignStmt:
+			sockets = append(sockets, pm.MatchFieldAssignment(node, file)...)
+		}
+		return true
+	})
+	return sockets
+}
+
+func TestPatternMatcher_DockerClient(t *testing.T) {
+	sockets := matchAll(t, `package ci
+import "github.com/docker/docker/client"
+func clients() {
+	client.NewClientWithOpts(client.WithHost("tcp://build-host:2375"), client.WithAPIVersionNegotiation())
+	client.NewClientWithOpts(client.WithAPIVersionNegotiation())
+	client.NewClientWithOpts(client.FromEnv)
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 docker client findings, got %d", len(sockets))
+	}
+
+	remote := sockets[0]
+	if remote.Protocol != types.ProtocolTCP || remote.Destination.Host != "build-host" || *remote.Destination.Port != 2375 {
+		t.Errorf("Unexpected remote docker client finding: %+v", remote)
+	}
+
+	local := sockets[1]
+	if local.Protocol != types.ProtocolUnix || local.Destination.Path != "/var/run/docker.sock" || !local.IsResolved {
+		t.Errorf("Expected default docker socket, got %+v", local)
+	}
+
+	env := sockets[2]
+	if env.IsResolved || env.RawValue != "$DOCKER_HOST" {
+		t.Errorf("Expected unresolved DOCKER_HOST client, got %+v", env)
+	}
+
+	for _, socket := range sockets {
+		if socket.Type != types.TrafficTypeEgress || len(socket.Tags) != 1 || socket.Tags[0] != TagDocker {
+			t.Errorf("Expected egress finding tagged docker, got %+v", socket)
+		}
+	}
+}
+
+func TestPatternMatcher_DockerClientRequiresImport(t *testing.T) {
+	sockets := matchAll(t, `package api
+import "example.com/internal/client"
+func f() {
+	client.NewClient("https://api.example.com", nil)
+}`)
+
+	if len(sockets) != 0 {
+		t.Errorf("Expected no findings for unrelated client package, got %d", len(sockets))
+	}
+}
+
+func TestPatternMatcher_Testcontainers(t *testing.T) {
+	sockets := matchAll(t, `package it
+import "github.com/testcontainers/testcontainers-go"
+func setup() {
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:16",
+		ExposedPorts: []string{"5432/tcp", "8125/udp"},
+	}
+	_ = req
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 exposed ports, got %d", len(sockets))
+	}
+	if *sockets[0].Destination.Port != 5432 || sockets[0].Protocol != types.ProtocolTCP {
+		t.Errorf("Unexpected first port: %+v", sockets[0])
+	}
+	if *sockets[1].Destination.Port != 8125 || sockets[1].Protocol != types.ProtocolUDP {
+		t.Errorf("Unexpected second port: %+v", sockets[1])
+	}
+	if sockets[0].Tags[0] != TagTestcontainers {
+		t.Errorf("Expected testcontainers tag, got %v", sockets[0].Tags)
+	}
+}
+
+func TestPatternMatcher_DockerPortMap(t *testing.T) {
+	sockets := matchAll(t, `package ci
+import "github.com/docker/go-connections/nat"
+func bindings() nat.PortMap {
+	return nat.PortMap{
+		"80/tcp":          []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: "8080"}},
+		nat.Port("53/udp"): []nat.PortBinding{{HostPort: "5353"}},
+	}
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 published ports, got %d", len(sockets))
+	}
+	if *sockets[0].Listen.Port != 8080 || sockets[0].Listen.Host != "127.0.0.1" || sockets[0].Type != types.TrafficTypeIngress {
+		t.Errorf("Unexpected first binding: %+v", sockets[0])
+	}
+	if *sockets[1].Listen.Port != 5353 || sockets[1].Listen.Host != "0.0.0.0" || sockets[1].Protocol != types.ProtocolUDP {
+		t.Errorf("Unexpected second binding: %+v", sockets[1])
+	}
+}
@@ -0,0 +1,91 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+var (
+	gnetImports = []string{"github.com/panjf2000/gnet/v2", "github.com/panjf2000/gnet"}
+	evioImports = []string{"github.com/tidwall/evio"}
+)
+
+var eventLoopRules = ruleFamily{
+	name:        "Event-loop servers",
+	code:        1400,
+	description: "Listeners of event-loop networking libraries (gnet, evio, netpoll), one per address they are started on, with the protocol taken from the scheme of the address.",
+	rationale:   "These libraries bypass net/http and often net.Listen, so their listeners are otherwise invisible.",
+	example:     `gnet.Run(handler, "tcp://:9000", gnet.WithMulticore(true))`,
+	rules:       []string{"netpoll.CreateListener", "gnet.Run", "gnet.Serve", "gnet.Rotate", "evio.Serve"},
+}
+
+func (pm *PatternMatcher) initializeEventLoopPatterns() {
+	pm.packagePaths["netpoll"] = "github.com/cloudwego/netpoll"
+	pm.ingressPatterns["netpoll.CreateListener"] = IngressPattern{Protocol: types.ProtocolTCP, AddressArg: 1, PortOnly: true}
+
+	// gnet.Run(handler, "tcp://:9000", opts...), and gnet.Serve in v1
+	for _, name := range []string{"gnet.Run", "gnet.Serve", "gnet.Rotate"} {
+		pm.callListMatchers[name] = matchEventLoopServe
+		pm.requiredImports[name] = gnetImports
+	}
+	// evio.Serve(events, "tcp://:5000", "unix://evio.sock", ...)
+	pm.callListMatchers["evio.Serve"] = matchEventLoopServe
+	pm.requiredImports["evio.Serve"] = evioImports
+}
+
+// matchEventLoopServe reports one listener per address an event-loop
+// server is started on: the second argument of gnet.Run and gnet.Serve,
+// the list passed to gnet.Rotate, and every address after the events of
+// evio.Serve.
+func matchEventLoopServe(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) []*types.SocketInfo {
+	if len(callExpr.Args) < 2 {
+		return nil
+	}
+	addresses := callExpr.Args[1:]
+	switch funcName {
+	case "gnet.Run", "gnet.Serve":
+		addresses = addresses[:1]
+	case "gnet.Rotate":
+		list, ok := callExpr.Args[1].(*ast.CompositeLit)
+		if !ok {
+			addresses = addresses[:1]
+			break
+		}
+		addresses = list.Elts
+	}
+
+	var sockets []*types.SocketInfo
+	for _, expr := range addresses {
+		socket := &types.SocketInfo{
+			Type:         types.TrafficTypeIngress,
+			Protocol:     types.ProtocolTCP,
+			PatternMatch: funcName,
+			FunctionName: "unknown",
+		}
+		address, ok := pm.resolveConstant(expr, file)
+		if !ok {
+			socket.RawValue = gotypes.ExprString(expr)
+		} else {
+			socket.RawValue = address
+			pm.parseEventLoopAddress(socket, address)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// parseEventLoopAddress fills the listener of a gnet or evio address such
+// as "tcp://:9000", "udp4://0.0.0.0:9001", "unix:///tmp/app.sock" or
+// "tcp-net://:5000?reuseport=true". Addresses without a scheme are tcp.
+func (pm *PatternMatcher) parseEventLoopAddress(socket *types.SocketInfo, address string) {
+	if scheme, _, ok := strings.Cut(address, "://"); ok {
+		if _, _, known := types.SplitScheme(address); !known {
+			socket.Notes = append(socket.Notes, types.Message(types.MsgUnknownListenScheme, "Scheme", scheme))
+			return
+		}
+	}
+	pm.parseIngressAddress(socket, address, true)
+}
@@ -0,0 +1,65 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_EventLoopServers(t *testing.T) {
+	sockets := matchFile(t, `package gateway
+import (
+	"github.com/cloudwego/netpoll"
+	"github.com/panjf2000/gnet/v2"
+	"github.com/tidwall/evio"
+)
+const gatewayAddr = "tcp://0.0.0.0:9000"
+func main() {
+	gnet.Run(&handler{}, gatewayAddr, gnet.WithMulticore(true))
+	gnet.Rotate(&handler{}, []string{"udp4://:9001", "unix:///run/gateway.sock"})
+	evio.Serve(events, "tcp-net://:5000?reuseport=true", "udp://:5001")
+	gnet.Run(&handler{}, cfg.Addr)
+	netpoll.CreateListener("tcp", ":8888")
+}`)
+
+	tests := []struct {
+		protocol types.Protocol
+		listen   string
+		pattern  string
+	}{
+		{types.ProtocolTCP, "0.0.0.0:9000", "gnet.Run"},
+		{types.ProtocolUDP, "0.0.0.0:9001", "gnet.Rotate"},
+		{types.ProtocolUnix, "/run/gateway.sock", "gnet.Rotate"},
+		{types.ProtocolTCP, "0.0.0.0:5000", "evio.Serve"},
+		{types.ProtocolUDP, "0.0.0.0:5001", "evio.Serve"},
+		{types.ProtocolTCP, "", "gnet.Run"},
+		{types.ProtocolTCP, "0.0.0.0:8888", "netpoll.CreateListener"},
+	}
+	if len(sockets) != len(tests) {
+		t.Fatalf("Expected %d listeners, got %d", len(tests), len(sockets))
+	}
+	for i, tt := range tests {
+		socket := sockets[i]
+		if socket.Type != types.TrafficTypeIngress || socket.Protocol != tt.protocol || socket.PatternMatch != tt.pattern {
+			t.Errorf("Listener %d: expected %s %s, got %s %s", i, tt.pattern, tt.protocol, socket.PatternMatch, socket.Protocol)
+		}
+		listen := ""
+		if socket.Listen != nil {
+			listen = socket.Listen.String()
+		}
+		if listen != tt.listen {
+			t.Errorf("Listener %d: expected %q, got %q", i, tt.listen, listen)
+		}
+	}
+	if sockets[5].IsResolved || sockets[5].RawValue != "cfg.Addr" {
+		t.Errorf("Expected a dynamic address to stay unresolved, got %+v", sockets[5])
+	}
+}
+
+func TestPatternMatcher_EventLoopUnknownScheme(t *testing.T) {
+	socket := &types.SocketInfo{Protocol: types.ProtocolTCP}
+	NewPatternMatcher().parseEventLoopAddress(socket, "quic://:443")
+	if socket.IsResolved || socket.Listen != nil || len(socket.Notes) != 1 {
+		t.Errorf("Expected an unresolved listener with a note, got %+v", socket)
+	}
+}
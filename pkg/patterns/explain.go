@@ -0,0 +1,124 @@
+package patterns
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"sort"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/internal/resolver"
+)
+
+// Explanation says how the matcher treats one node: the pattern it matched
+// and how the address was resolved, or, for a call, why no pattern matched.
+type Explanation struct {
+	// Node is the call, literal or assignment as written, e.g. net.Listen
+	// or http.Server{...}.
+	Node string
+	// Pos is where the node starts.
+	Pos token.Pos
+	// Pattern is the pattern the node matched, "" when none did.
+	Pattern string
+	// Reason says why no pattern matched.
+	Reason string
+	// Findings are what the node reports, resolved.
+	Findings []Finding
+	// Resolution lists the strategies tried to resolve the address of a
+	// call, each with its outcome, in order.
+	Resolution []string
+}
+
+// Explain matches and resolves node as Match does, and says why. Nodes
+// other than calls are only explained when they match; ok is false for
+// the rest.
+func (pm *PatternMatcher) Explain(node ast.Node, file *ast.File) (explanation Explanation, ok bool) {
+	explanation.Pos = node.Pos()
+	switch n := node.(type) {
+	case *ast.CallExpr:
+		explanation.Node = gotypes.ExprString(n.Fun)
+	case *ast.CompositeLit:
+		explanation.Node = gotypes.ExprString(n.Type) + "{...}"
+	case *ast.AssignStmt:
+		explanation.Node = gotypes.ExprString(n.Lhs[0]) + " " + n.Tok.String() + " ..."
+	default:
+		return explanation, false
+	}
+
+	findings := pm.MatchUnresolved(node, file)
+	if len(findings) == 0 {
+		call, isCall := node.(*ast.CallExpr)
+		if !isCall {
+			return explanation, false
+		}
+		explanation.Reason = pm.mismatch(call, file)
+		return explanation, true
+	}
+
+	explanation.Pattern = findings[0].Socket.PatternMatch
+	var trace resolver.Trace
+	for i := range findings {
+		pm.resolve(pm.resolver, &findings[i], file, &trace)
+	}
+	explanation.Findings = findings
+	explanation.Resolution = trace.Steps
+	return explanation, true
+}
+
+// mismatch says why call matched no pattern.
+func (pm *PatternMatcher) mismatch(call *ast.CallExpr, file *ast.File) string {
+	funcName := pm.extractFunctionName(call)
+	if funcName == "" {
+		return "the callee is not a function of a package or a method of a named value"
+	}
+	positional := pm.positionalName(call, file)
+
+	if pattern, ok := pm.ingressPatterns[positional]; ok {
+		return fmt.Sprintf("%s takes its address in argument %d, but the call passes %d arguments", positional, pattern.AddressArg, len(call.Args))
+	}
+	if pattern, ok := pm.egressPatterns[positional]; ok {
+		arg, _ := pattern.argument()
+		return fmt.Sprintf("%s takes its address in argument %d, but the call passes %d arguments", positional, arg, len(call.Args))
+	}
+	_, callMatcher := pm.callMatchers[funcName]
+	_, listMatcher := pm.callListMatchers[funcName]
+	if callMatcher || listMatcher {
+		if !pm.importsRequired(funcName, file) {
+			return fmt.Sprintf("%s is only matched in files importing %s", funcName, strings.Join(pm.requiredImports[funcName], " or "))
+		}
+		return fmt.Sprintf("%s is recognized, but its arguments set up no socket the matcher can read", funcName)
+	}
+
+	qualifier, function, qualified := strings.Cut(funcName, ".")
+	if positional == "" && qualified {
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj != nil {
+				return fmt.Sprintf("%s is declared in this file, not an imported package; methods are only matched by callee with -typed", qualifier)
+			}
+		}
+		path, _ := importedPath(file, qualifier)
+		return fmt.Sprintf("%s is imported from %s, not %s; map it with -import-aliases if it is a fork", qualifier, path, pm.packagePaths[qualifier])
+	}
+
+	if !qualified {
+		function = funcName
+	}
+	var similar []string
+	for name := range pm.ingressPatterns {
+		if name == function || strings.HasSuffix(name, "."+function) {
+			similar = append(similar, name)
+		}
+	}
+	for name := range pm.egressPatterns {
+		if name == function || strings.HasSuffix(name, "."+function) {
+			similar = append(similar, name)
+		}
+	}
+	sort.Strings(similar)
+	reason := "no pattern matches " + funcName
+	if len(similar) > 0 {
+		reason += "; patterns for " + function + ": " + strings.Join(similar, ", ")
+	}
+	return reason + "; wrappers can be added with -patterns"
+}
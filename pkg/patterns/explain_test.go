@@ -0,0 +1,107 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// explainCalls explains every call of code whose callee is named name.
+func explainCalls(t *testing.T, code, name string) []Explanation {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+	pm := NewPatternMatcher()
+	var explanations []Explanation
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if explanation, ok := pm.Explain(call, file); ok && explanation.Node == name {
+				explanations = append(explanations, explanation)
+			}
+		}
+		return true
+	})
+	return explanations
+}
+
+func TestPatternMatcher_Explain(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		call    string
+		pattern string
+		reason  string
+		steps   []string
+	}{
+		{
+			name: "matched and resolved",
+			code: `package main
+import "net"
+const addr = ":8080"
+func main() { net.Listen("tcp", addr) }`,
+			call:    "net.Listen",
+			pattern: "net.Listen",
+			steps:   []string{`constant addr: resolved to ":8080"`},
+		},
+		{
+			name: "too few arguments",
+			code: `package main
+import "net"
+func main() { net.Listen("tcp") }`,
+			call:   "net.Listen",
+			reason: "net.Listen takes its address in argument 1, but the call passes 1 arguments",
+		},
+		{
+			name: "local value",
+			code: `package main
+import "net/http"
+func main() {
+	srv := &http.Server{}
+	srv.ListenAndServe()
+}`,
+			call:   "srv.ListenAndServe",
+			reason: "srv is declared in this file",
+		},
+		{
+			name: "other package",
+			code: `package main
+import "example.com/net"
+func main() { net.Listen("tcp", ":80") }`,
+			call:   "net.Listen",
+			reason: "net is imported from example.com/net, not net",
+		},
+		{
+			name: "unknown wrapper",
+			code: `package main
+import "example.com/netutil"
+func main() { netutil.Listen(":80") }`,
+			call:   "netutil.Listen",
+			reason: "no pattern matches netutil.Listen; patterns for Listen: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanations := explainCalls(t, tt.code, tt.call)
+			if len(explanations) != 1 {
+				t.Fatalf("Expected 1 explanation of %s, got %d", tt.call, len(explanations))
+			}
+			explanation := explanations[0]
+			if explanation.Pattern != tt.pattern {
+				t.Errorf("Expected pattern %q, got %q", tt.pattern, explanation.Pattern)
+			}
+			if !strings.HasPrefix(explanation.Reason, tt.reason) || (tt.reason == "") != (explanation.Reason == "") {
+				t.Errorf("Expected reason starting with %q, got %q", tt.reason, explanation.Reason)
+			}
+			if strings.Join(explanation.Resolution, "\n") != strings.Join(tt.steps, "\n") {
+				t.Errorf("Expected resolution %q, got %q", tt.steps, explanation.Resolution)
+			}
+			if tt.pattern != "" && (len(explanation.Findings) != 1 || !explanation.Findings[0].Socket.IsResolved) {
+				t.Errorf("Expected one resolved finding, got %+v", explanation.Findings)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// maxCandidatePorts bounds the ports taken from a counting loop such as
+// for port := 8000; port < 9000; port++.
+const maxCandidatePorts = 64
+
+// FallbackPorts finds listeners that are retried on other ports when they
+// fail, either by listening again in an err != nil branch or by listening
+// in a loop over a list or range of ports. The result maps the position of
+// each ingress call involved to every port attempted, in order.
+func (pm *PatternMatcher) FallbackPorts(file *ast.File) map[token.Pos][]int {
+	fallbacks := make(map[token.Pos][]int)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			for i := range node.List {
+				calls := pm.retryChain(node.List[i:], file)
+				if len(calls) < 2 || fallbacks[calls[0].Pos()] != nil {
+					continue
+				}
+				var ports []int
+				for _, call := range calls {
+					if port, ok := pm.listenPort(call, file); ok {
+						ports = append(ports, port)
+					}
+				}
+				pm.addFallbacks(fallbacks, calls, ports)
+			}
+		case *ast.RangeStmt:
+			if ident, ok := node.Value.(*ast.Ident); ok {
+				pm.addFallbacks(fallbacks, pm.listensUsing(node.Body, ident.Name, file), pm.rangePorts(node.X, file))
+			}
+		case *ast.ForStmt:
+			if name, ports := countingPorts(node); name != "" {
+				pm.addFallbacks(fallbacks, pm.listensUsing(node.Body, name, file), ports)
+			}
+		}
+		return true
+	})
+
+	return fallbacks
+}
+
+func (pm *PatternMatcher) addFallbacks(fallbacks map[token.Pos][]int, calls []*ast.CallExpr, ports []int) {
+	if len(calls) == 0 || len(ports) < 2 {
+		return
+	}
+	for _, call := range calls {
+		fallbacks[call.Pos()] = ports
+	}
+}
+
+// retryChain returns the listen call starting stmts followed by those
+// retried in err != nil branches:
+//
+//	lis, err := net.Listen("tcp", ":8080")
+//	if err != nil {
+//		lis, err = net.Listen("tcp", ":8081")
+//	}
+func (pm *PatternMatcher) retryChain(stmts []ast.Stmt, file *ast.File) []*ast.CallExpr {
+	if len(stmts) == 0 {
+		return nil
+	}
+	if ifStmt, ok := stmts[0].(*ast.IfStmt); ok && ifStmt.Init != nil {
+		call, errName := pm.listenAssign(ifStmt.Init, file)
+		if call == nil || !isErrCheck(ifStmt.Cond, errName) {
+			return nil
+		}
+		return append([]*ast.CallExpr{call}, pm.retryChain(ifStmt.Body.List, file)...)
+	}
+
+	call, errName := pm.listenAssign(stmts[0], file)
+	if call == nil {
+		return nil
+	}
+	calls := []*ast.CallExpr{call}
+	if len(stmts) > 1 {
+		if ifStmt, ok := stmts[1].(*ast.IfStmt); ok && ifStmt.Init == nil && isErrCheck(ifStmt.Cond, errName) {
+			for i := range ifStmt.Body.List {
+				if retried := pm.retryChain(ifStmt.Body.List[i:], file); len(retried) > 0 {
+					return append(calls, retried...)
+				}
+			}
+		}
+	}
+	return calls
+}
+
+// listenAssign returns the ingress call made by stmt and the name of the
+// error it returns, the last variable assigned. Calls whose error is
+// discarded end a retry chain.
+func (pm *PatternMatcher) listenAssign(stmt ast.Stmt, file *ast.File) (*ast.CallExpr, string) {
+	if expr, ok := stmt.(*ast.ExprStmt); ok {
+		if call, ok := expr.X.(*ast.CallExpr); ok {
+			if _, ok := pm.ingressPatterns[pm.positionalName(call, file)]; ok {
+				return call, ""
+			}
+		}
+		return nil, ""
+	}
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return nil, ""
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return nil, ""
+	}
+	if _, ok := pm.ingressPatterns[pm.positionalName(call, file)]; !ok {
+		return nil, ""
+	}
+	errIdent, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+	if !ok || errIdent.Name == "_" {
+		return nil, ""
+	}
+	return call, errIdent.Name
+}
+
+func isErrCheck(cond ast.Expr, errName string) bool {
+	binary, ok := cond.(*ast.BinaryExpr)
+	if !ok || binary.Op != token.NEQ {
+		return false
+	}
+	x, okX := binary.X.(*ast.Ident)
+	y, okY := binary.Y.(*ast.Ident)
+	return okX && okY && x.Name == errName && y.Name == "nil"
+}
+
+// listenPort returns the port a listen call binds, where its address is a
+// literal or constant.
+func (pm *PatternMatcher) listenPort(call *ast.CallExpr, file *ast.File) (int, bool) {
+	pattern := pm.ingressPatterns[pm.positionalName(call, file)]
+	if len(call.Args) <= pattern.AddressArg {
+		return 0, false
+	}
+	value, ok := pm.resolveConstant(call.Args[pattern.AddressArg], file)
+	if !ok {
+		return 0, false
+	}
+	return parsePort(value)
+}
+
+// listensUsing returns the listen calls in body whose address refers to
+// the variable name.
+func (pm *PatternMatcher) listensUsing(body *ast.BlockStmt, name string, file *ast.File) []*ast.CallExpr {
+	var calls []*ast.CallExpr
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		pattern, ok := pm.ingressPatterns[pm.positionalName(call, file)]
+		if ok && len(call.Args) > pattern.AddressArg && refersTo(call.Args[pattern.AddressArg], name) {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls
+}
+
+func refersTo(expr ast.Expr, name string) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// rangePorts returns the ports of a list ranged over, written inline or
+// assigned to a variable: []int{8080, 8081} or []string{":8080", ":8081"}.
+func (pm *PatternMatcher) rangePorts(expr ast.Expr, file *ast.File) []int {
+	if ident, ok := expr.(*ast.Ident); ok {
+		expr = listValue(ident.Name, file)
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var ports []int
+	for _, elt := range lit.Elts {
+		if value, ok := pm.resolveConstant(elt, file); ok {
+			if port, ok := parsePort(value); ok {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// listValue finds the composite literal assigned to name in file.
+func listValue(name string, file *ast.File) ast.Expr {
+	var value ast.Expr
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && ident.Name == name && i < len(node.Rhs) {
+					if lit, ok := node.Rhs[i].(*ast.CompositeLit); ok {
+						value = lit
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, ident := range node.Names {
+				if ident.Name == name && i < len(node.Values) {
+					if lit, ok := node.Values[i].(*ast.CompositeLit); ok {
+						value = lit
+					}
+				}
+			}
+		}
+		return value == nil
+	})
+	return value
+}
+
+// countingPorts returns the loop variable and ports of
+// for port := 8080; port < 8083; port++.
+func countingPorts(loop *ast.ForStmt) (string, []int) {
+	init, ok := loop.Init.(*ast.AssignStmt)
+	if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+		return "", nil
+	}
+	ident, ok := init.Lhs[0].(*ast.Ident)
+	if !ok {
+		return "", nil
+	}
+	start, ok := intLiteral(init.Rhs[0])
+	if !ok {
+		return "", nil
+	}
+	cond, ok := loop.Cond.(*ast.BinaryExpr)
+	if !ok {
+		return "", nil
+	}
+	if x, ok := cond.X.(*ast.Ident); !ok || x.Name != ident.Name {
+		return "", nil
+	}
+	end, ok := intLiteral(cond.Y)
+	if !ok {
+		return "", nil
+	}
+	switch cond.Op {
+	case token.LSS:
+		end--
+	case token.LEQ:
+	default:
+		return "", nil
+	}
+	if inc, ok := loop.Post.(*ast.IncDecStmt); !ok || inc.Tok != token.INC {
+		return "", nil
+	}
+
+	var ports []int
+	for port := start; port <= end && len(ports) < maxCandidatePorts; port++ {
+		ports = append(ports, port)
+	}
+	return ident.Name, ports
+}
+
+func intLiteral(expr ast.Expr) (int, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	value, err := strconv.Atoi(lit.Value)
+	return value, err == nil
+}
+
+// parsePort reads a port number or the port of a host:port address.
+func parsePort(value string) (int, bool) {
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		value = value[i+1:]
+	}
+	port, err := strconv.Atoi(value)
+	return port, err == nil && port > 0 && port <= 65535
+}
+
+// ApplyFallbackPorts records the ports a listener falls back to.
+func ApplyFallbackPorts(socket *types.SocketInfo, ports []int) {
+	if len(ports) == 0 {
+		return
+	}
+	socket.CandidatePorts = append([]int(nil), ports...)
+}
@@ -0,0 +1,136 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestPatternMatcher_FallbackPorts(t *testing.T) {
+	code := `package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+const fallbackAddr = ":9091"
+
+func retry() {
+	lis, err := net.Listen("tcp", ":9090")
+	if err != nil {
+		log.Printf("port busy: %v", err)
+		lis, err = net.Listen("tcp", fallbackAddr)
+		if err != nil {
+			lis, err = net.Listen("tcp", "127.0.0.1:9092")
+		}
+	}
+	_ = lis
+}
+
+func serve() {
+	if err := http.ListenAndServe(":8080", nil); err != nil {
+		http.ListenAndServe(":8081", nil)
+	}
+}
+
+func loop() {
+	for _, port := range []int{7000, 7001, 7002} {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err == nil {
+			_ = lis
+			break
+		}
+	}
+}
+
+var addrs = []string{":6000", ":6001"}
+
+func loopVar() {
+	for _, addr := range addrs {
+		if _, err := net.Listen("tcp", addr); err == nil {
+			return
+		}
+	}
+}
+
+func counting() {
+	for port := 5000; port <= 5002; port++ {
+		if _, err := net.Listen("tcp", ":"+strconv.Itoa(port)); err == nil {
+			return
+		}
+	}
+}
+
+func single() {
+	lis, err := net.Listen("tcp", ":4000")
+	if err != nil {
+		panic(err)
+	}
+	_ = lis
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	pm := NewPatternMatcher()
+	fallbacks := pm.FallbackPorts(file)
+
+	byLine := make(map[int][]int)
+	for pos, ports := range fallbacks {
+		byLine[fset.Position(pos).Line] = ports
+	}
+
+	expected := map[int][]int{
+		14: {9090, 9091, 9092},
+		17: {9090, 9091, 9092},
+		19: {9090, 9091, 9092},
+		26: {8080, 8081},
+		27: {8080, 8081},
+		33: {7000, 7001, 7002},
+		45: {6000, 6001},
+		53: {5000, 5001, 5002},
+	}
+	if !reflect.DeepEqual(byLine, expected) {
+		t.Errorf("Expected fallback ports %v, got %v", expected, byLine)
+	}
+}
+
+func TestPatternMatcher_MatchFileCandidatePorts(t *testing.T) {
+	code := `package main
+
+import "net"
+
+func main() {
+	lis, err := net.Listen("tcp", ":9090")
+	if err != nil {
+		lis, err = net.Listen("tcp", ":9091")
+	}
+	_ = lis
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	findings := NewPatternMatcher().MatchFile(file)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(findings))
+	}
+	for _, finding := range findings {
+		if !reflect.DeepEqual(finding.Socket.CandidatePorts, []int{9090, 9091}) {
+			t.Errorf("Listener %s: expected candidate ports [9090 9091], got %v", finding.Socket.RawValue, finding.Socket.CandidatePorts)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package patterns
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rules describes the enabled pattern set, one sorted line per rule: every
+// positional pattern with its argument layout and the import paths its
+// qualifiers stand for, every call, literal and
+// field matcher, the imports gating them, and the rules the checks list
+// disabled.
+func (pm *PatternMatcher) Rules() []string {
+	var entries []string
+	for name, p := range pm.ingressPatterns {
+		entries = append(entries, fmt.Sprintf("ingress %s %s %d %t", name, p.Protocol, p.AddressArg, p.PortOnly))
+	}
+	for name, p := range pm.egressPatterns {
+		entry := fmt.Sprintf("egress %s %s %d %d", name, p.Protocol, p.AddressArg, p.URLArg)
+		if p.URL {
+			entry += " url"
+		}
+		if p.Request {
+			entry += " request"
+		}
+		entries = append(entries, entry)
+	}
+	for qualifier, path := range pm.packagePaths {
+		entries = append(entries, "package "+qualifier+" "+path)
+	}
+	for fork, original := range pm.importAliases {
+		entries = append(entries, "alias "+fork+" "+original)
+	}
+	for name := range pm.callMatchers {
+		entries = append(entries, "call "+name)
+	}
+	for name := range pm.callListMatchers {
+		entries = append(entries, "call "+name)
+	}
+	for name := range pm.literalMatchers {
+		entries = append(entries, "literal "+name)
+	}
+	for name := range pm.fieldMatchers {
+		entries = append(entries, "field "+name)
+	}
+	for name, paths := range pm.requiredImports {
+		entries = append(entries, "imports "+name+" "+strings.Join(paths, ","))
+	}
+	for name := range pm.disabled {
+		entries = append(entries, "disabled "+name)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// Fingerprint hashes Rules. Two runs with the same fingerprint applied the
+// same rules.
+func (pm *PatternMatcher) Fingerprint() string {
+	sum := sha256.Sum256([]byte(strings.Join(pm.Rules(), "\n")))
+	return hex.EncodeToString(sum[:])
+}
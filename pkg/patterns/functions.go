@@ -0,0 +1,114 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// FunctionScope follows a walk of a file and names the function the node
+// being visited is in, the way stack traces do: main, (*Server).Start or
+// Server.Start for methods, Start.func1 for the first function literal in
+// Start and Start.func1.1 for one nested in it. Function literals outside
+// any function, in package-level variables, are glob..func1 and so on.
+//
+// Pass every node of an ast.Inspect walk, including the nil that ends a
+// node's children, to Visit.
+type FunctionScope struct {
+	nodes     []ast.Node
+	functions []scopeFunction
+	literals  int
+}
+
+type scopeFunction struct {
+	node     ast.Node
+	name     string
+	literals int
+}
+
+// Visit enters node, or leaves the node entered last when node is nil.
+func (s *FunctionScope) Visit(node ast.Node) {
+	if node == nil {
+		if len(s.nodes) == 0 {
+			return
+		}
+		last := s.nodes[len(s.nodes)-1]
+		s.nodes = s.nodes[:len(s.nodes)-1]
+		if n := len(s.functions); n > 0 && s.functions[n-1].node == last {
+			s.functions = s.functions[:n-1]
+		}
+		return
+	}
+	s.nodes = append(s.nodes, node)
+
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		s.functions = append(s.functions, scopeFunction{node: n, name: FuncDeclName(n)})
+	case *ast.FuncLit:
+		var name string
+		if len(s.functions) == 0 {
+			s.literals++
+			name = "glob..func" + strconv.Itoa(s.literals)
+		} else {
+			outer := &s.functions[len(s.functions)-1]
+			outer.literals++
+			if _, ok := outer.node.(*ast.FuncDecl); ok {
+				name = outer.name + ".func" + strconv.Itoa(outer.literals)
+			} else {
+				name = outer.name + "." + strconv.Itoa(outer.literals)
+			}
+		}
+		s.functions = append(s.functions, scopeFunction{node: n, name: name})
+	}
+}
+
+// Name returns the name of the innermost function being visited, or ""
+// outside functions.
+func (s *FunctionScope) Name() string {
+	if len(s.functions) == 0 {
+		return ""
+	}
+	return s.functions[len(s.functions)-1].name
+}
+
+// Apply records the function being visited on a finding made there.
+func (s *FunctionScope) Apply(socket *types.SocketInfo) {
+	if name := s.Name(); name != "" {
+		socket.FunctionName = name
+	}
+}
+
+// FuncDeclName returns the name of a function declaration, qualified by
+// its receiver type for methods: (*Server).Start for a pointer receiver,
+// Server.Start for a value receiver, and (*List[...]).Push for a method of
+// a generic type.
+func FuncDeclName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if paren, ok := recv.(*ast.ParenExpr); ok {
+		recv = paren.X
+	}
+	pointer := false
+	if star, ok := recv.(*ast.StarExpr); ok {
+		pointer, recv = true, star.X
+	}
+	var typeName string
+	switch t := recv.(type) {
+	case *ast.Ident:
+		typeName = t.Name
+	case *ast.IndexExpr:
+		typeName = gotypes.ExprString(t.X) + "[...]"
+	case *ast.IndexListExpr:
+		typeName = gotypes.ExprString(t.X) + "[...]"
+	default:
+		return decl.Name.Name
+	}
+	if pointer {
+		return "(*" + typeName + ")." + decl.Name.Name
+	}
+	return typeName + "." + decl.Name.Name
+}
@@ -0,0 +1,63 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestFunctionScope(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "scope.go", `package server
+var handler = func() { connect() }
+type List[T any] struct{}
+func (l *List[T]) Push() { connect() }
+func (s Server) Addr() { connect() }
+func run() {
+	connect()
+	go func() {
+		connect()
+		func() { connect() }()
+	}()
+	defer func() { connect() }()
+}`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scope FunctionScope
+	var names []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		scope.Visit(n)
+		if call, ok := n.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == "connect" {
+				names = append(names, scope.Name())
+			}
+		}
+		return true
+	})
+
+	want := []string{"glob..func1", "(*List[...]).Push", "Server.Addr", "run", "run.func1", "run.func1.1", "run.func2"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+	if scope.Name() != "" {
+		t.Errorf("Expected no function after the walk, got %q", scope.Name())
+	}
+}
+
+func TestPatternMatcher_MatchFileFunctionName(t *testing.T) {
+	sockets := matchFile(t, `package main
+import "net"
+var conn, _ = net.Dial("tcp", "db:5432")
+func (c *client) connect() {
+	net.Dial("tcp", "cache:6379")
+}`)
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 findings, got %d", len(sockets))
+	}
+	if sockets[0].FunctionName != "unknown" || sockets[1].FunctionName != "(*client).connect" {
+		t.Errorf("Expected unknown and (*client).connect, got %q and %q", sockets[0].FunctionName, sockets[1].FunctionName)
+	}
+}
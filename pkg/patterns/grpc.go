@@ -0,0 +1,143 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// grpcDefaultPort is the port gRPC dials when a target names none.
+const grpcDefaultPort = 443
+
+var grpcImports = []string{"google.golang.org/grpc"}
+
+var grpcRules = ruleFamily{
+	name:        "gRPC",
+	code:        1300,
+	description: "gRPC clients dialing a target, which may be host:port, a resolver URI such as dns:///host:port, or a unix socket, and gRPC servers served on a listener created elsewhere.",
+	rationale:   "gRPC traffic is HTTP/2 to a long-lived peer; dials with insecure credentials are tagged plaintext, since they send requests unencrypted.",
+	example:     `conn, err := grpc.NewClient("payments:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))`,
+	rules:       []string{"grpc.Dial", "grpc.DialContext", "grpc.NewClient", "grpc.NewServer"},
+}
+
+func (pm *PatternMatcher) initializeGRPCPatterns() {
+	pm.packagePaths["grpc"] = "google.golang.org/grpc"
+
+	pm.egressPatterns["grpc.Dial"] = EgressPattern{Protocol: types.ProtocolGRPC, AddressArg: 0}
+	pm.egressPatterns["grpc.DialContext"] = EgressPattern{Protocol: types.ProtocolGRPC, AddressArg: 1}
+	pm.egressPatterns["grpc.NewClient"] = EgressPattern{Protocol: types.ProtocolGRPC, AddressArg: 0}
+
+	pm.callMatchers["grpc.NewServer"] = matchGRPCServer
+	pm.requiredImports["grpc.NewServer"] = grpcImports
+}
+
+// parseGRPCTarget fills the destination from a gRPC target: host:port,
+// a resolver URI such as dns:///host:port or dns://authority/host, or a
+// unix socket (unix:path, unix:///path, unix-abstract:name).
+func (pm *PatternMatcher) parseGRPCTarget(socket *types.SocketInfo, target string) {
+	socket.IsResolved = true
+	socket.Destination = nil
+
+	if scheme, path, ok := strings.Cut(target, ":"); ok && (scheme == "unix" || scheme == "unix-abstract") {
+		socket.Destination = types.NewPathEndpoint(strings.TrimPrefix(path, "//"))
+		return
+	}
+
+	endpoint := target
+	if _, rest, ok := strings.Cut(target, "://"); ok {
+		// The authority, if any, names the resolver's server
+		_, endpoint, _ = strings.Cut(rest, "/")
+	}
+	if endpoint == "" {
+		return
+	}
+
+	host, portValue, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host, portValue = endpoint, strconv.Itoa(grpcDefaultPort)
+	}
+	socket.Destination = types.NewEndpoint(host, nil)
+	if port, err := strconv.Atoi(portValue); err == nil {
+		socket.Destination.Port = &port
+	}
+}
+
+// matchGRPCServer reports a gRPC server created with grpc.NewServer and
+// served on a listener that is not created in the file, such as one passed
+// in by the caller. A listener created locally with net.Listen is reported
+// where it is created, as gRPC once handed to Serve.
+func matchGRPCServer(pm *PatternMatcher, call *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	server := assignedTo(file, call)
+	if server == "" {
+		return nil
+	}
+
+	var listener ast.Expr
+	ast.Inspect(file, func(n ast.Node) bool {
+		serve, ok := n.(*ast.CallExpr)
+		if !ok || listener != nil {
+			return listener == nil
+		}
+		if sel, ok := serve.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Serve" && len(serve.Args) == 1 && isServer(sel.X, server) {
+			listener = serve.Args[0]
+		}
+		return true
+	})
+	if listener == nil || pm.listensLocally(file, listener, 0) {
+		return nil
+	}
+
+	return &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     types.ProtocolGRPC,
+		RawValue:     gotypes.ExprString(listener),
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+	}
+}
+
+// listensLocally reports whether expr is a listener created in file by a
+// known listen call, directly or wrapped, as in tls.NewListener(lis, cfg)
+// or a cmux sub-listener.
+func (pm *PatternMatcher) listensLocally(file *ast.File, expr ast.Expr, depth int) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || depth > 4 {
+		return false
+	}
+
+	local := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || local || len(assign.Rhs) != 1 {
+			return !local
+		}
+		lhs, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || lhs.Name != ident.Name {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if _, ok := pm.ingressPatterns[pm.positionalName(call, file)]; ok {
+			local = true
+			return false
+		}
+		sources := call.Args
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			sources = append([]ast.Expr{sel.X}, sources...)
+		}
+		for _, source := range sources {
+			if pm.listensLocally(file, source, depth+1) {
+				local = true
+				return false
+			}
+		}
+		return true
+	})
+	return local
+}
@@ -0,0 +1,92 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_GRPCClients(t *testing.T) {
+	sockets := matchFile(t, `package main
+import "google.golang.org/grpc"
+const target = "dns:///orders.internal:9090"
+func connect(ctx context.Context) {
+	grpc.Dial("payments.internal:50051", grpc.WithInsecure())
+	grpc.DialContext(ctx, "dns://10.0.0.2/inventory.internal")
+	grpc.NewClient(target)
+	grpc.NewClient("unix:///var/run/agent.sock")
+}`)
+
+	want := []struct {
+		pattern string
+		host    string
+		port    int
+		path    string
+	}{
+		{"grpc.Dial", "payments.internal", 50051, ""},
+		{"grpc.DialContext", "inventory.internal", 443, ""},
+		{"grpc.NewClient", "orders.internal", 9090, ""},
+		{"grpc.NewClient", "", 0, "/var/run/agent.sock"},
+	}
+	if len(sockets) != len(want) {
+		t.Fatalf("Expected %d clients, got %d: %+v", len(want), len(sockets), sockets)
+	}
+	for i, w := range want {
+		socket := sockets[i]
+		if socket.Type != types.TrafficTypeEgress || socket.Protocol != types.ProtocolGRPC || socket.PatternMatch != w.pattern {
+			t.Errorf("Client %d: expected grpc egress from %s, got %s %s from %s", i, w.pattern, socket.Protocol, socket.Type, socket.PatternMatch)
+		}
+		if !socket.IsResolved || socket.Destination == nil {
+			t.Errorf("Client %d: expected a resolved destination, got %+v", i, socket)
+			continue
+		}
+		if w.path != "" {
+			if socket.Destination.Path != w.path {
+				t.Errorf("Client %d: expected unix socket %s, got %+v", i, w.path, socket.Destination)
+			}
+			continue
+		}
+		if socket.Destination.Host != w.host || socket.Destination.Port == nil || *socket.Destination.Port != w.port {
+			t.Errorf("Client %d: expected %s:%d, got %+v", i, w.host, w.port, socket.Destination)
+		}
+	}
+}
+
+func TestPatternMatcher_GRPCServer(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "google.golang.org/grpc"
+func serve(lis net.Listener) {
+	s := grpc.NewServer()
+	s.Serve(lis)
+}`)
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 server, got %d", len(sockets))
+	}
+	socket := sockets[0]
+	if socket.Type != types.TrafficTypeIngress || socket.Protocol != types.ProtocolGRPC || socket.IsResolved || socket.RawValue != "lis" {
+		t.Errorf("Expected unresolved grpc ingress on lis, got %+v", socket)
+	}
+}
+
+func TestPatternMatcher_GRPCServerLocalListener(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"crypto/tls"
+	"net"
+
+	"google.golang.org/grpc"
+)
+func serve(cfg *tls.Config) {
+	lis, _ := net.Listen("tcp", ":50051")
+	tlsLis := tls.NewListener(lis, cfg)
+	s := grpc.NewServer()
+	s.Serve(tlsLis)
+}`)
+	if len(sockets) != 1 {
+		t.Fatalf("Expected only the listener, got %d findings", len(sockets))
+	}
+	socket := sockets[0]
+	if socket.PatternMatch != "net.Listen" || *socket.Listen.Port != 50051 {
+		t.Errorf("Expected the net.Listen listener on 50051, got %+v", socket)
+	}
+}
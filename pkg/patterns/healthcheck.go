@@ -0,0 +1,81 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// healthcheckPaths are the last path segments of conventional health,
+// readiness and liveness endpoints.
+var healthcheckPaths = map[string]bool{
+	"health":      true,
+	"healthz":     true,
+	"healthcheck": true,
+	"_health":     true,
+	"healthy":     true,
+	"ready":       true,
+	"readyz":      true,
+	"readiness":   true,
+	"live":        true,
+	"livez":       true,
+	"liveness":    true,
+	"status":      true,
+	"ping":        true,
+}
+
+// tagHealthcheck tags egress calls to health endpoints, judging by the
+// resolved URL or by a literal path in the expression building it, as in
+// http.Get(baseURL + "/healthz").
+func tagHealthcheck(socket *types.SocketInfo, call *ast.CallExpr) {
+	if socket.Type != types.TrafficTypeEgress {
+		return
+	}
+	healthcheck := isHealthcheckURL(socket.RawValue)
+	for _, arg := range call.Args {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if value, err := strconv.Unquote(lit.Value); err == nil && isHealthcheckURL(value) {
+					healthcheck = true
+				}
+			}
+			return !healthcheck
+		})
+	}
+	if healthcheck && !containsTag(socket.Tags, types.TagHealthcheck) {
+		socket.Tags = append(socket.Tags, types.TagHealthcheck)
+	}
+}
+
+// isHealthcheckURL reports whether the path of url ends in a health
+// endpoint such as /healthz or /-/ready.
+func isHealthcheckURL(url string) bool {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		slash := strings.Index(url, "/")
+		if slash < 0 {
+			return false
+		}
+		url = url[slash:]
+	}
+	if !strings.HasPrefix(url, "/") {
+		return false
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	segments := strings.Split(strings.TrimRight(url, "/"), "/")
+	return healthcheckPaths[strings.ToLower(segments[len(segments)-1])]
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
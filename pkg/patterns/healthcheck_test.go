@@ -0,0 +1,48 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestIsHealthcheckURL(t *testing.T) {
+	tests := map[string]bool{
+		"http://payments:8080/healthz":         true,
+		"https://api.example.com/v1/status":    true,
+		"http://prometheus:9090/-/ready":       true,
+		"http://svc/ping?verbose=1":            true,
+		"/readyz":                              true,
+		"http://svc/health/":                   true,
+		"https://api.example.com/v1/users":     false,
+		"https://status.example.com":           false,
+		"http://svc/statuses":                  false,
+		"payments:8080":                        false,
+		"https://api.example.com/ping/results": false,
+	}
+	for url, want := range tests {
+		if got := isHealthcheckURL(url); got != want {
+			t.Errorf("isHealthcheckURL(%q) = %t, want %t", url, got, want)
+		}
+	}
+}
+
+func TestPatternMatcher_HealthcheckTag(t *testing.T) {
+	sockets := matchFile(t, `package main
+import "net/http"
+const base = "http://payments:8080"
+func check() {
+	http.Get("http://inventory:8080/readyz")
+	http.Get(base + "/healthz")
+	http.Get("http://inventory:8080/items")
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(sockets))
+	}
+	for i, want := range []bool{true, true, false} {
+		if got := containsTag(sockets[i].Tags, types.TagHealthcheck); got != want {
+			t.Errorf("Finding %d (%s): expected healthcheck tag %t, got tags %v", i, sockets[i].RawValue, want, sockets[i].Tags)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// serverStarts maps the methods that make an http.Server listen on its
+// Addr to the protocol it then serves.
+var serverStarts = map[string]types.Protocol{
+	"ListenAndServe":    types.ProtocolHTTP,
+	"ListenAndServeTLS": types.ProtocolHTTPS,
+}
+
+// serverDefaultAddresses are what an http.Server with an empty Addr
+// listens on.
+var serverDefaultAddresses = map[types.Protocol]string{
+	types.ProtocolHTTP:  ":80",
+	types.ProtocolHTTPS: ":443",
+}
+
+var httpServerRules = ruleFamily{
+	name:        "HTTP servers",
+	code:        1200,
+	description: "An http.Server literal, listening on its Addr, or on :http or :https when Addr is empty, once started with ListenAndServe or ListenAndServeTLS in the same file.",
+	rationale:   "Most production servers are configured through http.Server for its timeouts, so http.ListenAndServe alone misses them.",
+	example: `srv := &http.Server{Addr: ":8443", Handler: mux}
+srv.ListenAndServeTLS(certFile, keyFile)`,
+	rules: []string{"http.Server"},
+}
+
+func (pm *PatternMatcher) initializeHTTPServerPatterns() {
+	pm.literalMatchers["http.Server"] = matchHTTPServer
+	pm.requiredImports["http.Server"] = []string{"net/http"}
+}
+
+// matchHTTPServer reports the listener of an http.Server literal, taking
+// its address from Addr, or from a later srv.Addr = ... assignment. The
+// protocol follows the method that starts the server in the same file:
+// ListenAndServe or ListenAndServeTLS. A server only started with
+// Serve(l) listens on a listener reported where it is created, so it
+// yields nothing; one not started in the file is reported if it has an
+// Addr, as HTTPS when it has a TLSConfig.
+func matchHTTPServer(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var addr ast.Expr
+	protocol := types.ProtocolHTTP
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "Addr":
+			addr = kv.Value
+		case "TLSConfig":
+			protocol = types.ProtocolHTTPS
+		}
+	}
+
+	started, servesListener := false, false
+	if server := assignedTo(file, lit); server != "" {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.CallExpr:
+				sel, ok := node.Fun.(*ast.SelectorExpr)
+				if !ok || !isServer(sel.X, server) {
+					return true
+				}
+				if start, ok := serverStarts[sel.Sel.Name]; ok {
+					// Either start method wins over a TLSConfig
+					if !started || start == types.ProtocolHTTPS {
+						protocol = start
+					}
+					started = true
+				}
+				servesListener = servesListener || sel.Sel.Name == "Serve" || sel.Sel.Name == "ServeTLS"
+			case *ast.AssignStmt:
+				// srv.Addr = ":8080"
+				for i, lhs := range node.Lhs {
+					if sel, ok := lhs.(*ast.SelectorExpr); ok && sel.Sel.Name == "Addr" &&
+						isServer(sel.X, server) && addr == nil && i < len(node.Rhs) {
+						addr = node.Rhs[i]
+					}
+				}
+			}
+			return true
+		})
+	}
+	if !started && (servesListener || addr == nil) {
+		return nil
+	}
+
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     protocol,
+		PatternMatch: typeName,
+		FunctionName: "unknown",
+	}
+	address := ""
+	if addr != nil {
+		value, ok := pm.resolveConstant(addr, file)
+		if !ok {
+			socket.RawValue = gotypes.ExprString(addr)
+			return []*types.SocketInfo{socket}
+		}
+		address = value
+	}
+	socket.RawValue = address
+	if address == "" {
+		address = serverDefaultAddresses[protocol]
+		socket.Notes = append(socket.Notes, types.Message(types.MsgHTTPServerDefaultAddr, "Address", address))
+	}
+	pm.parseIngressAddress(socket, address, true)
+	return []*types.SocketInfo{socket}
+}
+
+// assignedTo returns the expression a constructor call or literal is
+// assigned to, such as srv or s.server, ".server" for the server field of
+// an enclosing literal, or "" if it is not assigned.
+func assignedTo(file *ast.File, value ast.Expr) string {
+	isValue := func(expr ast.Expr) bool {
+		if unary, ok := expr.(*ast.UnaryExpr); ok {
+			expr = unary.X
+		}
+		return expr == value
+	}
+
+	var server string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if server != "" {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range node.Rhs {
+				if isValue(rhs) && i < len(node.Lhs) {
+					server = gotypes.ExprString(node.Lhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range node.Values {
+				if isValue(value) && i < len(node.Names) {
+					server = node.Names[i].Name
+				}
+			}
+		case *ast.KeyValueExpr:
+			// &app{server: &http.Server{...}} is started as a.server
+			if key, ok := node.Key.(*ast.Ident); ok && isValue(node.Value) {
+				server = "." + key.Name
+			}
+		}
+		return true
+	})
+	return server
+}
+
+// isServer reports whether expr refers to the server assignedTo found.
+func isServer(expr ast.Expr, server string) bool {
+	if strings.HasPrefix(server, ".") {
+		return strings.HasSuffix(gotypes.ExprString(expr), server)
+	}
+	return gotypes.ExprString(expr) == server
+}
@@ -0,0 +1,111 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_HTTPServer(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		protocol types.Protocol
+		port     int
+		raw      string
+	}{
+		{
+			name: "ListenAndServe",
+			code: `srv := &http.Server{Addr: ":8080", Handler: mux}
+	srv.ListenAndServe()`,
+			protocol: types.ProtocolHTTP,
+			port:     8080,
+			raw:      ":8080",
+		},
+		{
+			name: "ListenAndServeTLS",
+			code: `srv := http.Server{Addr: addr}
+	srv.ListenAndServeTLS("cert.pem", "key.pem")`,
+			protocol: types.ProtocolHTTPS,
+			port:     8443,
+			raw:      "0.0.0.0:8443",
+		},
+		{
+			name: "Addr assigned after construction",
+			code: `srv := &http.Server{}
+	srv.Addr = ":9090"
+	srv.ListenAndServe()`,
+			protocol: types.ProtocolHTTP,
+			port:     9090,
+			raw:      ":9090",
+		},
+		{
+			name: "empty Addr",
+			code: `srv := &http.Server{TLSConfig: cfg}
+	srv.ListenAndServeTLS("", "")`,
+			protocol: types.ProtocolHTTPS,
+			port:     443,
+		},
+		{
+			name:     "started elsewhere",
+			code:     `s.server = &http.Server{Addr: ":7070", TLSConfig: cfg}`,
+			protocol: types.ProtocolHTTPS,
+			port:     7070,
+			raw:      ":7070",
+		},
+		{
+			name: "server field",
+			code: `a := &app{server: &http.Server{Addr: ":6060"}}
+	a.server.ListenAndServe()`,
+			protocol: types.ProtocolHTTP,
+			port:     6060,
+			raw:      ":6060",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sockets := matchAll(t, "package main\nimport \"net/http\"\nconst addr = \"0.0.0.0:8443\"\nfunc run() {\n\t"+tt.code+"\n}")
+			if len(sockets) != 1 {
+				t.Fatalf("Expected 1 listener, got %d", len(sockets))
+			}
+			socket := sockets[0]
+			if socket.Type != types.TrafficTypeIngress || socket.Protocol != tt.protocol || socket.PatternMatch != "http.Server" {
+				t.Errorf("Expected %s ingress from http.Server, got %s %s from %s", tt.protocol, socket.Protocol, socket.Type, socket.PatternMatch)
+			}
+			if !socket.IsResolved || socket.Listen == nil || *socket.Listen.Port != tt.port || socket.RawValue != tt.raw {
+				t.Errorf("Expected port %d from %q, got %+v from %q", tt.port, tt.raw, socket.Listen, socket.RawValue)
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_HTTPServerNotListening(t *testing.T) {
+	for name, code := range map[string]string{
+		"Serve on a listener": `l, _ := net.Listen("tcp", ":8080")
+	srv := &http.Server{Addr: ":9999"}
+	srv.Serve(l)`,
+		"no address": `srv := &http.Server{Handler: mux}
+	_ = srv`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, socket := range matchAll(t, "package main\nimport (\n\t\"net\"\n\t\"net/http\"\n)\nfunc run() {\n\t"+code+"\n}") {
+				if socket.PatternMatch == "http.Server" {
+					t.Errorf("Expected no http.Server listener, got %+v", socket)
+				}
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_HTTPServerUnresolved(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "net/http"
+func run(cfg Config) {
+	srv := &http.Server{Addr: cfg.Listen}
+	srv.ListenAndServe()
+}`)
+	if len(sockets) != 1 || sockets[0].IsResolved || sockets[0].RawValue != "cfg.Listen" {
+		t.Errorf("Expected an unresolved listener on cfg.Listen, got %+v", sockets)
+	}
+}
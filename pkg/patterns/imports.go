@@ -0,0 +1,242 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"strings"
+)
+
+// methodPatterns maps methods to the positional pattern of the package
+// function they stand in for, e.g. a call on an *http.Client to http.Get,
+// or to the pattern of the method itself, such as a websocket.Dialer's
+// Dial. Recognizing them takes type information, except on the receivers
+// in untypedReceivers.
+var methodPatterns = map[string]string{
+	"(*net/http.Client).Get":      "http.Get",
+	"(*net/http.Client).Post":     "http.Post",
+	"(*net/http.Client).PostForm": "http.PostForm",
+	"(*net.Dialer).Dial":          "net.Dial",
+
+	"(*github.com/gorilla/websocket.Dialer).Dial":        "websocket.Dialer.Dial",
+	"(*github.com/gorilla/websocket.Dialer).DialContext": "websocket.Dialer.DialContext",
+	"(*github.com/gorilla/websocket.Upgrader).Upgrade":   "websocket.Upgrader.Upgrade",
+}
+
+// untypedReceivers are the types whose methods match without type
+// information when the file shows the receiver's type, see receiverType.
+// gorilla/websocket dials and upgrades only through methods, where the
+// standard library has package functions for the same calls.
+var untypedReceivers = map[string]bool{
+	"github.com/gorilla/websocket.Dialer":   true,
+	"github.com/gorilla/websocket.Upgrader": true,
+}
+
+// packageVariables maps package variables to their type, for
+// receiverType.
+var packageVariables = map[string]string{
+	"github.com/gorilla/websocket.DefaultDialer": "github.com/gorilla/websocket.Dialer",
+}
+
+// AddImportAlias makes files importing fork match the patterns of
+// original, for forks of a package such as net/http published under a
+// different module path.
+func (pm *PatternMatcher) AddImportAlias(fork, original string) {
+	pm.importAliases[fork] = original
+}
+
+// positionalName returns the positional pattern a call refers to. Patterns
+// are keyed by import path and function, so when the file imports the
+// call's qualifier, it is the import path that decides: a local package
+// named http does not match the net/http patterns, and a configured fork
+// does under whatever name it is imported. A qualifier declared in the
+// file, such as a local variable named http, is never a package; other
+// qualifiers the file does not import are taken at face value. With type
+// information for file, the callee decides instead.
+func (pm *PatternMatcher) positionalName(callExpr *ast.CallExpr, file *ast.File) string {
+	if name, ok := pm.typedName(callExpr, file); ok {
+		return name
+	}
+	if sel, ok := callExpr.Fun.(*ast.SelectorExpr); ok {
+		if typeName := pm.receiverType(sel.X, file, 0); untypedReceivers[typeName] {
+			return methodPattern(typeName, sel.Sel.Name)
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj != nil {
+			return ""
+		}
+	}
+	funcName := pm.extractFunctionName(callExpr)
+	qualifier, function, ok := strings.Cut(funcName, ".")
+	if !ok {
+		return funcName
+	}
+	path, imported := importedPath(file, qualifier)
+	if !imported {
+		return funcName
+	}
+	if original, ok := pm.importAliases[path]; ok {
+		path = original
+	}
+
+	for patternQualifier, patternPath := range pm.packagePaths {
+		if patternPath == path {
+			return patternQualifier + "." + function
+		}
+	}
+	if _, ok := pm.packagePaths[qualifier]; ok {
+		// Same name as a pattern package, but a different import path.
+		return ""
+	}
+	return funcName
+}
+
+// SetTypesInfo registers the type information of file, as loaded by
+// go/packages, so that calls in it are matched by their callee: dot and
+// renamed imports of net and net/http match, and so do methods standing in
+// for a pattern, such as Get on an *http.Client. A nil info forgets file.
+func (pm *PatternMatcher) SetTypesInfo(file *ast.File, info *gotypes.Info) {
+	if info == nil {
+		pm.typesInfo.Delete(file)
+		return
+	}
+	pm.typesInfo.Store(file, info)
+}
+
+// typedName is positionalName from type information, reporting false if
+// there is none for the call.
+func (pm *PatternMatcher) typedName(callExpr *ast.CallExpr, file *ast.File) (string, bool) {
+	value, ok := pm.typesInfo.Load(file)
+	if !ok {
+		return "", false
+	}
+	var ident *ast.Ident
+	switch fun := ast.Unparen(callExpr.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return "", false
+	}
+	object, ok := value.(*gotypes.Info).Uses[ident]
+	if !ok {
+		// Left unchecked, e.g. after a type error
+		return "", false
+	}
+	fn, ok := object.(*gotypes.Func)
+	if !ok || fn.Pkg() == nil {
+		return "", true
+	}
+	if fn.Type().(*gotypes.Signature).Recv() != nil {
+		return methodPatterns[fn.FullName()], true
+	}
+
+	path := fn.Pkg().Path()
+	if original, ok := pm.importAliases[path]; ok {
+		path = original
+	}
+	for patternQualifier, patternPath := range pm.packagePaths {
+		if patternPath == path {
+			return patternQualifier + "." + fn.Name(), true
+		}
+	}
+	if _, ok := pm.packagePaths[fn.Pkg().Name()]; ok {
+		// Same name as a pattern package, but a different import path.
+		return "", true
+	}
+	return fn.Pkg().Name() + "." + fn.Name(), true
+}
+
+// receiverType returns the type of a method's receiver as far as file
+// shows it, such as "github.com/gorilla/websocket.Dialer": a literal of a
+// package type or its address, a variable listed in packageVariables, or
+// a variable declared with one of those or with the type.
+func (pm *PatternMatcher) receiverType(expr ast.Expr, file *ast.File, depth int) string {
+	if depth > 4 {
+		return ""
+	}
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return pm.receiverType(e.X, file, depth+1)
+		}
+	case *ast.StarExpr:
+		return pm.receiverType(e.X, file, depth+1)
+	case *ast.CompositeLit:
+		return pm.receiverType(e.Type, file, depth+1)
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok || pkg.Obj != nil {
+			return ""
+		}
+		path, imported := importedPath(file, pkg.Name)
+		if !imported {
+			return ""
+		}
+		if original, ok := pm.importAliases[path]; ok {
+			path = original
+		}
+		name := path + "." + e.Sel.Name
+		if typeName, ok := packageVariables[name]; ok {
+			return typeName
+		}
+		return name
+	case *ast.Ident:
+		if e.Obj == nil {
+			return ""
+		}
+		switch decl := e.Obj.Decl.(type) {
+		case *ast.ValueSpec:
+			if decl.Type != nil {
+				return pm.receiverType(decl.Type, file, depth+1)
+			}
+			for i, name := range decl.Names {
+				if name.Name == e.Name && i < len(decl.Values) {
+					return pm.receiverType(decl.Values[i], file, depth+1)
+				}
+			}
+		case *ast.AssignStmt:
+			if len(decl.Lhs) != len(decl.Rhs) {
+				return ""
+			}
+			for i, lhs := range decl.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok && ident.Name == e.Name {
+					return pm.receiverType(decl.Rhs[i], file, depth+1)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// methodPattern returns the pattern method stands in for on typeName.
+func methodPattern(typeName, method string) string {
+	if name, ok := methodPatterns["(*"+typeName+")."+method]; ok {
+		return name
+	}
+	return methodPatterns["("+typeName+")."+method]
+}
+
+// importedPath returns the import path file binds to name.
+func importedPath(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		if importName(spec) == name {
+			return strings.Trim(spec.Path.Value, `"`), true
+		}
+	}
+	return "", false
+}
+
+// importName returns the local name an import binds, assuming the package
+// is named after the last path element without a major version suffix.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	elems := strings.Split(strings.Trim(spec.Path.Value, `"`), "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersionSuffix(name) {
+		name = elems[len(elems)-2]
+	}
+	return name
+}
@@ -0,0 +1,147 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"reflect"
+	"testing"
+)
+
+func matchCalls(t *testing.T, pm *PatternMatcher, code string) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matched []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if socket := pm.MatchSocketPattern(call, file); socket != nil {
+				matched = append(matched, socket.PatternMatch)
+			}
+		}
+		return true
+	})
+	return matched
+}
+
+func TestPatternMatcher_ImportPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "standard library",
+			code: "package main\nimport \"net/http\"\nfunc main() { http.ListenAndServe(\":8080\", nil) }",
+			want: []string{"http.ListenAndServe"},
+		},
+		{
+			name: "renamed import",
+			code: "package main\nimport stdhttp \"net/http\"\nfunc main() { stdhttp.ListenAndServe(\":8080\", nil) }",
+			want: []string{"http.ListenAndServe"},
+		},
+		{
+			name: "local package with the same name",
+			code: "package main\nimport \"example.com/app/http\"\nfunc main() { http.ListenAndServe(\":8080\", nil) }",
+		},
+		{
+			name: "versioned local package with the same name",
+			code: "package main\nimport \"example.com/net/v2\"\nfunc main() { net.Dial(\"tcp\", \"db:5432\") }",
+		},
+		{
+			name: "local variable with the same name",
+			code: "package main\nfunc main() {\n\thttp := fake{}\n\thttp.ListenAndServe(\":8080\", nil)\n}",
+		},
+		{
+			name: "qualifier not imported",
+			code: "package main\nfunc main() { net.Dial(\"tcp\", \"db:5432\") }",
+			want: []string{"net.Dial"},
+		},
+	}
+
+	pm := NewPatternMatcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchCalls(t, pm, tt.code)
+			if len(got) != len(tt.want) || (len(got) == 1 && got[0] != tt.want[0]) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_AddImportAlias(t *testing.T) {
+	code := "package main\nimport fasthttp \"github.com/acme/http\"\nfunc main() { fasthttp.ListenAndServe(\":8080\", nil) }"
+
+	pm := NewPatternMatcher()
+	if got := matchCalls(t, pm, code); len(got) != 0 {
+		t.Fatalf("Expected no match before aliasing, got %v", got)
+	}
+	fingerprint := pm.Fingerprint()
+
+	pm.AddImportAlias("github.com/acme/http", "net/http")
+	got := matchCalls(t, pm, code)
+	if len(got) != 1 || got[0] != "http.ListenAndServe" {
+		t.Errorf("Expected the fork to match http.ListenAndServe, got %v", got)
+	}
+	if pm.Fingerprint() == fingerprint {
+		t.Error("Expected the fingerprint to change with an import alias")
+	}
+}
+
+func TestPatternMatcher_TypesInfo(t *testing.T) {
+	code := `package main
+
+import (
+	. "net"
+	"net/http"
+)
+
+func main() {
+	Dial("tcp", "db:5432")
+	client := &http.Client{}
+	client.Get("http://api.example.com")
+	var d Dialer
+	d.Dial("tcp", "cache:6379")
+	http := struct{ Get func(string) }{}
+	http.Get("http://not.example.com")
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &gotypes.Info{Uses: make(map[*ast.Ident]gotypes.Object)}
+	config := gotypes.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("main", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewPatternMatcher()
+	if got := matchTypedCalls(pm, file); len(got) != 0 {
+		t.Errorf("Expected nothing matched without types, got %v", got)
+	}
+	pm.SetTypesInfo(file, info)
+	want := []string{"net.Dial", "http.Get", "net.Dial"}
+	if got := matchTypedCalls(pm, file); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func matchTypedCalls(pm *PatternMatcher, file *ast.File) []string {
+	var matched []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if socket := pm.MatchSocketPattern(call, file); socket != nil {
+				matched = append(matched, socket.PatternMatch)
+			}
+		}
+		return true
+	})
+	return matched
+}
@@ -0,0 +1,178 @@
+package patterns
+
+import (
+	"go/ast"
+	"net/url"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for control-plane dependencies on the Kubernetes API server.
+const (
+	TagKubernetes = "kubernetes"
+	TagAPIServer  = "api-server"
+)
+
+var (
+	restImports          = []string{"k8s.io/client-go/rest"}
+	clientcmdImports     = []string{"k8s.io/client-go/tools/clientcmd"}
+	controllerRuntime    = []string{"sigs.k8s.io/controller-runtime"}
+	crConfigImports      = []string{"sigs.k8s.io/controller-runtime/pkg/client/config"}
+	kubeClientsetImports = []string{"k8s.io/client-go/kubernetes", "k8s.io/client-go/dynamic"}
+	crClientImports      = []string{"sigs.k8s.io/controller-runtime/pkg/client"}
+)
+
+// kubeConfigLoaders determine where the API server is: in-cluster service
+// environment, a kubeconfig file, or an explicit master URL.
+var kubeConfigLoaders = map[string][]string{
+	"rest.InClusterConfig":                                   restImports,
+	"clientcmd.BuildConfigFromFlags":                         clientcmdImports,
+	"clientcmd.RESTConfigFromKubeConfig":                     clientcmdImports,
+	"clientcmd.NewNonInteractiveDeferredLoadingClientConfig": clientcmdImports,
+	"ctrl.GetConfig":                                         controllerRuntime,
+	"ctrl.GetConfigOrDie":                                    controllerRuntime,
+	"config.GetConfig":                                       crConfigImports,
+	"config.GetConfigOrDie":                                  crConfigImports,
+}
+
+// kubeClientConstructors build clients from a *rest.Config obtained elsewhere.
+var kubeClientConstructors = map[string][]string{
+	"kubernetes.NewForConfig":      kubeClientsetImports,
+	"kubernetes.NewForConfigOrDie": kubeClientsetImports,
+	"dynamic.NewForConfig":         kubeClientsetImports,
+	"dynamic.NewForConfigOrDie":    kubeClientsetImports,
+	"client.New":                   crClientImports,
+}
+
+var kubernetesRules = ruleFamily{
+	name:        "Kubernetes API clients",
+	code:        1600,
+	description: "Egress to the Kubernetes API server, where a client-go or controller-runtime config is loaded: in-cluster, from a kubeconfig, or from an explicit host; and clients built from a config obtained elsewhere.",
+	rationale:   "A workload talking to the API server needs a network path to it and RBAC permissions; both are easy to overlook because the address is not in the code.",
+	example: `config, err := rest.InClusterConfig()
+clientset, err := kubernetes.NewForConfig(config)`,
+	rules: []string{
+		"rest.InClusterConfig", "clientcmd.BuildConfigFromFlags", "clientcmd.RESTConfigFromKubeConfig",
+		"clientcmd.NewNonInteractiveDeferredLoadingClientConfig",
+		"ctrl.GetConfig", "ctrl.GetConfigOrDie", "config.GetConfig", "config.GetConfigOrDie",
+		"kubernetes.NewForConfig", "kubernetes.NewForConfigOrDie",
+		"dynamic.NewForConfig", "dynamic.NewForConfigOrDie", "client.New",
+		"rest.Config",
+	},
+}
+
+func (pm *PatternMatcher) initializeKubernetesPatterns() {
+	for name, imports := range kubeConfigLoaders {
+		pm.callMatchers[name] = matchKubeConfigLoader
+		pm.requiredImports[name] = imports
+	}
+	for name, imports := range kubeClientConstructors {
+		pm.callMatchers[name] = matchKubeClientConstructor
+		pm.requiredImports[name] = imports
+	}
+
+	pm.literalMatchers["rest.Config"] = matchRestConfigLiteral
+	pm.requiredImports["rest.Config"] = restImports
+}
+
+func newAPIServerSocket(funcName, rawValue string) *types.SocketInfo {
+	return &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     types.ProtocolHTTPS,
+		RawValue:     rawValue,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+		Tags:         []string{TagKubernetes, TagAPIServer},
+	}
+}
+
+// matchKubeConfigLoader reports API server egress where the client config
+// is loaded. The host is only known when a literal master URL is given.
+func matchKubeConfigLoader(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	switch funcName {
+	case "rest.InClusterConfig":
+		return newAPIServerSocket(funcName, "in-cluster")
+	case "clientcmd.BuildConfigFromFlags":
+		socket := newAPIServerSocket(funcName, "kubeconfig")
+		if len(callExpr.Args) > 0 {
+			if master := pm.extractStringLiteral(callExpr.Args[0]); master != "" {
+				parseAPIServerURL(socket, master)
+			}
+		}
+		return socket
+	default:
+		return newAPIServerSocket(funcName, "kubeconfig")
+	}
+}
+
+// matchKubeClientConstructor reports API server egress at client
+// construction, unless the file also loads the config: in that case the
+// loader already represents this connection.
+func matchKubeClientConstructor(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	if pm.loadsKubeConfig(file) {
+		return nil
+	}
+	return newAPIServerSocket(funcName, "rest.Config")
+}
+
+func (pm *PatternMatcher) loadsKubeConfig(file *ast.File) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			name := pm.extractFunctionName(node)
+			if _, ok := kubeConfigLoaders[name]; ok && pm.importsRequired(name, file) {
+				found = true
+			}
+		case *ast.CompositeLit:
+			if sel, ok := node.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Config" {
+				if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "rest" && pm.importsRequired("rest.Config", file) {
+					found = true
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// matchRestConfigLiteral handles &rest.Config{Host: "https://10.0.0.1:6443"}.
+func matchRestConfigLiteral(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	socket := newAPIServerSocket(typeName, "")
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Host" {
+			if host := pm.extractStringLiteral(kv.Value); host != "" {
+				parseAPIServerURL(socket, host)
+			}
+		}
+	}
+	return []*types.SocketInfo{socket}
+}
+
+func parseAPIServerURL(socket *types.SocketInfo, master string) {
+	socket.RawValue = master
+	parsed, err := url.Parse(master)
+	if err != nil || parsed.Host == "" {
+		return
+	}
+
+	host := parsed.Hostname()
+	port := 443
+	if parsed.Scheme == "http" {
+		socket.Protocol = types.ProtocolHTTP
+		port = 80
+	}
+	if explicit, err := strconv.Atoi(parsed.Port()); err == nil {
+		port = explicit
+	}
+	socket.Destination = types.NewEndpoint(host, &port)
+	socket.IsResolved = true
+}
@@ -0,0 +1,77 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_KubernetesConfigLoaders(t *testing.T) {
+	sockets := matchAll(t, `package main
+import (
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+func main() {
+	cfg, _ := rest.InClusterConfig()
+	external, _ := clientcmd.BuildConfigFromFlags("https://api.cluster.example.com:6443", "")
+	local, _ := clientcmd.BuildConfigFromFlags("", "/home/user/.kube/config")
+	kubernetes.NewForConfig(cfg)
+	_, _ = external, local
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 config loader findings (constructor deduplicated), got %d", len(sockets))
+	}
+
+	for _, socket := range sockets {
+		if socket.Type != types.TrafficTypeEgress || socket.Protocol != types.ProtocolHTTPS {
+			t.Errorf("Expected https egress, got %s %s", socket.Type, socket.Protocol)
+		}
+		if len(socket.Tags) != 2 || socket.Tags[1] != TagAPIServer {
+			t.Errorf("Expected api-server tag, got %v", socket.Tags)
+		}
+	}
+
+	if sockets[0].IsResolved || sockets[0].RawValue != "in-cluster" {
+		t.Errorf("Expected unresolved in-cluster finding, got %+v", sockets[0])
+	}
+	if !sockets[1].IsResolved || sockets[1].Destination.Host != "api.cluster.example.com" || *sockets[1].Destination.Port != 6443 {
+		t.Errorf("Expected resolved master URL, got %+v", sockets[1])
+	}
+	if sockets[2].IsResolved || sockets[2].RawValue != "kubeconfig" {
+		t.Errorf("Expected unresolved kubeconfig finding, got %+v", sockets[2])
+	}
+}
+
+func TestPatternMatcher_KubernetesClientConstructor(t *testing.T) {
+	sockets := matchAll(t, `package controller
+import (
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+func newClient(cfg *rest.Config) *kubernetes.Clientset {
+	return kubernetes.NewForConfigOrDie(cfg)
+}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 client construction finding, got %d", len(sockets))
+	}
+	if sockets[0].PatternMatch != "kubernetes.NewForConfigOrDie" || sockets[0].IsResolved {
+		t.Errorf("Unexpected constructor finding: %+v", sockets[0])
+	}
+}
+
+func TestPatternMatcher_RestConfigLiteral(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "k8s.io/client-go/rest"
+var cfg = &rest.Config{Host: "https://10.0.0.1:6443"}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 rest.Config finding, got %d", len(sockets))
+	}
+	if sockets[0].Destination.Host != "10.0.0.1" || *sockets[0].Destination.Port != 6443 {
+		t.Errorf("Unexpected rest.Config destination: %+v", sockets[0])
+	}
+}
@@ -0,0 +1,322 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// nonConsumers are packages that receive listeners only to print or wrap
+// errors, never to serve on them.
+var nonConsumers = map[string]bool{
+	"fmt":    true,
+	"log":    true,
+	"errors": true,
+}
+
+// consumerProtocols refines the protocol of a listener from the library
+// that serves on it; a listener handed to http.Serve speaks HTTP even
+// though it was created with net.Listen("tcp", ...).
+var consumerProtocols = map[string]types.Protocol{
+	"http.Serve":    types.ProtocolHTTP,
+	"http.ServeTLS": types.ProtocolHTTPS,
+	"grpc.Serve":    types.ProtocolGRPC,
+}
+
+// ListenerUse describes what happens to a listener after it is created.
+type ListenerUse struct {
+	// Consumers are the libraries the listener is handed to.
+	Consumers []string
+	// Facets are the protocols served on a multiplexed (cmux) listener.
+	Facets []types.Protocol
+}
+
+func (u *ListenerUse) addConsumer(consumer string) {
+	for _, existing := range u.Consumers {
+		if existing == consumer {
+			return
+		}
+	}
+	u.Consumers = append(u.Consumers, consumer)
+}
+
+func (u *ListenerUse) addFacet(protocol types.Protocol) {
+	for _, existing := range u.Facets {
+		if existing == protocol {
+			return
+		}
+	}
+	u.Facets = append(u.Facets, protocol)
+}
+
+// cmuxMatcherFacets maps cmux matcher constructors to the protocol the
+// matched sub-listener carries.
+var cmuxMatcherFacets = map[string]types.Protocol{
+	"cmux.HTTP1":                  types.ProtocolHTTP,
+	"cmux.HTTP1Fast":              types.ProtocolHTTP,
+	"cmux.HTTP1HeaderField":       types.ProtocolHTTP,
+	"cmux.HTTP1HeaderFieldPrefix": types.ProtocolHTTP,
+	"cmux.HTTP2":                  types.ProtocolHTTP,
+	"cmux.Any":                    types.ProtocolTCP,
+}
+
+// ListenerConsumers finds listeners that are created locally and then
+// handed to a library (grpcServer.Serve(lis), cmux.New(lis),
+// e.Listener = lis). The result maps the position of each ingress call
+// to how its listener is used. Listeners multiplexed with cmux also get
+// one facet per protocol served on their sub-listeners.
+func (pm *PatternMatcher) ListenerConsumers(file *ast.File) map[token.Pos]*ListenerUse {
+	consumers := make(map[token.Pos]*ListenerUse)
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+		pm.collectListenerConsumers(funcDecl.Body, file, consumers)
+	}
+
+	return consumers
+}
+
+func (pm *PatternMatcher) collectListenerConsumers(body *ast.BlockStmt, file *ast.File, consumers map[token.Pos]*ListenerUse) {
+	imports := importNames(file)
+	origins := make(map[string]string)      // variable -> package that constructed it
+	listeners := make(map[string]token.Pos) // variable -> listen call position
+	muxes := make(map[string]token.Pos)     // cmux variable -> root listen call position
+	subListeners := make(map[string]token.Pos)
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		if call, ok := assign.Rhs[0].(*ast.CallExpr); ok {
+			if _, isIngress := pm.ingressPatterns[pm.positionalName(call, file)]; isIngress {
+				listeners[ident.Name] = call.Pos()
+				return true
+			}
+		}
+		if pkg := constructorPackage(assign.Rhs[0]); pkg != "" {
+			origins[ident.Name] = pkg
+		}
+		return true
+	})
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	use := func(pos token.Pos) *ListenerUse {
+		if consumers[pos] == nil {
+			consumers[pos] = &ListenerUse{}
+		}
+		return consumers[pos]
+	}
+
+	addConsumer := func(listener string, consumer string) {
+		if consumer == "" {
+			return
+		}
+		if pos, ok := listeners[listener]; ok {
+			use(pos).addConsumer(consumer)
+			if consumer == "cmux.New" {
+				muxes[listener] = pos
+			}
+			return
+		}
+		if pos, ok := subListeners[listener]; ok {
+			if protocol, ok := consumerProtocols[consumer]; ok {
+				use(pos).addFacet(protocol)
+			}
+		}
+	}
+
+	pm.collectCmuxSubListeners(body, listeners, muxes, subListeners, use)
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			for _, arg := range node.Args {
+				if ident, ok := arg.(*ast.Ident); ok {
+					addConsumer(ident.Name, consumerName(node.Fun, origins, imports))
+				}
+			}
+		case *ast.AssignStmt:
+			// e.Listener = lis
+			for i, rhs := range node.Rhs {
+				ident, ok := rhs.(*ast.Ident)
+				if !ok || i >= len(node.Lhs) {
+					continue
+				}
+				addConsumer(ident.Name, consumerName(node.Lhs[i], origins, imports))
+			}
+		}
+		return true
+	})
+}
+
+// collectCmuxSubListeners records m := cmux.New(lis) muxes and the
+// sub-listeners split off them with m.Match(...), taking a facet from
+// each matcher that identifies its protocol.
+func (pm *PatternMatcher) collectCmuxSubListeners(body *ast.BlockStmt, listeners, muxes, subListeners map[string]token.Pos,
+	use func(token.Pos) *ListenerUse) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 || len(assign.Lhs) != 1 {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if pm.extractFunctionName(call) == "cmux.New" && len(call.Args) == 1 {
+			if arg, ok := call.Args[0].(*ast.Ident); ok {
+				if pos, ok := listeners[arg.Name]; ok {
+					muxes[ident.Name] = pos
+				}
+			}
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "Match" && sel.Sel.Name != "MatchWithWriters") {
+			return true
+		}
+		mux, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		pos, ok := muxes[mux.Name]
+		if !ok {
+			return true
+		}
+
+		subListeners[ident.Name] = pos
+		for _, arg := range call.Args {
+			if protocol, ok := pm.cmuxMatcherFacet(arg); ok {
+				use(pos).addFacet(protocol)
+			}
+		}
+		return true
+	})
+}
+
+func (pm *PatternMatcher) cmuxMatcherFacet(expr ast.Expr) (types.Protocol, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	name := pm.extractFunctionName(call)
+	for _, arg := range call.Args {
+		if strings.HasPrefix(pm.extractStringLiteral(arg), "application/grpc") {
+			return types.ProtocolGRPC, true
+		}
+	}
+	protocol, ok := cmuxMatcherFacets[name]
+	return protocol, ok
+}
+
+// consumerName names the library behind a call target or field such as
+// grpcServer.Serve, where grpcServer was built by grpc.NewServer().
+func consumerName(expr ast.Expr, origins map[string]string, imports map[string]bool) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+
+	owner := ident.Name
+	if pkg, ok := origins[ident.Name]; ok {
+		owner = pkg
+	} else if !imports[ident.Name] {
+		return ""
+	}
+
+	if nonConsumers[owner] {
+		return ""
+	}
+	return owner + "." + sel.Sel.Name
+}
+
+// constructorPackage returns the package of pkg.New(...), &pkg.T{} or pkg.T{}.
+func constructorPackage(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+
+	var fun ast.Expr
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		fun = e.Fun
+	case *ast.CompositeLit:
+		fun = e.Type
+	default:
+		return ""
+	}
+
+	if sel, ok := fun.(*ast.SelectorExpr); ok {
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			return ident.Name
+		}
+	}
+	return ""
+}
+
+// importNames returns the local names under which packages are imported.
+func importNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, spec := range file.Imports {
+		names[importName(spec)] = true
+	}
+	return names
+}
+
+// ApplyListenerUse annotates a listener finding with the libraries serving
+// on it and, where the library implies one, the application protocol.
+// Multiplexed listeners keep their transport protocol and list the
+// protocols they serve as facets instead.
+func ApplyListenerUse(socket *types.SocketInfo, use *ListenerUse) {
+	if use == nil {
+		return
+	}
+	socket.ConsumedBy = append(socket.ConsumedBy, use.Consumers...)
+	socket.Facets = append(socket.Facets, use.Facets...)
+	if len(use.Facets) > 0 {
+		return
+	}
+	for _, consumer := range use.Consumers {
+		if protocol, ok := consumerProtocols[consumer]; ok {
+			socket.Protocol = protocol
+		}
+	}
+}
+
+// isMajorVersionSuffix reports whether elem is a module major version
+// suffix such as "v2", which is not part of the package name.
+func isMajorVersionSuffix(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_ListenerConsumers(t *testing.T) {
+	code := `package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+)
+
+func serveGRPC() {
+	lis, err := net.Listen("tcp", ":9000")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lis.Close()
+	fmt.Println(lis)
+	s := grpc.NewServer()
+	s.Serve(lis)
+}
+
+func serveMux() {
+	lis, _ := net.Listen("tcp", ":9001")
+	m := cmux.New(lis)
+	_ = m
+}
+
+func serveEcho() {
+	lis, _ := net.Listen("tcp", ":9002")
+	e := echo.New()
+	e.Listener = lis
+}
+
+func serveHTTP() {
+	lis, _ := net.Listen("tcp", ":9003")
+	http.Serve(lis, nil)
+}
+
+func bare() {
+	lis, _ := net.Listen("tcp", ":9004")
+	lis.Accept()
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	pm := NewPatternMatcher()
+	consumers := pm.ListenerConsumers(file)
+
+	byPort := make(map[string][]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if socket := pm.MatchSocketPattern(call, file); socket != nil {
+				if use := consumers[call.Pos()]; use != nil {
+					byPort[socket.RawValue] = use.Consumers
+				}
+			}
+		}
+		return true
+	})
+
+	expected := map[string][]string{
+		":9000": {"grpc.Serve"},
+		":9001": {"cmux.New"},
+		":9002": {"echo.Listener"},
+		":9003": {"http.Serve"},
+		":9004": nil,
+	}
+	for port, want := range expected {
+		if got := byPort[port]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Listener %s: expected consumers %v, got %v", port, want, got)
+		}
+	}
+}
+
+func TestPatternMatcher_CmuxFacets(t *testing.T) {
+	code := `package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	lis, _ := net.Listen("tcp", ":8080")
+	m := cmux.New(lis)
+	grpcL := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+	httpL := m.Match(cmux.HTTP1Fast())
+	restL := m.Match(cmux.Any())
+
+	grpcS := grpc.NewServer()
+	go grpcS.Serve(grpcL)
+	go http.Serve(httpL, nil)
+	go http.Serve(restL, nil)
+	m.Serve()
+}
+`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	consumers := NewPatternMatcher().ListenerConsumers(file)
+	if len(consumers) != 1 {
+		t.Fatalf("Expected one multiplexed listener, got %d", len(consumers))
+	}
+
+	for _, use := range consumers {
+		wantFacets := []types.Protocol{types.ProtocolGRPC, types.ProtocolHTTP, types.ProtocolTCP}
+		if !reflect.DeepEqual(use.Facets, wantFacets) {
+			t.Errorf("Expected facets %v, got %v", wantFacets, use.Facets)
+		}
+		if !reflect.DeepEqual(use.Consumers, []string{"cmux.New"}) {
+			t.Errorf("Expected cmux.New consumer, got %v", use.Consumers)
+		}
+
+		socket := &types.SocketInfo{Protocol: types.ProtocolTCP}
+		ApplyListenerUse(socket, use)
+		if socket.Protocol != types.ProtocolTCP {
+			t.Errorf("Multiplexed listener should keep its transport protocol, got %s", socket.Protocol)
+		}
+	}
+}
+
+func TestApplyListenerUse(t *testing.T) {
+	socket := &types.SocketInfo{Protocol: types.ProtocolTCP}
+	ApplyListenerUse(socket, &ListenerUse{Consumers: []string{"grpc.Serve"}})
+
+	if socket.Protocol != types.ProtocolGRPC {
+		t.Errorf("Expected protocol grpc, got %s", socket.Protocol)
+	}
+	if !reflect.DeepEqual(socket.ConsumedBy, []string{"grpc.Serve"}) {
+		t.Errorf("Unexpected ConsumedBy: %v", socket.ConsumedBy)
+	}
+
+	unknown := &types.SocketInfo{Protocol: types.ProtocolTCP}
+	ApplyListenerUse(unknown, &ListenerUse{Consumers: []string{"cmux.New"}})
+	if unknown.Protocol != types.ProtocolTCP {
+		t.Errorf("Expected protocol to stay tcp, got %s", unknown.Protocol)
+	}
+}
@@ -0,0 +1,192 @@
+package patterns
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/internal/resolver"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Finding is a socket detected at Pos. SourceFile, SourceLine and
+// ProcessName are left for the caller, which owns the file set.
+type Finding struct {
+	Socket *types.SocketInfo
+	Pos    token.Pos
+	// End is just past the matched node, such as the closing parenthesis
+	// of a call, when the finding covers one.
+	End token.Pos
+	// CallSite is the call passing the address into the helper the
+	// finding is in, when it was resolved from there.
+	CallSite token.Pos
+
+	// call is the matched call while its address is still to be resolved.
+	call *ast.CallExpr
+}
+
+// Match reports the findings for a single node of file: a call to a known
+// socket API (with its address resolved where possible), a composite
+// literal of a known configuration type, or an assignment to a known
+// configuration field. Other nodes yield nothing.
+//
+// Listener hand-off and cgo preambles are properties of the whole file;
+// use MatchFile to include them.
+func (pm *PatternMatcher) Match(node ast.Node, file *ast.File) []Finding {
+	findings := pm.MatchUnresolved(node, file)
+	for i := range findings {
+		pm.Resolve(&findings[i], file)
+	}
+	return findings
+}
+
+// MatchUnresolved is Match without address resolution, for callers that
+// resolve in a separate pass. Pass each finding to Resolve afterwards.
+func (pm *PatternMatcher) MatchUnresolved(node ast.Node, file *ast.File) []Finding {
+	findings := pm.enabledFindings(pm.matchUnresolved(node, file))
+	for i := range findings {
+		if findings[i].Pos == node.Pos() && !findings[i].End.IsValid() {
+			findings[i].End = node.End()
+		}
+	}
+	return findings
+}
+
+func (pm *PatternMatcher) matchUnresolved(node ast.Node, file *ast.File) []Finding {
+	switch n := node.(type) {
+	case *ast.CallExpr:
+		socket := pm.MatchSocketPattern(n, file)
+		if socket == nil {
+			return findingsAt(pm.MatchCallList(n, file), n.Pos())
+		}
+		finding := Finding{Socket: socket, Pos: n.Pos()}
+		if pm.HasAddressArgument(socket.PatternMatch) {
+			finding.call = n
+		}
+		return []Finding{finding}
+	case *ast.CompositeLit:
+		if elided := pm.matchElidedElements(n, file); elided != nil {
+			return elided
+		}
+		return findingsAt(pm.MatchCompositeLiteral(n, file), n.Pos())
+	case *ast.AssignStmt:
+		return findingsAt(pm.MatchFieldAssignment(n, file), n.Pos())
+	}
+	return nil
+}
+
+// Resolve fills in the address of a finding from MatchUnresolved and tags
+// calls to health endpoints. It does nothing for findings that are already
+// resolved.
+func (pm *PatternMatcher) Resolve(finding *Finding, file *ast.File) {
+	pm.resolve(pm.resolver, finding, file, nil)
+}
+
+// ResolveContext is Resolve giving up once ctx is done, leaving the
+// address unresolved, or resolved only as far as it got.
+func (pm *PatternMatcher) ResolveContext(ctx context.Context, finding *Finding, file *ast.File) {
+	pm.resolve(pm.resolver.WithContext(ctx), finding, file, nil)
+}
+
+// resolve is Resolve with valueResolver recording the strategies tried in
+// trace.
+func (pm *PatternMatcher) resolve(valueResolver *resolver.ValueResolver, finding *Finding, file *ast.File, trace *resolver.Trace) {
+	if finding.call == nil {
+		return
+	}
+	if arg := pm.addressArgument(finding.Socket.PatternMatch, finding.call); arg != nil {
+		finding.CallSite = valueResolver.ResolveArgumentTraced(finding.Socket, arg, file, trace)
+		switch {
+		case !finding.Socket.IsResolved:
+		case pm.egressPatterns[finding.Socket.PatternMatch].URL && strings.Contains(finding.Socket.RawValue, "://"):
+			// Only a value that is a URL is parsed as one; a guess made
+			// from a variable name is kept as the resolver made it
+			pm.parseEgressURL(finding.Socket, finding.Socket.RawValue)
+		case finding.Socket.Protocol == types.ProtocolGRPC:
+			pm.parseGRPCTarget(finding.Socket, finding.Socket.RawValue)
+		}
+	}
+	tagHealthcheck(finding.Socket, finding.call)
+	finding.call = nil
+}
+
+// SetPackageDir registers the directory file was read from, so that its
+// addresses resolve from constants and package-level variables declared
+// in other files of its package, or in packages of its module it imports,
+// such as config.DefaultAddr. An empty dir forgets file.
+func (pm *PatternMatcher) SetPackageDir(file *ast.File, dir string) {
+	pm.resolver.SetPackageDir(file, dir)
+}
+
+// ForgetPackages drops the declarations read from other files, which may
+// have changed since.
+func (pm *PatternMatcher) ForgetPackages() {
+	pm.resolver.ForgetPackages()
+}
+
+// NeedsResolve reports whether Resolve still has to resolve the finding's
+// address.
+func (f *Finding) NeedsResolve() bool {
+	return f.call != nil
+}
+
+// MatchFile reports every finding in file in source order, with the
+// enclosing function, listener consumers, fallback ports, TLS verification,
+// shutdown paths and socket options applied, followed by findings from the cgo preamble
+// and from staticsocket:declare comments.
+func (pm *PatternMatcher) MatchFile(file *ast.File) []Finding {
+	consumers := pm.ListenerConsumers(file)
+	fallbacks := pm.FallbackPorts(file)
+	verification := pm.TLSVerification(file)
+	shutdowns := pm.ShutdownPaths(file)
+	options := pm.SocketOptions(file)
+
+	var findings []Finding
+	var scope FunctionScope
+	ast.Inspect(file, func(n ast.Node) bool {
+		scope.Visit(n)
+		for _, finding := range pm.Match(n, file) {
+			scope.Apply(finding.Socket)
+			ApplyListenerUse(finding.Socket, consumers[finding.Pos])
+			ApplyFallbackPorts(finding.Socket, fallbacks[finding.Pos])
+			ApplyTLSVerification(finding.Socket, verification)
+			ApplyShutdown(finding.Socket, shutdowns[finding.Pos])
+			ApplySocketOptions(finding.Socket, options[finding.Pos])
+			findings = append(findings, finding)
+		}
+		return true
+	})
+
+	findings = append(findings, pm.MatchCgoPreamble(file)...)
+	return append(findings, pm.MatchDeclarations(file)...)
+}
+
+// matchElidedElements matches the elements of a slice literal such as
+// []webrtc.ICEServer{{URLs: ...}}, which leave out their type, as literals
+// of the slice's element type.
+func (pm *PatternMatcher) matchElidedElements(lit *ast.CompositeLit, file *ast.File) []Finding {
+	array, ok := lit.Type.(*ast.ArrayType)
+	if !ok {
+		return nil
+	}
+	var findings []Finding
+	for _, elt := range lit.Elts {
+		element, ok := elt.(*ast.CompositeLit)
+		if !ok || element.Type != nil {
+			continue
+		}
+		typed := *element
+		typed.Type = array.Elt
+		findings = append(findings, findingsAt(pm.MatchCompositeLiteral(&typed, file), element.Pos())...)
+	}
+	return findings
+}
+
+func findingsAt(sockets []*types.SocketInfo, pos token.Pos) []Finding {
+	findings := make([]Finding, 0, len(sockets))
+	for _, socket := range sockets {
+		findings = append(findings, Finding{Socket: socket, Pos: pos})
+	}
+	return findings
+}
@@ -0,0 +1,129 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_Match(t *testing.T) {
+	code := `package main
+import (
+	"net"
+	"net/http"
+)
+const addr = "db.internal:5432"
+func main() {
+	net.Dial("tcp", addr)
+	fmt.Println("not a socket")
+}`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewPatternMatcher()
+	var findings []Finding
+	ast.Inspect(file, func(n ast.Node) bool {
+		findings = append(findings, pm.Match(n, file)...)
+		return true
+	})
+
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	socket := findings[0].Socket
+	if !socket.IsResolved || socket.Destination.Host != "db.internal" || *socket.Destination.Port != 5432 {
+		t.Errorf("Expected Match to resolve the constant address, got %+v", socket)
+	}
+	if line := fset.Position(findings[0].Pos).Line; line != 8 {
+		t.Errorf("Expected finding on line 8, got %d", line)
+	}
+}
+
+func TestPatternMatcher_MatchFile(t *testing.T) {
+	code := `package main
+import (
+	"net"
+	"net/http"
+)
+func main() {
+	lis, _ := net.Listen("tcp", ":8080")
+	http.Serve(lis, nil)
+}`
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := NewPatternMatcher().MatchFile(file)
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	socket := findings[0].Socket
+	if socket.Protocol != types.ProtocolHTTP || len(socket.ConsumedBy) != 1 {
+		t.Errorf("Expected MatchFile to apply the listener consumer, got %+v", socket)
+	}
+}
+
+func TestPatternMatcher_MatchUnresolved(t *testing.T) {
+	code := `package main
+import "net"
+const addr = "db.internal:5432"
+func main() {
+	net.Dial("tcp", addr)
+}`
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pm := NewPatternMatcher()
+	var findings []Finding
+	ast.Inspect(file, func(n ast.Node) bool {
+		findings = append(findings, pm.MatchUnresolved(n, file)...)
+		return true
+	})
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Socket.IsResolved {
+		t.Fatalf("Expected MatchUnresolved to leave the address alone, got %+v", findings[0].Socket)
+	}
+
+	pm.Resolve(&findings[0], file)
+	pm.Resolve(&findings[0], file)
+	socket := findings[0].Socket
+	if !socket.IsResolved || socket.Destination.Host != "db.internal" || *socket.Destination.Port != 5432 {
+		t.Errorf("Expected Resolve to resolve the constant address, got %+v", socket)
+	}
+}
+
+func TestPatternMatcher_MatchDestinationKind(t *testing.T) {
+	code := `package main
+import "net"
+const backup = "192.168.1.20:5432"
+func main() {
+	net.Dial("tcp", "10.0.0.5:5432")
+	net.Dial("tcp", "db.internal:5432")
+	net.Dial("tcp", backup)
+}`
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := NewPatternMatcher().MatchFile(file)
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(findings))
+	}
+	for i, want := range []types.EndpointKind{types.EndpointKindIP, types.EndpointKindHostname, types.EndpointKindIP} {
+		if got := findings[i].Socket.Destination.Kind; got != want {
+			t.Errorf("Finding %d (%s): expected destination kind %s, got %s", i, findings[i].Socket.RawValue, want, got)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package patterns
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for peer-to-peer networking, where the same process listens for
+// and dials other peers.
+const (
+	TagP2P        = "p2p"
+	TagLibp2p     = "libp2p"
+	TagBitTorrent = "bittorrent"
+)
+
+// torrentDefaultListenPort is where anacrolix/torrent listens unless
+// ClientConfig.ListenPort is set.
+const torrentDefaultListenPort = 42069
+
+var (
+	libp2pImports    = []string{"github.com/libp2p/go-libp2p"}
+	multiaddrImports = []string{"github.com/multiformats/go-multiaddr"}
+	peerImports      = []string{"github.com/libp2p/go-libp2p/core/peer"}
+	torrentImports   = []string{"github.com/anacrolix/torrent"}
+)
+
+// libp2pListenOptions are the host options that decide where it listens.
+var libp2pListenOptions = map[string]bool{
+	"libp2p.ListenAddrStrings": true,
+	"libp2p.ListenAddrs":       true,
+	"libp2p.NoListenAddrs":     true,
+}
+
+var p2pRules = ruleFamily{
+	name:        "Peer-to-peer",
+	code:        2300,
+	description: "libp2p hosts, listening on their listen options or the library defaults; multiaddrs naming a peer, such as bootstrap nodes; and anacrolix/torrent clients.",
+	rationale:   "Peer-to-peer processes listen for and dial arbitrary peers, which most network policies are not written for.",
+	example:     `host, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/4001", "/ip4/0.0.0.0/udp/4001/quic-v1"))`,
+	rules: []string{
+		"libp2p.ListenAddrStrings", "libp2p.ListenAddrs", "libp2p.New",
+		"ma.NewMultiaddr", "ma.StringCast", "multiaddr.NewMultiaddr", "multiaddr.StringCast",
+		"peer.AddrInfoFromString", "torrent.NewClient",
+	},
+}
+
+func (pm *PatternMatcher) initializeP2PPatterns() {
+	for _, name := range []string{"libp2p.ListenAddrStrings", "libp2p.ListenAddrs"} {
+		pm.callListMatchers[name] = matchLibp2pListenAddrs
+		pm.requiredImports[name] = libp2pImports
+	}
+	pm.callMatchers["libp2p.New"] = matchLibp2pHost
+	pm.requiredImports["libp2p.New"] = libp2pImports
+
+	// go-multiaddr is conventionally imported as ma.
+	for _, qualifier := range []string{"ma", "multiaddr"} {
+		for _, function := range []string{"NewMultiaddr", "StringCast"} {
+			name := qualifier + "." + function
+			pm.callMatchers[name] = matchPeerMultiaddr
+			pm.requiredImports[name] = multiaddrImports
+		}
+	}
+	pm.callMatchers["peer.AddrInfoFromString"] = matchPeerMultiaddr
+	pm.requiredImports["peer.AddrInfoFromString"] = peerImports
+
+	pm.callMatchers["torrent.NewClient"] = matchTorrentClient
+	pm.requiredImports["torrent.NewClient"] = torrentImports
+}
+
+func newP2PSocket(trafficType types.TrafficType, funcName, rawValue string, tags ...string) *types.SocketInfo {
+	return &types.SocketInfo{
+		Type:         trafficType,
+		Protocol:     types.ProtocolTCP,
+		RawValue:     rawValue,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+		Tags:         append([]string{TagP2P}, tags...),
+	}
+}
+
+// matchLibp2pListenAddrs reports one listener per multiaddr given to
+// libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/4001", ...) or, wrapped in
+// ma.StringCast or ma.NewMultiaddr, to libp2p.ListenAddrs.
+func matchLibp2pListenAddrs(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for _, arg := range callExpr.Args {
+		if call, ok := arg.(*ast.CallExpr); ok && len(call.Args) == 1 {
+			arg = call.Args[0]
+		}
+		raw, _ := pm.resolveConstant(arg, file)
+		socket := newP2PSocket(types.TrafficTypeIngress, funcName, raw, TagLibp2p)
+		parseMultiaddr(socket, raw)
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// matchLibp2pHost reports the default listeners of a libp2p host created
+// without listen options. Options passed as a slice are matched where the
+// slice is built.
+func matchLibp2pHost(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	if callExpr.Ellipsis.IsValid() {
+		return nil
+	}
+	for _, arg := range callExpr.Args {
+		var option string
+		switch opt := arg.(type) {
+		case *ast.CallExpr:
+			option = pm.extractFunctionName(opt)
+		case *ast.SelectorExpr:
+			if ident, ok := opt.X.(*ast.Ident); ok {
+				option = ident.Name + "." + opt.Sel.Name
+			}
+		}
+		if libp2pListenOptions[option] {
+			return nil
+		}
+	}
+
+	// libp2p listens on all interfaces on ports picked by the OS.
+	port := 0
+	socket := newP2PSocket(types.TrafficTypeIngress, funcName, "default", TagLibp2p)
+	socket.Listen = types.NewEndpoint("0.0.0.0", &port)
+	socket.IsResolved = true
+	return socket
+}
+
+// matchPeerMultiaddr reports a multiaddr naming a peer, which carries a
+// /p2p/ component, as egress to that peer, e.g. a bootstrap node. Listen
+// addresses have no peer ID and are reported by the listen options.
+func matchPeerMultiaddr(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	if len(callExpr.Args) != 1 {
+		return nil
+	}
+	raw, _ := pm.resolveConstant(callExpr.Args[0], file)
+	if !strings.Contains(raw, "/p2p/") && !strings.Contains(raw, "/ipfs/") {
+		return nil
+	}
+	socket := newP2PSocket(types.TrafficTypeEgress, funcName, raw, TagLibp2p)
+	parseMultiaddr(socket, raw)
+	return socket
+}
+
+// parseMultiaddr fills the endpoint from a multiaddr such as
+// "/ip4/0.0.0.0/udp/4001/quic-v1" or "/dns4/boot.example.com/tcp/4001/p2p/Qm...".
+func parseMultiaddr(socket *types.SocketInfo, raw string) {
+	parts := strings.Split(strings.TrimPrefix(raw, "/"), "/")
+	host, port, found := "", 0, false
+	for i := 0; i < len(parts); i++ {
+		switch parts[i] {
+		case "ip4", "ip6", "dns", "dns4", "dns6", "dnsaddr":
+			if i+1 < len(parts) {
+				host = parts[i+1]
+			}
+			i++
+		case "tcp", "udp":
+			if i+1 >= len(parts) {
+				return
+			}
+			value, err := strconv.Atoi(parts[i+1])
+			if err != nil {
+				return
+			}
+			port, found = value, true
+			if parts[i] == "udp" {
+				socket.Protocol = types.ProtocolUDP
+			}
+			i++
+		case "unix":
+			socket.Protocol = types.ProtocolUnix
+			setP2PEndpoint(socket, types.NewPathEndpoint("/"+strings.Join(parts[i+1:], "/")))
+			socket.IsResolved = true
+			return
+		case "p2p", "ipfs", "certhash", "sni":
+			i++
+		}
+		// Other protocols, such as quic-v1, ws or webtransport, take no value.
+	}
+	if host == "" || !found {
+		return
+	}
+	setP2PEndpoint(socket, types.NewEndpoint(host, &port))
+	socket.IsResolved = true
+}
+
+func setP2PEndpoint(socket *types.SocketInfo, endpoint *types.Endpoint) {
+	if socket.Type == types.TrafficTypeIngress {
+		socket.Listen = endpoint
+	} else {
+		socket.Destination = endpoint
+	}
+}
+
+// matchTorrentClient reports the peer listener of an anacrolix/torrent
+// client, on the ListenPort set in the file or the library default.
+func matchTorrentClient(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	port := torrentDefaultListenPort
+	raw := "default"
+	ast.Inspect(file, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		if sel, ok := assign.Lhs[0].(*ast.SelectorExpr); ok && sel.Sel.Name == "ListenPort" {
+			if value, ok := intLiteral(assign.Rhs[0]); ok {
+				port, raw = value, strconv.Itoa(value)
+			}
+		}
+		return true
+	})
+
+	socket := newP2PSocket(types.TrafficTypeIngress, funcName, raw, TagBitTorrent)
+	socket.Listen = types.NewEndpoint("0.0.0.0", &port)
+	socket.IsResolved = true
+	return socket
+}
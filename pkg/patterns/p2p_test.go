@@ -0,0 +1,106 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func matchFile(t *testing.T, code string) []*types.SocketInfo {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+	var sockets []*types.SocketInfo
+	for _, finding := range NewPatternMatcher().MatchFile(file) {
+		sockets = append(sockets, finding.Socket)
+	}
+	return sockets
+}
+
+func TestPatternMatcher_Libp2pListenAddrs(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"github.com/libp2p/go-libp2p"
+	ma "github.com/multiformats/go-multiaddr"
+)
+const quicAddr = "/ip4/0.0.0.0/udp/4001/quic-v1"
+func start() {
+	libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/4001", quicAddr),
+		libp2p.ListenAddrs(ma.StringCast("/ip6/::/tcp/4002/ws")),
+	)
+}`)
+
+	expected := []struct {
+		host     string
+		port     int
+		protocol types.Protocol
+	}{
+		{"0.0.0.0", 4001, types.ProtocolTCP},
+		{"0.0.0.0", 4001, types.ProtocolUDP},
+		{"::", 4002, types.ProtocolTCP},
+	}
+	if len(sockets) != len(expected) {
+		t.Fatalf("Expected %d listeners, got %d", len(expected), len(sockets))
+	}
+	for i, want := range expected {
+		socket := sockets[i]
+		if socket.Type != types.TrafficTypeIngress || socket.Protocol != want.protocol {
+			t.Errorf("Listener %d: expected %s ingress, got %s %s", i, want.protocol, socket.Protocol, socket.Type)
+		}
+		if !socket.IsResolved || socket.Listen.Host != want.host || *socket.Listen.Port != want.port {
+			t.Errorf("Listener %d: expected %s:%d, got %+v", i, want.host, want.port, socket.Listen)
+		}
+		if len(socket.Tags) != 2 || socket.Tags[0] != TagP2P || socket.Tags[1] != TagLibp2p {
+			t.Errorf("Listener %d: expected p2p and libp2p tags, got %v", i, socket.Tags)
+		}
+	}
+}
+
+func TestPatternMatcher_Libp2pDefaultsAndPeers(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+func start() {
+	h, _ := libp2p.New(libp2p.Identity(key))
+	info, _ := peer.AddrInfoFromString("/dns4/boot.example.com/tcp/4001/p2p/QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN")
+	h.Connect(ctx, *info)
+	libp2p.New(libp2p.NoListenAddrs)
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected a default listener and a peer, got %d findings", len(sockets))
+	}
+	listener := sockets[0]
+	if listener.Type != types.TrafficTypeIngress || listener.RawValue != "default" || *listener.Listen.Port != 0 {
+		t.Errorf("Expected default listener on an OS-assigned port, got %+v", listener)
+	}
+	peer := sockets[1]
+	if peer.Type != types.TrafficTypeEgress || !peer.IsResolved || peer.Destination.Host != "boot.example.com" || *peer.Destination.Port != 4001 {
+		t.Errorf("Expected egress to boot.example.com:4001, got %+v", peer.Destination)
+	}
+}
+
+func TestPatternMatcher_TorrentClient(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "github.com/anacrolix/torrent"
+func start() {
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.ListenPort = 6881
+	torrent.NewClient(cfg)
+}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(sockets))
+	}
+	socket := sockets[0]
+	if socket.Type != types.TrafficTypeIngress || *socket.Listen.Port != 6881 || socket.Tags[1] != TagBitTorrent {
+		t.Errorf("Expected bittorrent listener on 6881, got %+v", socket)
+	}
+}
@@ -0,0 +1,23 @@
+package patterns
+
+import "github.com/yuvalk/staticsocket/pkg/types"
+
+var packetRules = ruleFamily{
+	name:        "Packet sockets",
+	code:        1100,
+	description: "Datagram and raw IP listeners opened with net.ListenPacket, net.ListenIP and golang.org/x/net/icmp, with the protocol taken from the network argument: udp, unixgram, or ip4:icmp and other raw IP networks.",
+	rationale:   "Monitoring agents, DNS servers and pingers receive packets rather than accept connections; raw IP and ICMP sockets need elevated privileges, so they matter to an inventory even without a port.",
+	example: `pc, err := net.ListenPacket("udp", ":8125")
+conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")`,
+	rules: []string{"net.ListenPacket", "net.ListenIP", "icmp.ListenPacket"},
+}
+
+func (pm *PatternMatcher) initializePacketPatterns() {
+	pm.ingressPatterns["net.ListenPacket"] = IngressPattern{Protocol: types.ProtocolUDP, AddressArg: 1, Network: true, AnyNetwork: true}
+	pm.ingressPatterns["net.ListenIP"] = IngressPattern{Protocol: types.ProtocolIP, AddressArg: 1, Network: true}
+
+	// icmp.ListenPacket("udp4", "0.0.0.0") opens an unprivileged ICMP
+	// datagram socket, so the network does not change the protocol
+	pm.packagePaths["icmp"] = "golang.org/x/net/icmp"
+	pm.ingressPatterns["icmp.ListenPacket"] = IngressPattern{Protocol: types.ProtocolICMP, AddressArg: 1}
+}
@@ -0,0 +1,64 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_PacketListeners(t *testing.T) {
+	sockets := matchAll(t, `package main
+
+import (
+	"net"
+
+	"golang.org/x/net/icmp"
+)
+
+func main() {
+	net.ListenPacket("udp", ":8125")
+	net.ListenPacket("udp6", "[::]:8126")
+	net.ListenPacket("unixgram", "/var/run/agent.sock")
+	net.ListenPacket("ip4:icmp", "0.0.0.0")
+	net.ListenIP("ip4:gre", nil)
+	net.ListenIP("ip6:58", &net.IPAddr{IP: net.IPv6unspecified})
+	icmp.ListenPacket("udp4", "0.0.0.0")
+	net.Listen("unix", "/tmp/app.sock")
+	net.Dial("udp", "collector:8125")
+	net.Dial("ip4:icmp", "10.0.0.1")
+}
+`)
+
+	expected := []struct {
+		pattern  string
+		protocol types.Protocol
+		endpoint string
+		resolved bool
+	}{
+		{"net.ListenPacket", types.ProtocolUDP, "0.0.0.0:8125", true},
+		{"net.ListenPacket", types.ProtocolUDP, "[::]:8126", true},
+		{"net.ListenPacket", types.ProtocolUnix, "/var/run/agent.sock", true},
+		{"net.ListenPacket", types.ProtocolICMP, "0.0.0.0", true},
+		{"net.ListenIP", types.ProtocolIP, "0.0.0.0", true},
+		{"net.ListenIP", types.ProtocolICMP, "", false},
+		{"icmp.ListenPacket", types.ProtocolICMP, "0.0.0.0", true},
+		{"net.Listen", types.ProtocolUnix, "/tmp/app.sock", true},
+		{"net.Dial", types.ProtocolUDP, "collector:8125", true},
+		{"net.Dial", types.ProtocolICMP, "10.0.0.1", true},
+	}
+	if len(sockets) != len(expected) {
+		t.Fatalf("Expected %d findings, got %d: %+v", len(expected), len(sockets), sockets)
+	}
+	for i, want := range expected {
+		socket := sockets[i]
+		endpoint := socket.Listen
+		if socket.Type == types.TrafficTypeEgress {
+			endpoint = socket.Destination
+		}
+		if socket.PatternMatch != want.pattern || socket.Protocol != want.protocol || endpoint.String() != want.endpoint || socket.IsResolved != want.resolved {
+			t.Errorf("Finding %d: expected %s %s %q (resolved %t), got %s %s %q (resolved %t)", i,
+				want.pattern, want.protocol, want.endpoint, want.resolved,
+				socket.PatternMatch, socket.Protocol, endpoint.String(), socket.IsResolved)
+		}
+	}
+}
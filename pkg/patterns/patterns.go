@@ -0,0 +1,643 @@
+// Package patterns recognizes socket-creating Go APIs in syntax trees. It
+// has no dependency on the directory-walking analyzer: tools that already
+// hold parsed files can call Match per node or MatchFile per file.
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yuvalk/staticsocket/internal/resolver"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+type PatternMatcher struct {
+	ingressPatterns  map[string]IngressPattern
+	egressPatterns   map[string]EgressPattern
+	callMatchers     map[string]callMatcher
+	callListMatchers map[string]callListMatcher
+	literalMatchers  map[string]literalMatcher
+	fieldMatchers    map[string]fieldMatcher
+	resolver         *resolver.ValueResolver
+
+	// packagePaths maps the qualifier of positional pattern names to the
+	// import path it stands for, e.g. "http" to "net/http".
+	packagePaths  map[string]string
+	importAliases map[string]string
+
+	// requiredImports restricts a call or literal matcher to files importing
+	// one of the listed paths, for selectors as generic as client.NewClient.
+	requiredImports map[string][]string
+
+	// typesInfo holds the type information registered with SetTypesInfo,
+	// by file.
+	typesInfo sync.Map
+
+	// disabled holds the names of the rules SetChecks disabled.
+	disabled map[string]bool
+}
+
+// callMatcher handles calls whose endpoint is not a single positional
+// string argument, such as option lists or client constructors.
+type callMatcher func(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo
+
+// callListMatcher handles calls configuring several sockets at once, such
+// as an option listing one listen address per argument.
+type callListMatcher func(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) []*types.SocketInfo
+
+// literalMatcher extracts findings from a composite literal of a known type.
+type literalMatcher func(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo
+
+// fieldMatcher extracts a finding from an assignment to a known
+// configuration field, such as o.MetricsBindAddress = ":8080" in an
+// option func.
+type fieldMatcher func(pm *PatternMatcher, value ast.Expr, file *ast.File, field string) *types.SocketInfo
+
+type IngressPattern struct {
+	Protocol   types.Protocol
+	AddressArg int  // argument index for address
+	PortOnly   bool // true if address is just port (e.g., ":8080")
+	Network    bool // true if the first argument is a network selecting the protocol (e.g. "udp")
+	AnyNetwork bool // true if the network may select any transport, so that a network not known statically leaves the protocol unknown
+}
+
+type EgressPattern struct {
+	Protocol   types.Protocol
+	AddressArg int  // argument index for address
+	URLArg     int  // argument index for URL (for HTTP patterns)
+	URL        bool // true if the destination is the URL at URLArg
+	Request    bool // true if the call builds a request, which is egress only once sent in the same function (see requestSent)
+	Network    bool // true if the first argument is a network selecting the protocol (e.g. "udp")
+	AnyNetwork bool // true if the network may select any transport, so that a network not known statically leaves the protocol unknown
+}
+
+func NewPatternMatcher() *PatternMatcher {
+	pm := &PatternMatcher{
+		ingressPatterns:  make(map[string]IngressPattern),
+		egressPatterns:   make(map[string]EgressPattern),
+		callMatchers:     make(map[string]callMatcher),
+		callListMatchers: make(map[string]callListMatcher),
+		literalMatchers:  make(map[string]literalMatcher),
+		fieldMatchers:    make(map[string]fieldMatcher),
+		resolver:         resolver.New(),
+		requiredImports:  make(map[string][]string),
+		packagePaths:     map[string]string{"net": "net", "http": "net/http"},
+		importAliases:    make(map[string]string),
+	}
+	pm.initializePatterns()
+	return pm
+}
+
+var standardLibraryRules = ruleFamily{
+	name:        "Standard library",
+	code:        1000,
+	description: "Listeners and connections opened directly with net and net/http.",
+	rationale:   "These are the sockets every Go service ends up calling, directly or through a library; each one is a port to expose or a destination to allow.",
+	example: `ln, err := net.Listen("tcp", ":8080")
+resp, err := http.Get("https://api.example.com/v1/status")`,
+	rules: []string{
+		"net.Listen", "net.ListenTCP", "net.ListenUDP", "net.ListenUnix",
+		"http.ListenAndServe", "http.ListenAndServeTLS",
+		"net.Dial", "net.DialTCP", "net.DialUDP", "net.DialTimeout",
+		"http.Get", "http.Post", "http.PostForm",
+		"http.NewRequest", "http.NewRequestWithContext",
+	},
+}
+
+func (pm *PatternMatcher) initializePatterns() {
+	// Ingress patterns (listeners)
+	pm.ingressPatterns["net.Listen"] = IngressPattern{Protocol: types.ProtocolTCP, AddressArg: 1, Network: true, AnyNetwork: true}
+	pm.ingressPatterns["net.ListenTCP"] = IngressPattern{Protocol: types.ProtocolTCP, AddressArg: 1, Network: true}
+	pm.ingressPatterns["net.ListenUDP"] = IngressPattern{Protocol: types.ProtocolUDP, AddressArg: 1, Network: true}
+	pm.ingressPatterns["net.ListenUnix"] = IngressPattern{Protocol: types.ProtocolUnix, AddressArg: 1, Network: true}
+	pm.ingressPatterns["http.ListenAndServe"] = IngressPattern{Protocol: types.ProtocolHTTP, AddressArg: 0, PortOnly: true}
+	pm.ingressPatterns["http.ListenAndServeTLS"] = IngressPattern{Protocol: types.ProtocolHTTPS, AddressArg: 0, PortOnly: true}
+
+	// Egress patterns (outbound connections)
+	pm.egressPatterns["net.Dial"] = EgressPattern{Protocol: types.ProtocolTCP, AddressArg: 1, Network: true, AnyNetwork: true}
+	pm.egressPatterns["net.DialTCP"] = EgressPattern{Protocol: types.ProtocolTCP, AddressArg: 2, Network: true}
+	pm.egressPatterns["net.DialUDP"] = EgressPattern{Protocol: types.ProtocolUDP, AddressArg: 2, Network: true}
+	pm.egressPatterns["net.DialTimeout"] = EgressPattern{Protocol: types.ProtocolTCP, AddressArg: 1, Network: true, AnyNetwork: true}
+	pm.egressPatterns["http.Get"] = EgressPattern{Protocol: types.ProtocolHTTP, URLArg: 0, URL: true}
+	pm.egressPatterns["http.Post"] = EgressPattern{Protocol: types.ProtocolHTTP, URLArg: 0, URL: true}
+	pm.egressPatterns["http.PostForm"] = EgressPattern{Protocol: types.ProtocolHTTP, URLArg: 0, URL: true}
+	pm.egressPatterns["http.NewRequest"] = EgressPattern{Protocol: types.ProtocolHTTP, URLArg: 1, URL: true, Request: true}
+	pm.egressPatterns["http.NewRequestWithContext"] = EgressPattern{Protocol: types.ProtocolHTTP, URLArg: 2, URL: true, Request: true}
+
+	pm.initializeDockerPatterns()
+	pm.initializeKubernetesPatterns()
+	pm.initializeControllerRuntimePatterns()
+	pm.initializeCoordinationPatterns()
+	pm.initializeWebRTCPatterns()
+	pm.initializeP2PPatterns()
+	pm.initializeRedisPatterns()
+	pm.initializeHTTPServerPatterns()
+	pm.initializeGRPCPatterns()
+	pm.initializeOptionPatterns()
+	pm.initializeEventLoopPatterns()
+	pm.initializeDatabasePatterns()
+	pm.initializePacketPatterns()
+	pm.initializeWebSocketPatterns()
+}
+
+func (pm *PatternMatcher) MatchSocketPattern(callExpr *ast.CallExpr, file *ast.File) *types.SocketInfo {
+	funcName := pm.extractFunctionName(callExpr)
+	positional := pm.positionalName(callExpr, file)
+	if funcName == "" && positional == "" {
+		return nil
+	}
+
+	if positional != "" {
+		// Check for ingress patterns
+		if pattern, exists := pm.ingressPatterns[positional]; exists {
+			return pm.matchIngressPattern(callExpr, file, pattern, positional)
+		}
+
+		// Check for egress patterns
+		if pattern, exists := pm.egressPatterns[positional]; exists {
+			if pattern.Request && !requestSent(file, callExpr) {
+				return nil
+			}
+			socket := pm.matchEgressPattern(callExpr, file, pattern, positional)
+			if socket != nil {
+				pm.tagOptions(socket, callExpr, file)
+			}
+			return socket
+		}
+
+		// A method standing in for a call matcher, such as Upgrade on a
+		// websocket.Upgrader
+		if matcher, exists := pm.callMatchers[positional]; exists && positional != funcName && pm.importsRequired(positional, file) {
+			return matcher(pm, callExpr, file, positional)
+		}
+	}
+
+	if matcher, exists := pm.callMatchers[funcName]; exists && pm.importsRequired(funcName, file) {
+		return matcher(pm, callExpr, file, funcName)
+	}
+
+	return nil
+}
+
+// MatchCallList reports the findings of a call configuring several sockets
+// at once, such as libp2p.ListenAddrStrings.
+func (pm *PatternMatcher) MatchCallList(callExpr *ast.CallExpr, file *ast.File) []*types.SocketInfo {
+	funcName := pm.extractFunctionName(callExpr)
+	if matcher, exists := pm.callListMatchers[funcName]; exists && pm.importsRequired(funcName, file) {
+		return matcher(pm, callExpr, file, funcName)
+	}
+	return nil
+}
+
+// HasAddressArgument reports whether findings of the named pattern carry
+// their address in a positional call argument that the resolver can chase.
+// Call and literal matchers extract what they can themselves.
+func (pm *PatternMatcher) HasAddressArgument(patternMatch string) bool {
+	_, ingress := pm.ingressPatterns[patternMatch]
+	_, egress := pm.egressPatterns[patternMatch]
+	return ingress || egress
+}
+
+// MatchCompositeLiteral reports findings configured through struct or map
+// literals of known types, such as container port declarations.
+func (pm *PatternMatcher) MatchCompositeLiteral(lit *ast.CompositeLit, file *ast.File) []*types.SocketInfo {
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	typeName := ident.Name + "." + sel.Sel.Name
+	if matcher, exists := pm.literalMatchers[typeName]; exists && pm.importsRequired(typeName, file) {
+		return matcher(pm, lit, file, typeName)
+	}
+	return nil
+}
+
+// MatchFieldAssignment reports findings configured by assigning to a known
+// field, as option funcs and post-construction tweaks do.
+func (pm *PatternMatcher) MatchFieldAssignment(assign *ast.AssignStmt, file *ast.File) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for i, lhs := range assign.Lhs {
+		sel, ok := lhs.(*ast.SelectorExpr)
+		if !ok || i >= len(assign.Rhs) {
+			continue
+		}
+		field := sel.Sel.Name
+		matcher, exists := pm.fieldMatchers[field]
+		if !exists || !pm.importsRequired(field, file) {
+			continue
+		}
+		if socket := matcher(pm, assign.Rhs[i], file, field); socket != nil {
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+func (pm *PatternMatcher) importsRequired(name string, file *ast.File) bool {
+	paths, restricted := pm.requiredImports[name]
+	if !restricted {
+		return true
+	}
+	return importsAny(file, paths)
+}
+
+func importsAny(file *ast.File, paths []string) bool {
+	for _, spec := range file.Imports {
+		imported := strings.Trim(spec.Path.Value, `"`)
+		for _, path := range paths {
+			if imported == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (pm *PatternMatcher) matchIngressPattern(callExpr *ast.CallExpr, file *ast.File, pattern IngressPattern, funcName string) *types.SocketInfo {
+	if len(callExpr.Args) <= pattern.AddressArg {
+		return nil
+	}
+
+	addressArg := callExpr.Args[pattern.AddressArg]
+	rawValue := pm.extractStringLiteral(addressArg)
+
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     pm.networkProtocol(callExpr, file, pattern.Protocol, pattern.Network, pattern.AnyNetwork),
+		RawValue:     rawValue,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+	}
+
+	if rawValue != "" {
+		pm.parseIngressAddress(socket, rawValue, pattern.PortOnly)
+	} else if isNil(addressArg) && socket.Protocol.Transport() == types.ProtocolIP {
+		// net.ListenIP("ip4:icmp", nil) listens on every address
+		socket.IsResolved = true
+		socket.Listen = types.NewEndpoint("0.0.0.0", nil)
+	}
+
+	return socket
+}
+
+// argument returns the index of the argument holding the destination of
+// a call, and whether it is a URL.
+func (p EgressPattern) argument() (int, bool) {
+	if p.URL {
+		return p.URLArg, true
+	}
+	return p.AddressArg, false
+}
+
+// addressArgument returns the argument of call holding the address of a
+// finding of the named positional pattern, or nil.
+func (pm *PatternMatcher) addressArgument(patternMatch string, call *ast.CallExpr) ast.Expr {
+	index := -1
+	if pattern, ok := pm.ingressPatterns[patternMatch]; ok {
+		index = pattern.AddressArg
+	} else if pattern, ok := pm.egressPatterns[patternMatch]; ok {
+		index, _ = pattern.argument()
+	}
+	if index < 0 || index >= len(call.Args) {
+		return nil
+	}
+	return call.Args[index]
+}
+
+func (pm *PatternMatcher) matchEgressPattern(callExpr *ast.CallExpr, file *ast.File, pattern EgressPattern, funcName string) *types.SocketInfo {
+	var rawValue string
+	argIndex, isURL := pattern.argument()
+
+	if len(callExpr.Args) <= argIndex {
+		return nil
+	}
+
+	arg := callExpr.Args[argIndex]
+	rawValue = pm.extractStringLiteral(arg)
+
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     pm.networkProtocol(callExpr, file, pattern.Protocol, pattern.Network, pattern.AnyNetwork),
+		RawValue:     rawValue,
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+	}
+
+	if rawValue != "" {
+		switch {
+		case isURL:
+			pm.parseEgressURL(socket, rawValue)
+		case pattern.Protocol == types.ProtocolGRPC:
+			pm.parseGRPCTarget(socket, rawValue)
+		default:
+			pm.parseEgressAddress(socket, rawValue)
+		}
+	}
+
+	return socket
+}
+
+// networkProtocol returns the protocol selected by the network in the
+// first argument of callExpr, when network is set and it is a known
+// string literal or constant. Otherwise it returns protocol, or
+// types.ProtocolUnknown when anyNetwork is set, since the network may
+// select another transport than protocol's.
+func (pm *PatternMatcher) networkProtocol(callExpr *ast.CallExpr, file *ast.File, protocol types.Protocol, network, anyNetwork bool) types.Protocol {
+	if !network || len(callExpr.Args) == 0 {
+		return protocol
+	}
+	value, ok := pm.resolver.ResolveString(callExpr.Args[0], file)
+	if selected, known := types.NetworkProtocol(value); ok && known {
+		return selected
+	}
+	if anyNetwork {
+		return types.ProtocolUnknown
+	}
+	return protocol
+}
+
+func isNil(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
+
+func (pm *PatternMatcher) extractFunctionName(callExpr *ast.CallExpr) string {
+	switch fun := callExpr.Fun.(type) {
+	case *ast.SelectorExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			return ident.Name + "." + fun.Sel.Name
+		}
+	case *ast.Ident:
+		return fun.Name
+	}
+	return ""
+}
+
+func (pm *PatternMatcher) extractStringLiteral(expr ast.Expr) string {
+	value, _ := pm.foldString(expr, nil)
+	return value
+}
+
+// foldString returns the value of a string literal, interpreted or raw,
+// or of a concatenation such as "host" + ":" + "8080". Identifiers are
+// looked up with constant, when given.
+func (pm *PatternMatcher) foldString(expr ast.Expr, constant func(*ast.Ident) (string, bool)) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			value, err := strconv.Unquote(e.Value)
+			return value, err == nil
+		}
+	case *ast.ParenExpr:
+		return pm.foldString(e.X, constant)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := pm.foldString(e.X, constant)
+		if !ok {
+			return "", false
+		}
+		y, ok := pm.foldString(e.Y, constant)
+		if !ok {
+			return "", false
+		}
+		return x + y, true
+	case *ast.Ident:
+		if constant != nil {
+			return constant(e)
+		}
+	}
+	return "", false
+}
+
+// resolveConstant returns the literal value behind expr: a basic literal,
+// a package-level constant or variable initialized with one, the default
+// of a flag bound to the variable (flag.StringVar(&addr, "addr", ":8080",
+// ...) or addr := flag.String("addr", ":8080", ...)), or a concatenation
+// of those with string literals. String values are unquoted.
+func (pm *PatternMatcher) resolveConstant(expr ast.Expr, file *ast.File) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return basicLitValue(e)
+	case *ast.StarExpr:
+		if ident, ok := e.X.(*ast.Ident); ok {
+			return pm.flagDefault(ident.Name, file, false)
+		}
+	case *ast.Ident:
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				for i, name := range valueSpec.Names {
+					if name.Name != e.Name || i >= len(valueSpec.Values) {
+						continue
+					}
+					switch value := valueSpec.Values[i].(type) {
+					case *ast.BasicLit:
+						return basicLitValue(value)
+					case *ast.BinaryExpr, *ast.ParenExpr:
+						return pm.resolveConstant(value, file)
+					}
+				}
+			}
+		}
+		return pm.flagDefault(e.Name, file, true)
+	case *ast.BinaryExpr, *ast.ParenExpr:
+		// Only a string literal operand makes + a concatenation rather
+		// than an addition of numeric constants
+		if !hasStringLiteral(e) {
+			return "", false
+		}
+		return pm.foldString(e, func(ident *ast.Ident) (string, bool) {
+			return pm.resolveConstant(ident, file)
+		})
+	}
+	return "", false
+}
+
+func hasStringLiteral(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// flagDefault finds the default value of the flag bound to name, either by
+// address (flag.XxxVar) or by the pointer flag.Xxx returns.
+func (pm *PatternMatcher) flagDefault(name string, file *ast.File, byAddress bool) (string, bool) {
+	value, found := "", false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if byAddress {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 4 || !isFlagCall(pm.extractFunctionName(call), "Var") {
+				return true
+			}
+			if ref, ok := call.Args[0].(*ast.UnaryExpr); ok && ref.Op == token.AND {
+				if ident, ok := ref.X.(*ast.Ident); ok && ident.Name == name {
+					if lit, ok := call.Args[2].(*ast.BasicLit); ok {
+						value, found = basicLitValue(lit)
+					}
+				}
+			}
+			return true
+		}
+
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		call, isCall := assign.Rhs[0].(*ast.CallExpr)
+		if !ok || !isCall || ident.Name != name || len(call.Args) != 3 || !isFlagCall(pm.extractFunctionName(call), "") {
+			return true
+		}
+		if lit, ok := call.Args[1].(*ast.BasicLit); ok {
+			value, found = basicLitValue(lit)
+		}
+		return true
+	})
+	return value, found
+}
+
+func isFlagCall(funcName, suffix string) bool {
+	pkg, fn, ok := strings.Cut(funcName, ".")
+	if !ok || (pkg != "flag" && pkg != "pflag") {
+		return false
+	}
+	return strings.HasSuffix(fn, "Var") == (suffix == "Var")
+}
+
+func basicLitValue(lit *ast.BasicLit) (string, bool) {
+	if lit.Kind == token.STRING {
+		value, err := strconv.Unquote(lit.Value)
+		return value, err == nil
+	}
+	return lit.Value, true
+}
+
+func (pm *PatternMatcher) parseIngressAddress(socket *types.SocketInfo, address string, portOnly bool) {
+	socket.IsResolved = true
+
+	if scheme, rest, ok := types.SplitScheme(address); ok {
+		socket.Protocol = socket.Protocol.WithScheme(scheme)
+		if scheme == types.ProtocolUnix {
+			socket.Listen = types.NewPathEndpoint(rest)
+			return
+		}
+		address = rest
+	}
+
+	switch socket.Protocol.Transport() {
+	case types.ProtocolUnix:
+		socket.Listen = types.NewPathEndpoint(address)
+		return
+	case types.ProtocolIP:
+		// Raw IP sockets have no port: "0.0.0.0", "::" or "" for all
+		if address == "" {
+			address = "0.0.0.0"
+		}
+		socket.Listen = types.NewEndpoint(address, nil)
+		return
+	}
+
+	// host:port, [::1]:8080, or a port alone such as ":8080" for all
+	// interfaces
+	host, port, ok := types.SplitHostPort(address)
+	if !ok || (portOnly && host == "" && port == nil) {
+		return
+	}
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	socket.Listen = types.NewEndpoint(host, port)
+}
+
+func (pm *PatternMatcher) parseEgressAddress(socket *types.SocketInfo, address string) {
+	socket.IsResolved = true
+
+	if scheme, rest, ok := types.SplitScheme(address); ok {
+		socket.Protocol = socket.Protocol.WithScheme(scheme)
+		if scheme == types.ProtocolUnix {
+			socket.Destination = types.NewPathEndpoint(rest)
+			return
+		}
+		address = rest
+	}
+
+	switch socket.Protocol.Transport() {
+	case types.ProtocolUnix:
+		socket.Destination = types.NewPathEndpoint(address)
+		return
+	case types.ProtocolIP:
+		socket.Destination = types.NewEndpoint(address, nil)
+		return
+	}
+
+	if host, port, ok := types.SplitHostPort(address); ok {
+		socket.Destination = types.NewEndpoint(host, port)
+	}
+}
+
+func (pm *PatternMatcher) parseEgressURL(socket *types.SocketInfo, url string) {
+	socket.IsResolved = true
+
+	// Parse URL to extract scheme, host, and port
+	var remainingURL string
+	var defaultPort int
+	
+	if strings.HasPrefix(url, "https://") {
+		socket.Protocol = types.ProtocolHTTPS
+		remainingURL = url[8:]
+		defaultPort = 443
+	} else if strings.HasPrefix(url, "http://") {
+		socket.Protocol = types.ProtocolHTTP
+		remainingURL = url[7:]
+		defaultPort = 80
+	} else if strings.HasPrefix(url, "wss://") {
+		socket.Protocol = types.ProtocolWSS
+		remainingURL = url[6:]
+		defaultPort = 443
+	} else if strings.HasPrefix(url, "ws://") {
+		socket.Protocol = types.ProtocolWebSocket
+		remainingURL = url[5:]
+		defaultPort = 80
+	} else {
+		// No scheme prefix, treat as raw URL
+		remainingURL = url
+		defaultPort = 80
+	}
+
+	// Extract host and port from URL (everything before the first slash)
+	parts := strings.Split(remainingURL, "/")
+	if len(parts) > 0 && parts[0] != "" {
+		host, port := types.SplitURLHost(parts[0])
+		if port == nil {
+			// Host without explicit port, use default
+			port = &defaultPort
+		}
+		socket.Destination = types.NewEndpoint(host, port)
+	}
+	pm.webSocketScheme(socket)
+}
\ No newline at end of file
@@ -0,0 +1,499 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_MatchIngressPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected *types.SocketInfo
+	}{
+		{
+			name: "HTTP ListenAndServe with port",
+			code: `package main
+import "net/http"
+func main() {
+	http.ListenAndServe(":8080", nil)
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeIngress,
+				Protocol:     types.ProtocolHTTP,
+				RawValue:     ":8080",
+				PatternMatch: "http.ListenAndServe",
+				IsResolved:   true,
+				Listen:       types.NewEndpoint("0.0.0.0", intPtr(8080)),
+			},
+		},
+		{
+			name: "HTTPS ListenAndServeTLS",
+			code: `package main
+import "net/http"
+func main() {
+	http.ListenAndServeTLS(":8443", "cert.pem", "key.pem", nil)
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeIngress,
+				Protocol:     types.ProtocolHTTPS,
+				RawValue:     ":8443",
+				PatternMatch: "http.ListenAndServeTLS",
+				IsResolved:   true,
+				Listen:       types.NewEndpoint("0.0.0.0", intPtr(8443)),
+			},
+		},
+		{
+			name: "TCP net.Listen",
+			code: `package main
+import "net"
+func main() {
+	net.Listen("tcp", "localhost:9090")
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeIngress,
+				Protocol:     types.ProtocolTCP,
+				RawValue:     "localhost:9090",
+				PatternMatch: "net.Listen",
+				IsResolved:   true,
+				Listen:       types.NewEndpoint("localhost", intPtr(9090)),
+			},
+		},
+		{
+			name: "UDP net.ListenUDP",
+			code: `package main
+import "net"
+func main() {
+	net.ListenUDP("udp", &net.UDPAddr{Port: 5353})
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeIngress,
+				Protocol:     types.ProtocolUDP,
+				RawValue:     "",
+				PatternMatch: "net.ListenUDP",
+				IsResolved:   false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "test.go", tt.code, 0)
+			if err != nil {
+				t.Fatalf("Failed to parse code: %v", err)
+			}
+
+			pm := NewPatternMatcher()
+			var result *types.SocketInfo
+
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if socket := pm.MatchSocketPattern(call, file); socket != nil {
+						result = socket
+						return false
+					}
+				}
+				return true
+			})
+
+			if result == nil {
+				t.Fatal("Expected to find a socket pattern, but found none")
+			}
+
+			if result.Type != tt.expected.Type {
+				t.Errorf("Type: expected %s, got %s", tt.expected.Type, result.Type)
+			}
+			if result.Protocol != tt.expected.Protocol {
+				t.Errorf("Protocol: expected %s, got %s", tt.expected.Protocol, result.Protocol)
+			}
+			if result.PatternMatch != tt.expected.PatternMatch {
+				t.Errorf("PatternMatch: expected %s, got %s", tt.expected.PatternMatch, result.PatternMatch)
+			}
+			if result.RawValue != tt.expected.RawValue {
+				t.Errorf("RawValue: expected %s, got %s", tt.expected.RawValue, result.RawValue)
+			}
+			if result.IsResolved != tt.expected.IsResolved {
+				t.Errorf("IsResolved: expected %t, got %t", tt.expected.IsResolved, result.IsResolved)
+			}
+
+			if tt.expected.Listen != nil {
+				if result.Listen == nil || result.Listen.Port == nil {
+					t.Error("Expected Listen.Port to be set, but it was nil")
+				} else if *result.Listen.Port != *tt.expected.Listen.Port {
+					t.Errorf("Listen.Port: expected %d, got %d", *tt.expected.Listen.Port, *result.Listen.Port)
+				} else if result.Listen.Host != tt.expected.Listen.Host {
+					t.Errorf("Listen.Host: expected %s, got %s", tt.expected.Listen.Host, result.Listen.Host)
+				}
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_MatchEgressPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected *types.SocketInfo
+	}{
+		{
+			name: "HTTP GET request",
+			code: `package main
+import "net/http"
+func main() {
+	http.Get("https://api.example.com/data")
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolHTTPS,
+				RawValue:     "https://api.example.com/data",
+				PatternMatch: "http.Get",
+				IsResolved:   true,
+				Destination:  types.NewEndpoint("api.example.com", intPtr(443)),
+			},
+		},
+		{
+			name: "HTTP POST request",
+			code: `package main
+import "net/http"
+func main() {
+	http.Post("http://localhost:8080/api", "application/json", nil)
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolHTTP,
+				RawValue:     "http://localhost:8080/api",
+				PatternMatch: "http.Post",
+				IsResolved:   true,
+				Destination:  types.NewEndpoint("localhost", intPtr(8080)),
+			},
+		},
+		{
+			name: "TCP net.Dial",
+			code: `package main
+import "net"
+func main() {
+	net.Dial("tcp", "database.internal:5432")
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolTCP,
+				RawValue:     "database.internal:5432",
+				PatternMatch: "net.Dial",
+				IsResolved:   true,
+				Destination:  types.NewEndpoint("database.internal", intPtr(5432)),
+			},
+		},
+		{
+			name: "TCP net.DialTimeout",
+			code: `package main
+import ("net"; "time")
+func main() {
+	net.DialTimeout("tcp", "example.com:443", 5*time.Second)
+}`,
+			expected: &types.SocketInfo{
+				Type:         types.TrafficTypeEgress,
+				Protocol:     types.ProtocolTCP,
+				RawValue:     "example.com:443",
+				PatternMatch: "net.DialTimeout",
+				IsResolved:   true,
+				Destination:  types.NewEndpoint("example.com", intPtr(443)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "test.go", tt.code, 0)
+			if err != nil {
+				t.Fatalf("Failed to parse code: %v", err)
+			}
+
+			pm := NewPatternMatcher()
+			var result *types.SocketInfo
+
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if socket := pm.MatchSocketPattern(call, file); socket != nil {
+						result = socket
+						return false
+					}
+				}
+				return true
+			})
+
+			if result == nil {
+				t.Fatal("Expected to find a socket pattern, but found none")
+			}
+
+			if result.Type != tt.expected.Type {
+				t.Errorf("Type: expected %s, got %s", tt.expected.Type, result.Type)
+			}
+			if result.Protocol != tt.expected.Protocol {
+				t.Errorf("Protocol: expected %s, got %s", tt.expected.Protocol, result.Protocol)
+			}
+			if result.PatternMatch != tt.expected.PatternMatch {
+				t.Errorf("PatternMatch: expected %s, got %s", tt.expected.PatternMatch, result.PatternMatch)
+			}
+
+			if tt.expected.Destination != nil {
+				if result.Destination == nil || result.Destination.Port == nil {
+					t.Error("Expected Destination.Port to be set, but it was nil")
+				} else if result.Destination.Host != tt.expected.Destination.Host {
+					t.Errorf("Destination.Host: expected %s, got %s", tt.expected.Destination.Host, result.Destination.Host)
+				} else if *result.Destination.Port != *tt.expected.Destination.Port {
+					t.Errorf("Destination.Port: expected %d, got %d", *tt.expected.Destination.Port, *result.Destination.Port)
+				}
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_NoMatch(t *testing.T) {
+	code := `package main
+import "fmt"
+func main() {
+	fmt.Println("Hello, World!")
+}`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	pm := NewPatternMatcher()
+	var result *types.SocketInfo
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if socket := pm.MatchSocketPattern(call, file); socket != nil {
+				result = socket
+				return false
+			}
+		}
+		return true
+	})
+
+	if result != nil {
+		t.Errorf("Expected no socket pattern match, but found: %+v", result)
+	}
+}
+
+func TestExtractFunctionName(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "Package selector",
+			code: `package main
+func main() {
+	http.Get("url")
+}`,
+			expected: "http.Get",
+		},
+		{
+			name: "Simple identifier",
+			code: `package main
+func Get(url string) {}
+func main() {
+	Get("url")
+}`,
+			expected: "Get",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "test.go", tt.code, 0)
+			if err != nil {
+				t.Fatalf("Failed to parse code: %v", err)
+			}
+
+			pm := NewPatternMatcher()
+			var result string
+
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					result = pm.extractFunctionName(call)
+					if result != "" {
+						return false
+					}
+				}
+				return true
+			})
+
+			if result != tt.expected {
+				t.Errorf("Expected function name %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+// Helper functions
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestPatternMatcher_Fingerprint(t *testing.T) {
+	pm := NewPatternMatcher()
+	fingerprint := pm.Fingerprint()
+	if fingerprint != NewPatternMatcher().Fingerprint() {
+		t.Error("Expected identical pattern sets to share a fingerprint")
+	}
+
+	pm.ingressPatterns["amqp.Listen"] = IngressPattern{Protocol: types.ProtocolTCP}
+	if pm.Fingerprint() == fingerprint {
+		t.Error("Expected an added pattern to change the fingerprint")
+	}
+}
+
+func TestPatternMatcher_FoldsStringLiterals(t *testing.T) {
+	sockets := matchFile(t, "package main\n"+
+		"import (\n\t\"net\"\n\t\"net/http\"\n)\n"+
+		"const host = \"db.internal\"\n"+
+		"const addr = host + \":5432\"\n"+
+		"func main() {\n"+
+		"\tnet.Listen(\"tcp\", `:8080`)\n"+
+		"\tnet.Dial(\"tcp\", \"cache\" + \":\" + \"6379\")\n"+
+		"\thttp.Get((\"http://api.example.com\" + \":8443\") + \"/v1\")\n"+
+		"\tnet.Dial(\"tcp\", host + \":5432\")\n"+
+		"\tnet.Dial(\"tcp\", addr)\n"+
+		"}\n")
+
+	expected := []struct {
+		host string
+		port int
+	}{
+		{"0.0.0.0", 8080},
+		{"cache", 6379},
+		{"api.example.com", 8443},
+		{"db.internal", 5432},
+		{"db.internal", 5432},
+	}
+	if len(sockets) != len(expected) {
+		t.Fatalf("Expected %d findings, got %d", len(expected), len(sockets))
+	}
+	for i, want := range expected {
+		socket := sockets[i]
+		endpoint := socket.Destination
+		if i == 0 {
+			endpoint = socket.Listen
+		}
+		if !socket.IsResolved || endpoint == nil || endpoint.Host != want.host || endpoint.Port == nil || *endpoint.Port != want.port {
+			t.Errorf("Finding %d: expected %s:%d, got %+v (raw %q)", i, want.host, want.port, endpoint, socket.RawValue)
+		}
+	}
+}
+
+func TestPatternMatcher_SchemeAddresses(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "net"
+func main() {
+	net.Listen("tcp", "tcp://0.0.0.0:8080")
+	net.Dial("udp", "udp://collector:8125")
+	net.Dial("unix", "unix:///var/run/agent.sock")
+}`)
+	want := []struct {
+		protocol types.Protocol
+		endpoint string
+	}{
+		{types.ProtocolTCP, "0.0.0.0:8080"},
+		{types.ProtocolUDP, "collector:8125"},
+		{types.ProtocolUnix, "/var/run/agent.sock"},
+	}
+	if len(sockets) != len(want) {
+		t.Fatalf("Expected %d findings, got %d", len(want), len(sockets))
+	}
+	for i, socket := range sockets {
+		endpoint := socket.Listen
+		if socket.Type == types.TrafficTypeEgress {
+			endpoint = socket.Destination
+		}
+		if socket.Protocol != want[i].protocol || endpoint.String() != want[i].endpoint {
+			t.Errorf("Finding %d: expected %s %s, got %s %s", i, want[i].protocol, want[i].endpoint, socket.Protocol, endpoint)
+		}
+	}
+}
+
+func TestPatternMatcher_UnknownNetwork(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "net"
+const network = "udp"
+func main() {
+	net.Dial(cfg.Network, "collector:8125")
+	net.Dial(network, "collector:8125")
+	net.Listen(proto, "tcp://0.0.0.0:8080")
+	net.ListenPacket(cfg.Network, ":8125")
+	net.ListenTCP(cfg.Network, addr)
+}`)
+	want := []types.Protocol{types.ProtocolUnknown, types.ProtocolUDP, types.ProtocolTCP, types.ProtocolUnknown, types.ProtocolTCP}
+	if len(sockets) != len(want) {
+		t.Fatalf("Expected %d findings, got %d", len(want), len(sockets))
+	}
+	for i, socket := range sockets {
+		if socket.Protocol != want[i] {
+			t.Errorf("Finding %d (%s): expected protocol %s, got %s", i, socket.PatternMatch, want[i], socket.Protocol)
+		}
+	}
+	if endpoint := sockets[0].Destination.String(); endpoint != "collector:8125" {
+		t.Errorf("Expected the address of a dynamic network parsed, got %q", endpoint)
+	}
+}
+
+func TestPatternMatcher_IPv6Addresses(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"net"
+	"net/http"
+)
+const metricsAddr = "[::1]:9090"
+func main() {
+	net.Listen("tcp6", "[::]:8080")
+	net.Listen("tcp", "[fe80::1%eth0]:8081")
+	http.ListenAndServe(metricsAddr, nil)
+	net.Dial("tcp", "[2001:db8::1]:443")
+	upstream := "[2001:db8::2]:5432"
+	net.Dial("tcp", upstream)
+	http.Get("http://[2001:db8::3]:8443/v1")
+	http.Get("https://[2001:db8::4]/v1")
+	net.Dial("tcp", "10.0.0.1:6379")
+}`)
+	want := []struct {
+		endpoint string
+		family   types.AddressFamily
+	}{
+		{"[::]:8080", types.FamilyIPv6},
+		{"[fe80::1%eth0]:8081", types.FamilyIPv6},
+		{"[::1]:9090", types.FamilyIPv6},
+		{"[2001:db8::1]:443", types.FamilyIPv6},
+		{"[2001:db8::2]:5432", types.FamilyIPv6},
+		{"[2001:db8::3]:8443", types.FamilyIPv6},
+		{"[2001:db8::4]:443", types.FamilyIPv6},
+		{"10.0.0.1:6379", types.FamilyIPv4},
+	}
+	if len(sockets) != len(want) {
+		t.Fatalf("Expected %d findings, got %d", len(want), len(sockets))
+	}
+	for i, socket := range sockets {
+		endpoint := socket.Listen
+		if socket.Type == types.TrafficTypeEgress {
+			endpoint = socket.Destination
+		}
+		if !socket.IsResolved || endpoint.String() != want[i].endpoint || endpoint.Family != want[i].family {
+			t.Errorf("Finding %d: expected %s (%s), got %s (%s, resolved %t)", i, want[i].endpoint, want[i].family, endpoint, endpoint.Family, socket.IsResolved)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package patterns
+
+import (
+	"go/ast"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for Redis clients and their high-availability topologies.
+const (
+	TagRedis         = "redis"
+	TagRedisSentinel = "redis-sentinel"
+	TagRedisCluster  = "redis-cluster"
+)
+
+// redisDefaultAddr is where a go-redis client connects without Addr.
+const redisDefaultAddr = "localhost:6379"
+
+var redisImports = []string{
+	"github.com/redis/go-redis/v9",
+	"github.com/go-redis/redis/v8",
+	"github.com/go-redis/redis/v7",
+	"github.com/go-redis/redis",
+}
+
+var redisRules = ruleFamily{
+	name:        "Redis",
+	code:        1900,
+	description: "go-redis clients: a single node, the sentinels of a failover client, the seeds of a cluster, and universal clients, which pick one of those modes from their options.",
+	rationale:   "Sentinel and cluster clients connect to nodes discovered at runtime, so their findings list the seeds and say so.",
+	example:     `rdb := redis.NewClient(&redis.Options{Addr: "cache:6379"})`,
+	rules:       []string{"redis.Options", "redis.FailoverOptions", "redis.ClusterOptions", "redis.UniversalOptions"},
+}
+
+func (pm *PatternMatcher) initializeRedisPatterns() {
+	for name, matcher := range map[string]literalMatcher{
+		"redis.Options":          matchRedisOptions,
+		"redis.FailoverOptions":  matchRedisFailoverOptions,
+		"redis.ClusterOptions":   matchRedisClusterOptions,
+		"redis.UniversalOptions": matchRedisUniversalOptions,
+	} {
+		pm.literalMatchers[name] = matcher
+		pm.requiredImports[name] = redisImports
+	}
+}
+
+// redisFields returns the literal string fields and string list fields set
+// in a go-redis options literal.
+func (pm *PatternMatcher) redisFields(lit *ast.CompositeLit, file *ast.File) (map[string]string, map[string][]string) {
+	values := make(map[string]string)
+	lists := make(map[string][]string)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if list, ok := kv.Value.(*ast.CompositeLit); ok {
+			lists[key.Name] = []string{}
+			for _, item := range list.Elts {
+				value, _ := pm.resolveConstant(item, file)
+				lists[key.Name] = append(lists[key.Name], value)
+			}
+			continue
+		}
+		values[key.Name], _ = pm.resolveConstant(kv.Value, file)
+	}
+	return values, lists
+}
+
+func (pm *PatternMatcher) newRedisSocket(typeName, addr string, tags ...string) *types.SocketInfo {
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     types.ProtocolTCP,
+		RawValue:     addr,
+		PatternMatch: typeName,
+		FunctionName: "unknown",
+		Tags:         append([]string{TagRedis}, tags...),
+	}
+	if addr != "" {
+		pm.parseEgressAddress(socket, addr)
+	}
+	return socket
+}
+
+// matchRedisOptions handles a single-node client, redis.Options{Addr: ...}.
+func matchRedisOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	values, _ := pm.redisFields(lit, file)
+	addr, set := values["Addr"]
+	if !set {
+		addr = redisDefaultAddr
+	}
+	return []*types.SocketInfo{pm.newRedisSocket(typeName, addr)}
+}
+
+// matchRedisFailoverOptions reports each sentinel seed. The client asks the
+// sentinels for the master and then connects to it, so the data nodes are
+// only known at runtime.
+func matchRedisFailoverOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	values, lists := pm.redisFields(lit, file)
+	return pm.redisSentinelSeeds(typeName, lists["SentinelAddrs"], values["MasterName"])
+}
+
+// matchRedisClusterOptions reports each cluster seed. The client discovers
+// the other nodes from the seeds and connects to all of them.
+func matchRedisClusterOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	_, lists := pm.redisFields(lit, file)
+	return pm.redisClusterSeeds(typeName, lists["Addrs"])
+}
+
+// matchRedisUniversalOptions follows redis.NewUniversalClient: a master name
+// selects sentinel mode, several addresses cluster mode, and a single
+// address a plain client.
+func matchRedisUniversalOptions(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	values, lists := pm.redisFields(lit, file)
+	addrs := lists["Addrs"]
+	switch {
+	case values["MasterName"] != "":
+		return pm.redisSentinelSeeds(typeName, addrs, values["MasterName"])
+	case len(addrs) > 1:
+		return pm.redisClusterSeeds(typeName, addrs)
+	case len(addrs) == 1:
+		return []*types.SocketInfo{pm.newRedisSocket(typeName, addrs[0])}
+	}
+	return []*types.SocketInfo{pm.newRedisSocket(typeName, redisDefaultAddr)}
+}
+
+func (pm *PatternMatcher) redisSentinelSeeds(typeName string, addrs []string, master string) []*types.SocketInfo {
+	if master == "" {
+		master = "unknown"
+	}
+	if addrs == nil {
+		addrs = []string{""}
+	}
+	var sockets []*types.SocketInfo
+	for i, addr := range addrs {
+		socket := pm.newRedisSocket(typeName, addr, TagRedisSentinel)
+		socket.Notes = append(socket.Notes, types.Message(types.MsgRedisSentinelSeed,
+			"Seed", i+1, "Seeds", len(addrs), "Master", master))
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+func (pm *PatternMatcher) redisClusterSeeds(typeName string, addrs []string) []*types.SocketInfo {
+	if addrs == nil {
+		addrs = []string{""}
+	}
+	var sockets []*types.SocketInfo
+	for i, addr := range addrs {
+		socket := pm.newRedisSocket(typeName, addr, TagRedisCluster)
+		socket.Notes = append(socket.Notes, types.Message(types.MsgRedisClusterSeed, "Seed", i+1, "Seeds", len(addrs)))
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
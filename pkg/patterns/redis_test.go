@@ -0,0 +1,74 @@
+package patterns
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatternMatcher_RedisSentinel(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "github.com/redis/go-redis/v9"
+const sentinel = "sentinel-2.internal:26379"
+func connect() {
+	redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    "cache",
+		SentinelAddrs: []string{"sentinel-0.internal:26379", "sentinel-1.internal:26379", sentinel},
+	})
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 sentinel seeds, got %d", len(sockets))
+	}
+	for i, socket := range sockets {
+		host := "sentinel-" + string(rune('0'+i)) + ".internal"
+		if !socket.IsResolved || socket.Destination.Host != host || *socket.Destination.Port != 26379 {
+			t.Errorf("Seed %d: expected %s:26379, got %+v", i, host, socket.Destination)
+		}
+		if len(socket.Tags) != 2 || socket.Tags[0] != TagRedis || socket.Tags[1] != TagRedisSentinel {
+			t.Errorf("Seed %d: expected redis and redis-sentinel tags, got %v", i, socket.Tags)
+		}
+		if len(socket.Notes) != 1 || !strings.Contains(socket.Notes[0], "of 3 for master cache") {
+			t.Errorf("Seed %d: expected a topology note, got %v", i, socket.Notes)
+		}
+	}
+}
+
+func TestPatternMatcher_RedisCluster(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "github.com/go-redis/redis/v8"
+func connect() {
+	redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"node-0:7000", "node-1:7001"}})
+	redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{"node-2:7002", "node-3:7003"}})
+}`)
+
+	if len(sockets) != 4 {
+		t.Fatalf("Expected 4 cluster seeds, got %d", len(sockets))
+	}
+	for i, socket := range sockets {
+		if socket.Tags[len(socket.Tags)-1] != TagRedisCluster || *socket.Destination.Port != 7000+i {
+			t.Errorf("Seed %d: expected redis-cluster seed on port %d, got %+v", i, 7000+i, socket)
+		}
+		if len(socket.Notes) != 1 || !strings.HasPrefix(socket.Notes[0], "cluster seed") {
+			t.Errorf("Seed %d: expected a topology note, got %v", i, socket.Notes)
+		}
+	}
+}
+
+func TestPatternMatcher_RedisSingleNode(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "github.com/redis/go-redis/v9"
+func connect() {
+	redis.NewClient(&redis.Options{Addr: "cache.internal:6380"})
+	redis.NewClient(&redis.Options{DB: 1})
+}`)
+
+	if len(sockets) != 2 {
+		t.Fatalf("Expected 2 clients, got %d", len(sockets))
+	}
+	for i, want := range []string{"cache.internal", "localhost"} {
+		socket := sockets[i]
+		if socket.Destination.Host != want || len(socket.Tags) != 1 || socket.Notes != nil {
+			t.Errorf("Client %d: expected plain redis egress to %s, got %+v", i, want, socket)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// requestSenders are the methods that send a request, of http.Client and
+// of http.RoundTripper, and of the clients of other libraries named the
+// same way.
+var requestSenders = map[string]bool{
+	"Do":        true,
+	"RoundTrip": true,
+}
+
+// requestCopies are the methods of http.Request returning a copy of it,
+// to the same URL.
+var requestCopies = map[string]bool{
+	"WithContext": true,
+	"Clone":       true,
+}
+
+// requestSent reports whether the request built by call, such as
+// http.NewRequest(...), is sent in the function declaring it: assigned to
+// a variable that is passed, itself or a copy of it, to a Do or
+// RoundTrip call, as in client.Do(req).
+func requestSent(file *ast.File, call *ast.CallExpr) bool {
+	body := declaringBody(file, call.Pos())
+	if body == nil {
+		return false
+	}
+
+	requests := make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && len(assign.Rhs) == 1 && assign.Rhs[0] == call {
+			if ident, ok := assign.Lhs[0].(*ast.Ident); ok && ident.Name != "_" {
+				requests[ident.Name] = true
+			}
+		}
+		if spec, ok := n.(*ast.ValueSpec); ok && len(spec.Values) == 1 && spec.Values[0] == call && spec.Names[0].Name != "_" {
+			requests[spec.Names[0].Name] = true
+		}
+		return len(requests) == 0
+	})
+	if len(requests) == 0 {
+		return false
+	}
+
+	// req = req.WithContext(ctx), retry := req.Clone(ctx)
+	for added := true; added; {
+		added = false
+		ast.Inspect(body, func(n ast.Node) bool {
+			assign, ok := n.(*ast.AssignStmt)
+			if !ok {
+				return true
+			}
+			for i, rhs := range assign.Rhs {
+				if i >= len(assign.Lhs) {
+					break
+				}
+				lhs, ok := assign.Lhs[i].(*ast.Ident)
+				if !ok || requests[lhs.Name] || !isRequest(rhs, requests) {
+					continue
+				}
+				requests[lhs.Name] = true
+				added = true
+			}
+			return true
+		})
+	}
+
+	sent := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		send, ok := n.(*ast.CallExpr)
+		if !ok || sent {
+			return !sent
+		}
+		if sel, ok := send.Fun.(*ast.SelectorExpr); ok && requestSenders[sel.Sel.Name] && len(send.Args) == 1 && isRequest(send.Args[0], requests) {
+			sent = true
+		}
+		return true
+	})
+	return sent
+}
+
+// isRequest reports whether expr is one of the request variables, or a
+// copy of one.
+func isRequest(expr ast.Expr, requests map[string]bool) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return requests[e.Name]
+	case *ast.CallExpr:
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && requestCopies[sel.Sel.Name] {
+			return isRequest(sel.X, requests)
+		}
+	}
+	return false
+}
+
+// declaringBody returns the body of the function declaration containing
+// pos, function literals in it included.
+func declaringBody(file *ast.File, pos token.Pos) *ast.BlockStmt {
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil &&
+			funcDecl.Body.Pos() <= pos && pos < funcDecl.Body.End() {
+			return funcDecl.Body
+		}
+	}
+	return nil
+}
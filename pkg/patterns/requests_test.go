@@ -0,0 +1,65 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_RequestsSent(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"context"
+	"net/http"
+)
+const webhookURL = "https://api.external.com/webhook"
+func backgroundWorker(ctx context.Context) {
+	client := &http.Client{}
+	req, _ := http.NewRequestWithContext(ctx, "POST", webhookURL, nil)
+	client.Do(req)
+}
+func withContext(ctx context.Context) {
+	req, err := http.NewRequest(http.MethodGet, "http://inventory.internal:8080/items", nil)
+	if err != nil {
+		return
+	}
+	req = req.WithContext(ctx)
+	http.DefaultClient.Do(req)
+}
+func roundTrip() {
+	var req, _ = http.NewRequest("GET", "http://metadata.internal/v1", nil)
+	retry := req.Clone(context.Background())
+	http.DefaultTransport.RoundTrip(retry)
+}
+func built() *http.Request {
+	req, _ := http.NewRequest("GET", "http://returned.internal/", nil)
+	return req
+}
+func other(client *http.Client) {
+	req, _ := http.NewRequest("GET", "http://unsent.internal/", nil)
+	sent, _ := http.NewRequest("GET", "http://sent.internal/", nil)
+	client.Do(sent)
+	_ = req
+}`)
+
+	tests := []struct {
+		pattern  string
+		protocol types.Protocol
+		endpoint string
+	}{
+		{"http.NewRequestWithContext", types.ProtocolHTTPS, "api.external.com:443"},
+		{"http.NewRequest", types.ProtocolHTTP, "inventory.internal:8080"},
+		{"http.NewRequest", types.ProtocolHTTP, "metadata.internal:80"},
+		{"http.NewRequest", types.ProtocolHTTP, "sent.internal:80"},
+	}
+	if len(sockets) != len(tests) {
+		t.Fatalf("Expected %d findings, got %d: %v", len(tests), len(sockets), sockets)
+	}
+	for i, tt := range tests {
+		socket := sockets[i]
+		if socket.PatternMatch != tt.pattern || socket.Type != types.TrafficTypeEgress || socket.Protocol != tt.protocol || socket.EndpointName() != tt.endpoint {
+			t.Errorf("Finding %d: expected %s %s %s, got %s %s %s", i, tt.pattern, tt.protocol, tt.endpoint,
+				socket.PatternMatch, socket.Protocol, socket.EndpointName())
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package patterns
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// ruleFamily documents the rules of one API family: what they report,
+// why that traffic matters and what code they match. Each initializer
+// declares the family of the rules it adds. A rule's ID is the family's
+// code plus its 1-based position in rules, so rules are only ever
+// appended.
+type ruleFamily struct {
+	name        string
+	code        int
+	description string
+	rationale   string
+	example     string
+	rules       []string
+}
+
+// ruleFamilies lists the documented families in the order they are
+// written out.
+var ruleFamilies = []*ruleFamily{
+	&standardLibraryRules,
+	&packetRules,
+	&httpServerRules,
+	&grpcRules,
+	&eventLoopRules,
+	&dockerRules,
+	&kubernetesRules,
+	&controllerRuntimeRules,
+	&coordinationRules,
+	&redisRules,
+	&optionClientRules,
+	&databaseRules,
+	&webRTCRules,
+	&p2pRules,
+	&cgoRules,
+	&webSocketRules,
+	&declarationRules,
+}
+
+// RuleFamily is the documentation of a family of rules.
+type RuleFamily struct {
+	Name        string
+	Description string
+	Rationale   string
+	// Example is Go code the rules match.
+	Example string
+	Rules   []RuleDoc
+}
+
+// RuleDoc is the documentation of one rule. ID is the pattern_match of
+// its findings and the rule ID of SARIF results; Code is the stable code,
+// such as SS1003, that checks lists enable and disable it by, empty for
+// custom patterns.
+type RuleDoc struct {
+	ID   string
+	Code string
+	// Summary says what the rule reports, for positional patterns where
+	// the address is taken from.
+	Summary string
+	// Imports lists the import paths a file needs for the rule to apply;
+	// empty for rules matched by package name alone.
+	Imports []string
+}
+
+// Anchor is the fragment naming the rule in generated documentation.
+func (d RuleDoc) Anchor() string {
+	return types.RuleAnchor(d.ID)
+}
+
+// RuleDocs documents the rules of pm, by family. Custom patterns are
+// listed last, in a family of their own.
+func (pm *PatternMatcher) RuleDocs() []RuleFamily {
+	documented := make(map[string]bool)
+	var families []RuleFamily
+	for _, family := range ruleFamilies {
+		doc := RuleFamily{
+			Name:        family.name,
+			Description: family.description,
+			Rationale:   family.rationale,
+			Example:     family.example,
+		}
+		for i, id := range family.rules {
+			documented[id] = true
+			rule := pm.ruleDoc(id)
+			rule.Code = ruleCode(family, i)
+			doc.Rules = append(doc.Rules, rule)
+		}
+		families = append(families, doc)
+	}
+
+	custom := RuleFamily{
+		Name:        "Custom patterns",
+		Description: "Functions added with a pattern file (-patterns), such as internal wrappers of the standard library.",
+		Rationale:   "Wrappers hide the standard library call from the built-in rules; describing them keeps their sockets in the inventory.",
+	}
+	for _, id := range pm.positionalNames() {
+		if !documented[id] {
+			custom.Rules = append(custom.Rules, pm.ruleDoc(id))
+		}
+	}
+	if len(custom.Rules) > 0 {
+		families = append(families, custom)
+	}
+	return families
+}
+
+// positionalNames returns the names of the positional patterns, sorted.
+func (pm *PatternMatcher) positionalNames() []string {
+	names := make([]string, 0, len(pm.ingressPatterns)+len(pm.egressPatterns))
+	for name := range pm.ingressPatterns {
+		names = append(names, name)
+	}
+	for name := range pm.egressPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (pm *PatternMatcher) ruleDoc(id string) RuleDoc {
+	doc := RuleDoc{ID: id, Imports: pm.requiredImports[id]}
+	if path, ok := pm.packagePaths[strings.Split(id, ".")[0]]; ok && doc.Imports == nil {
+		doc.Imports = []string{path}
+	}
+	if pattern, ok := pm.ingressPatterns[id]; ok {
+		doc.Summary = fmt.Sprintf("Reports an ingress %s socket listening on the address in argument %d.", pattern.Protocol, pattern.AddressArg)
+		if pattern.PortOnly {
+			doc.Summary += ` A port alone, such as ":8080", listens on all interfaces.`
+		}
+	} else if pattern, ok := pm.egressPatterns[id]; ok {
+		arg, url := pattern.argument()
+		kind := "address"
+		if url {
+			kind = "URL"
+		}
+		doc.Summary = fmt.Sprintf("Reports an egress %s connection to the %s in argument %d.", pattern.Protocol, kind, arg)
+		if pattern.Request {
+			doc.Summary += " The request must be sent in the same function, passed to a Do or RoundTrip call such as client.Do(req)."
+		}
+	}
+	return doc
+}
+
+// WriteRuleDocs writes families as a Markdown or HTML page, each rule
+// under a heading whose anchor is RuleDoc.Anchor.
+func WriteRuleDocs(writer io.Writer, families []RuleFamily, format string) error {
+	switch strings.ToLower(format) {
+	case "markdown", "md":
+		return writeRuleDocsMarkdown(writer, families)
+	case "html":
+		// html/template drops comments, so the marker is written apart
+		if _, err := io.WriteString(writer, "<!DOCTYPE html>\n"+generatedMarker); err != nil {
+			return err
+		}
+		return ruleDocsHTML.Execute(writer, families)
+	default:
+		return fmt.Errorf("unsupported rule documentation format %q: expected markdown or html", format)
+	}
+}
+
+func writeRuleDocsMarkdown(writer io.Writer, families []RuleFamily) error {
+	var b strings.Builder
+	b.WriteString("# staticsocket rules\n\n")
+	b.WriteString(generatedMarker + "\n")
+	b.WriteString("Each finding names the rule that produced it in `pattern_match`, which is also its rule ID in SARIF output. Built-in rules also have a stable code, such as `SS1001`, to enable or disable them with `-checks`.\n")
+	for _, family := range families {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n**Why it matters:** %s\n", family.Name, family.Description, family.Rationale)
+		if family.Example != "" {
+			fmt.Fprintf(&b, "\n```go\n%s\n```\n", strings.TrimSpace(family.Example))
+		}
+		for _, rule := range family.Rules {
+			fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n### `%s`\n", rule.Anchor(), rule.ID)
+			if rule.Code != "" || rule.Summary != "" || len(rule.Imports) > 0 {
+				b.WriteString("\n")
+			}
+			if rule.Code != "" {
+				fmt.Fprintf(&b, "Code: `%s`\n", rule.Code)
+				if rule.Summary != "" || len(rule.Imports) > 0 {
+					b.WriteString("\n")
+				}
+			}
+			if rule.Summary != "" {
+				fmt.Fprintf(&b, "%s\n", rule.Summary)
+			}
+			if len(rule.Imports) > 0 {
+				fmt.Fprintf(&b, "Applies in files importing `%s`.\n", strings.Join(rule.Imports, "`, `"))
+			}
+		}
+	}
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// generatedMarker marks the documentation as generated, in Markdown and
+// HTML alike.
+const generatedMarker = "<!-- Code generated by \"staticsocket patterns doc\". DO NOT EDIT. -->\n"
+
+var ruleDocsHTML = template.Must(template.New("rules").Parse(`<html>
+<head>
+<meta charset="utf-8">
+<title>staticsocket rules</title>
+</head>
+<body>
+<h1>staticsocket rules</h1>
+<p>Each finding names the rule that produced it in <code>pattern_match</code>, which is also its rule ID in SARIF output. Built-in rules also have a stable code, such as <code>SS1001</code>, to enable or disable them with <code>-checks</code>.</p>
+{{- range .}}
+<h2>{{.Name}}</h2>
+<p>{{.Description}}</p>
+<p><strong>Why it matters:</strong> {{.Rationale}}</p>
+{{- if .Example}}
+<pre><code>{{.Example}}</code></pre>
+{{- end}}
+{{- range .Rules}}
+<h3 id="{{.Anchor}}"><code>{{.ID}}</code></h3>
+{{- if .Code}}
+<p>Code: <code>{{.Code}}</code></p>
+{{- end}}
+{{- if .Summary}}
+<p>{{.Summary}}</p>
+{{- end}}
+{{- if .Imports}}
+<p>Applies in files importing {{range $i, $path := .Imports}}{{if $i}}, {{end}}<code>{{$path}}</code>{{end}}.</p>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,119 @@
+package patterns
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_RuleDocs(t *testing.T) {
+	pm := NewPatternMatcher()
+	families := pm.RuleDocs()
+
+	documented := make(map[string]bool)
+	anchors := make(map[string]string)
+	codes := make(map[string]string)
+	for _, family := range families {
+		if family.Description == "" || family.Rationale == "" || family.Example == "" {
+			t.Errorf("Family %s lacks a description, rationale or example", family.Name)
+		}
+		for _, rule := range family.Rules {
+			if documented[rule.ID] {
+				t.Errorf("Rule %s is documented twice", rule.ID)
+			}
+			documented[rule.ID] = true
+			if other, ok := anchors[rule.Anchor()]; ok {
+				t.Errorf("Rules %s and %s share the anchor %s", rule.ID, other, rule.Anchor())
+			}
+			anchors[rule.Anchor()] = rule.ID
+			if other, ok := codes[rule.Code]; ok || rule.Code == "" {
+				t.Errorf("Rule %s has the code %q of %s", rule.ID, rule.Code, other)
+			}
+			codes[rule.Code] = rule.ID
+		}
+	}
+
+	var rules []string
+	for name := range pm.ingressPatterns {
+		rules = append(rules, name)
+	}
+	for name := range pm.egressPatterns {
+		rules = append(rules, name)
+	}
+	for name := range pm.callMatchers {
+		rules = append(rules, name)
+	}
+	for name := range pm.callListMatchers {
+		rules = append(rules, name)
+	}
+	for name := range pm.literalMatchers {
+		rules = append(rules, name)
+	}
+	for name := range pm.fieldMatchers {
+		rules = append(rules, name)
+	}
+	for _, name := range rules {
+		if !documented[name] {
+			t.Errorf("Rule %s is not documented in any family", name)
+		}
+		delete(documented, name)
+	}
+	for name := range documented {
+		// Rules matching comments rather than code
+		if !strings.HasPrefix(name, "cgo:") && !strings.HasPrefix(name, "staticsocket:") {
+			t.Errorf("Documented rule %s does not exist", name)
+		}
+	}
+	if families[len(families)-1].Name == "Custom patterns" {
+		t.Error("Expected no custom patterns family without custom patterns")
+	}
+
+	if err := pm.AddCustomPattern(CustomPattern{Function: "netutil.ListenSecure", Package: "example.com/netutil", Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTPS}); err != nil {
+		t.Fatal(err)
+	}
+	families = pm.RuleDocs()
+	custom := families[len(families)-1]
+	if custom.Name != "Custom patterns" || len(custom.Rules) != 1 || custom.Rules[0].ID != "netutil.ListenSecure" || custom.Rules[0].Code != "" {
+		t.Fatalf("Expected the custom pattern documented last, got %+v", custom)
+	}
+	if want := "Reports an ingress https socket listening on the address in argument 0."; custom.Rules[0].Summary != want {
+		t.Errorf("Expected summary %q, got %q", want, custom.Rules[0].Summary)
+	}
+	if len(custom.Rules[0].Imports) != 1 || custom.Rules[0].Imports[0] != "example.com/netutil" {
+		t.Errorf("Expected the custom pattern's package, got %v", custom.Rules[0].Imports)
+	}
+}
+
+func TestWriteRuleDocs(t *testing.T) {
+	families := NewPatternMatcher().RuleDocs()
+
+	var markdown bytes.Buffer
+	if err := WriteRuleDocs(&markdown, families, "markdown"); err != nil {
+		t.Fatalf("WriteRuleDocs failed: %v", err)
+	}
+	if !strings.Contains(markdown.String(), "<a id=\"net-listen\"></a>\n### `net.Listen`") {
+		t.Error("Expected an anchored heading per rule")
+	}
+	committed, err := os.ReadFile("../../docs/rules.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if markdown.String() != string(committed) {
+		t.Error("docs/rules.md is out of date: run staticsocket patterns doc -output docs/rules.md")
+	}
+
+	var html bytes.Buffer
+	if err := WriteRuleDocs(&html, families, "html"); err != nil {
+		t.Fatalf("WriteRuleDocs failed: %v", err)
+	}
+	if !strings.Contains(html.String(), `<h3 id="grpc-newclient"><code>grpc.NewClient</code></h3>`) || !strings.Contains(html.String(), "DO NOT EDIT") {
+		t.Error("Expected anchored rule headings and the generated marker in HTML")
+	}
+
+	if err := WriteRuleDocs(&html, families, "pdf"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,334 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"slices"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for how listeners are shut down.
+const (
+	// TagNoShutdown marks listeners that nothing in their file closes.
+	TagNoShutdown = "no-shutdown"
+	// TagGracefulShutdown marks listeners whose server is drained, with
+	// Shutdown or GracefulStop, when the process is signaled.
+	TagGracefulShutdown = "graceful-shutdown"
+)
+
+// shutdownMethods are the methods that close a socket or stop the server
+// serving on it.
+var shutdownMethods = map[string]bool{
+	"Close":        true,
+	"Shutdown":     true,
+	"GracefulStop": true,
+	"Stop":         true,
+}
+
+// drainMethods are the shutdown methods that let in-flight requests
+// finish.
+var drainMethods = map[string]bool{
+	"Shutdown":     true,
+	"GracefulStop": true,
+}
+
+// signalNames maps the signal values of the os and syscall packages to
+// signal names.
+var signalNames = map[string]string{
+	"os.Interrupt": "SIGINT",
+	"os.Kill":      "SIGKILL",
+}
+
+// closableLiterals are the configuration literals whose sockets are closed
+// through the value itself or the client built from it.
+var closableLiterals = map[string]bool{
+	"http.Server":       true,
+	"clientv3.Config":   true,
+	"turn.ClientConfig": true,
+}
+
+// Shutdown describes how a socket found in a file is closed.
+type Shutdown struct {
+	// Call is the first call closing the socket or stopping the server
+	// it is handed to, as written (e.g. "defer lis.Close()"); empty when
+	// there is none in the file.
+	Call string
+	// Graceful is set when Call drains the server rather than closing it.
+	Graceful bool
+	// Signals are the signals the function making Call subscribes to with
+	// signal.Notify or signal.NotifyContext, by name (e.g. "SIGTERM"), or
+	// "all" for every signal.
+	Signals []string
+}
+
+// ShutdownPaths finds the calls that close the sockets of file: a Close of
+// the listener or connection a socket call is assigned to, often deferred,
+// or a Shutdown, GracefulStop, Stop or Close of what it is handed to, such
+// as grpcServer.Serve(lis) and grpcServer.GracefulStop(), of an
+// http.Server literal itself or of the client built from a config literal. Local variables are followed within their
+// function; fields such as s.lis across the file. A shutdown is triggered
+// by the signals its function subscribes to, as in
+//
+//	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM)
+//	<-ctx.Done()
+//	srv.Shutdown(context.Background())
+//
+// The result maps the position of every socket call and closable literal
+// to how it is closed.
+func (pm *PatternMatcher) ShutdownPaths(file *ast.File) map[token.Pos]*Shutdown {
+	scan := &shutdownScan{
+		pm:      pm,
+		file:    file,
+		imports: importNames(file),
+		holders: make(map[token.Pos][]string),
+		owners:  make(map[string][]string),
+		closers: make(map[string]Shutdown),
+	}
+	for i, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+			scan.function(funcDecl.Body, strconv.Itoa(i)+":")
+		}
+	}
+
+	paths := make(map[token.Pos]*Shutdown, len(scan.order))
+	for _, pos := range scan.order {
+		shutdown := scan.closer(scan.holders[pos])
+		paths[pos] = &shutdown
+	}
+	return paths
+}
+
+// shutdownScan collects, by key, what holds each socket, what each holder
+// hands it to and how each is closed. Keys of local variables are prefixed
+// with their function's scope; keys of fields are ".name".
+type shutdownScan struct {
+	pm      *PatternMatcher
+	file    *ast.File
+	imports map[string]bool
+
+	order   []token.Pos
+	holders map[token.Pos][]string
+	owners  map[string][]string
+	closers map[string]Shutdown
+}
+
+func (s *shutdownScan) function(body *ast.BlockStmt, scope string) {
+	key := func(expr ast.Expr) string {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			if e.Name == "_" || s.imports[e.Name] {
+				return ""
+			}
+			return scope + e.Name
+		case *ast.SelectorExpr:
+			return "." + e.Sel.Name
+		}
+		return ""
+	}
+	hold := func(value ast.Expr, holder string) {
+		if pos, ok := s.socketNode(value); ok {
+			s.track(pos)
+			if holder != "" {
+				s.holders[pos] = append(s.holders[pos], holder)
+			}
+		} else {
+			// e.Listener = lis
+			s.handTo(key(value), holder)
+		}
+	}
+
+	signals := s.signals(body)
+	deferred := make(map[*ast.CallExpr]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.DeferStmt:
+			deferred[node.Call] = true
+		case *ast.AssignStmt:
+			for i, rhs := range node.Rhs {
+				if i < len(node.Lhs) {
+					hold(rhs, key(node.Lhs[i]))
+				}
+				// m := cmux.New(lis), client, err := clientv3.New(clientv3.Config{...})
+				if call, ok := rhs.(*ast.CallExpr); ok && i < len(node.Lhs) {
+					for _, arg := range call.Args {
+						hold(arg, key(node.Lhs[i]))
+					}
+				}
+			}
+		case *ast.KeyValueExpr:
+			// &app{server: &http.Server{...}}
+			if field, ok := node.Key.(*ast.Ident); ok {
+				hold(node.Value, "."+field.Name)
+			}
+		case *ast.CompositeLit:
+			if pos, ok := s.socketNode(node); ok {
+				s.track(pos)
+			}
+		case *ast.CallExpr:
+			if pos, ok := s.socketNode(node); ok {
+				s.track(pos)
+			}
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || key(sel.X) == "" {
+				return true
+			}
+			if shutdownMethods[sel.Sel.Name] {
+				shutdown := Shutdown{Call: gotypes.ExprString(node), Graceful: drainMethods[sel.Sel.Name], Signals: signals}
+				if deferred[node] {
+					shutdown.Call = "defer " + shutdown.Call
+				}
+				s.close(key(sel.X), shutdown)
+				// resp.Body.Close() closes resp
+				if inner, ok := sel.X.(*ast.SelectorExpr); ok {
+					s.close(key(inner.X), shutdown)
+				}
+				return true
+			}
+			// grpcServer.Serve(lis)
+			for _, arg := range node.Args {
+				hold(arg, key(sel.X))
+			}
+		}
+		return true
+	})
+}
+
+// socketNode returns the position of the finding expr is, if it is a
+// socket call or a closable configuration literal.
+func (s *shutdownScan) socketNode(expr ast.Expr) (token.Pos, bool) {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		name := s.pm.positionalName(e, s.file)
+		if _, ok := s.pm.ingressPatterns[name]; ok {
+			return e.Pos(), true
+		}
+		if _, ok := s.pm.egressPatterns[name]; ok {
+			return e.Pos(), true
+		}
+	case *ast.CompositeLit:
+		sel, ok := e.Type.(*ast.SelectorExpr)
+		if !ok {
+			return token.NoPos, false
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return token.NoPos, false
+		}
+		name := pkg.Name + "." + sel.Sel.Name
+		if closableLiterals[name] && s.pm.importsRequired(name, s.file) {
+			return e.Pos(), true
+		}
+	}
+	return token.NoPos, false
+}
+
+func (s *shutdownScan) track(pos token.Pos) {
+	if _, seen := s.holders[pos]; !seen {
+		s.holders[pos] = nil
+		s.order = append(s.order, pos)
+	}
+}
+
+func (s *shutdownScan) handTo(from, to string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	for _, owner := range s.owners[from] {
+		if owner == to {
+			return
+		}
+	}
+	s.owners[from] = append(s.owners[from], to)
+}
+
+func (s *shutdownScan) close(key string, shutdown Shutdown) {
+	if _, seen := s.closers[key]; key != "" && !seen {
+		s.closers[key] = shutdown
+	}
+}
+
+// signals returns the signals body subscribes to, in the order named.
+func (s *shutdownScan) signals(body *ast.BlockStmt) []string {
+	if !s.imports["signal"] {
+		return nil
+	}
+	var names []string
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch gotypes.ExprString(call.Fun) {
+		case "signal.Notify", "signal.NotifyContext":
+		default:
+			return true
+		}
+		if len(call.Args) == 1 && !slices.Contains(names, "all") {
+			names = append(names, "all")
+		}
+		for _, arg := range call.Args[min(1, len(call.Args)):] {
+			name := gotypes.ExprString(arg)
+			if known, ok := signalNames[name]; ok {
+				name = known
+			} else if sel, ok := arg.(*ast.SelectorExpr); ok {
+				name = sel.Sel.Name
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// closer returns how the first call closing any of holders or what they
+// are handed to, breadth first, shuts the socket down.
+func (s *shutdownScan) closer(holders []string) Shutdown {
+	seen := make(map[string]bool)
+	queue := append([]string(nil), holders...)
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if shutdown, ok := s.closers[key]; ok {
+			return shutdown
+		}
+		queue = append(queue, s.owners[key]...)
+	}
+	return Shutdown{}
+}
+
+// ApplyShutdown records the call closing a socket and, for listeners, the
+// signals that trigger it. Listeners drained when the process is signaled
+// are tagged graceful-shutdown. Listeners nothing in their file closes are
+// tagged no-shutdown with a note, as an advisory: a process that cannot
+// stop accepting connections cannot drain them before it exits.
+func ApplyShutdown(socket *types.SocketInfo, shutdown *Shutdown) {
+	if shutdown == nil {
+		return
+	}
+	if shutdown.Call != "" {
+		socket.ClosedBy = shutdown.Call
+		if socket.Type == types.TrafficTypeIngress && len(shutdown.Signals) > 0 {
+			socket.ShutdownSignals = append(socket.ShutdownSignals, shutdown.Signals...)
+			if shutdown.Graceful {
+				socket.Tags = append(socket.Tags, TagGracefulShutdown)
+			}
+		}
+		return
+	}
+	if socket.Type == types.TrafficTypeIngress {
+		socket.Tags = append(socket.Tags, TagNoShutdown)
+		socket.Notes = append(socket.Notes, types.Message(types.MsgNoShutdown))
+	}
+}
@@ -0,0 +1,205 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_ShutdownPaths(t *testing.T) {
+	code := `package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+)
+
+type app struct {
+	lis net.Listener
+}
+
+func (a *app) start() {
+	a.lis, _ = net.Listen("tcp", ":9000")
+}
+
+func (a *app) stop() {
+	a.lis.Close()
+}
+
+func serveGRPC() {
+	lis, _ := net.Listen("tcp", ":9001")
+	s := grpc.NewServer()
+	go s.Serve(lis)
+	s.GracefulStop()
+}
+
+func serveHTTP(ctx context.Context) {
+	srv := &http.Server{Addr: ":9002"}
+	go srv.ListenAndServe()
+	<-ctx.Done()
+	srv.Shutdown(context.Background())
+}
+
+func serveMux() {
+	lis, _ := net.Listen("tcp", ":9003")
+	m := cmux.New(lis)
+	defer m.Close()
+}
+
+func dial() {
+	conn, _ := net.Dial("tcp", "db:5432")
+	defer conn.Close()
+	resp, _ := http.Get("http://example.com/9004")
+	defer resp.Body.Close()
+}
+
+func leak() {
+	lis, _ := net.Listen("tcp", ":9005")
+	http.Serve(lis, nil)
+}
+
+func otherFunction() {
+	lis, _ := net.Listen("tcp", ":9006")
+	lis.Accept()
+}
+
+func closesAnotherLis() {
+	var lis net.Listener
+	lis.Close()
+}
+
+func packageServer() {
+	http.ListenAndServe(":9007", nil)
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	pm := NewPatternMatcher()
+	closedBy := make(map[string]string)
+	for _, finding := range pm.MatchFile(file) {
+		closedBy[finding.Socket.EndpointName()] = finding.Socket.ClosedBy
+		if finding.Socket.Type == types.TrafficTypeIngress && finding.Socket.ClosedBy == "" && !containsTag(finding.Socket.Tags, TagNoShutdown) {
+			t.Errorf("Expected unclosed listener %s to be tagged %s", finding.Socket.EndpointName(), TagNoShutdown)
+		}
+	}
+
+	expected := map[string]string{
+		"0.0.0.0:9000":   "a.lis.Close()",
+		"0.0.0.0:9001":   "s.GracefulStop()",
+		"0.0.0.0:9002":   "srv.Shutdown(context.Background())",
+		"0.0.0.0:9003":   "defer m.Close()",
+		"db:5432":        "defer conn.Close()",
+		"example.com:80": "defer resp.Body.Close()",
+		"0.0.0.0:9005":   "",
+		"0.0.0.0:9006":   "",
+		"0.0.0.0:9007":   "",
+	}
+	for endpoint, want := range expected {
+		got, ok := closedBy[endpoint]
+		if !ok {
+			t.Errorf("Expected a finding for %s, got %v", endpoint, closedBy)
+			continue
+		}
+		if got != want {
+			t.Errorf("Finding %s: expected closed by %q, got %q", endpoint, want, got)
+		}
+	}
+}
+
+func TestApplyShutdown(t *testing.T) {
+	egress := &types.SocketInfo{Type: types.TrafficTypeEgress}
+	ApplyShutdown(egress, &Shutdown{})
+	if len(egress.Tags) != 0 || len(egress.Notes) != 0 {
+		t.Errorf("Expected unclosed egress to carry no advisory, got %+v", egress)
+	}
+
+	untracked := &types.SocketInfo{Type: types.TrafficTypeIngress}
+	ApplyShutdown(untracked, nil)
+	if len(untracked.Tags) != 0 {
+		t.Errorf("Expected findings without a shutdown path to be left alone, got %+v", untracked)
+	}
+
+	listener := &types.SocketInfo{Type: types.TrafficTypeIngress}
+	ApplyShutdown(listener, &Shutdown{})
+	if !containsTag(listener.Tags, TagNoShutdown) || len(listener.Notes) != 1 {
+		t.Errorf("Expected an advisory on an unclosed listener, got %+v", listener)
+	}
+}
+
+func TestPatternMatcher_ShutdownSignals(t *testing.T) {
+	sockets := matchFile(t, `package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+)
+
+func serveHTTP() {
+	srv := &http.Server{Addr: ":8080"}
+	go srv.ListenAndServe()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	srv.Shutdown(context.Background())
+}
+
+func serveGRPC() {
+	lis, _ := net.Listen("tcp", ":9090")
+	s := grpc.NewServer()
+	go s.Serve(lis)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs)
+	<-sigs
+	s.Stop()
+}
+
+func serveUnsignaled(ctx context.Context) {
+	srv := &http.Server{Addr: ":8081"}
+	go srv.ListenAndServe()
+	<-ctx.Done()
+	srv.Shutdown(ctx)
+}
+`)
+
+	expected := map[string]struct {
+		signals  []string
+		graceful bool
+	}{
+		"0.0.0.0:8080": {[]string{"SIGINT", "SIGTERM"}, true},
+		"0.0.0.0:9090": {[]string{"all"}, false},
+		"0.0.0.0:8081": {nil, false},
+	}
+	for _, socket := range sockets {
+		want, ok := expected[socket.EndpointName()]
+		if !ok {
+			continue
+		}
+		delete(expected, socket.EndpointName())
+		if !reflect.DeepEqual(socket.ShutdownSignals, want.signals) {
+			t.Errorf("Listener %s: expected shutdown signals %v, got %v", socket.EndpointName(), want.signals, socket.ShutdownSignals)
+		}
+		if containsTag(socket.Tags, TagGracefulShutdown) != want.graceful {
+			t.Errorf("Listener %s: expected graceful-shutdown tag %t, got tags %v", socket.EndpointName(), want.graceful, socket.Tags)
+		}
+	}
+	for endpoint := range expected {
+		t.Errorf("Expected a finding for %s", endpoint)
+	}
+}
@@ -0,0 +1,277 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"strconv"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// optionSetters maps the methods of net.TCPConn, net.UDPConn and the
+// other connection types that set socket options to the option they set.
+var optionSetters = map[string]string{
+	"SetKeepAlive":       types.SocketOptionKeepAlive,
+	"SetKeepAlivePeriod": types.SocketOptionKeepAlivePeriod,
+	"SetKeepAliveConfig": types.SocketOptionKeepAliveConfig,
+	"SetNoDelay":         types.SocketOptionNoDelay,
+	"SetReadBuffer":      types.SocketOptionReadBuffer,
+	"SetWriteBuffer":     types.SocketOptionWriteBuffer,
+	"SetLinger":          types.SocketOptionLinger,
+}
+
+// optionFields maps the fields of net.Dialer and net.ListenConfig that
+// configure the sockets they open to the option they set.
+var optionFields = map[string]string{
+	"KeepAlive":       types.SocketOptionKeepAlivePeriod,
+	"KeepAliveConfig": types.SocketOptionKeepAliveConfig,
+	"Control":         types.SocketOptionControl,
+	"ControlContext":  types.SocketOptionControl,
+}
+
+// acceptMethods return a connection of the listener they are called on,
+// whose options are those of the listener's sockets.
+var acceptMethods = map[string]bool{
+	"Accept":     true,
+	"AcceptTCP":  true,
+	"AcceptUnix": true,
+}
+
+// SocketOptions finds the options set on the sockets of file: calls such
+// as SetNoDelay or SetReadBuffer on a connection, possibly after a type
+// assertion to *net.TCPConn, or on the connections a listener accepts,
+// and the KeepAlive and Control fields of the net.Dialer or
+// net.ListenConfig a socket is opened with. Local variables are followed
+// within their function; fields such as s.conn across the file. The result
+// maps the position of every socket call with options to them, in source
+// order.
+func (pm *PatternMatcher) SocketOptions(file *ast.File) map[token.Pos][]types.SocketOption {
+	scan := &optionScan{
+		pm:      pm,
+		file:    file,
+		imports: importNames(file),
+		holders: make(map[token.Pos][]string),
+		derived: make(map[string][]string),
+		options: make(map[string][]types.SocketOption),
+		direct:  make(map[token.Pos][]types.SocketOption),
+	}
+	for i, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+			scan.function(funcDecl.Body, strconv.Itoa(i)+":")
+		}
+	}
+
+	options := make(map[token.Pos][]types.SocketOption)
+	for _, pos := range scan.order {
+		found := append(append([]types.SocketOption(nil), scan.direct[pos]...), scan.reached(scan.holders[pos])...)
+		if len(found) > 0 {
+			options[pos] = found
+		}
+	}
+	return options
+}
+
+// optionScan collects, by key, what holds each socket, what each holder
+// is passed on to and the options set on each. Keys follow shutdownScan.
+type optionScan struct {
+	pm      *PatternMatcher
+	file    *ast.File
+	imports map[string]bool
+
+	order   []token.Pos
+	holders map[token.Pos][]string
+	derived map[string][]string
+	options map[string][]types.SocketOption
+	// direct holds the options of the dialer or listen config a socket
+	// call is made on.
+	direct map[token.Pos][]types.SocketOption
+}
+
+func (s *optionScan) function(body *ast.BlockStmt, scope string) {
+	key := func(expr ast.Expr) string {
+		switch e := unwrapConn(expr).(type) {
+		case *ast.Ident:
+			if e.Name == "_" || s.imports[e.Name] {
+				return ""
+			}
+			return scope + e.Name
+		case *ast.SelectorExpr:
+			return "." + e.Sel.Name
+		}
+		return ""
+	}
+	assign := func(lhs ast.Expr, rhs ast.Expr) {
+		holder := key(lhs)
+		if holder == "" {
+			return
+		}
+		if call, ok := rhs.(*ast.CallExpr); ok {
+			if s.socketCall(call) {
+				s.track(call.Pos())
+				s.holders[call.Pos()] = append(s.holders[call.Pos()], holder)
+				return
+			}
+			// conn, err := lis.Accept()
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && acceptMethods[sel.Sel.Name] {
+				s.derive(key(sel.X), holder)
+			}
+			return
+		}
+		if options := s.configOptions(rhs); options != nil {
+			s.options[holder] = append(s.options[holder], options...)
+			return
+		}
+		// tcp := conn.(*net.TCPConn), s.conn = conn
+		s.derive(key(rhs), holder)
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if len(node.Rhs) == 1 && len(node.Lhs) > 1 {
+				// conn, err := net.Dial(...), tcp, ok := conn.(*net.TCPConn)
+				assign(node.Lhs[0], node.Rhs[0])
+				return true
+			}
+			for i, rhs := range node.Rhs {
+				if i < len(node.Lhs) {
+					assign(node.Lhs[i], rhs)
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range node.Values {
+				if i < len(node.Names) {
+					assign(node.Names[i], value)
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if s.socketCall(node) {
+				s.track(node.Pos())
+				// dialer.Dial(...), (&net.Dialer{KeepAlive: -1}).Dial(...)
+				if options := s.configOptions(sel.X); options != nil {
+					s.direct[node.Pos()] = append(s.direct[node.Pos()], options...)
+				} else if config := key(sel.X); config != "" {
+					s.holders[node.Pos()] = append(s.holders[node.Pos()], config)
+				}
+				return true
+			}
+			if option, ok := optionSetters[sel.Sel.Name]; ok && len(node.Args) == 1 {
+				if holder := key(sel.X); holder != "" {
+					s.options[holder] = append(s.options[holder], types.SocketOption{Name: option, Value: gotypes.ExprString(node.Args[0])})
+				}
+			}
+		}
+		return true
+	})
+}
+
+// socketCall reports whether call is a call of a positional pattern.
+func (s *optionScan) socketCall(call *ast.CallExpr) bool {
+	name := s.pm.positionalName(call, s.file)
+	if _, ok := s.pm.ingressPatterns[name]; ok {
+		return true
+	}
+	_, ok := s.pm.egressPatterns[name]
+	return ok
+}
+
+// configOptions returns the options set by a net.Dialer or
+// net.ListenConfig literal, an empty slice for one setting none, and nil
+// for other expressions.
+func (s *optionScan) configOptions(expr ast.Expr) []types.SocketOption {
+	expr = unwrapConn(expr)
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Dialer" && sel.Sel.Name != "ListenConfig") {
+		return nil
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok {
+		return nil
+	} else if path, _ := importedPath(s.file, pkg.Name); path != "net" {
+		return nil
+	}
+	options := []types.SocketOption{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		field, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if option, ok := optionFields[field.Name]; ok {
+			options = append(options, types.SocketOption{Name: option, Value: gotypes.ExprString(kv.Value)})
+		}
+	}
+	return options
+}
+
+func (s *optionScan) track(pos token.Pos) {
+	if _, seen := s.holders[pos]; !seen {
+		s.holders[pos] = nil
+		s.order = append(s.order, pos)
+	}
+}
+
+func (s *optionScan) derive(from, to string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+	for _, key := range s.derived[from] {
+		if key == to {
+			return
+		}
+	}
+	s.derived[from] = append(s.derived[from], to)
+}
+
+// reached returns the options set on holders and on what they are passed
+// on to, breadth first.
+func (s *optionScan) reached(holders []string) []types.SocketOption {
+	var options []types.SocketOption
+	seen := make(map[string]bool)
+	queue := append([]string(nil), holders...)
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		options = append(options, s.options[key]...)
+		queue = append(queue, s.derived[key]...)
+	}
+	return options
+}
+
+// unwrapConn strips parentheses and type assertions, as in
+// conn.(*net.TCPConn).SetNoDelay(false).
+func unwrapConn(expr ast.Expr) ast.Expr {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.TypeAssertExpr:
+			expr = e.X
+		default:
+			return expr
+		}
+	}
+}
+
+// ApplySocketOptions records the options set on a socket.
+func ApplySocketOptions(socket *types.SocketInfo, options []types.SocketOption) {
+	socket.SocketOptions = append(socket.SocketOptions, options...)
+}
@@ -0,0 +1,143 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"reflect"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_SocketOptions(t *testing.T) {
+	code := `package main
+
+import (
+	"net"
+	"time"
+)
+
+const bufferSize = 1 << 20
+
+type client struct {
+	conn net.Conn
+}
+
+func (c *client) connect() {
+	c.conn, _ = net.Dial("tcp", "db:5432")
+}
+
+func (c *client) tune() {
+	c.conn.(*net.TCPConn).SetNoDelay(false)
+}
+
+func dial() {
+	conn, _ := net.Dial("tcp", "cache:6379")
+	tcp, ok := conn.(*net.TCPConn)
+	if ok {
+		tcp.SetKeepAlive(true)
+		tcp.SetKeepAlivePeriod(30 * time.Second)
+	}
+}
+
+func serve() {
+	lis, _ := net.Listen("tcp", ":9000")
+	for {
+		conn, _ := lis.Accept()
+		conn.(*net.TCPConn).SetLinger(0)
+	}
+}
+
+func udp() {
+	conn, _ := net.ListenPacket("udp", ":9001")
+	udp := conn.(*net.UDPConn)
+	udp.SetReadBuffer(bufferSize)
+	udp.SetWriteBuffer(bufferSize)
+}
+
+func defaults() {
+	conn, _ := net.Dial("tcp", "api:443")
+	conn.Close()
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+
+	options := make(map[string][]types.SocketOption)
+	for _, finding := range NewPatternMatcher().MatchFile(file) {
+		options[finding.Socket.EndpointName()] = finding.Socket.SocketOptions
+	}
+
+	expected := map[string][]types.SocketOption{
+		"db:5432": {{Name: types.SocketOptionNoDelay, Value: "false"}},
+		"cache:6379": {
+			{Name: types.SocketOptionKeepAlive, Value: "true"},
+			{Name: types.SocketOptionKeepAlivePeriod, Value: "30 * time.Second"},
+		},
+		"0.0.0.0:9000": {{Name: types.SocketOptionLinger, Value: "0"}},
+		"0.0.0.0:9001": {
+			{Name: types.SocketOptionReadBuffer, Value: "bufferSize"},
+			{Name: types.SocketOptionWriteBuffer, Value: "bufferSize"},
+		},
+		"api:443": nil,
+	}
+	for endpoint, want := range expected {
+		got, ok := options[endpoint]
+		if !ok {
+			t.Errorf("Expected a finding for %s, got %v", endpoint, options)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Finding %s: expected options %v, got %v", endpoint, want, got)
+		}
+	}
+}
+
+func TestPatternMatcher_SocketOptionsOfDialer(t *testing.T) {
+	code := `package main
+
+import (
+	"net"
+	"time"
+)
+
+func main() {
+	d := &net.Dialer{Timeout: time.Second, KeepAlive: -1}
+	d.Dial("tcp", "db:5432")
+	(&net.Dialer{KeepAlive: 15 * time.Second}).Dial("tcp", "cache:6379")
+	var plain net.Dialer
+	plain.Dial("tcp", "api:443")
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &gotypes.Info{Uses: make(map[*ast.Ident]gotypes.Object)}
+	config := gotypes.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("main", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+	pm := NewPatternMatcher()
+	pm.SetTypesInfo(file, info)
+
+	options := make(map[string][]types.SocketOption)
+	for _, finding := range pm.MatchFile(file) {
+		options[finding.Socket.EndpointName()] = finding.Socket.SocketOptions
+	}
+	expected := map[string][]types.SocketOption{
+		"db:5432":    {{Name: types.SocketOptionKeepAlivePeriod, Value: "-1"}},
+		"cache:6379": {{Name: types.SocketOptionKeepAlivePeriod, Value: "15 * time.Second"}},
+		"api:443":    nil,
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Expected options %v, got %v", expected, options)
+	}
+}
@@ -0,0 +1,126 @@
+package patterns
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// TLSVerification records where a file sets InsecureSkipVerify, in a
+// tls.Config literal, a library config with the same field, or an
+// assignment such as tr.TLSClientConfig.InsecureSkipVerify = true. Settings
+// are kept per enclosing function, named as FunctionScope names them; ""
+// holds package-level settings.
+type TLSVerification struct {
+	// disabled lists the functions setting InsecureSkipVerify to true.
+	disabled map[string]bool
+	// dynamic lists the functions setting it to a value not known
+	// statically.
+	dynamic map[string]bool
+}
+
+// TLSVerification collects the InsecureSkipVerify settings of file.
+func (pm *PatternMatcher) TLSVerification(file *ast.File) *TLSVerification {
+	verification := &TLSVerification{disabled: make(map[string]bool), dynamic: make(map[string]bool)}
+	var scope FunctionScope
+	ast.Inspect(file, func(n ast.Node) bool {
+		scope.Visit(n)
+		switch node := n.(type) {
+		case *ast.KeyValueExpr:
+			if key, ok := node.Key.(*ast.Ident); ok && key.Name == "InsecureSkipVerify" {
+				verification.record(scope.Name(), node.Value, file)
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if sel, ok := lhs.(*ast.SelectorExpr); ok && sel.Sel.Name == "InsecureSkipVerify" && i < len(node.Rhs) {
+					verification.record(scope.Name(), node.Rhs[i], file)
+				}
+			}
+		}
+		return true
+	})
+	return verification
+}
+
+func (v *TLSVerification) record(function string, value ast.Expr, file *ast.File) {
+	skip, known := boolConstant(value, file)
+	switch {
+	case !known:
+		v.dynamic[function] = true
+	case skip:
+		v.disabled[function] = true
+	}
+}
+
+// ApplyTLSVerification sets VerifiesTLS on egress that uses TLS: false
+// when its function, or the file at package level, turns certificate
+// verification off; true when nothing in the file does; and unknown when
+// the setting is not known statically or is made in another function,
+// whose config may or may not reach this connection. Plaintext egress and
+// ingress are left unset.
+func ApplyTLSVerification(socket *types.SocketInfo, verification *TLSVerification) {
+	if socket.Type != types.TrafficTypeEgress || !usesTLS(socket) || verification == nil {
+		return
+	}
+	function := socket.FunctionName
+	verifies := false
+	switch {
+	case verification.disabled[function] || verification.disabled[""]:
+		socket.VerifiesTLS = &verifies
+		socket.Notes = append(socket.Notes, types.Message(types.MsgTLSVerificationDisabled))
+	case len(verification.disabled) > 0 || len(verification.dynamic) > 0:
+	default:
+		verifies = true
+		socket.VerifiesTLS = &verifies
+	}
+}
+
+// usesTLS reports whether egress is encrypted with TLS: a secure protocol
+// such as https, or gRPC without plaintext transport credentials.
+func usesTLS(socket *types.SocketInfo) bool {
+	if socket.Protocol == types.ProtocolGRPC {
+		for _, tag := range socket.Tags {
+			if tag == TagPlaintext {
+				return false
+			}
+		}
+		return true
+	}
+	return socket.Protocol.Secure()
+}
+
+// boolConstant evaluates true, false and constants of file declared as
+// either.
+func boolConstant(expr ast.Expr, file *ast.File) (value, ok bool) {
+	ident, isIdent := expr.(*ast.Ident)
+	if !isIdent {
+		return false, false
+	}
+	switch ident.Name {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	for _, decl := range file.Decls {
+		genDecl, isGen := decl.(*ast.GenDecl)
+		if !isGen || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec, isValue := spec.(*ast.ValueSpec)
+			if !isValue {
+				continue
+			}
+			for i, name := range valueSpec.Names {
+				if name.Name == ident.Name && i < len(valueSpec.Values) {
+					if literal, isLiteral := valueSpec.Values[i].(*ast.Ident); isLiteral && literal.Name != ident.Name {
+						return boolConstant(literal, file)
+					}
+				}
+			}
+		}
+	}
+	return false, false
+}
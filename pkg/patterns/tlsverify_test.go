@@ -0,0 +1,85 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_TLSVerification(t *testing.T) {
+	sockets := matchFile(t, `package client
+import (
+	"crypto/tls"
+	"net/http"
+)
+const skipVerify = true
+func fetch() {
+	http.Get("https://api.example.com/v1")
+	http.Get("http://plain.example.com/")
+}
+func insecure() {
+	tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify}}
+	client := &http.Client{Transport: tr}
+	client.Get("https://legacy.internal/")
+	http.Get("https://legacy.internal/health")
+}`)
+
+	tests := []struct {
+		host     string
+		verifies string
+	}{
+		{"api.example.com", ""},
+		{"plain.example.com", ""},
+		{"legacy.internal", "false"},
+	}
+	if len(sockets) != len(tests) {
+		t.Fatalf("Expected %d findings, got %d", len(tests), len(sockets))
+	}
+	for i, tt := range tests {
+		socket := sockets[i]
+		verifies := ""
+		if socket.VerifiesTLS != nil {
+			verifies = map[bool]string{true: "true", false: "false"}[*socket.VerifiesTLS]
+		}
+		if socket.Destination.Host != tt.host || verifies != tt.verifies {
+			t.Errorf("Finding %d: expected %s verifies %q, got %s verifies %q", i, tt.host, tt.verifies, socket.Destination.Host, verifies)
+		}
+	}
+}
+
+func TestApplyTLSVerification(t *testing.T) {
+	egress := func(protocol types.Protocol, function string, tags ...string) *types.SocketInfo {
+		return &types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: protocol, FunctionName: function, Tags: tags}
+	}
+	none := &TLSVerification{disabled: map[string]bool{}, dynamic: map[string]bool{}}
+	elsewhere := &TLSVerification{disabled: map[string]bool{"other": true}, dynamic: map[string]bool{}}
+	global := &TLSVerification{disabled: map[string]bool{"": true}, dynamic: map[string]bool{}}
+
+	tests := []struct {
+		name         string
+		socket       *types.SocketInfo
+		verification *TLSVerification
+		expected     *bool
+	}{
+		{"https verified by default", egress(types.ProtocolHTTPS, "fetch"), none, boolPtr(true)},
+		{"grpc over TLS", egress(types.ProtocolGRPC, "dial"), none, boolPtr(true)},
+		{"plaintext grpc", egress(types.ProtocolGRPC, "dial", TagPlaintext), none, nil},
+		{"plain http", egress(types.ProtocolHTTP, "fetch"), none, nil},
+		{"disabled in another function", egress(types.ProtocolHTTPS, "fetch"), elsewhere, nil},
+		{"disabled at package level", egress(types.ProtocolHTTPS, "fetch"), global, boolPtr(false)},
+		{"ingress", &types.SocketInfo{Type: types.TrafficTypeIngress, Protocol: types.ProtocolHTTPS}, none, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ApplyTLSVerification(tt.socket, tt.verification)
+			got := tt.socket.VerifiesTLS
+			if (got == nil) != (tt.expected == nil) || (got != nil && *got != *tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,220 @@
+package patterns
+
+import (
+	"go/ast"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Tags for real-time media traffic, which uses UDP to endpoints negotiated
+// at runtime through ICE.
+const (
+	TagWebRTC = "webrtc"
+	TagICE    = "ice"
+	TagSTUN   = "stun"
+	TagTURN   = "turn"
+)
+
+// Default ports of STUN and TURN servers (RFC 8489, RFC 8656).
+const (
+	stunDefaultPort    = 3478
+	stunTLSDefaultPort = 5349
+)
+
+var (
+	webrtcImports = []string{"github.com/pion/webrtc", "github.com/pion/webrtc/v2", "github.com/pion/webrtc/v3", "github.com/pion/webrtc/v4"}
+	turnImports   = []string{"github.com/pion/turn", "github.com/pion/turn/v2", "github.com/pion/turn/v3", "github.com/pion/turn/v4"}
+	stunImports   = []string{"github.com/pion/stun", "github.com/pion/stun/v2", "github.com/pion/stun/v3"}
+)
+
+var webRTCRules = ruleFamily{
+	name:        "WebRTC, STUN and TURN",
+	code:        2200,
+	description: "STUN and TURN servers of ICE configurations and pion clients, and the UDP ports a peer connection gathers host candidates on, within the ephemeral range the file sets.",
+	rationale:   "Real-time media uses UDP to endpoints negotiated at runtime, so firewalls need the STUN and TURN servers and the candidate port range.",
+	example: `pc, err := webrtc.NewPeerConnection(webrtc.Configuration{
+	ICEServers: []webrtc.ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}},
+})`,
+	rules: []string{"webrtc.ICEServer", "webrtc.NewPeerConnection", "webrtc.NewAPI", "turn.ClientConfig", "stun.Dial"},
+}
+
+func (pm *PatternMatcher) initializeWebRTCPatterns() {
+	pm.literalMatchers["webrtc.ICEServer"] = matchICEServer
+	pm.requiredImports["webrtc.ICEServer"] = webrtcImports
+	for _, name := range []string{"webrtc.NewPeerConnection", "webrtc.NewAPI"} {
+		pm.callMatchers[name] = matchPeerConnection
+		pm.requiredImports[name] = webrtcImports
+	}
+
+	pm.literalMatchers["turn.ClientConfig"] = matchTURNClientConfig
+	pm.requiredImports["turn.ClientConfig"] = turnImports
+	pm.callMatchers["stun.Dial"] = matchSTUNDial
+	pm.requiredImports["stun.Dial"] = stunImports
+}
+
+func newICESocket(typeName, rawValue string, tags ...string) *types.SocketInfo {
+	return &types.SocketInfo{
+		Type:         types.TrafficTypeEgress,
+		Protocol:     types.ProtocolUDP,
+		RawValue:     rawValue,
+		PatternMatch: typeName,
+		FunctionName: "unknown",
+		Tags:         append([]string{TagWebRTC}, tags...),
+	}
+}
+
+// matchICEServer reports one egress per STUN or TURN URL of an ICE server,
+// e.g. webrtc.ICEServer{URLs: []string{"stun:stun.l.google.com:19302"}}.
+func matchICEServer(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name != "URLs" {
+			continue
+		}
+		urls, ok := kv.Value.(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		for _, urlExpr := range urls.Elts {
+			raw, _ := pm.resolveConstant(urlExpr, file)
+			socket := newICESocket(typeName, raw)
+			parseICEServerURL(socket, raw)
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+// parseICEServerURL fills the destination from a STUN or TURN URI such as
+// "turns:turn.example.com:443?transport=tcp" (RFC 7064, RFC 7065).
+func parseICEServerURL(socket *types.SocketInfo, raw string) {
+	scheme, rest, ok := strings.Cut(raw, ":")
+	if !ok {
+		return
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+
+	port := stunDefaultPort
+	switch scheme {
+	case "stun", "turn":
+	case "stuns", "turns":
+		port = stunTLSDefaultPort
+		socket.Protocol = types.ProtocolTCP
+	default:
+		return
+	}
+	if strings.HasPrefix(scheme, "stun") {
+		socket.Tags = append(socket.Tags, TagSTUN)
+	} else {
+		socket.Tags = append(socket.Tags, TagTURN)
+	}
+	if query == "transport=tcp" {
+		socket.Protocol = types.ProtocolTCP
+	}
+
+	host := rest
+	if h, p, err := net.SplitHostPort(rest); err == nil {
+		host = h
+		if explicit, err := strconv.Atoi(p); err == nil {
+			port = explicit
+		}
+	}
+	if host == "" {
+		return
+	}
+	socket.Destination = types.NewEndpoint(host, &port)
+	socket.IsResolved = true
+}
+
+// matchPeerConnection reports the UDP ports ICE gathers host candidates on
+// for a peer connection. Peers reach them through hole punching, so they
+// are ingress on ports chosen at runtime, within the range set with
+// SettingEngine.SetEphemeralUDPPortRange when the file sets one.
+func matchPeerConnection(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	socket := newICESocket(funcName, "ephemeral", TagICE)
+	socket.Type = types.TrafficTypeIngress
+	if ports, ok := ephemeralUDPPortRange(file); ok {
+		socket.Listen = types.NewEndpoint("0.0.0.0", nil)
+		socket.Listen.PortRange = ports
+		socket.RawValue = strconv.Itoa(ports.Start) + "-" + strconv.Itoa(ports.End)
+		socket.IsResolved = true
+	}
+	return socket
+}
+
+func ephemeralUDPPortRange(file *ast.File) (*types.PortRange, bool) {
+	var ports *types.PortRange
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return ports == nil
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "SetEphemeralUDPPortRange" {
+			return true
+		}
+		start, okStart := intLiteral(call.Args[0])
+		end, okEnd := intLiteral(call.Args[1])
+		if okStart && okEnd {
+			ports = &types.PortRange{Start: start, End: end}
+		}
+		return ports == nil
+	})
+	return ports, ports != nil
+}
+
+// matchTURNClientConfig reports the STUN and TURN servers a pion/turn
+// client talks to, given as host:port.
+func matchTURNClientConfig(pm *PatternMatcher, lit *ast.CompositeLit, file *ast.File, typeName string) []*types.SocketInfo {
+	var sockets []*types.SocketInfo
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		var tag string
+		switch key.Name {
+		case "STUNServerAddr":
+			tag = TagSTUN
+		case "TURNServerAddr":
+			tag = TagTURN
+		default:
+			continue
+		}
+		raw, _ := pm.resolveConstant(kv.Value, file)
+		socket := newICESocket(typeName, raw, tag)
+		if raw != "" {
+			pm.parseEgressAddress(socket, raw)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// matchSTUNDial handles stun.Dial("udp", "stun.l.google.com:19302").
+func matchSTUNDial(pm *PatternMatcher, callExpr *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	if len(callExpr.Args) != 2 {
+		return nil
+	}
+	raw, _ := pm.resolveConstant(callExpr.Args[1], file)
+	socket := newICESocket(funcName, raw, TagSTUN)
+	if strings.HasPrefix(pm.extractStringLiteral(callExpr.Args[0]), "tcp") {
+		socket.Protocol = types.ProtocolTCP
+	}
+	if raw != "" {
+		pm.parseEgressAddress(socket, raw)
+	}
+	return socket
+}
@@ -0,0 +1,122 @@
+package patterns
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_ICEServers(t *testing.T) {
+	code := `package main
+import "github.com/pion/webrtc/v4"
+const turnURL = "turns:turn.example.com:443?transport=tcp"
+func connect() {
+	config := webrtc.Configuration{
+		ICEServers: []webrtc.ICEServer{
+			{URLs: []string{"stun:stun.l.google.com:19302", "turn:turn.example.com"}},
+			{URLs: []string{turnURL}, Username: "user"},
+		},
+	}
+	webrtc.NewPeerConnection(config)
+}`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse code: %v", err)
+	}
+	findings := NewPatternMatcher().MatchFile(file)
+	if len(findings) != 4 {
+		t.Fatalf("Expected 3 ICE servers and 1 peer connection, got %d", len(findings))
+	}
+
+	expected := []struct {
+		line     int
+		host     string
+		port     int
+		protocol types.Protocol
+		tag      string
+	}{
+		{7, "stun.l.google.com", 19302, types.ProtocolUDP, TagSTUN},
+		{7, "turn.example.com", 3478, types.ProtocolUDP, TagTURN},
+		{8, "turn.example.com", 443, types.ProtocolTCP, TagTURN},
+	}
+	for i, want := range expected {
+		socket := findings[i].Socket
+		if line := fset.Position(findings[i].Pos).Line; line != want.line {
+			t.Errorf("ICE server %d: expected line %d, got %d", i, want.line, line)
+		}
+		if !socket.IsResolved || socket.Destination.Host != want.host || *socket.Destination.Port != want.port {
+			t.Errorf("ICE server %d: expected %s:%d, got %+v", i, want.host, want.port, socket.Destination)
+		}
+		if socket.Type != types.TrafficTypeEgress || socket.Protocol != want.protocol {
+			t.Errorf("ICE server %d: expected %s egress, got %s %s", i, want.protocol, socket.Protocol, socket.Type)
+		}
+		if len(socket.Tags) != 2 || socket.Tags[0] != TagWebRTC || socket.Tags[1] != want.tag {
+			t.Errorf("ICE server %d: expected webrtc and %s tags, got %v", i, want.tag, socket.Tags)
+		}
+	}
+
+	peer := findings[3].Socket
+	if peer.Type != types.TrafficTypeIngress || peer.Protocol != types.ProtocolUDP || peer.IsResolved {
+		t.Errorf("Expected unresolved UDP ingress for the peer connection, got %+v", peer)
+	}
+}
+
+func TestPatternMatcher_PeerConnectionPortRange(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "github.com/pion/webrtc/v3"
+func newAPI() *webrtc.API {
+	s := webrtc.SettingEngine{}
+	s.SetEphemeralUDPPortRange(10000, 10100)
+	return webrtc.NewAPI(webrtc.WithSettingEngine(s))
+}`)
+
+	if len(sockets) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(sockets))
+	}
+	listen := sockets[0].Listen
+	if !sockets[0].IsResolved || listen == nil || listen.PortRange == nil || *listen.PortRange != (types.PortRange{Start: 10000, End: 10100}) {
+		t.Errorf("Expected ICE ports 10000-10100, got %+v", listen)
+	}
+}
+
+func TestPatternMatcher_STUNAndTURNClients(t *testing.T) {
+	sockets := matchAll(t, `package main
+import (
+	"github.com/pion/stun/v2"
+	"github.com/pion/turn/v4"
+)
+func dial() {
+	stun.Dial("udp4", "stun.example.com:3478")
+	turn.NewClient(&turn.ClientConfig{
+		STUNServerAddr: "stun.example.com:3478",
+		TURNServerAddr: "turn.example.com:3478",
+	})
+}`)
+
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(sockets))
+	}
+	for i, want := range []string{TagSTUN, TagSTUN, TagTURN} {
+		socket := sockets[i]
+		if socket.Protocol != types.ProtocolUDP || !socket.IsResolved || *socket.Destination.Port != 3478 {
+			t.Errorf("Finding %d: expected resolved UDP egress to port 3478, got %+v", i, socket)
+		}
+		if socket.Tags[len(socket.Tags)-1] != want {
+			t.Errorf("Finding %d: expected %s tag, got %v", i, want, socket.Tags)
+		}
+	}
+}
+
+func TestPatternMatcher_WebRTCRequiresImport(t *testing.T) {
+	sockets := matchAll(t, `package main
+import "example.com/webrtc"
+var server = webrtc.ICEServer{URLs: []string{"stun:stun.example.com"}}`)
+
+	if len(sockets) != 0 {
+		t.Errorf("Expected no findings without pion/webrtc, got %d", len(sockets))
+	}
+}
@@ -0,0 +1,121 @@
+package patterns
+
+import (
+	"go/ast"
+	gotypes "go/types"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+var (
+	gorillaWebSocketImports = []string{"github.com/gorilla/websocket"}
+	nhooyrWebSocketImports  = []string{"nhooyr.io/websocket", "github.com/coder/websocket"}
+)
+
+var webSocketRules = ruleFamily{
+	name:        "WebSocket",
+	code:        2500,
+	description: "Dials of gorilla/websocket and nhooyr.io/websocket clients to a ws:// or wss:// URL, and HTTP requests upgraded to a WebSocket connection, on the address of the HTTP server the file starts when it starts one.",
+	rationale:   "Real-time services keep their connections open over WebSocket rather than making HTTP requests, so without these rules their peers do not show up at all.",
+	example: `conn, _, err := websocket.DefaultDialer.Dial("wss://stream.example.com/v1/feed", nil)
+ws, err := upgrader.Upgrade(w, r, nil)`,
+	rules: []string{
+		"websocket.Dialer.Dial", "websocket.Dialer.DialContext", "websocket.Upgrader.Upgrade",
+		"websocket.Dial", "websocket.Accept",
+	},
+}
+
+func (pm *PatternMatcher) initializeWebSocketPatterns() {
+	// gorilla/websocket dials and upgrades through methods, see
+	// methodPatterns; the package functions are nhooyr.io/websocket's,
+	// maintained as github.com/coder/websocket.
+	pm.packagePaths["websocket"] = "nhooyr.io/websocket"
+	pm.importAliases["github.com/coder/websocket"] = "nhooyr.io/websocket"
+
+	pm.egressPatterns["websocket.Dialer.Dial"] = EgressPattern{Protocol: types.ProtocolWebSocket, URLArg: 0, URL: true}
+	pm.egressPatterns["websocket.Dialer.DialContext"] = EgressPattern{Protocol: types.ProtocolWebSocket, URLArg: 1, URL: true}
+	pm.callMatchers["websocket.Upgrader.Upgrade"] = matchWebSocketUpgrade
+	for _, name := range []string{"websocket.Dialer.Dial", "websocket.Dialer.DialContext", "websocket.Upgrader.Upgrade"} {
+		pm.requiredImports[name] = gorillaWebSocketImports
+	}
+
+	pm.egressPatterns["websocket.Dial"] = EgressPattern{Protocol: types.ProtocolWebSocket, URLArg: 1, URL: true}
+	pm.callMatchers["websocket.Accept"] = matchWebSocketUpgrade
+	pm.requiredImports["websocket.Accept"] = nhooyrWebSocketImports
+}
+
+// matchWebSocketUpgrade reports an HTTP request upgraded to a WebSocket
+// connection, as in upgrader.Upgrade(w, r, nil) or websocket.Accept(w, r,
+// nil). The connection is served by the HTTP server the handler is
+// registered with, so when the file starts exactly one, the upgrade is
+// ingress on its address, as wss when it serves TLS.
+func matchWebSocketUpgrade(pm *PatternMatcher, call *ast.CallExpr, file *ast.File, funcName string) *types.SocketInfo {
+	socket := &types.SocketInfo{
+		Type:         types.TrafficTypeIngress,
+		Protocol:     types.ProtocolWebSocket,
+		RawValue:     gotypes.ExprString(call.Fun),
+		PatternMatch: funcName,
+		FunctionName: "unknown",
+	}
+	servers := pm.httpServers(file)
+	if len(servers) != 1 || !servers[0].IsResolved {
+		return socket
+	}
+	socket.Listen = servers[0].Listen
+	socket.RawValue = servers[0].RawValue
+	socket.IsResolved = true
+	if servers[0].Protocol == types.ProtocolHTTPS {
+		socket.Protocol = types.ProtocolWSS
+	}
+	return socket
+}
+
+// httpServers returns the HTTP listeners file starts, with
+// http.ListenAndServe or an http.Server, resolved from literals and
+// constants of the file.
+func (pm *PatternMatcher) httpServers(file *ast.File) []*types.SocketInfo {
+	var servers []*types.SocketInfo
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			name := pm.positionalName(node, file)
+			pattern, ok := pm.ingressPatterns[name]
+			if !ok || (pattern.Protocol != types.ProtocolHTTP && pattern.Protocol != types.ProtocolHTTPS) {
+				return true
+			}
+			socket := pm.matchIngressPattern(node, file, pattern, name)
+			if socket == nil {
+				return true
+			}
+			if !socket.IsResolved {
+				if value, ok := pm.resolveConstant(node.Args[pattern.AddressArg], file); ok {
+					socket.RawValue = value
+					pm.parseIngressAddress(socket, value, pattern.PortOnly)
+				}
+			}
+			servers = append(servers, socket)
+		case *ast.CompositeLit:
+			for _, socket := range pm.MatchCompositeLiteral(node, file) {
+				if socket.Type == types.TrafficTypeIngress && (socket.Protocol == types.ProtocolHTTP || socket.Protocol == types.ProtocolHTTPS) {
+					servers = append(servers, socket)
+				}
+			}
+		}
+		return true
+	})
+	return servers
+}
+
+// webSocketScheme keeps the protocol of a WebSocket dial to an http:// or
+// https:// URL, which the clients accept as ws:// and wss://.
+func (pm *PatternMatcher) webSocketScheme(socket *types.SocketInfo) {
+	if pm.egressPatterns[socket.PatternMatch].Protocol != types.ProtocolWebSocket {
+		return
+	}
+	switch socket.Protocol {
+	case types.ProtocolHTTP:
+		socket.Protocol = types.ProtocolWebSocket
+	case types.ProtocolHTTPS:
+		socket.Protocol = types.ProtocolWSS
+	}
+}
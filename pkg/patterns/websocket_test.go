@@ -0,0 +1,119 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func TestPatternMatcher_GorillaWebSocket(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+const feedURL = "wss://stream.example.com/v1/feed"
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024}
+func subscribe(ctx context.Context) {
+	websocket.DefaultDialer.Dial(feedURL, nil)
+	dialer := &websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	dialer.DialContext(ctx, "ws://quotes.internal:8080/ws", nil)
+	websocket.IsCloseError(nil)
+}
+func handle(w http.ResponseWriter, r *http.Request) {
+	conn, _ := upgrader.Upgrade(w, r, nil)
+	defer conn.Close()
+}
+func main() {
+	http.HandleFunc("/ws", handle)
+	http.ListenAndServe(":8081", nil)
+}`)
+
+	tests := []struct {
+		pattern  string
+		kind     types.TrafficType
+		protocol types.Protocol
+		endpoint string
+	}{
+		{"websocket.Dialer.Dial", types.TrafficTypeEgress, types.ProtocolWSS, "stream.example.com:443"},
+		{"websocket.Dialer.DialContext", types.TrafficTypeEgress, types.ProtocolWebSocket, "quotes.internal:8080"},
+		{"websocket.Upgrader.Upgrade", types.TrafficTypeIngress, types.ProtocolWebSocket, "0.0.0.0:8081"},
+		{"http.ListenAndServe", types.TrafficTypeIngress, types.ProtocolHTTP, "0.0.0.0:8081"},
+	}
+	if len(sockets) != len(tests) {
+		t.Fatalf("Expected %d findings, got %d: %v", len(tests), len(sockets), sockets)
+	}
+	for i, tt := range tests {
+		socket := sockets[i]
+		if socket.PatternMatch != tt.pattern || socket.Type != tt.kind || socket.Protocol != tt.protocol || socket.EndpointName() != tt.endpoint {
+			t.Errorf("Finding %d: expected %s %s %s %s, got %s %s %s %s", i, tt.pattern, tt.kind, tt.protocol, tt.endpoint,
+				socket.PatternMatch, socket.Type, socket.Protocol, socket.EndpointName())
+		}
+	}
+}
+
+func TestPatternMatcher_NhooyrWebSocket(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"context"
+	"net/http"
+
+	"nhooyr.io/websocket"
+)
+func subscribe(ctx context.Context) {
+	websocket.Dial(ctx, "https://events.example.com/stream", nil)
+}
+func handle(w http.ResponseWriter, r *http.Request) {
+	websocket.Accept(w, r, nil)
+}
+func serve(handler http.Handler) {
+	http.ListenAndServeTLS(":8443", "cert.pem", "key.pem", handler)
+	http.ListenAndServe(":8080", handler)
+}`)
+
+	if len(sockets) != 4 {
+		t.Fatalf("Expected 4 findings, got %d: %v", len(sockets), sockets)
+	}
+	if dial := sockets[0]; dial.PatternMatch != "websocket.Dial" || dial.Protocol != types.ProtocolWSS || dial.EndpointName() != "events.example.com:443" {
+		t.Errorf("Expected a wss dial to events.example.com:443, got %s %s %s", dial.PatternMatch, dial.Protocol, dial.EndpointName())
+	}
+	// Two servers: the upgrade may be on either
+	if accept := sockets[1]; accept.PatternMatch != "websocket.Accept" || accept.Type != types.TrafficTypeIngress || accept.IsResolved || accept.RawValue != "websocket.Accept" {
+		t.Errorf("Expected an unresolved websocket.Accept ingress, got %+v", accept)
+	}
+}
+
+func TestPatternMatcher_WebSocketImports(t *testing.T) {
+	sockets := matchFile(t, `package main
+import (
+	"context"
+
+	"example.com/internal/websocket"
+	gorilla "github.com/gorilla/websocket"
+)
+func main() {
+	websocket.Dial(context.Background(), "ws://local.example.com", nil)
+	websocket.Accept(nil, nil, nil)
+	d := gorilla.Dialer{}
+	d.Dial("ws://feed.example.com/ws", nil)
+}`)
+	if len(sockets) != 1 || sockets[0].EndpointName() != "feed.example.com:80" {
+		t.Errorf("Expected only the gorilla dial to feed.example.com:80, got %v", sockets)
+	}
+
+	sockets = matchFile(t, `package main
+import (
+	"context"
+
+	"github.com/coder/websocket"
+)
+func main() {
+	websocket.Dial(context.Background(), "ws://feed.example.com:9000", nil)
+}`)
+	if len(sockets) != 1 || sockets[0].Protocol != types.ProtocolWebSocket || sockets[0].EndpointName() != "feed.example.com:9000" {
+		t.Errorf("Expected a websocket dial to feed.example.com:9000, got %v", sockets)
+	}
+}
@@ -0,0 +1,263 @@
+// Package policy checks analysis results against the listeners and
+// destinations a codebase is allowed, so that CI can fail on a socket
+// outside them.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Policy allows and denies sockets. A socket violates the policy when a
+// deny rule matches it or no allow rule does; deny rules win.
+type Policy struct {
+	Allow Rules `yaml:"allow" json:"allow"`
+	Deny  Rules `yaml:"deny" json:"deny"`
+	// Unresolved decides findings whose endpoint is not known statically
+	// and that no rule without a host, port or path decides, such as a
+	// protocol-only rule: "deny", the default, reports them as
+	// violations, "allow" lets them pass.
+	Unresolved string `yaml:"unresolved" json:"unresolved"`
+}
+
+// Rules are the rules of one side of a policy.
+type Rules struct {
+	Ingress []Rule `yaml:"ingress" json:"ingress"`
+	Egress  []Rule `yaml:"egress" json:"egress"`
+}
+
+// Rule matches sockets by endpoint. Unset fields match anything, and a
+// socket whose endpoint lacks a field a rule sets does not match it.
+type Rule struct {
+	// Host is an interface address for ingress rules and a destination
+	// for egress rules: a host name, which a leading "*." turns into any
+	// subdomain of it, an IP address, a CIDR such as 10.0.0.0/8, or "*".
+	Host string `yaml:"host" json:"host"`
+	// Port is a port or an inclusive range such as 8000-8100.
+	Port string `yaml:"port" json:"port"`
+	// Path is a unix socket path, with path.Match wildcards.
+	Path     string         `yaml:"path" json:"path"`
+	Protocol types.Protocol `yaml:"protocol" json:"protocol"`
+}
+
+func (r Rule) String() string {
+	var fields []string
+	for _, field := range []struct{ name, value string }{
+		{"host", r.Host}, {"port", r.Port}, {"path", r.Path}, {"protocol", string(r.Protocol)},
+	} {
+		if field.value != "" {
+			fields = append(fields, field.name+"="+field.value)
+		}
+	}
+	if len(fields) == 0 {
+		return "any"
+	}
+	return strings.Join(fields, " ")
+}
+
+// Violation is a socket the policy does not allow.
+type Violation struct {
+	Socket types.SocketInfo
+	// Reason says which rule denied the socket, or that none allowed it.
+	Reason string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s %s %s (%s): %s", v.Socket.SourceFile, v.Socket.SourceLine,
+		v.Socket.Type, v.Socket.Protocol, v.Socket.EndpointName(), v.Socket.PatternMatch, v.Reason)
+}
+
+// Read decodes a policy in YAML or JSON and validates its rules.
+func Read(reader io.Reader) (*Policy, error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	var p Policy
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	switch p.Unresolved {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("invalid unresolved %q: expected allow or deny", p.Unresolved)
+	}
+	for _, side := range []struct {
+		name  string
+		rules Rules
+	}{{"allow", p.Allow}, {"deny", p.Deny}} {
+		for i, rule := range side.rules.Ingress {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("%s.ingress[%d]: %w", side.name, i, err)
+			}
+		}
+		for i, rule := range side.rules.Egress {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("%s.egress[%d]: %w", side.name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	if r.Port != "" {
+		if _, _, err := parsePorts(r.Port); err != nil {
+			return err
+		}
+	}
+	if strings.Contains(r.Host, "/") {
+		if _, err := netip.ParsePrefix(r.Host); err != nil {
+			return fmt.Errorf("invalid CIDR %q: %w", r.Host, err)
+		}
+	}
+	if _, err := path.Match(r.Path, ""); err != nil {
+		return fmt.Errorf("invalid path %q: %w", r.Path, err)
+	}
+	return nil
+}
+
+// parsePorts parses a port or an inclusive port range.
+func parsePorts(ports string) (int, int, error) {
+	first, last, isRange := strings.Cut(ports, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	end := start
+	if err == nil && isRange {
+		end, err = strconv.Atoi(strings.TrimSpace(last))
+	}
+	if err != nil || start < 0 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port %q: expected a port or a range such as 8000-8100", ports)
+	}
+	return start, end, nil
+}
+
+// Check returns the sockets of results the policy does not allow, in the
+// order of results.
+func (p *Policy) Check(results *types.AnalysisResults) []Violation {
+	var violations []Violation
+	for _, socket := range results.Sockets {
+		if reason, ok := p.allows(socket); !ok {
+			violations = append(violations, Violation{Socket: socket, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// allows reports whether the policy allows socket, and why not.
+func (p *Policy) allows(socket types.SocketInfo) (string, bool) {
+	endpoint, allow, deny := socket.Listen, p.Allow.Ingress, p.Deny.Ingress
+	if socket.Type == types.TrafficTypeEgress {
+		endpoint, allow, deny = socket.Destination, p.Allow.Egress, p.Deny.Egress
+	}
+	// Only the rules matching any endpoint apply to an unknown one
+	resolved := socket.IsResolved
+	for _, rule := range deny {
+		if (resolved || rule.anyEndpoint()) && rule.matches(socket, endpoint, true) {
+			return fmt.Sprintf("denied by %s rule %s", socket.Type, rule), false
+		}
+	}
+	for _, rule := range allow {
+		if (resolved || rule.anyEndpoint()) && rule.matches(socket, endpoint, false) {
+			return "", true
+		}
+	}
+	switch {
+	case resolved:
+		return fmt.Sprintf("no %s rule allows it", socket.Type), false
+	case p.Unresolved == "allow":
+		return "", true
+	}
+	return "endpoint not known statically", false
+}
+
+// anyEndpoint reports whether the rule matches sockets whatever their
+// endpoint, having no host, port or path.
+func (r Rule) anyEndpoint() bool {
+	return r.Host == "" && r.Port == "" && r.Path == ""
+}
+
+// matches reports whether the rule matches socket, whose endpoint is
+// endpoint. For an allow rule a listener matches a port rule only when
+// every port it may bind, including the ports it retries on, is in range;
+// for a deny rule any of them being in range is enough.
+func (r Rule) matches(socket types.SocketInfo, endpoint *types.Endpoint, deny bool) bool {
+	if r.Protocol != "" && r.Protocol != socket.Protocol {
+		return false
+	}
+	if r.Host != "" && (endpoint == nil || !matchHost(r.Host, endpoint.Host)) {
+		return false
+	}
+	if r.Path != "" {
+		if endpoint == nil || endpoint.Path == "" {
+			return false
+		}
+		if matched, _ := path.Match(r.Path, endpoint.Path); !matched {
+			return false
+		}
+	}
+	if r.Port == "" {
+		return true
+	}
+	start, end, _ := parsePorts(r.Port)
+	var ranges []types.PortRange
+	switch {
+	case endpoint == nil:
+		return false
+	case endpoint.Port != nil:
+		ranges = append(ranges, types.PortRange{Start: *endpoint.Port, End: *endpoint.Port})
+	case endpoint.PortRange != nil:
+		ranges = append(ranges, *endpoint.PortRange)
+	default:
+		return false
+	}
+	for _, port := range socket.CandidatePorts {
+		ranges = append(ranges, types.PortRange{Start: port, End: port})
+	}
+	for _, ports := range ranges {
+		inside := ports.Start >= start && ports.End <= end
+		overlaps := ports.Start <= end && ports.End >= start
+		if deny && overlaps {
+			return true
+		}
+		if !deny && !inside {
+			return false
+		}
+	}
+	return !deny
+}
+
+// matchHost reports whether host matches pattern, a host name, a "*."
+// wildcard, an IP address, a CIDR or "*".
+func matchHost(pattern, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	pattern = strings.ToLower(pattern)
+	switch {
+	case pattern == "*":
+		return host != ""
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	case strings.Contains(pattern, "/"):
+		prefix, err := netip.ParsePrefix(pattern)
+		addr, addrErr := netip.ParseAddr(host)
+		return err == nil && addrErr == nil && prefix.Contains(addr.Unmap())
+	}
+	if want, err := netip.ParseAddr(strings.Trim(pattern, "[]")); err == nil {
+		addr, err := netip.ParseAddr(host)
+		return err == nil && addr.Unmap() == want.Unmap()
+	}
+	return host == strings.TrimSuffix(pattern, ".")
+}
@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestRead(t *testing.T) {
+	p, err := Read(strings.NewReader(`
+allow:
+  ingress:
+    - port: 8080
+    - host: 127.0.0.0/8
+  egress:
+    - host: "*.example.com"
+      port: 443
+deny:
+  egress:
+    - host: 10.0.0.0/8
+      port: 5432-5433
+unresolved: allow
+`))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(p.Allow.Ingress) != 2 || p.Allow.Ingress[0].Port != "8080" || len(p.Deny.Egress) != 1 || p.Unresolved != "allow" {
+		t.Errorf("Unexpected policy: %+v", p)
+	}
+
+	if _, err := Read(strings.NewReader(`{"allow": {"egress": [{"host": "db", "port": 5432}]}}`)); err != nil {
+		t.Errorf("Expected a JSON policy read, got %v", err)
+	}
+
+	invalid := []string{
+		"allow:\n  ingress:\n    - port: http\n",
+		"allow:\n  ingress:\n    - port: 9000-8000\n",
+		"deny:\n  egress:\n    - host: 10.0.0.0/33\n",
+		"allow:\n  egress:\n    - hostname: db\n",
+		"unresolved: maybe\n",
+	}
+	for _, policy := range invalid {
+		if _, err := Read(strings.NewReader(policy)); err == nil {
+			t.Errorf("Expected an error for %q", policy)
+		}
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	p := &Policy{
+		Allow: Rules{
+			Ingress: []Rule{{Port: "8000-8100"}, {Host: "127.0.0.0/8"}, {Path: "/run/app/*.sock"}},
+			Egress:  []Rule{{Host: "*.example.com", Port: "443"}, {Host: "10.0.0.0/8"}, {Host: "db", Protocol: types.ProtocolGRPC}},
+		},
+		Deny: Rules{
+			Egress: []Rule{{Host: "10.1.0.0/16", Port: "5432"}},
+		},
+	}
+	ingress := func(endpoint *types.Endpoint, candidates ...int) types.SocketInfo {
+		return types.SocketInfo{Type: types.TrafficTypeIngress, Protocol: types.ProtocolTCP, Listen: endpoint, CandidatePorts: candidates, IsResolved: true}
+	}
+	egress := func(protocol types.Protocol, host string, port int) types.SocketInfo {
+		return types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: protocol, Destination: types.NewEndpoint(host, intPtr(port)), IsResolved: true}
+	}
+
+	tests := []struct {
+		name   string
+		socket types.SocketInfo
+		reason string
+	}{
+		{"port in range", ingress(types.NewEndpoint("0.0.0.0", intPtr(8080))), ""},
+		{"port out of range", ingress(types.NewEndpoint("0.0.0.0", intPtr(9090))), "no ingress rule allows it"},
+		{"retry port out of range", ingress(types.NewEndpoint("0.0.0.0", intPtr(8080)), 8081, 9000), "no ingress rule allows it"},
+		{"port range in range", ingress(&types.Endpoint{Host: "0.0.0.0", PortRange: &types.PortRange{Start: 8000, End: 8010}}), ""},
+		{"loopback interface", ingress(types.NewEndpoint("127.0.0.1", intPtr(9090))), ""},
+		{"socket path", ingress(types.NewPathEndpoint("/run/app/api.sock")), ""},
+		{"other socket path", ingress(types.NewPathEndpoint("/tmp/api.sock")), "no ingress rule allows it"},
+		{"wildcard host", egress(types.ProtocolHTTPS, "API.example.com", 443), ""},
+		{"wildcard apex", egress(types.ProtocolHTTPS, "example.com", 443), "no egress rule allows it"},
+		{"wildcard wrong port", egress(types.ProtocolHTTP, "api.example.com", 80), "no egress rule allows it"},
+		{"CIDR", egress(types.ProtocolTCP, "10.2.3.4", 6379), ""},
+		{"denied", egress(types.ProtocolTCP, "10.1.2.3", 5432), "denied by egress rule host=10.1.0.0/16 port=5432"},
+		{"protocol", egress(types.ProtocolGRPC, "db", 5432), ""},
+		{"other protocol", egress(types.ProtocolTCP, "db", 5432), "no egress rule allows it"},
+		{"unresolved", types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolHTTP, RawValue: "cfg.URL"}, "endpoint not known statically"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := p.Check(&types.AnalysisResults{Sockets: []types.SocketInfo{tt.socket}})
+			switch {
+			case tt.reason == "" && len(violations) > 0:
+				t.Errorf("Expected the socket allowed, got %s", violations[0])
+			case tt.reason != "" && (len(violations) != 1 || violations[0].Reason != tt.reason):
+				t.Errorf("Expected a violation %q, got %v", tt.reason, violations)
+			}
+		})
+	}
+
+	p.Unresolved = "allow"
+	if violations := p.Check(&types.AnalysisResults{Sockets: []types.SocketInfo{{Type: types.TrafficTypeEgress, RawValue: "cfg.URL"}}}); len(violations) != 0 {
+		t.Errorf("Expected unresolved findings allowed, got %v", violations)
+	}
+}
+
+func TestPolicy_CheckDeny(t *testing.T) {
+	p := &Policy{
+		Allow: Rules{
+			Ingress: []Rule{{Port: "1-65535"}},
+			Egress:  []Rule{{Host: "*"}},
+		},
+		Deny: Rules{
+			Ingress: []Rule{{Port: "22"}, {Port: "6000-6063"}},
+			Egress:  []Rule{{Protocol: types.ProtocolUDP}},
+		},
+		Unresolved: "allow",
+	}
+	ingress := func(endpoint *types.Endpoint, candidates ...int) types.SocketInfo {
+		return types.SocketInfo{Type: types.TrafficTypeIngress, Protocol: types.ProtocolTCP, Listen: endpoint, CandidatePorts: candidates, IsResolved: true}
+	}
+
+	tests := []struct {
+		name   string
+		socket types.SocketInfo
+		reason string
+	}{
+		{"allowed", ingress(types.NewEndpoint("0.0.0.0", intPtr(8080)), 8081), ""},
+		{"retry port denied", ingress(types.NewEndpoint("0.0.0.0", intPtr(8080)), 22), "denied by ingress rule port=22"},
+		{"port range overlapping", ingress(&types.Endpoint{Host: "0.0.0.0", PortRange: &types.PortRange{Start: 5990, End: 6010}}), "denied by ingress rule port=6000-6063"},
+		{"port range apart", ingress(&types.Endpoint{Host: "0.0.0.0", PortRange: &types.PortRange{Start: 7000, End: 7010}}), ""},
+		{"unresolved protocol denied", types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolUDP, RawValue: "addr"}, "denied by egress rule protocol=udp"},
+		{"unresolved allowed", types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolTCP, RawValue: "addr"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := p.Check(&types.AnalysisResults{Sockets: []types.SocketInfo{tt.socket}})
+			switch {
+			case tt.reason == "" && len(violations) > 0:
+				t.Errorf("Expected the socket allowed, got %s", violations[0])
+			case tt.reason != "" && (len(violations) != 1 || violations[0].Reason != tt.reason):
+				t.Errorf("Expected a violation %q, got %v", tt.reason, violations)
+			}
+		})
+	}
+
+	// Protocol-only allow rules decide unresolved findings too
+	p = &Policy{Allow: Rules{Egress: []Rule{{Protocol: types.ProtocolGRPC}}}}
+	unresolved := types.SocketInfo{Type: types.TrafficTypeEgress, Protocol: types.ProtocolGRPC, RawValue: "target"}
+	if violations := p.Check(&types.AnalysisResults{Sockets: []types.SocketInfo{unresolved}}); len(violations) != 0 {
+		t.Errorf("Expected an unresolved grpc finding allowed by protocol, got %v", violations)
+	}
+}
+
+func TestViolation_String(t *testing.T) {
+	v := Violation{
+		Socket: types.SocketInfo{
+			Type:         types.TrafficTypeIngress,
+			Protocol:     types.ProtocolTCP,
+			SourceFile:   "cmd/server/main.go",
+			SourceLine:   12,
+			Listen:       types.NewEndpoint("0.0.0.0", intPtr(9090)),
+			PatternMatch: "net.Listen",
+			IsResolved:   true,
+		},
+		Reason: "no ingress rule allows it",
+	}
+	if want := "cmd/server/main.go:12: ingress tcp 0.0.0.0:9090 (net.Listen): no ingress rule allows it"; v.String() != want {
+		t.Errorf("Expected %q, got %q", want, v.String())
+	}
+}
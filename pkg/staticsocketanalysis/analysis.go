@@ -0,0 +1,326 @@
+// Package staticsocketanalysis exposes the staticsocket pattern matcher as
+// a go/analysis Analyzer, so that it runs under go vet -vettool, as a
+// golangci-lint plugin or in any other analysis driver.
+package staticsocketanalysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// Analyzer reports the sockets a package opens that the policy set by its
+// flags does not allow, and calls of functions of other packages that open
+// such sockets, known from their facts.
+var Analyzer = newAnalyzer(&Config{})
+
+// Config is the policy of an Analyzer.
+type Config struct {
+	// TrafficType restricts diagnostics to ingress or egress findings.
+	TrafficType types.TrafficType
+	// Allow lists the endpoints, as findings name them, such as
+	// 0.0.0.0:8080 or api.example.com:443, that are not reported.
+	Allow []string
+	// Patterns is a YAML or JSON file of additional patterns.
+	Patterns string
+	// Checks enables and disables rules by code or name, such as
+	// "all,-SS1003" split at its commas.
+	Checks []string
+}
+
+// SocketFact lists the sockets a function opens, directly or through the
+// functions it calls.
+type SocketFact struct {
+	Sockets []FactSocket
+}
+
+// FactSocket is a socket of a SocketFact.
+type FactSocket struct {
+	Type     types.TrafficType
+	Protocol types.Protocol
+	Endpoint string
+	Resolved bool
+}
+
+func (*SocketFact) AFact() {}
+
+func (f *SocketFact) String() string {
+	sockets := make([]string, len(f.Sockets))
+	for i, socket := range f.Sockets {
+		sockets[i] = socket.String()
+	}
+	return "sockets(" + strings.Join(sockets, "; ") + ")"
+}
+
+func (s FactSocket) String() string {
+	return fmt.Sprintf("%s %s %s", s.Type, s.Protocol, s.Endpoint)
+}
+
+// New returns an Analyzer with the policy in settings, the plugin entry
+// point golangci-lint calls with the plugin's settings: "type", a string,
+// "allow", a list of endpoints, "patterns", a file path, and "checks", a
+// list of rules to enable or disable.
+func New(settings any) ([]*analysis.Analyzer, error) {
+	config := &Config{}
+	if settings != nil {
+		values, ok := settings.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("staticsocket: settings must be a map, got %T", settings)
+		}
+		for key, value := range values {
+			switch key {
+			case "type":
+				config.TrafficType = types.TrafficType(fmt.Sprint(value))
+			case "patterns":
+				config.Patterns = fmt.Sprint(value)
+			case "allow", "checks":
+				list, ok := value.([]any)
+				if !ok {
+					return nil, fmt.Errorf("staticsocket: %s must be a list, got %T", key, value)
+				}
+				for _, item := range list {
+					if key == "allow" {
+						config.Allow = append(config.Allow, fmt.Sprint(item))
+					} else {
+						config.Checks = append(config.Checks, fmt.Sprint(item))
+					}
+				}
+			default:
+				return nil, fmt.Errorf("staticsocket: unknown setting %q", key)
+			}
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	// Patterns and checks are read again by each pass, but fail early
+	if _, err := newPatternMatcher(config); err != nil {
+		return nil, err
+	}
+	return []*analysis.Analyzer{newAnalyzer(config)}, nil
+}
+
+func (c *Config) validate() error {
+	switch c.TrafficType {
+	case "", types.TrafficTypeIngress, types.TrafficTypeEgress:
+		return nil
+	}
+	return fmt.Errorf("staticsocket: invalid type %q: expected ingress or egress", c.TrafficType)
+}
+
+// allowed reports whether the policy lets socket pass without a
+// diagnostic.
+func (c *Config) allowed(socket FactSocket) bool {
+	if c.TrafficType != "" && socket.Type != c.TrafficType {
+		return true
+	}
+	for _, endpoint := range c.Allow {
+		if socket.Resolved && endpoint == socket.Endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func newAnalyzer(config *Config) *analysis.Analyzer {
+	a := &analysis.Analyzer{
+		Name:      "staticsocket",
+		Doc:       "report the network sockets a package opens\n\nThe analyzer matches calls such as net.Listen, http.Get or grpc.NewClient, resolves their addresses where they are known statically, and reports every socket the policy set by its flags does not allow. Functions opening sockets export a fact, so that calls of them from other packages are reported too.",
+		URL:       "https://github.com/yuvalk/staticsocket",
+		FactTypes: []analysis.Fact{new(SocketFact)},
+	}
+	a.Flags.Func("type", "only report findings of this traffic type: ingress, egress", func(value string) error {
+		config.TrafficType = types.TrafficType(value)
+		return config.validate()
+	})
+	a.Flags.Func("allow", "comma-separated endpoints, such as 0.0.0.0:8080 or api.example.com:443, not to report", func(value string) error {
+		for _, endpoint := range strings.Split(value, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				config.Allow = append(config.Allow, endpoint)
+			}
+		}
+		return nil
+	})
+	a.Flags.StringVar(&config.Patterns, "patterns", config.Patterns, "YAML or JSON file of additional ingress and egress patterns")
+	a.Flags.Func("checks", "comma-separated rules to enable or disable by code or name, applied in order: all, -SS1003, SS16*", func(value string) error {
+		config.Checks = append(config.Checks, strings.Split(value, ",")...)
+		return nil
+	})
+	a.Run = func(pass *analysis.Pass) (any, error) {
+		return run(pass, config)
+	}
+	return a
+}
+
+// isStandard reports whether path is a package of the standard library,
+// whose first path element has no dot.
+func isStandard(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func run(pass *analysis.Pass, config *Config) (any, error) {
+	// Calls into the standard library are the patterns themselves
+	if isStandard(pass.Pkg.Path()) {
+		return nil, nil
+	}
+	pm, err := newPatternMatcher(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The sockets each function opens itself or through other packages,
+	// and the functions of this package it calls
+	direct := make(map[*gotypes.Func][]FactSocket)
+	calls := make(map[*gotypes.Func][]*gotypes.Func)
+	var funcs []*gotypes.Func
+	for _, file := range pass.Files {
+		pm.SetTypesInfo(file, pass.TypesInfo)
+		if name := pass.Fset.File(file.Pos()).Name(); name != "" {
+			pm.SetPackageDir(file, filepath.Dir(name))
+		}
+
+		matched := make(map[token.Pos]bool)
+		for _, finding := range pm.MatchFile(file) {
+			if !finding.Pos.IsValid() {
+				continue
+			}
+			matched[finding.Pos] = true
+			socket := factSocket(finding.Socket)
+			if !config.allowed(socket) {
+				pass.Reportf(finding.Pos, "%s", finding.Socket.Summary())
+			}
+			if fn := enclosingFunc(pass, file, finding.Pos); fn != nil {
+				direct[fn] = append(direct[fn], socket)
+			}
+		}
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Body == nil {
+				continue
+			}
+			fn, _ := pass.TypesInfo.Defs[funcDecl.Name].(*gotypes.Func)
+			if fn == nil {
+				continue
+			}
+			funcs = append(funcs, fn)
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || matched[call.Pos()] {
+					return true
+				}
+				callee := typeutil.StaticCallee(pass.TypesInfo, call)
+				switch {
+				case callee == nil || callee.Pkg() == nil:
+				case callee.Pkg() == pass.Pkg:
+					calls[fn] = append(calls[fn], callee)
+				default:
+					var fact SocketFact
+					if !pass.ImportObjectFact(callee, &fact) {
+						break
+					}
+					var reported []string
+					for _, socket := range fact.Sockets {
+						if !config.allowed(socket) {
+							reported = append(reported, socket.String())
+						}
+					}
+					if len(reported) > 0 {
+						pass.Reportf(call.Pos(), "%s.%s opens %s", callee.Pkg().Name(), callee.Name(), strings.Join(reported, "; "))
+					}
+					direct[fn] = append(direct[fn], fact.Sockets...)
+				}
+				return true
+			})
+		}
+	}
+
+	for _, fn := range funcs {
+		if sockets := reachable(fn, direct, calls); len(sockets) > 0 {
+			pass.ExportObjectFact(fn, &SocketFact{Sockets: sockets})
+		}
+	}
+	return nil, nil
+}
+
+// reachable returns the sockets fn opens itself and through the functions
+// of its package it calls, sorted and without duplicates.
+func reachable(fn *gotypes.Func, direct map[*gotypes.Func][]FactSocket, calls map[*gotypes.Func][]*gotypes.Func) []FactSocket {
+	seen := map[*gotypes.Func]bool{fn: true}
+	queue := []*gotypes.Func{fn}
+	unique := make(map[FactSocket]bool)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, socket := range direct[next] {
+			unique[socket] = true
+		}
+		for _, callee := range calls[next] {
+			if !seen[callee] {
+				seen[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	sockets := make([]FactSocket, 0, len(unique))
+	for socket := range unique {
+		sockets = append(sockets, socket)
+	}
+	sort.Slice(sockets, func(i, j int) bool { return sockets[i].String() < sockets[j].String() })
+	return sockets
+}
+
+func factSocket(socket *types.SocketInfo) FactSocket {
+	return FactSocket{Type: socket.Type, Protocol: socket.Protocol, Endpoint: socket.EndpointName(), Resolved: socket.IsResolved}
+}
+
+// enclosingFunc returns the function declared at package level whose body
+// holds pos, or nil.
+func enclosingFunc(pass *analysis.Pass, file *ast.File, pos token.Pos) *gotypes.Func {
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if ok && funcDecl.Body != nil && funcDecl.Pos() <= pos && pos < funcDecl.End() {
+			fn, _ := pass.TypesInfo.Defs[funcDecl.Name].(*gotypes.Func)
+			return fn
+		}
+	}
+	return nil
+}
+
+// newPatternMatcher returns a matcher with the patterns of config added
+// and its checks applied.
+func newPatternMatcher(config *Config) (*patterns.PatternMatcher, error) {
+	pm := patterns.NewPatternMatcher()
+	if config.Patterns != "" {
+		file, err := os.Open(config.Patterns)
+		if err != nil {
+			return nil, fmt.Errorf("reading patterns: %w", err)
+		}
+		defer file.Close()
+		custom, err := patterns.ReadCustomPatterns(file)
+		if err != nil {
+			return nil, fmt.Errorf("parsing patterns file %s: %w", config.Patterns, err)
+		}
+		for _, p := range custom {
+			if err := pm.AddCustomPattern(p); err != nil {
+				return nil, fmt.Errorf("invalid patterns file %s: %w", config.Patterns, err)
+			}
+		}
+	}
+	if err := pm.SetChecks(config.Checks); err != nil {
+		return nil, fmt.Errorf("staticsocket: %w", err)
+	}
+	return pm, nil
+}
@@ -0,0 +1,51 @@
+package staticsocketanalysis
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analyzers, err := New(map[string]any{"allow": []any{"0.0.0.0:8080"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), analyzers[0], "example.com/netutil", "example.com/server")
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(map[string]any{"type": "sideways"}); err == nil {
+		t.Error("Expected an invalid type rejected")
+	}
+	if _, err := New(map[string]any{"allowed": []any{}}); err == nil {
+		t.Error("Expected an unknown setting rejected")
+	}
+	if _, err := New(map[string]any{"checks": []any{"all", "-SS0000"}}); err == nil {
+		t.Error("Expected a check naming no rule rejected")
+	}
+	if _, err := New(map[string]any{"checks": []any{"all", "-SS1003"}}); err != nil {
+		t.Errorf("Expected checks accepted, got %v", err)
+	}
+	if _, err := New(nil); err != nil {
+		t.Errorf("Expected no settings accepted, got %v", err)
+	}
+}
+
+func TestConfig_Allowed(t *testing.T) {
+	config := &Config{TrafficType: "egress", Allow: []string{"db:5432"}}
+	tests := []struct {
+		socket FactSocket
+		want   bool
+	}{
+		{FactSocket{Type: "ingress", Endpoint: "0.0.0.0:8080", Resolved: true}, true},
+		{FactSocket{Type: "egress", Endpoint: "db:5432", Resolved: true}, true},
+		{FactSocket{Type: "egress", Endpoint: "cache:6379", Resolved: true}, false},
+		{FactSocket{Type: "egress", Endpoint: "db:5432"}, false},
+	}
+	for _, tt := range tests {
+		if got := config.allowed(tt.socket); got != tt.want {
+			t.Errorf("allowed(%v) = %t, want %t", tt.socket, got, tt.want)
+		}
+	}
+}
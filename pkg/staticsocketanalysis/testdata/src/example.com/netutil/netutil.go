@@ -0,0 +1,17 @@
+package netutil
+
+import "net"
+
+const metricsAddr = ":9090"
+
+func ListenMetrics() (net.Listener, error) { // want ListenMetrics:`sockets\(ingress tcp 0.0.0.0:9090\)`
+	return listen(metricsAddr)
+}
+
+func listen(addr string) (net.Listener, error) { // want listen:`sockets\(ingress tcp 0.0.0.0:9090\)`
+	return net.Listen("tcp", addr) // want `Listens for tcp on 0.0.0.0:9090`
+}
+
+func DialCollector() (net.Conn, error) { // want DialCollector:`sockets\(egress udp collector:8125\)`
+	return net.Dial("udp", "collector:8125") // want `Connects over udp to collector:8125`
+}
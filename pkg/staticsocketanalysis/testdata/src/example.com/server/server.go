@@ -0,0 +1,15 @@
+package server
+
+import (
+	"net"
+	"net/http"
+
+	"example.com/netutil"
+)
+
+func Run(network string) { // want Run:`sockets\(egress udp collector:8125; egress unknown db:5432; ingress http 0.0.0.0:8080; ingress tcp 0.0.0.0:9090\)`
+	netutil.ListenMetrics() // want `netutil.ListenMetrics opens ingress tcp 0.0.0.0:9090`
+	netutil.DialCollector() // want `netutil.DialCollector opens egress udp collector:8125`
+	http.ListenAndServe(":8080", nil)
+	net.Dial(network, "db:5432") // want `Connects over unknown to db:5432 \(network not known statically\)`
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// addressSchemes maps the schemes of socket addresses written as URIs to
+// the protocol they select.
+var addressSchemes = map[string]Protocol{
+	"tcp":        ProtocolTCP,
+	"tcp4":       ProtocolTCP,
+	"tcp6":       ProtocolTCP,
+	"udp":        ProtocolUDP,
+	"udp4":       ProtocolUDP,
+	"udp6":       ProtocolUDP,
+	"unix":       ProtocolUnix,
+	"unixgram":   ProtocolUnix,
+	"unixpacket": ProtocolUnix,
+}
+
+// icmpProtocols are the IP protocol names and numbers of ICMP and ICMPv6.
+var icmpProtocols = map[string]bool{"icmp": true, "1": true, "ipv6-icmp": true, "58": true}
+
+// NetworkProtocol returns the protocol selected by the network argument of
+// the Listen, ListenPacket, ListenIP and Dial functions of package net:
+// "tcp6" selects tcp, "unixgram" unix, "ip4:icmp" or "ip6:58" icmp, and
+// other raw IP networks, such as "ip:gre", ip. It reports false for
+// networks it does not know.
+func NetworkProtocol(network string) (Protocol, bool) {
+	network = strings.ToLower(network)
+	if protocol, ok := addressSchemes[network]; ok {
+		return protocol, true
+	}
+	family, ipProtocol, ok := strings.Cut(network, ":")
+	if !ok || ipProtocol == "" || (family != "ip" && family != "ip4" && family != "ip6") {
+		return "", false
+	}
+	if icmpProtocols[ipProtocol] {
+		return ProtocolICMP, true
+	}
+	return ProtocolIP, true
+}
+
+// SplitScheme splits a socket address written as a URI, such as
+// tcp://0.0.0.0:8080, udp4://:53, unix:///tmp/app.sock or unix:/tmp/app.sock,
+// into the protocol its scheme selects and the address proper: host:port,
+// or the path of a unix socket. Query parameters, as in evio's
+// tcp://:5000?reuseport=true, are dropped, and evio's -net variants of the
+// schemes are accepted. It reports false for addresses without one of
+// these schemes, including URLs such as http://host.
+func SplitScheme(address string) (Protocol, string, bool) {
+	scheme, rest, ok := strings.Cut(address, "://")
+	if !ok {
+		if path, ok := strings.CutPrefix(address, "unix:"); ok && strings.HasPrefix(path, "/") {
+			return ProtocolUnix, path, true
+		}
+		return "", address, false
+	}
+	protocol, known := addressSchemes[strings.TrimSuffix(strings.ToLower(scheme), "-net")]
+	if !known {
+		return "", address, false
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	return protocol, rest, true
+}
+
+// WithScheme returns the protocol of a socket of protocol p whose address
+// has a scheme selecting protocol scheme: the scheme's for plain tcp and
+// udp sockets, for sockets of unknown protocol and for unix socket paths, p
+// for application protocols such as http carried over tcp.
+func (p Protocol) WithScheme(scheme Protocol) Protocol {
+	if p == "" || p == ProtocolTCP || p == ProtocolUDP || p == ProtocolUnknown || scheme == ProtocolUnix {
+		return scheme
+	}
+	return p
+}
+
+// SplitHostPort splits a host:port address with net.SplitHostPort, so that
+// IPv6 hosts such as [::1]:8080 or [fe80::1%eth0]:443 keep their colons.
+// The host is returned without brackets, and empty for a port alone such
+// as ":8080"; the port is nil when it is not a number, such as a named
+// port. It reports false for addresses that are not host:port, including
+// IPv6 addresses without brackets.
+func SplitHostPort(address string) (string, *int, bool) {
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", nil, false
+	}
+	if port, err := strconv.Atoi(portValue); err == nil {
+		return host, &port, true
+	}
+	return host, nil, true
+}
+
+// SplitURLHost splits the host of a URL, such as api.example.com:8443 or
+// [2001:db8::1], into the host, without brackets, and its port, nil when
+// the URL has none.
+func SplitURLHost(hostPort string) (string, *int) {
+	if host, port, ok := SplitHostPort(hostPort); ok {
+		return host, port
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hostPort, "["), "]"), nil
+}
@@ -0,0 +1,127 @@
+package types
+
+import "testing"
+
+func TestSplitScheme(t *testing.T) {
+	tests := []struct {
+		address  string
+		protocol Protocol
+		rest     string
+		ok       bool
+	}{
+		{"tcp://0.0.0.0:8080", ProtocolTCP, "0.0.0.0:8080", true},
+		{"tcp6://[::1]:8080", ProtocolTCP, "[::1]:8080", true},
+		{"udp4://:53", ProtocolUDP, ":53", true},
+		{"unix:///tmp/app.sock", ProtocolUnix, "/tmp/app.sock", true},
+		{"unix://app.sock", ProtocolUnix, "app.sock", true},
+		{"unix:/tmp/app.sock", ProtocolUnix, "/tmp/app.sock", true},
+		{"tcp-net://:5000?reuseport=true", ProtocolTCP, ":5000", true},
+		{"TCP://db:5432", ProtocolTCP, "db:5432", true},
+		{"http://api:8080", "", "http://api:8080", false},
+		{"db:5432", "", "db:5432", false},
+		{"unix:app.sock", "", "unix:app.sock", false},
+	}
+
+	for _, tt := range tests {
+		protocol, rest, ok := SplitScheme(tt.address)
+		if protocol != tt.protocol || rest != tt.rest || ok != tt.ok {
+			t.Errorf("SplitScheme(%q) = %q, %q, %t; want %q, %q, %t", tt.address, protocol, rest, ok, tt.protocol, tt.rest, tt.ok)
+		}
+	}
+}
+
+func TestProtocol_WithScheme(t *testing.T) {
+	tests := []struct {
+		protocol, scheme, want Protocol
+	}{
+		{ProtocolTCP, ProtocolUDP, ProtocolUDP},
+		{ProtocolUDP, ProtocolTCP, ProtocolTCP},
+		{ProtocolHTTP, ProtocolTCP, ProtocolHTTP},
+		{ProtocolGRPC, ProtocolUnix, ProtocolUnix},
+		{"", ProtocolTCP, ProtocolTCP},
+		{ProtocolUnknown, ProtocolUDP, ProtocolUDP},
+	}
+	for _, tt := range tests {
+		if got := tt.protocol.WithScheme(tt.scheme); got != tt.want {
+			t.Errorf("%q.WithScheme(%q) = %q, want %q", tt.protocol, tt.scheme, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkProtocol(t *testing.T) {
+	tests := []struct {
+		network  string
+		protocol Protocol
+		ok       bool
+	}{
+		{"tcp6", ProtocolTCP, true},
+		{"udp", ProtocolUDP, true},
+		{"unixgram", ProtocolUnix, true},
+		{"ip4:icmp", ProtocolICMP, true},
+		{"ip6:ipv6-icmp", ProtocolICMP, true},
+		{"ip4:1", ProtocolICMP, true},
+		{"ip:gre", ProtocolIP, true},
+		{"ip4", "", false},
+		{"sctp", "", false},
+	}
+
+	for _, tt := range tests {
+		protocol, ok := NetworkProtocol(tt.network)
+		if protocol != tt.protocol || ok != tt.ok {
+			t.Errorf("NetworkProtocol(%q) = %q, %t; want %q, %t", tt.network, protocol, ok, tt.protocol, tt.ok)
+		}
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		address string
+		host    string
+		port    int
+		ok      bool
+	}{
+		{"[::1]:8080", "::1", 8080, true},
+		{"[2001:db8::1]:443", "2001:db8::1", 443, true},
+		{"[fe80::1%eth0]:9000", "fe80::1%eth0", 9000, true},
+		{"localhost:8080", "localhost", 8080, true},
+		{":8080", "", 8080, true},
+		{"db:postgres", "db", 0, true},
+		{"::1", "", 0, false},
+		{"localhost", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		host, port, ok := SplitHostPort(tt.address)
+		got := 0
+		if port != nil {
+			got = *port
+		}
+		if host != tt.host || got != tt.port || ok != tt.ok {
+			t.Errorf("SplitHostPort(%q) = %q, %d, %t; want %q, %d, %t", tt.address, host, got, ok, tt.host, tt.port, tt.ok)
+		}
+	}
+}
+
+func TestSplitURLHost(t *testing.T) {
+	tests := []struct {
+		hostPort string
+		host     string
+		port     int
+	}{
+		{"[2001:db8::1]:8443", "2001:db8::1", 8443},
+		{"[2001:db8::1]", "2001:db8::1", 0},
+		{"api.example.com:8443", "api.example.com", 8443},
+		{"api.example.com", "api.example.com", 0},
+	}
+
+	for _, tt := range tests {
+		host, port := SplitURLHost(tt.hostPort)
+		got := 0
+		if port != nil {
+			got = *port
+		}
+		if host != tt.host || got != tt.port {
+			t.Errorf("SplitURLHost(%q) = %q, %d; want %q, %d", tt.hostPort, host, got, tt.host, tt.port)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvoyEgressCluster is the cluster the Envoy allowlist routes permitted
+// requests to, typically a dynamic forward proxy cluster defined next to
+// the generated route configuration.
+const EnvoyEgressCluster = "egress_forward_proxy"
+
+var (
+	templateAction = regexp.MustCompile(`{{.*?}}`)
+	formatVerb     = regexp.MustCompile(`%[-+# 0-9.*\[\]]*[a-zA-Z]`)
+)
+
+// egressAllowlist holds the hosts egress goes to by port. Hosts are names,
+// IPs or wildcards such as "*.s3.amazonaws.com".
+type egressAllowlist struct {
+	ports      map[int]map[string]bool
+	unresolved int
+	// Egress whose network, and so whether the proxy carries it, is not
+	// known statically
+	unknownProtocol int
+}
+
+// egressAllowlist collects the TCP destinations of egress findings that
+// leave the host. Unresolved destinations whose source expression spells
+// out the domain, such as "https://" + region + ".s3.amazonaws.com", are
+// allowed as a wildcard of it; other unresolved findings, and egress of
+// unknown protocol, are counted.
+func (r *AnalysisResults) egressAllowlist() egressAllowlist {
+	list := egressAllowlist{ports: make(map[int]map[string]bool)}
+	for _, socket := range r.Sockets {
+		if socket.Type == TrafficTypeEgress && socket.Protocol == ProtocolUnknown {
+			list.unknownProtocol++
+			continue
+		}
+		if socket.Type != TrafficTypeEgress || socket.Protocol.Transport() != ProtocolTCP {
+			continue
+		}
+		endpoint := socket.Destination
+		if (endpoint != nil && endpoint.Path != "") || isLoopback(endpoint) {
+			continue
+		}
+		host, port, ok := "", 0, false
+		if endpoint != nil && endpoint.Host != "" && endpoint.Port != nil {
+			host, port, ok = strings.Trim(endpoint.Host, "[]"), *endpoint.Port, true
+		} else {
+			host, port, ok = templateHost(socket.RawValue, socket.Protocol)
+		}
+		if !ok {
+			list.unresolved++
+			continue
+		}
+		if list.ports[port] == nil {
+			list.ports[port] = make(map[string]bool)
+		}
+		list.ports[port][host] = true
+	}
+
+	// Squid rejects a domain next to a wildcard covering it
+	for _, hosts := range list.ports {
+		for host := range hosts {
+			for wildcard := range hosts {
+				if strings.HasPrefix(wildcard, "*.") && host != wildcard && strings.HasSuffix(host, wildcard[1:]) {
+					delete(hosts, host)
+					break
+				}
+			}
+		}
+	}
+	return list
+}
+
+func (l egressAllowlist) sortedPorts() []int {
+	ports := make([]int, 0, len(l.ports))
+	for port := range l.ports {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// templateHost derives the host and port of an unresolved destination from
+// its source expression: string literals are kept, and everything else,
+// including fmt.Sprintf verbs and template actions, becomes a wildcard. A
+// wildcard in the host widens it to every subdomain of the domain after
+// it, which must have at least two labels; a wildcard in the port fails.
+func templateHost(raw string, protocol Protocol) (string, int, bool) {
+	pattern := raw
+	if expr, err := parser.ParseExpr(raw); err == nil {
+		pattern = wildcardPattern(expr)
+	}
+	pattern = templateAction.ReplaceAllString(pattern, "*")
+	for strings.Contains(pattern, "**") {
+		pattern = strings.ReplaceAll(pattern, "**", "*")
+	}
+
+	scheme, rest, hasScheme := strings.Cut(pattern, "://")
+	if !hasScheme {
+		scheme, rest = "", pattern
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+
+	host, port := rest, 0
+	if h, p, err := net.SplitHostPort(rest); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", 0, false
+		}
+		host, port = h, n
+	} else {
+		switch {
+		case scheme == "https" || (scheme == "" && protocol == ProtocolHTTPS):
+			port = 443
+		case scheme == "http" || (scheme == "" && protocol == ProtocolHTTP):
+			port = 80
+		default:
+			return "", 0, false
+		}
+	}
+
+	if i := strings.LastIndex(host, "*"); i >= 0 {
+		domain := host[i+1:]
+		if j := strings.Index(domain, "."); j >= 0 {
+			domain = domain[j+1:]
+		} else {
+			domain = ""
+		}
+		if !strings.Contains(domain, ".") {
+			return "", 0, false
+		}
+		host = "*." + domain
+	}
+	if host == "" {
+		return "", 0, false
+	}
+	return host, port, true
+}
+
+// wildcardPattern renders expr with every value not spelled out in it
+// replaced by "*".
+func wildcardPattern(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if value, err := strconv.Unquote(e.Value); err == nil && e.Kind == token.STRING {
+			return value
+		}
+	case *ast.ParenExpr:
+		return wildcardPattern(e.X)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return wildcardPattern(e.X) + wildcardPattern(e.Y)
+		}
+	case *ast.CallExpr:
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Sprintf" && len(e.Args) > 0 {
+			if lit, ok := e.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				format, err := strconv.Unquote(lit.Value)
+				if err == nil {
+					format = strings.ReplaceAll(format, "%%", "\x00")
+					format = formatVerb.ReplaceAllString(format, "*")
+					return strings.ReplaceAll(format, "\x00", "%")
+				}
+			}
+		}
+	}
+	return "*"
+}
+
+// exportSquid writes squid.conf access rules allowing egress to the
+// destinations found, per port: a dstdomain ACL of the host names, where
+// a wildcard "*.example.com" becomes ".example.com", and a dst ACL of the
+// IPs. Everything else is denied.
+func (r *AnalysisResults) exportSquid(writer io.Writer) error {
+	list := r.egressAllowlist()
+	var b strings.Builder
+	var rules []string
+	for _, port := range list.sortedPorts() {
+		var domains, ips []string
+		for _, host := range sortedKeys(list.ports[port]) {
+			switch {
+			case IsIPLiteral(host):
+				ips = append(ips, host)
+			case strings.HasPrefix(host, "*."):
+				domains = append(domains, host[1:])
+			case list.ports[port]["*."+host]:
+				// .example.com covers example.com
+			default:
+				domains = append(domains, host)
+			}
+		}
+		fmt.Fprintf(&b, "acl staticsocket_port_%d port %d\n", port, port)
+		if len(domains) > 0 {
+			fmt.Fprintf(&b, "acl staticsocket_domains_%d dstdomain %s\n", port, strings.Join(domains, " "))
+			rules = append(rules, fmt.Sprintf("http_access allow staticsocket_port_%d staticsocket_domains_%d", port, port))
+		}
+		if len(ips) > 0 {
+			fmt.Fprintf(&b, "acl staticsocket_ips_%d dst %s\n", port, strings.Join(ips, " "))
+			rules = append(rules, fmt.Sprintf("http_access allow staticsocket_port_%d staticsocket_ips_%d", port, port))
+		}
+	}
+	for _, rule := range rules {
+		b.WriteString(rule + "\n")
+	}
+	if list.unresolved > 0 {
+		fmt.Fprintf(&b, "# %d egress findings have no statically known destination and are not allowed\n", list.unresolved)
+	}
+	if list.unknownProtocol > 0 {
+		fmt.Fprintf(&b, "# %d egress findings of unknown protocol are not allowed\n", list.unknownProtocol)
+	}
+	b.WriteString("http_access deny all\n")
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// envoyRouteConfig is the subset of the Envoy v3 RouteConfiguration schema
+// the export needs.
+type envoyRouteConfig struct {
+	Name         string             `yaml:"name"`
+	VirtualHosts []envoyVirtualHost `yaml:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `yaml:"name"`
+	Domains []string     `yaml:"domains"`
+	Routes  []envoyRoute `yaml:"routes"`
+}
+
+type envoyRoute struct {
+	Match          envoyRouteMatch      `yaml:"match"`
+	Route          *envoyRouteAction    `yaml:"route,omitempty"`
+	DirectResponse *envoyDirectResponse `yaml:"direct_response,omitempty"`
+}
+
+type envoyRouteMatch struct {
+	Prefix         string    `yaml:"prefix,omitempty"`
+	ConnectMatcher *struct{} `yaml:"connect_matcher,omitempty"`
+}
+
+type envoyRouteAction struct {
+	Cluster        string               `yaml:"cluster"`
+	UpgradeConfigs []envoyUpgradeConfig `yaml:"upgrade_configs,omitempty"`
+}
+
+type envoyUpgradeConfig struct {
+	UpgradeType   string    `yaml:"upgrade_type"`
+	ConnectConfig *struct{} `yaml:"connect_config"`
+}
+
+type envoyDirectResponse struct {
+	Status int `yaml:"status"`
+}
+
+// exportEnvoy writes an Envoy route configuration for an egress proxy: a
+// virtual host matching the authority, host:port, of every destination
+// found, which forwards CONNECT tunnels and plain HTTP to
+// EnvoyEgressCluster, and a catch-all virtual host answering 403.
+func (r *AnalysisResults) exportEnvoy(writer io.Writer) error {
+	list := r.egressAllowlist()
+	var domains []string
+	for _, port := range list.sortedPorts() {
+		for _, host := range sortedKeys(list.ports[port]) {
+			domains = append(domains, net.JoinHostPort(host, strconv.Itoa(port)))
+			if port == 80 {
+				domains = append(domains, host)
+			}
+		}
+	}
+
+	config := envoyRouteConfig{Name: "staticsocket_egress"}
+	if len(domains) > 0 {
+		config.VirtualHosts = append(config.VirtualHosts, envoyVirtualHost{
+			Name:    "allowed",
+			Domains: domains,
+			Routes: []envoyRoute{
+				{
+					Match: envoyRouteMatch{ConnectMatcher: &struct{}{}},
+					Route: &envoyRouteAction{
+						Cluster:        EnvoyEgressCluster,
+						UpgradeConfigs: []envoyUpgradeConfig{{UpgradeType: "CONNECT", ConnectConfig: &struct{}{}}},
+					},
+				},
+				{Match: envoyRouteMatch{Prefix: "/"}, Route: &envoyRouteAction{Cluster: EnvoyEgressCluster}},
+			},
+		})
+	}
+	config.VirtualHosts = append(config.VirtualHosts, envoyVirtualHost{
+		Name:    "denied",
+		Domains: []string{"*"},
+		Routes:  []envoyRoute{{Match: envoyRouteMatch{Prefix: "/"}, DirectResponse: &envoyDirectResponse{Status: 403}}},
+	})
+
+	if list.unresolved > 0 {
+		if _, err := fmt.Fprintf(writer, "# %d egress findings have no statically known destination and are not allowed\n", list.unresolved); err != nil {
+			return err
+		}
+	}
+	if list.unknownProtocol > 0 {
+		if _, err := fmt.Fprintf(writer, "# %d egress findings of unknown protocol are not allowed\n", list.unknownProtocol); err != nil {
+			return err
+		}
+	}
+	encoder := yaml.NewEncoder(writer)
+	defer encoder.Close()
+	encoder.SetIndent(2)
+	return encoder.Encode(config)
+}
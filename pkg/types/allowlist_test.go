@@ -0,0 +1,108 @@
+package types
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func allowlistResults() AnalysisResults {
+	return AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, Destination: NewEndpoint("payments.internal", intPtr(443))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, Destination: NewEndpoint("eu.s3.amazonaws.com", intPtr(443))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, RawValue: `"https://" + region + ".s3.amazonaws.com/bucket"`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, RawValue: `fmt.Sprintf("https://api-%s.example.com:8443/v1", env)`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, RawValue: `http://{{ .Host }}.svc.example.org/healthz`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, Destination: NewEndpoint("10.0.0.5", intPtr(5432))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, Destination: NewEndpoint("localhost", intPtr(6379))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUDP, Destination: NewEndpoint("dns.internal", intPtr(53))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUnix, Destination: NewPathEndpoint("/var/run/docker.sock")},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, RawValue: `"https://" + host`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, RawValue: "cfg.URL"},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUnknown, Destination: NewEndpoint("collector", intPtr(8125))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, Listen: NewEndpoint("0.0.0.0", intPtr(8080))},
+		},
+	}
+}
+
+func TestAnalysisResults_ExportSquid(t *testing.T) {
+	results := allowlistResults()
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "squid"); err != nil {
+		t.Fatalf("Failed to export squid allowlist: %v", err)
+	}
+
+	expected := `acl staticsocket_port_80 port 80
+acl staticsocket_domains_80 dstdomain .svc.example.org
+acl staticsocket_port_443 port 443
+acl staticsocket_domains_443 dstdomain .s3.amazonaws.com payments.internal
+acl staticsocket_port_5432 port 5432
+acl staticsocket_ips_5432 dst 10.0.0.5
+acl staticsocket_port_8443 port 8443
+acl staticsocket_domains_8443 dstdomain .example.com
+http_access allow staticsocket_port_80 staticsocket_domains_80
+http_access allow staticsocket_port_443 staticsocket_domains_443
+http_access allow staticsocket_port_5432 staticsocket_ips_5432
+http_access allow staticsocket_port_8443 staticsocket_domains_8443
+# 2 egress findings have no statically known destination and are not allowed
+# 1 egress findings of unknown protocol are not allowed
+http_access deny all
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected squid allowlist:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestAnalysisResults_ExportEnvoy(t *testing.T) {
+	results := allowlistResults()
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "envoy"); err != nil {
+		t.Fatalf("Failed to export envoy allowlist: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "# 2 egress findings") {
+		t.Errorf("Expected the unresolved findings counted, got:\n%s", buf.String())
+	}
+
+	var config envoyRouteConfig
+	if err := yaml.Unmarshal(buf.Bytes(), &config); err != nil {
+		t.Fatalf("Failed to parse route configuration: %v", err)
+	}
+	if len(config.VirtualHosts) != 2 || config.VirtualHosts[1].Domains[0] != "*" || config.VirtualHosts[1].Routes[0].DirectResponse.Status != 403 {
+		t.Fatalf("Expected an allowed and a denied virtual host, got %+v", config.VirtualHosts)
+	}
+	allowed := config.VirtualHosts[0]
+	wantDomains := []string{"*.svc.example.org:80", "*.svc.example.org", "*.s3.amazonaws.com:443", "payments.internal:443", "10.0.0.5:5432", "*.example.com:8443"}
+	if !reflect.DeepEqual(allowed.Domains, wantDomains) {
+		t.Errorf("Expected domains %v, got %v", wantDomains, allowed.Domains)
+	}
+	if len(allowed.Routes) != 2 || allowed.Routes[0].Match.ConnectMatcher == nil || allowed.Routes[0].Route.Cluster != EnvoyEgressCluster {
+		t.Errorf("Expected a CONNECT route to %s, got %+v", EnvoyEgressCluster, allowed.Routes)
+	}
+}
+
+func TestTemplateHost(t *testing.T) {
+	tests := []struct {
+		raw      string
+		protocol Protocol
+		host     string
+		port     int
+		ok       bool
+	}{
+		{`"https://api.example.com/users/" + id`, ProtocolHTTPS, "api.example.com", 443, true},
+		{`fmt.Sprintf("%s-%[2]d.cache.internal.example:%d", name, shard, port)`, ProtocolTCP, "", 0, false},
+		{`fmt.Sprintf("tenant-%s.db.example.com:5432", tenant)`, ProtocolTCP, "*.db.example.com", 5432, true},
+		{`"https://" + host + ".com"`, ProtocolHTTPS, "", 0, false},
+		{`"https://user:" + password + "@api.example.com"`, ProtocolHTTPS, "api.example.com", 443, true},
+		{`cfg.Endpoint`, ProtocolHTTPS, "", 0, false},
+	}
+	for _, tt := range tests {
+		host, port, ok := templateHost(tt.raw, tt.protocol)
+		if host != tt.host || port != tt.port || ok != tt.ok {
+			t.Errorf("templateHost(%s) = %q, %d, %t; want %q, %d, %t", tt.raw, host, port, ok, tt.host, tt.port, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Annotations on generated Backstage entities.
+const (
+	// AnnotationListenPorts lists the transport and port of every listener,
+	// e.g. "tcp/8080,udp/9000-9010".
+	AnnotationListenPorts = "staticsocket.io/listen-ports"
+	// AnnotationDependencies lists the destinations egress goes to, as
+	// host:port.
+	AnnotationDependencies = "staticsocket.io/dependencies"
+)
+
+// backstageEntity is the subset of the backstage.io/v1alpha1 Component
+// schema the export needs.
+type backstageEntity struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   backstageMetadata `yaml:"metadata"`
+	Spec       backstageSpec     `yaml:"spec"`
+}
+
+type backstageMetadata struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type backstageSpec struct {
+	Type         string   `yaml:"type"`
+	Lifecycle    string   `yaml:"lifecycle"`
+	Owner        string   `yaml:"owner"`
+	ProvidesAPIs []string `yaml:"providesApis,omitempty"`
+	ConsumesAPIs []string `yaml:"consumesApis,omitempty"`
+}
+
+// exportBackstage writes one catalog-info Component fragment per process,
+// to be merged into the entity the service already has. Listeners become
+// providesApis and egress becomes consumesApis, both named
+// <host>-<protocol>-<port> after the process or destination, so a client
+// dialing ledger:50051 over gRPC consumes the API a process named ledger
+// provides on that port. Ports and dependencies are also listed in
+// annotations. Loopback and unix socket traffic, and health checks, are
+// left out.
+func (r *AnalysisResults) exportBackstage(writer io.Writer) error {
+	type process struct {
+		provides     map[string]bool
+		consumes     map[string]bool
+		ports        map[string]bool
+		dependencies map[string]bool
+		unresolved   int
+	}
+	processes := make(map[string]*process)
+	for _, socket := range r.Sockets {
+		name := threagileID(socket.ProcessName)
+		p, ok := processes[name]
+		if !ok {
+			p = &process{
+				provides:     make(map[string]bool),
+				consumes:     make(map[string]bool),
+				ports:        make(map[string]bool),
+				dependencies: make(map[string]bool),
+			}
+			processes[name] = p
+		}
+
+		endpoint := socket.Listen
+		if socket.Type == TrafficTypeEgress {
+			endpoint = socket.Destination
+		}
+		transport := string(socket.Protocol.Transport())
+		if transport == string(ProtocolUnix) || (endpoint != nil && endpoint.Path != "") || isLoopback(endpoint) {
+			continue
+		}
+		if socket.Type == TrafficTypeEgress && containsString(socket.Tags, TagHealthcheck) {
+			continue
+		}
+		port, ok := backstagePort(endpoint)
+		if !ok || socket.Protocol == ProtocolUnknown || (socket.Type == TrafficTypeEgress && endpoint.Host == "") {
+			p.unresolved++
+			continue
+		}
+
+		if socket.Type == TrafficTypeIngress {
+			p.ports[transport+"/"+port] = true
+			p.provides[backstageAPI(socket.ProcessName, socket.Protocol, port)] = true
+			continue
+		}
+		p.dependencies[endpoint.String()] = true
+		p.consumes[backstageAPI(endpoint.Host, socket.Protocol, port)] = true
+	}
+
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	encoder := yaml.NewEncoder(writer)
+	defer encoder.Close()
+	encoder.SetIndent(2)
+	for _, name := range names {
+		p := processes[name]
+		entity := backstageEntity{
+			APIVersion: "backstage.io/v1alpha1",
+			Kind:       "Component",
+			Metadata:   backstageMetadata{Name: name, Annotations: make(map[string]string)},
+			Spec: backstageSpec{
+				Type:         "service",
+				Lifecycle:    "unknown",
+				Owner:        "unknown",
+				ProvidesAPIs: sortedKeys(p.provides),
+				ConsumesAPIs: sortedKeys(p.consumes),
+			},
+		}
+		if len(p.ports) > 0 {
+			entity.Metadata.Annotations[AnnotationListenPorts] = strings.Join(sortedKeys(p.ports), ",")
+		}
+		if len(p.dependencies) > 0 {
+			entity.Metadata.Annotations[AnnotationDependencies] = strings.Join(sortedKeys(p.dependencies), ",")
+		}
+		if p.unresolved > 0 {
+			entity.Metadata.Annotations[AnnotationUnresolved] = strconv.Itoa(p.unresolved)
+		}
+
+		if err := encoder.Encode(entity); err != nil {
+			return fmt.Errorf("encoding backstage entity %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// backstagePort returns the port or port range of endpoint, reporting
+// false when neither is known.
+func backstagePort(endpoint *Endpoint) (string, bool) {
+	switch {
+	case endpoint == nil:
+		return "", false
+	case endpoint.Port != nil:
+		return strconv.Itoa(*endpoint.Port), true
+	case endpoint.PortRange != nil:
+		return strconv.Itoa(endpoint.PortRange.Start) + "-" + strconv.Itoa(endpoint.PortRange.End), true
+	}
+	return "", false
+}
+
+// backstageAPI names the API served by host on port, within the character
+// set of Backstage entity names.
+func backstageAPI(host string, protocol Protocol, port string) string {
+	return threagileID(strings.Trim(host, "[]") + "-" + string(protocol) + "-" + port)
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnalysisResults_ExportBackstage(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeIngress, Protocol: ProtocolGRPC, ProcessName: "ledger", Listen: NewEndpoint("0.0.0.0", intPtr(50051))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", intPtr(8080))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolUDP, ProcessName: "api", Listen: &Endpoint{Host: "0.0.0.0", PortRange: &PortRange{Start: 9000, End: 9010}}},
+			{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "api", Listen: NewEndpoint("127.0.0.1", intPtr(6060))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "api"},
+			{Type: TrafficTypeEgress, Protocol: ProtocolGRPC, ProcessName: "api", Destination: NewEndpoint("ledger", intPtr(50051))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("10.0.0.5", intPtr(5432))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUnix, ProcessName: "api", Destination: NewPathEndpoint("/var/run/docker.sock")},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", Destination: NewEndpoint("status", intPtr(80)), Tags: []string{TagHealthcheck}},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", RawValue: "cfg.URL"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "backstage"); err != nil {
+		t.Fatalf("Failed to export backstage entities: %v", err)
+	}
+
+	var entities []backstageEntity
+	decoder := yaml.NewDecoder(&buf)
+	for {
+		var entity backstageEntity
+		err := decoder.Decode(&entity)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to parse backstage entity: %v", err)
+		}
+		entities = append(entities, entity)
+	}
+	if len(entities) != 2 || entities[0].Metadata.Name != "api" || entities[1].Metadata.Name != "ledger" {
+		t.Fatalf("Expected entities for api and ledger, got %+v", entities)
+	}
+
+	api := entities[0]
+	if api.APIVersion != "backstage.io/v1alpha1" || api.Kind != "Component" || api.Spec.Type != "service" {
+		t.Errorf("Unexpected entity header: %+v", api)
+	}
+	if want := []string{"api-http-8080", "api-udp-9000-9010"}; !reflect.DeepEqual(api.Spec.ProvidesAPIs, want) {
+		t.Errorf("Expected provided APIs %v, got %v", want, api.Spec.ProvidesAPIs)
+	}
+	if want := []string{"10-0-0-5-tcp-5432", "ledger-grpc-50051"}; !reflect.DeepEqual(api.Spec.ConsumesAPIs, want) {
+		t.Errorf("Expected consumed APIs %v, got %v", want, api.Spec.ConsumesAPIs)
+	}
+	wantAnnotations := map[string]string{
+		AnnotationListenPorts:  "tcp/8080,udp/9000-9010",
+		AnnotationDependencies: "10.0.0.5:5432,ledger:50051",
+		AnnotationUnresolved:   "2",
+	}
+	if !reflect.DeepEqual(api.Metadata.Annotations, wantAnnotations) {
+		t.Errorf("Expected annotations %v, got %v", wantAnnotations, api.Metadata.Annotations)
+	}
+
+	if !reflect.DeepEqual(entities[1].Spec.ProvidesAPIs, api.Spec.ConsumesAPIs[1:]) {
+		t.Errorf("Expected ledger to provide the API api consumes, got %v", entities[1].Spec.ProvidesAPIs)
+	}
+}
@@ -0,0 +1,116 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dotGraph collects the nodes and edges of the traffic topology in the
+// order they are first seen, so the same results render the same graph.
+type dotGraph struct {
+	nodes     []string
+	nodeAttrs map[string]string
+	edges     []dotEdge
+	edgeIndex map[dotEdge]bool
+}
+
+type dotEdge struct {
+	from, to string
+	attrs    string
+}
+
+func (g *dotGraph) node(id, attrs string) {
+	if _, seen := g.nodeAttrs[id]; !seen {
+		g.nodes = append(g.nodes, id)
+		g.nodeAttrs[id] = attrs
+	}
+}
+
+func (g *dotGraph) edge(from, to, attrs string) {
+	edge := dotEdge{from: from, to: to, attrs: attrs}
+	if !g.edgeIndex[edge] {
+		g.edges = append(g.edges, edge)
+		g.edgeIndex[edge] = true
+	}
+}
+
+// exportDOT writes the traffic topology as a Graphviz digraph: a box per
+// process, an ellipse per port it listens on with an edge into the
+// process, and an edge per egress flow from the process to the
+// destination host, labeled with the protocol and port. Unresolved
+// destinations are dashed and named by their source expression; egress
+// skipping TLS certificate verification is drawn red. Health checks are
+// left out, like in the threagile export.
+func (r *AnalysisResults) exportDOT(writer io.Writer) error {
+	graph := &dotGraph{nodeAttrs: make(map[string]string), edgeIndex: make(map[dotEdge]bool)}
+	for _, socket := range r.Sockets {
+		process := socket.ProcessName
+		if process == "" {
+			process = "unknown"
+		}
+		processID := "process:" + process
+		graph.node(processID, fmt.Sprintf("label=%s, shape=box, style=filled, fillcolor=%q", dotQuote(process), "#dbe9f6"))
+
+		switch socket.Type {
+		case TrafficTypeIngress:
+			label := fmt.Sprintf("%s %s", socket.Protocol, socket.EndpointName())
+			portID := "listen:" + process + ":" + label
+			attrs := "label=" + dotQuote(label) + ", shape=ellipse"
+			if !socket.IsResolved {
+				attrs += ", style=dashed"
+			}
+			graph.node(portID, attrs)
+			graph.edge(portID, processID, "")
+		case TrafficTypeEgress:
+			if containsString(socket.Tags, TagHealthcheck) {
+				break
+			}
+			host := socket.Destination.address()
+			hostID, hostAttrs := "host:"+host, "shape=box, style=rounded"
+			if !socket.IsResolved || host == "" {
+				host = socket.EndpointName()
+				hostID, hostAttrs = "unresolved:"+host, "shape=box, style=\"rounded,dashed\""
+			}
+			graph.node(hostID, "label="+dotQuote(host)+", "+hostAttrs)
+
+			label := string(socket.Protocol)
+			if port := socket.Destination.port(); port != nil && socket.IsResolved {
+				label += " :" + strconv.Itoa(*port)
+			}
+			attrs := "label=" + dotQuote(label)
+			if socket.VerifiesTLS != nil && !*socket.VerifiesTLS {
+				attrs += ", color=red, fontcolor=red"
+			}
+			graph.edge(processID, hostID, attrs)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph staticsocket {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, id := range graph.nodes {
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(id), graph.nodeAttrs[id])
+	}
+	for _, edge := range graph.edges {
+		if edge.attrs == "" {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.from), dotQuote(edge.to))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.from), dotQuote(edge.to), edge.attrs)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// dotQuote renders s as a DOT quoted string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalysisResults_ExportDOT(t *testing.T) {
+	skip := false
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", intPtr(8080)), IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("db", intPtr(5432)), IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("db", intPtr(5432)), IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, ProcessName: "worker", Destination: NewEndpoint("api.example.com", intPtr(443)), IsResolved: true, VerifiesTLS: &skip},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "worker", RawValue: `cfg["url"]`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", Destination: NewEndpoint("localhost", intPtr(8080)), IsResolved: true, Tags: []string{TagHealthcheck}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "dot"); err != nil {
+		t.Fatalf("Failed to export DOT: %v", err)
+	}
+	got := buf.String()
+	want := []string{
+		`"process:api" [label="api", shape=box`,
+		`"listen:api:http 0.0.0.0:8080" [label="http 0.0.0.0:8080", shape=ellipse];`,
+		`"listen:api:http 0.0.0.0:8080" -> "process:api";`,
+		`"process:api" -> "host:db" [label="tcp :5432"];`,
+		`"process:worker" -> "host:api.example.com" [label="https :443", color=red, fontcolor=red];`,
+		`"unresolved:cfg[\"url\"]" [label="cfg[\"url\"]", shape=box, style="rounded,dashed"];`,
+	}
+	for _, line := range want {
+		if !strings.Contains(got, line) {
+			t.Errorf("Expected %s in:\n%s", line, got)
+		}
+	}
+	if strings.Count(got, `-> "host:db"`) != 1 {
+		t.Errorf("Expected repeated flows drawn once:\n%s", got)
+	}
+	if strings.Contains(got, "localhost") {
+		t.Errorf("Expected health checks left out:\n%s", got)
+	}
+	if !strings.HasPrefix(got, "digraph staticsocket {\n") || !strings.HasSuffix(got, "}\n") {
+		t.Errorf("Expected a complete digraph:\n%s", got)
+	}
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ElasticsearchMapping is the index mapping for documents written by the
+// elasticsearch export. Create the index with it before the first bulk
+// request; dynamic mapping is disabled so a type mismatch fails loudly.
+const ElasticsearchMapping = `{
+  "mappings": {
+    "dynamic": "strict",
+    "properties": {
+      "@timestamp": {"type": "date"},
+      "run_id": {"type": "keyword"},
+      "tool_version": {"type": "keyword"},
+      "vcs": {
+        "properties": {
+          "repository_url": {"type": "keyword"},
+          "commit": {"type": "keyword"},
+          "branch": {"type": "keyword"}
+        }
+      },
+      "type": {"type": "keyword"},
+      "protocol": {"type": "keyword"},
+      "process_name": {"type": "keyword"},
+      "source_file": {"type": "keyword"},
+      "source_line": {"type": "integer"},
+      "function_name": {"type": "keyword"},
+      "listen": {"properties": ` + elasticsearchEndpointMapping + `},
+      "destination": {"properties": ` + elasticsearchEndpointMapping + `},
+      "endpoint": {"type": "keyword"},
+      "is_resolved": {"type": "boolean"},
+      "raw_value": {"type": "keyword"},
+      "pattern_match": {"type": "keyword"},
+      "unresolved_reason": {"type": "keyword"},
+      "tags": {"type": "keyword"},
+      "notes": {"type": "text"}
+    }
+  }
+}
+`
+
+const elasticsearchEndpointMapping = `{
+        "host": {"type": "keyword"},
+        "port": {"type": "integer"},
+        "port_range": {"type": "integer_range"},
+        "path": {"type": "keyword"},
+        "family": {"type": "keyword"},
+        "kind": {"type": "keyword"},
+        "fqdn_candidates": {"type": "keyword"}
+      }`
+
+// elasticsearchDocument is one finding as indexed, with the run it belongs
+// to, so findings of every scan can live in one index.
+type elasticsearchDocument struct {
+	Timestamp   string                 `json:"@timestamp,omitempty"`
+	RunID       string                 `json:"run_id"`
+	ToolVersion string                 `json:"tool_version,omitempty"`
+	VCS         *elasticsearchVCS      `json:"vcs,omitempty"`
+	Type        TrafficType            `json:"type"`
+	Protocol    Protocol               `json:"protocol"`
+	ProcessName string                 `json:"process_name"`
+	SourceFile  string                 `json:"source_file"`
+	SourceLine  int                    `json:"source_line"`
+	Function    string                 `json:"function_name"`
+	Listen      *elasticsearchEndpoint `json:"listen,omitempty"`
+	Destination *elasticsearchEndpoint `json:"destination,omitempty"`
+	Endpoint    string                 `json:"endpoint"`
+	IsResolved  bool                   `json:"is_resolved"`
+	RawValue    string                 `json:"raw_value"`
+	Pattern     string                 `json:"pattern_match"`
+	Reason      string                 `json:"unresolved_reason,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Notes       []string               `json:"notes,omitempty"`
+}
+
+type elasticsearchVCS struct {
+	RepositoryURL string `json:"repository_url,omitempty"`
+	Commit        string `json:"commit"`
+	Branch        string `json:"branch,omitempty"`
+}
+
+// elasticsearchEndpoint is an Endpoint with its port range in the
+// gte/lte form of an integer_range field.
+type elasticsearchEndpoint struct {
+	Host           string         `json:"host,omitempty"`
+	Port           *int           `json:"port,omitempty"`
+	PortRange      map[string]int `json:"port_range,omitempty"`
+	Path           string         `json:"path,omitempty"`
+	Family         AddressFamily  `json:"family,omitempty"`
+	Kind           EndpointKind   `json:"kind,omitempty"`
+	FQDNCandidates []string       `json:"fqdn_candidates,omitempty"`
+}
+
+func newElasticsearchEndpoint(e *Endpoint) *elasticsearchEndpoint {
+	if e == nil {
+		return nil
+	}
+	endpoint := &elasticsearchEndpoint{
+		Host:           e.Host,
+		Port:           e.Port,
+		Path:           e.Path,
+		Family:         e.Family,
+		Kind:           e.Kind,
+		FQDNCandidates: e.FQDNCandidates,
+	}
+	if e.PortRange != nil {
+		endpoint.PortRange = map[string]int{"gte": e.PortRange.Start, "lte": e.PortRange.End}
+	}
+	return endpoint
+}
+
+// exportElasticsearch writes the findings as a bulk request body: an index
+// action and a document per finding. The actions name no index, so the
+// body is posted to /<index>/_bulk. Document IDs derive from the run, so
+// re-sending the same results overwrites instead of duplicating.
+func (r *AnalysisResults) exportElasticsearch(writer io.Writer) error {
+	runID, err := r.runID()
+	if err != nil {
+		return err
+	}
+
+	base := elasticsearchDocument{RunID: runID}
+	if r.Scan != nil {
+		base.Timestamp = r.Scan.Timestamp.UTC().Format(time.RFC3339)
+		base.ToolVersion = r.Scan.ToolVersion
+	}
+	if r.VCS != nil {
+		base.VCS = &elasticsearchVCS{RepositoryURL: r.VCS.RepositoryURL, Commit: r.VCS.Commit, Branch: r.VCS.Branch}
+	}
+
+	encoder := json.NewEncoder(writer)
+	for i, socket := range r.Sockets {
+		action := map[string]map[string]string{"index": {"_id": fmt.Sprintf("%s-%d", runID, i+1)}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+
+		doc := base
+		doc.Type = socket.Type
+		doc.Protocol = socket.Protocol
+		doc.ProcessName = socket.ProcessName
+		doc.SourceFile = socket.SourceFile
+		doc.SourceLine = socket.SourceLine
+		doc.Function = socket.FunctionName
+		doc.Listen = newElasticsearchEndpoint(socket.Listen)
+		doc.Destination = newElasticsearchEndpoint(socket.Destination)
+		doc.Endpoint = socket.EndpointName()
+		doc.IsResolved = socket.IsResolved
+		doc.RawValue = socket.RawValue
+		doc.Pattern = socket.PatternMatch
+		doc.Reason = socket.UnresolvedReason
+		doc.Tags = socket.Tags
+		doc.Notes = socket.Notes
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
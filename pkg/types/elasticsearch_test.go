@@ -0,0 +1,103 @@
+package types
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// checkMapped fails for every field of doc the mapping's properties do
+// not declare, descending into objects.
+func checkMapped(t *testing.T, prefix string, doc, properties map[string]any) {
+	t.Helper()
+	for key, value := range doc {
+		field, ok := properties[key].(map[string]any)
+		if !ok {
+			t.Errorf("Field %s%s is not in the index mapping", prefix, key)
+			continue
+		}
+		if object, ok := value.(map[string]any); ok && field["type"] == nil {
+			nested, _ := field["properties"].(map[string]any)
+			checkMapped(t, prefix+key+".", object, nested)
+		}
+	}
+}
+
+func TestAnalysisResults_ExportElasticsearch(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolUDP,
+				SourceFile:   "main.go",
+				SourceLine:   10,
+				Listen:       &Endpoint{Host: "0.0.0.0", PortRange: &PortRange{Start: 10000, End: 10100}},
+				IsResolved:   true,
+				PatternMatch: "webrtc.NewAPI",
+				Tags:         []string{"webrtc"},
+			},
+			{
+				Type:             TrafficTypeEgress,
+				Protocol:         ProtocolHTTPS,
+				SourceFile:       "client.go",
+				SourceLine:       20,
+				Destination:      NewEndpoint("api.example.com", intPtr(443)),
+				IsResolved:       true,
+				PatternMatch:     "http.Get",
+				UnresolvedReason: "",
+				Notes:            []string{"note"},
+			},
+		},
+		Scan: &ScanInfo{ToolVersion: "v1.0.0", Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		VCS:  &VCSInfo{System: "git", Commit: "abc123", Branch: "main"},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "elasticsearch"); err != nil {
+		t.Fatalf("Failed to export bulk body: %v", err)
+	}
+
+	var mapping struct {
+		Mappings struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(ElasticsearchMapping), &mapping); err != nil {
+		t.Fatalf("Index mapping is not valid JSON: %v", err)
+	}
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Bulk line is not valid JSON: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("Expected an action and a document per finding, got %d lines", len(lines))
+	}
+
+	for i := 0; i < len(lines); i += 2 {
+		action, ok := lines[i]["index"].(map[string]any)
+		if !ok || action["_id"] == "" || action["_index"] != nil {
+			t.Errorf("Line %d: expected an index action with an ID and no index, got %v", i, lines[i])
+		}
+		doc := lines[i+1]
+		checkMapped(t, "", doc, mapping.Mappings.Properties)
+		if doc["@timestamp"] != "2024-01-02T03:04:05Z" || doc["vcs"].(map[string]any)["commit"] != "abc123" {
+			t.Errorf("Line %d: expected the run metadata on every document, got %v", i+1, doc)
+		}
+	}
+
+	portRange := lines[1]["listen"].(map[string]any)["port_range"].(map[string]any)
+	if portRange["gte"] != 10000.0 || portRange["lte"] != 10100.0 {
+		t.Errorf("Expected the port range as gte/lte, got %v", portRange)
+	}
+	if lines[3]["endpoint"] != "api.example.com:443" {
+		t.Errorf("Expected endpoint api.example.com:443, got %v", lines[3]["endpoint"])
+	}
+}
@@ -0,0 +1,201 @@
+package types
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// EndpointKind tells how an endpoint is addressed.
+type EndpointKind string
+
+const (
+	EndpointKindIP       EndpointKind = "ip"
+	EndpointKindHostname EndpointKind = "hostname"
+	EndpointKindPath     EndpointKind = "path"
+)
+
+// AddressFamily is the address family of an endpoint, when known.
+type AddressFamily string
+
+const (
+	FamilyIPv4 AddressFamily = "ipv4"
+	FamilyIPv6 AddressFamily = "ipv6"
+	FamilyUnix AddressFamily = "unix"
+)
+
+// PortRange is an inclusive range of ports, such as a published
+// container range 8000-8010.
+type PortRange struct {
+	Start int `json:"start" yaml:"start"`
+	End   int `json:"end" yaml:"end"`
+}
+
+// Endpoint is one side of a socket: the address a listener binds or the
+// destination a client connects to. Unset fields are unknown.
+type Endpoint struct {
+	// Host is an interface address or a destination host name or IP
+	Host      string     `json:"host,omitempty" yaml:"host,omitempty"`
+	Port      *int       `json:"port,omitempty" yaml:"port,omitempty"`
+	PortRange *PortRange `json:"port_range,omitempty" yaml:"port_range,omitempty"`
+	// Path is the file system path of a unix socket
+	Path   string        `json:"path,omitempty" yaml:"path,omitempty"`
+	Family AddressFamily `json:"family,omitempty" yaml:"family,omitempty"`
+	Kind   EndpointKind  `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// FQDNCandidates are the names an unqualified Host may resolve to
+	// through the DNS search path, in search order
+	FQDNCandidates []string `json:"fqdn_candidates,omitempty" yaml:"fqdn_candidates,omitempty"`
+}
+
+// NewEndpoint returns an endpoint for host and port, with Kind and Family
+// derived from host.
+func NewEndpoint(host string, port *int) *Endpoint {
+	e := &Endpoint{Port: port}
+	e.SetHost(host)
+	return e
+}
+
+// NewPathEndpoint returns the endpoint of a unix socket at path.
+func NewPathEndpoint(path string) *Endpoint {
+	return &Endpoint{Path: path, Family: FamilyUnix, Kind: EndpointKindPath}
+}
+
+// SetHost sets Host and derives Kind and Family from it: literal IPv4 and
+// IPv6 addresses, possibly bracketed or zoned, are of kind ip, anything
+// else is a host name.
+func (e *Endpoint) SetHost(host string) {
+	e.Host = host
+	e.Kind, e.Family = "", ""
+	if host == "" {
+		return
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if err != nil {
+		e.Kind = EndpointKindHostname
+		return
+	}
+	e.Kind = EndpointKindIP
+	if addr.Is4() {
+		e.Family = FamilyIPv4
+	} else {
+		e.Family = FamilyIPv6
+	}
+}
+
+// IsIP reports whether the endpoint is addressed by a literal IP.
+func (e *Endpoint) IsIP() bool {
+	return e != nil && e.Kind == EndpointKindIP
+}
+
+// address returns the host, or the path of a socket endpoint.
+func (e *Endpoint) address() string {
+	switch {
+	case e == nil:
+		return ""
+	case e.Path != "":
+		return e.Path
+	}
+	return e.Host
+}
+
+func (e *Endpoint) port() *int {
+	if e == nil {
+		return nil
+	}
+	return e.Port
+}
+
+func (e *Endpoint) fqdnCandidates() []string {
+	if e == nil {
+		return nil
+	}
+	return e.FQDNCandidates
+}
+
+// String renders the endpoint as host:port, a port range, a bare host or a
+// socket path, whichever is known.
+func (e *Endpoint) String() string {
+	switch {
+	case e == nil:
+		return ""
+	case e.Path != "":
+		return e.Path
+	case e.Port != nil:
+		return net.JoinHostPort(strings.Trim(e.Host, "[]"), strconv.Itoa(*e.Port))
+	case e.PortRange != nil:
+		return net.JoinHostPort(strings.Trim(e.Host, "[]"), strconv.Itoa(e.PortRange.Start)+"-"+strconv.Itoa(e.PortRange.End))
+	}
+	return e.Host
+}
+
+// IsIPLiteral reports whether host is a literal IPv4 or IPv6 address,
+// possibly bracketed or carrying a zone, rather than a name that needs a
+// DNS lookup.
+func IsIPLiteral(host string) bool {
+	return NewEndpoint(host, nil).IsIP()
+}
+
+// NewListener returns a resolved ingress socket of protocol listening on
+// host and port.
+func NewListener(protocol Protocol, host string, port int) SocketInfo {
+	return SocketInfo{Type: TrafficTypeIngress, Protocol: protocol, Listen: NewEndpoint(host, &port), IsResolved: true}
+}
+
+// NewConnection returns a resolved egress socket of protocol connecting to
+// host and port.
+func NewConnection(protocol Protocol, host string, port int) SocketInfo {
+	return SocketInfo{Type: TrafficTypeEgress, Protocol: protocol, Destination: NewEndpoint(host, &port), IsResolved: true}
+}
+
+// NewUnixListener returns a resolved ingress socket of protocol listening on
+// the unix socket at path.
+func NewUnixListener(protocol Protocol, path string) SocketInfo {
+	return SocketInfo{Type: TrafficTypeIngress, Protocol: protocol, Listen: NewPathEndpoint(path), IsResolved: true}
+}
+
+// NewUnixConnection returns a resolved egress socket of protocol connecting
+// to the unix socket at path.
+func NewUnixConnection(protocol Protocol, path string) SocketInfo {
+	return SocketInfo{Type: TrafficTypeEgress, Protocol: protocol, Destination: NewPathEndpoint(path), IsResolved: true}
+}
+
+// Endpoint returns the endpoint of the socket's own side of the traffic:
+// Listen for ingress, Destination for egress. It is nil when the address
+// is unknown.
+func (s SocketInfo) Endpoint() *Endpoint {
+	switch s.Type {
+	case TrafficTypeIngress:
+		return s.Listen
+	case TrafficTypeEgress:
+		return s.Destination
+	}
+	if s.Listen != nil {
+		return s.Listen
+	}
+	return s.Destination
+}
+
+// DestinationAddress returns the destination as host:port, a bare host or a
+// socket path, and "" when there is none, as for listeners. It stands in
+// for a Destination method, whose name the field holds; Listen and
+// Destination stay pointers so that an unknown side is left out of JSON
+// and YAML rather than written as an empty object.
+func (s SocketInfo) DestinationAddress() string {
+	return s.Destination.String()
+}
+
+// Host returns the host of Endpoint, or the path of a unix socket, and ""
+// when unknown.
+func (s SocketInfo) Host() string {
+	return s.Endpoint().address()
+}
+
+// Port returns the port of Endpoint, reporting false when it has none, as
+// for unresolved addresses, port ranges and unix sockets.
+func (s SocketInfo) Port() (int, bool) {
+	if port := s.Endpoint().port(); port != nil {
+		return *port, true
+	}
+	return 0, false
+}
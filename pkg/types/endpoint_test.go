@@ -0,0 +1,104 @@
+package types
+
+import "testing"
+
+func TestIsIPLiteral(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"::1", true},
+		{"[2001:db8::1]", true},
+		{"fe80::1%eth0", true},
+		{"db.internal", false},
+		{"localhost", false},
+		{"10.0.0.1.nip.io", false},
+		{"256.0.0.1", false},
+		{"", false},
+		{"/var/run/docker.sock", false},
+	}
+	for _, tt := range tests {
+		if got := IsIPLiteral(tt.host); got != tt.want {
+			t.Errorf("IsIPLiteral(%q) = %t, want %t", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestNewEndpoint(t *testing.T) {
+	port := 443
+	tests := []struct {
+		host   string
+		kind   EndpointKind
+		family AddressFamily
+		str    string
+	}{
+		{"10.0.0.1", EndpointKindIP, FamilyIPv4, "10.0.0.1:443"},
+		{"[2001:db8::1]", EndpointKindIP, FamilyIPv6, "[2001:db8::1]:443"},
+		{"api.example.com", EndpointKindHostname, "", "api.example.com:443"},
+		{"", "", "", ":443"},
+	}
+	for _, tt := range tests {
+		e := NewEndpoint(tt.host, &port)
+		if e.Kind != tt.kind || e.Family != tt.family || e.String() != tt.str {
+			t.Errorf("NewEndpoint(%q) = %+v (%s), want kind %q, family %q, %s", tt.host, e, e, tt.kind, tt.family, tt.str)
+		}
+	}
+
+	socket := NewPathEndpoint("/var/run/docker.sock")
+	if socket.Kind != EndpointKindPath || socket.Family != FamilyUnix || socket.String() != "/var/run/docker.sock" {
+		t.Errorf("Unexpected path endpoint %+v", socket)
+	}
+	ranged := &Endpoint{PortRange: &PortRange{Start: 8000, End: 8010}}
+	if got := ranged.String(); got != ":8000-8010" {
+		t.Errorf("Expected :8000-8010, got %s", got)
+	}
+	var missing *Endpoint
+	if missing.String() != "" || missing.IsIP() {
+		t.Error("Expected a nil endpoint to be empty")
+	}
+}
+
+func TestSocketInfo_Accessors(t *testing.T) {
+	tests := []struct {
+		name   string
+		socket SocketInfo
+		host   string
+		port   int
+		ok     bool
+	}{
+		{"listener", NewListener(ProtocolHTTP, "0.0.0.0", 8080), "0.0.0.0", 8080, true},
+		{"connection", NewConnection(ProtocolTCP, "db.internal", 5432), "db.internal", 5432, true},
+		{"unix", NewUnixConnection(ProtocolUnix, "/run/docker.sock"), "/run/docker.sock", 0, false},
+		{"unresolved", SocketInfo{Type: TrafficTypeEgress, RawValue: "cfg.URL"}, "", 0, false},
+		{"range", SocketInfo{Type: TrafficTypeIngress, Listen: &Endpoint{Host: "0.0.0.0", PortRange: &PortRange{Start: 8000, End: 8010}}}, "0.0.0.0", 0, false},
+		{"untyped", SocketInfo{Destination: NewEndpoint("api.example.com", intPtr(443))}, "api.example.com", 443, true},
+	}
+	for _, tt := range tests {
+		port, ok := tt.socket.Port()
+		if host := tt.socket.Host(); host != tt.host || port != tt.port || ok != tt.ok {
+			t.Errorf("%s: got %q, %d, %t; want %q, %d, %t", tt.name, host, port, ok, tt.host, tt.port, tt.ok)
+		}
+	}
+
+	listener := NewListener(ProtocolHTTP, "0.0.0.0", 8080)
+	if listener.Endpoint() != listener.Listen || !listener.IsResolved || listener.EndpointName() != "0.0.0.0:8080" {
+		t.Errorf("Expected a resolved listener on 0.0.0.0:8080, got %+v", listener)
+	}
+	if connection := NewConnection(ProtocolTCP, "::1", 6379); connection.Destination.Family != FamilyIPv6 || connection.EndpointName() != "[::1]:6379" {
+		t.Errorf("Expected an IPv6 destination [::1]:6379, got %+v", connection.Destination)
+	}
+	if connection := NewConnection(ProtocolTCP, "db.internal", 5432); connection.DestinationAddress() != "db.internal:5432" {
+		t.Errorf("Expected destination db.internal:5432, got %q", connection.DestinationAddress())
+	}
+	if listener.DestinationAddress() != "" {
+		t.Errorf("Expected no destination on a listener, got %q", listener.DestinationAddress())
+	}
+	if socket := NewUnixListener(ProtocolHTTP, "/run/app.sock"); socket.Listen.Kind != EndpointKindPath || socket.Listen.Port != nil {
+		t.Errorf("Expected a portless unix listener, got %+v", socket.Listen)
+	}
+	// a path given to NewConnection is a host name, not a socket
+	if connection := NewConnection(ProtocolTCP, "/run/docker.sock", 80); connection.Destination.Path != "" {
+		t.Errorf("Expected NewConnection to leave unix sockets to NewUnixConnection, got %+v", connection.Destination)
+	}
+}
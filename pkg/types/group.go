@@ -0,0 +1,143 @@
+package types
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EndpointGroup is one listener or destination with every source location
+// that references it.
+type EndpointGroup struct {
+	Type        TrafficType  `json:"type" yaml:"type"`
+	Protocol    Protocol     `json:"protocol" yaml:"protocol"`
+	Endpoint    string       `json:"endpoint" yaml:"endpoint"`
+	Occurrences []Occurrence `json:"occurrences" yaml:"occurrences"`
+	// Count is the number of findings referencing the endpoint, including
+	// those folded into a collapsed unresolved finding
+	Count int `json:"count" yaml:"count"`
+	// Whether every connection to a TLS destination verifies its
+	// certificate: false if any does not, unset if any is unknown
+	VerifiesTLS *bool `json:"verifies_tls,omitempty" yaml:"verifies_tls,omitempty"`
+}
+
+// Occurrence is a source location referencing an endpoint.
+type Occurrence struct {
+	ProcessName  string `json:"process_name" yaml:"process_name"`
+	SourceFile   string `json:"source_file" yaml:"source_file"`
+	SourceLine   int    `json:"source_line" yaml:"source_line"`
+	PatternMatch string `json:"pattern_match" yaml:"pattern_match"`
+}
+
+// EndpointName renders where a socket listens or connects, falling back to
+// the raw expression for unresolved values.
+func (s SocketInfo) EndpointName() string {
+	switch {
+	case s.Listen != nil && (s.Listen.Port != nil || s.Listen.PortRange != nil):
+		return s.Listen.String()
+	case s.Destination != nil && (s.Destination.Host != "" || s.Destination.Path != ""):
+		return s.Destination.String()
+	case s.RawValue != "":
+		return s.RawValue
+	}
+	return "unresolved"
+}
+
+// OccurrenceCount returns how many findings the socket stands for: the
+// folded count of a collapsed unresolved finding, or one.
+func (s SocketInfo) OccurrenceCount() int {
+	if s.Occurrences == 0 {
+		return 1
+	}
+	return s.Occurrences
+}
+
+// GroupByEndpoint groups sockets by traffic type, protocol and endpoint.
+// Host names are compared case-insensitively. Groups are sorted by type,
+// endpoint and protocol; occurrences keep the order of sockets.
+func GroupByEndpoint(sockets []SocketInfo) []EndpointGroup {
+	type key struct {
+		trafficType TrafficType
+		protocol    Protocol
+		endpoint    string
+	}
+	index := make(map[key]int)
+	groups := []EndpointGroup{}
+	for _, socket := range sockets {
+		endpoint := strings.TrimSuffix(strings.ToLower(socket.EndpointName()), ".")
+		k := key{socket.Type, socket.Protocol, endpoint}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, EndpointGroup{Type: socket.Type, Protocol: socket.Protocol, Endpoint: endpoint, VerifiesTLS: socket.VerifiesTLS})
+		} else {
+			groups[i].VerifiesTLS = joinVerifiesTLS(groups[i].VerifiesTLS, socket.VerifiesTLS)
+		}
+		groups[i].Count += socket.OccurrenceCount()
+		groups[i].Occurrences = append(groups[i].Occurrences, Occurrence{
+			ProcessName:  socket.ProcessName,
+			SourceFile:   socket.SourceFile,
+			SourceLine:   socket.SourceLine,
+			PatternMatch: socket.PatternMatch,
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Protocol < b.Protocol
+	})
+	return groups
+}
+
+// joinVerifiesTLS combines the verification of two connections to one
+// endpoint: any connection skipping verification makes the endpoint
+// unverified, and an unknown one makes it unknown otherwise.
+func joinVerifiesTLS(a, b *bool) *bool {
+	switch {
+	case a != nil && !*a:
+		return a
+	case b != nil && !*b:
+		return b
+	case a == nil || b == nil:
+		return nil
+	}
+	return a
+}
+
+// exportEndpoints writes one CSV row per endpoint group, with its source
+// locations joined.
+func (r *AnalysisResults) exportEndpoints(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	if err := csvWriter.Write([]string{"Type", "Protocol", "Endpoint", "Occurrences", "Locations", "VerifiesTLS"}); err != nil {
+		return err
+	}
+	for _, group := range GroupByEndpoint(r.Sockets) {
+		locations := make([]string, len(group.Occurrences))
+		for i, occurrence := range group.Occurrences {
+			locations[i] = fmt.Sprintf("%s:%d", occurrence.SourceFile, occurrence.SourceLine)
+		}
+		record := []string{
+			string(group.Type),
+			string(group.Protocol),
+			group.Endpoint,
+			fmt.Sprintf("%d", group.Count),
+			strings.Join(locations, ";"),
+			formatBoolPtr(group.VerifiesTLS),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
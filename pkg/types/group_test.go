@@ -0,0 +1,128 @@
+package types
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func groupSockets() []SocketInfo {
+	return []SocketInfo{
+		{
+			Type:        TrafficTypeEgress,
+			Protocol:    ProtocolHTTPS,
+			SourceFile:  "client.go",
+			SourceLine:  20,
+			Destination: NewEndpoint("API.example.com", intPtr(443)),
+		},
+		{
+			Type:       TrafficTypeIngress,
+			Protocol:   ProtocolHTTP,
+			SourceFile: "main.go",
+			SourceLine: 10,
+			Listen:     NewEndpoint("0.0.0.0", intPtr(8080)),
+		},
+		{
+			Type:        TrafficTypeEgress,
+			Protocol:    ProtocolHTTPS,
+			SourceFile:  "retry.go",
+			SourceLine:  7,
+			Destination: NewEndpoint("api.example.com", intPtr(443)),
+		},
+		{
+			Type:       TrafficTypeEgress,
+			Protocol:   ProtocolTCP,
+			SourceFile: "dial.go",
+			SourceLine: 3,
+			RawValue:   "addr",
+		},
+	}
+}
+
+func TestGroupByEndpoint(t *testing.T) {
+	groups := GroupByEndpoint(groupSockets())
+
+	expected := []struct {
+		trafficType TrafficType
+		endpoint    string
+		files       []string
+	}{
+		{TrafficTypeEgress, "addr", []string{"dial.go"}},
+		{TrafficTypeEgress, "api.example.com:443", []string{"client.go", "retry.go"}},
+		{TrafficTypeIngress, "0.0.0.0:8080", []string{"main.go"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d: %+v", len(expected), len(groups), groups)
+	}
+	for i, want := range expected {
+		group := groups[i]
+		if group.Type != want.trafficType || group.Endpoint != want.endpoint {
+			t.Errorf("Group %d: expected %s %s, got %s %s", i, want.trafficType, want.endpoint, group.Type, group.Endpoint)
+		}
+		if len(group.Occurrences) != len(want.files) {
+			t.Fatalf("Group %d: expected %d occurrences, got %d", i, len(want.files), len(group.Occurrences))
+		}
+		for j, file := range want.files {
+			if group.Occurrences[j].SourceFile != file {
+				t.Errorf("Group %d occurrence %d: expected %s, got %s", i, j, file, group.Occurrences[j].SourceFile)
+			}
+		}
+	}
+}
+
+func TestAnalysisResults_ExportEndpoints(t *testing.T) {
+	results := AnalysisResults{Sockets: groupSockets()}
+	results.Sockets[3].Occurrences = 4 // collapsed from four unresolved dials
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "endpoints"); err != nil {
+		t.Fatalf("Failed to export endpoints: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse endpoints CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected header and 3 rows, got %d records", len(records))
+	}
+	row := records[2]
+	if row[2] != "api.example.com:443" || row[3] != "2" || row[4] != "client.go:20;retry.go:7" {
+		t.Errorf("Unexpected row for api.example.com: %v", row)
+	}
+	if row := records[1]; row[2] != "addr" || row[3] != "4" || row[4] != "dial.go:3" {
+		t.Errorf("Expected the collapsed addr row to count 4 occurrences, got %v", row)
+	}
+}
+
+func TestGroupByEndpoint_VerifiesTLS(t *testing.T) {
+	verified, unverified := true, false
+	tests := []struct {
+		name     string
+		values   []*bool
+		expected string
+	}{
+		{"all verified", []*bool{&verified, &verified}, "true"},
+		{"one skips verification", []*bool{&verified, &unverified, nil}, "false"},
+		{"one unknown", []*bool{&verified, nil}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sockets []SocketInfo
+			for _, value := range tt.values {
+				sockets = append(sockets, SocketInfo{
+					Type:        TrafficTypeEgress,
+					Protocol:    ProtocolHTTPS,
+					Destination: NewEndpoint("api.example.com", intPtr(443)),
+					VerifiesTLS: value,
+				})
+			}
+			groups := GroupByEndpoint(sockets)
+			if len(groups) != 1 {
+				t.Fatalf("Expected 1 group, got %d", len(groups))
+			}
+			if got := formatBoolPtr(groups[0].VerifiesTLS); got != tt.expected {
+				t.Errorf("Expected verifies_tls %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SchemaVersion identifies the layout of exported results. It changes when
+// fields are renamed or removed, not when fields are added.
+const SchemaVersion = "1"
+
+// GeneratedHeader describes the provenance lines written ahead of an export
+// that is committed into a repository.
+type GeneratedHeader struct {
+	// GeneratedBy names the tool and version, e.g. "staticsocket v1.4.0".
+	GeneratedBy string
+	// CommandLine is the invocation that produced the file.
+	CommandLine string
+}
+
+// HeaderFormats lists the export formats that can carry a generated header.
+var HeaderFormats = []string{"yaml", "csv", "threagile", "endpoints", "networkpolicy", "dot", "backstage", "squid", "envoy", "envoy-clusters", "nginx"}
+
+// WriteGeneratedHeader writes header as '#' comment lines, starting with
+// the standard "Code generated ... DO NOT EDIT." marker that editors, linters
+// and code review tools recognize. JSON has no comment syntax and is rejected.
+func WriteGeneratedHeader(writer io.Writer, format string, header GeneratedHeader) error {
+	if !containsString(HeaderFormats, strings.ToLower(format)) {
+		return fmt.Errorf("format %s cannot carry a generated header", format)
+	}
+
+	lines := []string{
+		"Code generated by staticsocket. DO NOT EDIT.",
+		"generated-by: " + header.GeneratedBy,
+		"command: " + header.CommandLine,
+		"schema-version: " + SchemaVersion,
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(writer, "# %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteGeneratedHeader(t *testing.T) {
+	header := GeneratedHeader{GeneratedBy: "staticsocket v1.0.0", CommandLine: "staticsocket -format yaml"}
+
+	var buf bytes.Buffer
+	if err := WriteGeneratedHeader(&buf, "yaml", header); err != nil {
+		t.Fatalf("WriteGeneratedHeader failed: %v", err)
+	}
+	results := &AnalysisResults{Sockets: []SocketInfo{{Type: TrafficTypeIngress, Protocol: ProtocolTCP}}, TotalCount: 1}
+	if err := results.Export(&buf, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	firstLine := strings.SplitN(buf.String(), "\n", 2)[0]
+	if !regexp.MustCompile(`^# Code generated .* DO NOT EDIT\.$`).MatchString(firstLine) {
+		t.Errorf("Expected a generated-code marker, got %q", firstLine)
+	}
+	if !strings.Contains(buf.String(), "# schema-version: "+SchemaVersion) {
+		t.Errorf("Expected schema version in header:\n%s", buf.String())
+	}
+
+	var decoded AnalysisResults
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.TotalCount != 1 {
+		t.Errorf("Expected YAML with header to remain parseable, got %v", err)
+	}
+
+	if err := WriteGeneratedHeader(&buf, "json", header); err == nil {
+		t.Error("Expected JSON to be rejected")
+	}
+}
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// heatmap summarizes where network code concentrates in a repository.
+// Truncated results are summarized by the findings they list.
+type heatmap struct {
+	Total     int              `json:"total"`
+	Truncated bool             `json:"truncated,omitempty"`
+	Packages  []heatmapPackage `json:"packages"`
+	Tree      *heatmapNode     `json:"tree"`
+}
+
+// heatmapCounts are the findings of a package or directory tree by
+// direction, resolution and severity, the SARIF level of each finding.
+type heatmapCounts struct {
+	Findings   int            `json:"findings"`
+	Ingress    int            `json:"ingress"`
+	Egress     int            `json:"egress"`
+	Unresolved int            `json:"unresolved"`
+	Severities map[string]int `json:"severities"`
+	Protocols  map[string]int `json:"protocols"`
+}
+
+// heatmapPackage is the findings of the files of one directory.
+type heatmapPackage struct {
+	Package string `json:"package"`
+	heatmapCounts
+}
+
+// heatmapNode is a directory of the treemap. Value is the number of
+// findings in the directory's own files, so a node's size is the sum of
+// its value and its children's, as treemap layouts such as d3.hierarchy
+// compute it; Counts covers the whole subtree.
+type heatmapNode struct {
+	Name     string         `json:"name"`
+	Path     string         `json:"path"`
+	Value    int            `json:"value"`
+	Counts   heatmapCounts  `json:"counts"`
+	Children []*heatmapNode `json:"children,omitempty"`
+}
+
+func newHeatmapCounts() heatmapCounts {
+	return heatmapCounts{Severities: make(map[string]int), Protocols: make(map[string]int)}
+}
+
+func (c *heatmapCounts) add(socket SocketInfo) {
+	c.Findings++
+	switch socket.Type {
+	case TrafficTypeIngress:
+		c.Ingress++
+	case TrafficTypeEgress:
+		c.Egress++
+	}
+	if !socket.IsResolved {
+		c.Unresolved++
+	}
+	c.Severities[sarifLevel(socket)]++
+	c.Protocols[string(socket.Protocol)]++
+}
+
+// heatmapDir returns the slash-separated directory of a finding's file,
+// "." for findings without one.
+func heatmapDir(socket SocketInfo) string {
+	if socket.SourceFile == "" {
+		return "."
+	}
+	return filepath.ToSlash(filepath.Dir(socket.SourceFile))
+}
+
+// heatmapSegments splits a directory into the names of the treemap nodes
+// leading to it, the first of an absolute directory being "/".
+func heatmapSegments(dir string) []string {
+	var segments []string
+	if strings.HasPrefix(dir, "/") {
+		segments = append(segments, "/")
+	}
+	for _, name := range strings.Split(dir, "/") {
+		if name != "" && name != "." {
+			segments = append(segments, name)
+		}
+	}
+	return segments
+}
+
+// heatmap counts the findings per package directory, sorted by
+// descending count, and builds the directory tree they lie in.
+func (r *AnalysisResults) heatmap() heatmap {
+	root := &heatmapNode{Name: ".", Path: ".", Counts: newHeatmapCounts()}
+	nodes := map[string]*heatmapNode{".": root}
+	packages := make(map[string]*heatmapPackage)
+	for _, socket := range r.Sockets {
+		dir := heatmapDir(socket)
+		p, ok := packages[dir]
+		if !ok {
+			p = &heatmapPackage{Package: dir, heatmapCounts: newHeatmapCounts()}
+			packages[dir] = p
+		}
+		p.add(socket)
+
+		node := root
+		node.Counts.add(socket)
+		path := ""
+		for _, name := range heatmapSegments(dir) {
+			switch path {
+			case "":
+				path = name
+			case "/":
+				path += name
+			default:
+				path += "/" + name
+			}
+			child, ok := nodes[path]
+			if !ok {
+				child = &heatmapNode{Name: name, Path: path, Counts: newHeatmapCounts()}
+				nodes[path] = child
+				node.Children = append(node.Children, child)
+			}
+			node = child
+			node.Counts.add(socket)
+		}
+		node.Value++
+	}
+
+	hm := heatmap{Total: len(r.Sockets), Truncated: r.Truncated, Packages: []heatmapPackage{}, Tree: root}
+	for _, p := range packages {
+		hm.Packages = append(hm.Packages, *p)
+	}
+	sort.Slice(hm.Packages, func(i, j int) bool {
+		if hm.Packages[i].Findings != hm.Packages[j].Findings {
+			return hm.Packages[i].Findings > hm.Packages[j].Findings
+		}
+		return hm.Packages[i].Package < hm.Packages[j].Package
+	})
+	for _, node := range nodes {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+	}
+	return hm
+}
+
+// exportHeatmap writes the findings per package directory, busiest first,
+// and the same counts as a directory tree ready for a treemap, so
+// dashboards can show where network code concentrates in a repository.
+func (r *AnalysisResults) exportHeatmap(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.heatmap())
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestAnalysisResults_ExportHeatmap(t *testing.T) {
+	skip := false
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, SourceFile: "cmd/api/main.go", IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, SourceFile: "cmd/api/client.go", VerifiesTLS: &skip, IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, SourceFile: "cmd/worker/main.go"},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, SourceFile: "cmd/main.go", IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUDP, SourceFile: "main.go", IsResolved: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "heatmap"); err != nil {
+		t.Fatalf("Failed to export heatmap: %v", err)
+	}
+	var got heatmap
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse heatmap: %v\n%s", err, buf.String())
+	}
+
+	if got.Total != 5 {
+		t.Errorf("Expected 5 findings, got %d", got.Total)
+	}
+	var packages []string
+	for _, p := range got.Packages {
+		packages = append(packages, p.Package)
+	}
+	if want := []string{"cmd/api", ".", "cmd", "cmd/worker"}; !slices.Equal(packages, want) {
+		t.Errorf("Expected packages %v, got %v", want, packages)
+	}
+	api := got.Packages[0]
+	if api.Findings != 2 || api.Ingress != 1 || api.Egress != 1 || api.Severities["warning"] != 1 || api.Severities["note"] != 1 || api.Protocols["https"] != 1 {
+		t.Errorf("Unexpected counts for cmd/api: %+v", api)
+	}
+
+	root := got.Tree
+	if root.Name != "." || root.Value != 1 || root.Counts.Findings != 5 || root.Counts.Unresolved != 1 || len(root.Children) != 1 {
+		t.Fatalf("Unexpected root: %+v", root)
+	}
+	cmd := root.Children[0]
+	if cmd.Path != "cmd" || cmd.Value != 1 || cmd.Counts.Findings != 4 || cmd.Counts.Severities["warning"] != 2 || len(cmd.Children) != 2 {
+		t.Fatalf("Unexpected cmd node: %+v", cmd)
+	}
+	if worker := cmd.Children[1]; worker.Name != "worker" || worker.Path != "cmd/worker" || worker.Value != 1 || worker.Children != nil {
+		t.Errorf("Unexpected cmd/worker node: %+v", worker)
+	}
+}
+
+func TestHeatmapSegments(t *testing.T) {
+	tests := map[string][]string{
+		".":       nil,
+		"cmd/api": {"cmd", "api"},
+		"/src/a":  {"/", "src", "a"},
+	}
+	for dir, want := range tests {
+		if got := heatmapSegments(dir); !slices.Equal(got, want) {
+			t.Errorf("heatmapSegments(%q) = %v, want %v", dir, got, want)
+		}
+	}
+}
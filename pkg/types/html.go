@@ -0,0 +1,292 @@
+package types
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// htmlSnippetLines is the number of source lines shown on each side of a
+// finding in the HTML report.
+const htmlSnippetLines = 3
+
+type htmlReport struct {
+	Results    *AnalysisResults
+	Unresolved int
+	Packages   []htmlPackage
+	Sections   []htmlSection
+}
+
+// htmlSection is the table of the findings of one traffic type.
+type htmlSection struct {
+	Title    string
+	Findings []htmlFinding
+}
+
+// htmlPackage summarizes the findings of one package directory.
+type htmlPackage struct {
+	Dir        string
+	Process    string
+	Ingress    int
+	Egress     int
+	Unresolved int
+	Protocols  string
+}
+
+type htmlFinding struct {
+	Protocol Protocol
+	Endpoint string
+	Process  string
+	Function string
+	Location string
+	Resolved bool
+	RawValue string
+	Tags     string
+	Notes    []string
+	Snippet  []htmlLine
+}
+
+type htmlLine struct {
+	Number  int
+	Text    string
+	Finding bool
+}
+
+// exportHTML writes a standalone report page for reviewers who do not
+// read JSON: a summary per package directory, and tables of ingress and
+// egress findings that sort by any column and filter by text, protocol and
+// resolution, each finding with the source lines around it. Source files
+// are read from the paths in the results; findings whose file cannot be
+// read are shown without source.
+func (r *AnalysisResults) exportHTML(writer io.Writer) error {
+	report := htmlReport{Results: r}
+	ingress, egress := htmlSection{Title: "Ingress"}, htmlSection{Title: "Egress"}
+	packages := make(map[string]*htmlPackage)
+	protocols := make(map[string]map[string]bool)
+	sources := make(map[string][]string)
+	for _, socket := range r.Sockets {
+		dir := "."
+		if socket.SourceFile != "" {
+			dir = filepath.ToSlash(filepath.Dir(socket.SourceFile))
+		}
+		p, ok := packages[dir]
+		if !ok {
+			p = &htmlPackage{Dir: dir, Process: socket.ProcessName}
+			packages[dir] = p
+			protocols[dir] = make(map[string]bool)
+		}
+		protocols[dir][string(socket.Protocol)] = true
+		if !socket.IsResolved {
+			p.Unresolved++
+			report.Unresolved++
+		}
+
+		finding := htmlFinding{
+			Protocol: socket.Protocol,
+			Endpoint: socket.EndpointName(),
+			Process:  socket.ProcessName,
+			Function: socket.FunctionName,
+			Location: htmlLocation(socket),
+			Resolved: socket.IsResolved,
+			RawValue: socket.RawValue,
+			Tags:     strings.Join(socket.Tags, ", "),
+			Notes:    socket.Notes,
+		}
+		if socket.SourceFile != "" {
+			lines, ok := sources[socket.SourceFile]
+			if !ok {
+				if src, err := os.ReadFile(socket.SourceFile); err == nil {
+					lines = strings.Split(string(src), "\n")
+				}
+				sources[socket.SourceFile] = lines
+			}
+			finding.Snippet = htmlSnippet(lines, socket.SourceLine, socket.EndLine)
+		}
+		// The captured code, when the source cannot be read
+		if finding.Snippet == nil && socket.CodeSnippet != "" {
+			for i, text := range strings.Split(socket.CodeSnippet, "\n") {
+				finding.Snippet = append(finding.Snippet, htmlLine{Number: socket.SourceLine + i, Text: text, Finding: true})
+			}
+		}
+
+		switch socket.Type {
+		case TrafficTypeIngress:
+			p.Ingress++
+			ingress.Findings = append(ingress.Findings, finding)
+		case TrafficTypeEgress:
+			p.Egress++
+			egress.Findings = append(egress.Findings, finding)
+		}
+	}
+
+	report.Sections = []htmlSection{ingress, egress}
+	for dir, p := range packages {
+		p.Protocols = strings.Join(sortedKeys(protocols[dir]), ", ")
+		report.Packages = append(report.Packages, *p)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool { return report.Packages[i].Dir < report.Packages[j].Dir })
+
+	// html/template drops comments, so the doctype is written apart
+	if _, err := io.WriteString(writer, "<!DOCTYPE html>\n"); err != nil {
+		return err
+	}
+	return htmlReportTemplate.Execute(writer, report)
+}
+
+// htmlLocation returns where socket was found as file:line, or
+// file:line:column when the column is known.
+func htmlLocation(socket SocketInfo) string {
+	location := socket.SourceFile + ":" + strconv.Itoa(socket.SourceLine)
+	if socket.SourceColumn > 0 {
+		location += ":" + strconv.Itoa(socket.SourceColumn)
+	}
+	return location
+}
+
+// htmlSnippet returns the lines around line through endLine (1-based) of a
+// file split into lines, marking those of the finding. An endLine before
+// line marks line alone.
+func htmlSnippet(lines []string, line, endLine int) []htmlLine {
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+	endLine = min(max(endLine, line), len(lines))
+	start := max(line-htmlSnippetLines, 1)
+	end := min(endLine+htmlSnippetLines, len(lines))
+	snippet := make([]htmlLine, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		snippet = append(snippet, htmlLine{Number: n, Text: lines[n-1], Finding: n >= line && n <= endLine})
+	}
+	return snippet
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<html>
+<head>
+<meta charset="utf-8">
+<title>staticsocket report{{with .Results.ProcessName}}: {{.}}{{end}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eef2f7; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25b2"; }
+th.desc::after { content: " \25bc"; }
+tr.unresolved td.endpoint { color: #b35900; }
+.filters { margin: 0.5em 0; }
+.filters input, .filters select { margin-right: 1em; }
+pre { margin: 0.3em 0 0; background: #f6f8fa; padding: 0.3em; overflow-x: auto; }
+pre span.finding { background: #fff3b0; display: block; }
+.warning { background: #fdecea; border: 1px solid #f5c2c0; padding: 0.5em; }
+</style>
+</head>
+<body>
+<h1>staticsocket report</h1>
+<p>{{.Results.TotalCount}} findings: {{.Results.IngressCount}} ingress, {{.Results.EgressCount}} egress, {{.Unresolved}} unresolved
+{{- with .Results.UnknownProtocolCount}}, {{.}} of unknown protocol{{end}}.
+{{- with .Results.VCS}} Commit <code>{{.Commit}}</code>{{if .Dirty}} with local changes{{end}}.{{end}}
+{{- with .Results.Scan}}{{if .ToolVersion}} Generated by staticsocket {{.ToolVersion}}.{{end}}{{end}}</p>
+{{- if .Results.Truncated}}
+<p class="warning">Only the first {{.Results.Truncation.MaxFindings}} findings are listed; {{.Results.Truncation.Omitted}} are left out.</p>
+{{- end}}
+
+<h2>Packages</h2>
+<table class="sortable">
+<thead><tr><th>Directory</th><th>Process</th><th>Ingress</th><th>Egress</th><th>Unresolved</th><th>Protocols</th></tr></thead>
+<tbody>
+{{- range .Packages}}
+<tr><td><code>{{.Dir}}</code></td><td>{{.Process}}</td><td>{{.Ingress}}</td><td>{{.Egress}}</td><td>{{.Unresolved}}</td><td>{{.Protocols}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- range .Sections}}
+{{template "findings" .}}
+{{- end}}
+{{- with .Results.Errors}}
+<h2>Files not analyzed</h2>
+<ul>
+{{- range .}}
+<li><code>{{.File}}</code>: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("th").forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var ascending = !th.classList.contains("asc");
+      table.querySelectorAll("th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+      th.classList.add(ascending ? "asc" : "desc");
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = a.cells[column].dataset.sort || a.cells[column].textContent;
+        var y = b.cells[column].dataset.sort || b.cells[column].textContent;
+        var order = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y);
+        return ascending ? order : -order;
+      });
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+document.querySelectorAll("div.filters").forEach(function (filters) {
+  var table = document.getElementById(filters.dataset.table);
+  var text = filters.querySelector("input");
+  var protocol = filters.querySelector("select.protocol");
+  var resolution = filters.querySelector("select.resolution");
+  var protocols = {};
+  Array.prototype.forEach.call(table.tBodies[0].rows, function (row) { protocols[row.dataset.protocol] = true; });
+  Object.keys(protocols).sort().forEach(function (name) {
+    var option = document.createElement("option");
+    option.value = option.textContent = name;
+    protocol.appendChild(option);
+  });
+  function apply() {
+    var needle = text.value.toLowerCase();
+    Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+      var shown = row.textContent.toLowerCase().indexOf(needle) >= 0 &&
+        (protocol.value === "" || row.dataset.protocol === protocol.value) &&
+        (resolution.value === "" || row.dataset.resolved === resolution.value);
+      row.style.display = shown ? "" : "none";
+    });
+  }
+  [text, protocol, resolution].forEach(function (control) { control.addEventListener("input", apply); });
+});
+</script>
+</body>
+</html>
+{{define "findings"}}
+<h2>{{.Title}}</h2>
+{{- if .Findings}}
+<div class="filters" data-table="{{.Title}}">
+<input type="search" placeholder="Filter" aria-label="Filter {{.Title}} findings">
+<select class="protocol" aria-label="Protocol"><option value="">All protocols</option></select>
+<select class="resolution" aria-label="Resolution"><option value="">Resolved and unresolved</option><option value="true">Resolved</option><option value="false">Unresolved</option></select>
+</div>
+<table class="sortable" id="{{.Title}}">
+<thead><tr><th>Protocol</th><th>Endpoint</th><th>Process</th><th>Function</th><th>Location</th><th>Tags</th><th>Source</th></tr></thead>
+<tbody>
+{{- range .Findings}}
+<tr data-protocol="{{.Protocol}}" data-resolved="{{.Resolved}}"{{if not .Resolved}} class="unresolved"{{end}}>
+<td>{{.Protocol}}</td>
+<td class="endpoint"><code>{{.Endpoint}}</code>{{if and (not .Resolved) (ne .Endpoint "unresolved")}} (unresolved){{end}}</td>
+<td>{{.Process}}</td>
+<td><code>{{.Function}}</code></td>
+<td><code>{{.Location}}</code></td>
+<td>{{.Tags}}</td>
+<td>
+{{- range .Notes}}<p>{{.}}</p>{{end}}
+{{- if .Snippet}}<details><summary>Source</summary><pre>{{range .Snippet}}<span{{if .Finding}} class="finding"{{end}}>{{printf "%4d" .Number}}  {{.Text}}</span>{{if not .Finding}}{{"\n"}}{{end}}{{end}}</pre></details>{{else if .RawValue}}<code>{{.RawValue}}</code>{{end}}
+</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- else}}
+<p>None.</p>
+{{- end}}
+{{end}}
+`))
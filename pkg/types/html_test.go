@@ -0,0 +1,99 @@
+package types
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalysisResults_ExportHTML(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "api", "main.go")
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+	code := "package main\n\nfunc main() {\n\tnet.Listen(\"tcp\", \":8080\")\n}\n"
+	if err := os.WriteFile(source, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "api", SourceFile: source, SourceLine: 4, FunctionName: "main", Listen: NewEndpoint("0.0.0.0", intPtr(8080)), IsResolved: true},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", SourceFile: source, SourceLine: 9, RawValue: `cfg["<url>"]`},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "worker", SourceFile: filepath.Join(dir, "worker", "missing.go"), SourceLine: 1, Destination: NewEndpoint("db", intPtr(5432)), IsResolved: true, Tags: []string{"database"}},
+		},
+		TotalCount: 3, IngressCount: 1, EgressCount: 2,
+		Truncated:  true,
+		Truncation: &TruncationSummary{MaxFindings: 3, Omitted: 2},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "html"); err != nil {
+		t.Fatalf("Failed to export HTML: %v", err)
+	}
+	got := buf.String()
+	want := []string{
+		"<!DOCTYPE html>\n<html>",
+		"3 findings: 1 ingress, 2 egress, 1 unresolved.",
+		"Only the first 3 findings are listed; 2 are left out.",
+		"<td>api</td><td>1</td><td>1</td><td>1</td><td>http, tcp</td>",
+		"<td>worker</td><td>0</td><td>1</td><td>0</td><td>tcp</td>",
+		`<tr data-protocol="tcp" data-resolved="true">`,
+		`<span class="finding">   4  	net.Listen(&#34;tcp&#34;, &#34;:8080&#34;)</span>`,
+		`<code>cfg[&#34;&lt;url&gt;&#34;]</code> (unresolved)`,
+		`<code>db:5432</code>`,
+		`<table class="sortable" id="Egress">`,
+	}
+	for _, s := range want {
+		if !strings.Contains(got, s) {
+			t.Errorf("Expected %s in:\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "<url>") {
+		t.Errorf("Expected source expressions escaped:\n%s", got)
+	}
+	if strings.Count(got, "<details>") != 1 {
+		t.Errorf("Expected a snippet only for the finding whose line exists:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := (&AnalysisResults{}).Export(&buf, "html"); err != nil {
+		t.Fatalf("Failed to export empty HTML: %v", err)
+	}
+	if strings.Count(buf.String(), "<p>None.</p>") != 2 {
+		t.Errorf("Expected empty tables reported as none:\n%s", buf.String())
+	}
+}
+
+func TestAnalysisResults_ExportHTMLExtent(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	code := "package main\n\nfunc main() {\n\tnet.Listen(\n\t\t\"tcp\",\n\t\t\":8080\")\n}\n"
+	if err := os.WriteFile(source, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := AnalysisResults{Sockets: []SocketInfo{
+		{Type: TrafficTypeIngress, Protocol: ProtocolTCP, SourceFile: source, SourceLine: 4, SourceColumn: 2, EndLine: 6, EndColumn: 11, IsResolved: true},
+		{Type: TrafficTypeEgress, Protocol: ProtocolTCP, SourceFile: filepath.Join(dir, "missing.go"), SourceLine: 7, CodeSnippet: "net.Dial(\"tcp\", addr)"},
+	}}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "html"); err != nil {
+		t.Fatalf("Failed to export HTML: %v", err)
+	}
+	got := buf.String()
+	want := []string{
+		"main.go:4:2",
+		`<span class="finding">   4  	net.Listen(</span><span class="finding">   5  		&#34;tcp&#34;,</span><span class="finding">   6  		&#34;:8080&#34;)</span>`,
+		`<span>   7  }</span>`,
+		`<span class="finding">   7  net.Dial(&#34;tcp&#34;, addr)</span>`,
+	}
+	for _, s := range want {
+		if !strings.Contains(got, s) {
+			t.Errorf("Expected %s in:\n%s", s, got)
+		}
+	}
+}
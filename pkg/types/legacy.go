@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyEndpoints holds the flat fields SocketInfo had before Listen and
+// Destination. JSON and YAML output still carries them, and input falls
+// back to them when the nested endpoints are missing, so tools written
+// against older results keep working. CSV columns keep these names too.
+type legacyEndpoints struct {
+	ListenPort      *int    `json:"listen_port,omitempty" yaml:"listen_port,omitempty"`
+	ListenInterface string  `json:"listen_interface,omitempty" yaml:"listen_interface,omitempty"`
+	DestinationHost *string `json:"destination_host,omitempty" yaml:"destination_host,omitempty"`
+	DestinationPort *int    `json:"destination_port,omitempty" yaml:"destination_port,omitempty"`
+	DestinationIsIP bool    `json:"destination_is_ip,omitempty" yaml:"destination_is_ip,omitempty"`
+}
+
+// socketFields is SocketInfo without its marshaling methods.
+type socketFields SocketInfo
+
+type socketWire struct {
+	socketFields    `yaml:",inline"`
+	legacyEndpoints `yaml:",inline"`
+}
+
+func (s SocketInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(socketWire{socketFields(s), s.legacy()})
+}
+
+func (s *SocketInfo) UnmarshalJSON(data []byte) error {
+	var wire socketWire
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*s = SocketInfo(wire.socketFields)
+	s.applyLegacy(wire.legacyEndpoints)
+	return nil
+}
+
+func (s SocketInfo) MarshalYAML() (interface{}, error) {
+	return socketWire{socketFields(s), s.legacy()}, nil
+}
+
+func (s *SocketInfo) UnmarshalYAML(value *yaml.Node) error {
+	var wire socketWire
+	if err := value.Decode(&wire); err != nil {
+		return err
+	}
+	*s = SocketInfo(wire.socketFields)
+	s.applyLegacy(wire.legacyEndpoints)
+	return nil
+}
+
+// legacy flattens the endpoints. Socket paths go where older versions
+// put them: in the interface or destination host.
+func (s SocketInfo) legacy() legacyEndpoints {
+	l := legacyEndpoints{
+		ListenPort:      s.Listen.port(),
+		ListenInterface: s.Listen.address(),
+		DestinationPort: s.Destination.port(),
+		DestinationIsIP: s.Destination.IsIP(),
+	}
+	if host := s.Destination.address(); host != "" {
+		l.DestinationHost = &host
+	}
+	return l
+}
+
+func (s *SocketInfo) applyLegacy(l legacyEndpoints) {
+	if s.Listen == nil && (l.ListenPort != nil || l.ListenInterface != "") {
+		if strings.HasPrefix(l.ListenInterface, "/") {
+			s.Listen = NewPathEndpoint(l.ListenInterface)
+		} else {
+			s.Listen = NewEndpoint(l.ListenInterface, l.ListenPort)
+		}
+	}
+	if s.Destination == nil && (l.DestinationHost != nil || l.DestinationPort != nil) {
+		if host := formatStringPtr(l.DestinationHost); strings.HasPrefix(host, "/") {
+			s.Destination = NewPathEndpoint(host)
+		} else {
+			s.Destination = NewEndpoint(host, l.DestinationPort)
+		}
+	}
+}
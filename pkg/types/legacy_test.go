@@ -0,0 +1,81 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSocketInfo_LegacyFieldsWritten(t *testing.T) {
+	socket := SocketInfo{
+		Type:        TrafficTypeEgress,
+		Destination: NewEndpoint("10.0.0.5", intPtr(5432)),
+	}
+
+	data, err := json.Marshal(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"destination":{"host":"10.0.0.5","port":5432,"family":"ipv4","kind":"ip"}`,
+		`"destination_host":"10.0.0.5"`, `"destination_port":5432`, `"destination_is_ip":true`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected JSON to contain %s, got %s", want, data)
+		}
+	}
+
+	out, err := yaml.Marshal(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"destination:\n    host: 10.0.0.5", "destination_host: 10.0.0.5", "destination_port: 5432"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected YAML to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSocketInfo_LegacyFieldsRead(t *testing.T) {
+	legacy := `{"type":"ingress","listen_port":8080,"listen_interface":"0.0.0.0"}
+{"type":"egress","destination_host":"db.internal","destination_port":5432}
+{"type":"egress","destination_host":"/var/run/docker.sock"}`
+
+	var sockets []SocketInfo
+	for _, line := range strings.Split(legacy, "\n") {
+		var socket SocketInfo
+		if err := json.Unmarshal([]byte(line), &socket); err != nil {
+			t.Fatal(err)
+		}
+		sockets = append(sockets, socket)
+	}
+
+	if l := sockets[0].Listen; l == nil || l.Host != "0.0.0.0" || *l.Port != 8080 || l.Kind != EndpointKindIP {
+		t.Errorf("Unexpected listen endpoint %+v", l)
+	}
+	if d := sockets[1].Destination; d == nil || d.Host != "db.internal" || *d.Port != 5432 || d.Kind != EndpointKindHostname {
+		t.Errorf("Unexpected destination %+v", d)
+	}
+	if d := sockets[2].Destination; d == nil || d.Path != "/var/run/docker.sock" || d.Kind != EndpointKindPath {
+		t.Errorf("Unexpected socket destination %+v", d)
+	}
+
+	var fromYAML SocketInfo
+	if err := yaml.Unmarshal([]byte("type: egress\ndestination_host: db.internal\ndestination_port: 5432\n"), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if d := fromYAML.Destination; d == nil || d.String() != "db.internal:5432" {
+		t.Errorf("Unexpected destination from YAML %+v", d)
+	}
+}
+
+func TestSocketInfo_NestedFieldsWin(t *testing.T) {
+	var socket SocketInfo
+	data := `{"listen":{"host":"127.0.0.1","port":9090},"listen_port":8080}`
+	if err := json.Unmarshal([]byte(data), &socket); err != nil {
+		t.Fatal(err)
+	}
+	if *socket.Listen.Port != 9090 {
+		t.Errorf("Expected the nested endpoint to take precedence, got %+v", socket.Listen)
+	}
+}
@@ -0,0 +1,217 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IDs of the human-facing messages findings carry, in notes and in the
+// result text of SARIF output. Each is rendered from a text/template of
+// the active message catalog, with the parameters listed by
+// DefaultMessages.
+const (
+	MsgTLSVerificationDisabled = "tls-verification-disabled"
+	MsgHTTPServerDefaultAddr   = "http-server-default-addr"
+	MsgClientDefaultEndpoint   = "client-default-endpoint"
+	MsgManagerDefaultMetrics   = "manager-default-metrics"
+	MsgRedisSentinelSeed       = "redis-sentinel-seed"
+	MsgRedisClusterSeed        = "redis-cluster-seed"
+	MsgUnknownListenScheme     = "unknown-listen-scheme"
+	MsgLocalMayAlsoBe          = "local-may-also-be"
+	MsgIngressSummary          = "ingress-summary"
+	MsgEgressSummary           = "egress-summary"
+	MsgAddressUnresolved       = "address-unresolved"
+	MsgSkipsTLSVerification    = "skips-tls-verification"
+	MsgProtocolUnknown         = "protocol-unknown"
+	MsgNoShutdown              = "no-shutdown"
+	MsgDeclared                = "declared"
+	MsgInvalidDeclaration      = "invalid-declaration"
+)
+
+// messageDef is a built-in message: its English template and the
+// parameters it is rendered with.
+type messageDef struct {
+	text   string
+	params []string
+}
+
+var defaultMessages = map[string]messageDef{
+	MsgTLSVerificationDisabled: {text: "TLS certificate verification is disabled (InsecureSkipVerify)"},
+	MsgHTTPServerDefaultAddr:   {text: "Addr is empty; net/http listens on {{.Address}}", params: []string{"Address"}},
+	MsgClientDefaultEndpoint:   {text: "no option sets an endpoint; the client connects to {{.Endpoint}}", params: []string{"Endpoint"}},
+	MsgManagerDefaultMetrics:   {text: "no option sets a metrics bind address; the controller-runtime default {{.Address}} is assumed", params: []string{"Address"}},
+	MsgRedisSentinelSeed: {
+		text:   "sentinel seed {{.Seed}} of {{.Seeds}} for master {{.Master}}; the master and replicas it reports are dialed at runtime",
+		params: []string{"Seed", "Seeds", "Master"},
+	},
+	MsgRedisClusterSeed: {
+		text:   "cluster seed {{.Seed}} of {{.Seeds}}; every node the seeds report is dialed at runtime",
+		params: []string{"Seed", "Seeds"},
+	},
+	MsgNoShutdown:           {text: "no Close, Shutdown, GracefulStop or Stop of this listener, or of the server it is handed to, was found; it is only released when the process exits"},
+	MsgUnknownListenScheme:  {text: "unknown listen scheme {{.Scheme}}", params: []string{"Scheme"}},
+	MsgLocalMayAlsoBe:       {text: "{{.Name}} may also be {{.Expr}}", params: []string{"Name", "Expr"}},
+	MsgIngressSummary:       {text: "Listens for {{.Protocol}} on {{.Endpoint}}", params: []string{"Protocol", "Endpoint"}},
+	MsgEgressSummary:        {text: "Connects over {{.Protocol}} to {{.Endpoint}}", params: []string{"Protocol", "Endpoint"}},
+	MsgAddressUnresolved:    {text: "{{.Summary}} (address not resolved statically)", params: []string{"Summary"}},
+	MsgSkipsTLSVerification: {text: "{{.Summary}} without verifying TLS certificates", params: []string{"Summary"}},
+	MsgProtocolUnknown:      {text: "{{.Summary}} (network not known statically)", params: []string{"Summary"}},
+	MsgDeclared:             {text: "declared in a staticsocket:declare comment{{if .Reason}}: {{.Reason}}{{end}}", params: []string{"Reason"}},
+	MsgInvalidDeclaration:   {text: "invalid staticsocket:declare comment: {{.Error}}", params: []string{"Error"}},
+}
+
+// catalogEntry is a message of the active catalog: its template source
+// and the parsed template.
+type catalogEntry struct {
+	text string
+	tmpl *template.Template
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = mustParseCatalog(DefaultMessages())
+)
+
+// DefaultMessages returns the built-in English catalog: the template of
+// every message by ID.
+func DefaultMessages() map[string]string {
+	messages := make(map[string]string, len(defaultMessages))
+	for id, def := range defaultMessages {
+		messages[id] = def.text
+	}
+	return messages
+}
+
+// MessageParams returns the parameters the message id is rendered with,
+// for writing its template.
+func MessageParams(id string) []string {
+	return defaultMessages[id].params
+}
+
+// SetMessageCatalog replaces the templates of the messages in messages,
+// to rephrase or translate them; the others keep their current text. It
+// fails, changing nothing, on unknown IDs and on templates that do not
+// parse or use parameters the message does not have. Set the catalog
+// before analyzing: notes are rendered as findings are made.
+func SetMessageCatalog(messages map[string]string) error {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	merged := make(map[string]catalogEntry, len(catalog))
+	for id, entry := range catalog {
+		merged[id] = entry
+	}
+	ids := make([]string, 0, len(messages))
+	for id := range messages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		tmpl, err := parseMessage(id, messages[id])
+		if err != nil {
+			return err
+		}
+		merged[id] = catalogEntry{text: messages[id], tmpl: tmpl}
+	}
+	catalog = merged
+	return nil
+}
+
+// ResetMessageCatalog restores the built-in messages.
+func ResetMessageCatalog() {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	catalog = mustParseCatalog(DefaultMessages())
+}
+
+// ReadMessageCatalog decodes a message catalog file, in YAML or JSON:
+//
+//	messages:
+//	  tls-verification-disabled: TLS-Zertifikatsprüfung ist deaktiviert (InsecureSkipVerify)
+//	  http-server-default-addr: Addr ist leer; net/http lauscht auf {{.Address}}
+func ReadMessageCatalog(reader io.Reader) (map[string]string, error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	var file struct {
+		Messages map[string]string `yaml:"messages"`
+	}
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return file.Messages, nil
+}
+
+// WriteMessageCatalog writes the active templates in the layout
+// ReadMessageCatalog reads, as a starting point for a translation.
+func WriteMessageCatalog(writer io.Writer) error {
+	catalogMu.RLock()
+	messages := make(map[string]string, len(catalog))
+	for id, entry := range catalog {
+		messages[id] = entry.text
+	}
+	catalogMu.RUnlock()
+
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	return encoder.Encode(map[string]map[string]string{"messages": messages})
+}
+
+// Message renders the message id of the active catalog with params,
+// given as name and value pairs: Message(MsgUnknownListenScheme, "Scheme",
+// scheme).
+func Message(id string, params ...any) string {
+	catalogMu.RLock()
+	entry, ok := catalog[id]
+	catalogMu.RUnlock()
+	if !ok {
+		return id
+	}
+
+	data := make(map[string]any, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		data[fmt.Sprint(params[i])] = params[i+1]
+	}
+	var b strings.Builder
+	if err := entry.tmpl.Execute(&b, data); err != nil {
+		return id
+	}
+	return b.String()
+}
+
+func parseMessage(id, text string) (*template.Template, error) {
+	def, ok := defaultMessages[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown message %q", id)
+	}
+	tmpl, err := template.New(id).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("message %s: %w", id, err)
+	}
+	sample := make(map[string]any, len(def.params))
+	for _, param := range def.params {
+		sample[param] = param
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("message %s: %w; parameters are %s", id, err, strings.Join(def.params, ", "))
+	}
+	return tmpl, nil
+}
+
+func mustParseCatalog(messages map[string]string) map[string]catalogEntry {
+	parsed := make(map[string]catalogEntry, len(messages))
+	for id, text := range messages {
+		tmpl, err := parseMessage(id, text)
+		if err != nil {
+			panic(err)
+		}
+		parsed[id] = catalogEntry{text: text, tmpl: tmpl}
+	}
+	return parsed
+}
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMessage_Defaults(t *testing.T) {
+	if got := Message(MsgHTTPServerDefaultAddr, "Address", ":http"); got != "Addr is empty; net/http listens on :http" {
+		t.Errorf("Unexpected message: %q", got)
+	}
+	got := Message(MsgRedisSentinelSeed, "Seed", 1, "Seeds", 3, "Master", "mymaster")
+	if got != "sentinel seed 1 of 3 for master mymaster; the master and replicas it reports are dialed at runtime" {
+		t.Errorf("Unexpected message: %q", got)
+	}
+	if got := Message("no-such-message"); got != "no-such-message" {
+		t.Errorf("Expected an unknown message to render as its ID, got %q", got)
+	}
+}
+
+func TestDefaultMessages_ParamsMatchTemplates(t *testing.T) {
+	for id, text := range DefaultMessages() {
+		if _, err := parseMessage(id, text); err != nil {
+			t.Errorf("Default message %s: %v", id, err)
+		}
+		for _, param := range MessageParams(id) {
+			if !strings.Contains(text, "{{."+param+"}}") {
+				t.Errorf("Default message %s does not use its parameter %s", id, param)
+			}
+		}
+	}
+}
+
+func TestSetMessageCatalog(t *testing.T) {
+	t.Cleanup(ResetMessageCatalog)
+
+	err := SetMessageCatalog(map[string]string{
+		MsgHTTPServerDefaultAddr: "Addr ist leer; net/http lauscht auf {{.Address}}",
+	})
+	if err != nil {
+		t.Fatalf("SetMessageCatalog failed: %v", err)
+	}
+	if got := Message(MsgHTTPServerDefaultAddr, "Address", ":https"); got != "Addr ist leer; net/http lauscht auf :https" {
+		t.Errorf("Expected the translated message, got %q", got)
+	}
+	if got := Message(MsgTLSVerificationDisabled); got != "TLS certificate verification is disabled (InsecureSkipVerify)" {
+		t.Errorf("Expected messages missing from the catalog to keep their text, got %q", got)
+	}
+
+	ResetMessageCatalog()
+	if got := Message(MsgHTTPServerDefaultAddr, "Address", ":http"); got != "Addr is empty; net/http listens on :http" {
+		t.Errorf("Expected the reset catalog to restore the default, got %q", got)
+	}
+}
+
+func TestSetMessageCatalog_Invalid(t *testing.T) {
+	t.Cleanup(ResetMessageCatalog)
+
+	tests := map[string]map[string]string{
+		"unknown ID":        {"no-such-message": "text"},
+		"unparsable":        {MsgUnknownListenScheme: "{{.Scheme"},
+		"unknown parameter": {MsgUnknownListenScheme: "{{.Protocol}}"},
+		"one bad entry": {
+			MsgTLSVerificationDisabled: "TLS-Prüfung deaktiviert",
+			MsgUnknownListenScheme:     "{{.Protocol}}",
+		},
+	}
+	for name, messages := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := SetMessageCatalog(messages); err == nil {
+				t.Fatal("Expected an error")
+			}
+			if got := Message(MsgTLSVerificationDisabled); got != "TLS certificate verification is disabled (InsecureSkipVerify)" {
+				t.Errorf("Expected a rejected catalog to change nothing, got %q", got)
+			}
+		})
+	}
+}
+
+func TestReadMessageCatalog(t *testing.T) {
+	messages, err := ReadMessageCatalog(strings.NewReader(`
+messages:
+  unknown-listen-scheme: "Unbekanntes Schema {{.Scheme}}"
+`))
+	if err != nil {
+		t.Fatalf("ReadMessageCatalog failed: %v", err)
+	}
+	if messages[MsgUnknownListenScheme] != "Unbekanntes Schema {{.Scheme}}" {
+		t.Errorf("Unexpected catalog: %v", messages)
+	}
+
+	if _, err := ReadMessageCatalog(strings.NewReader(`{"mesages": {}}`)); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestWriteMessageCatalog_RoundTrip(t *testing.T) {
+	t.Cleanup(ResetMessageCatalog)
+	if err := SetMessageCatalog(map[string]string{MsgUnknownListenScheme: "Unbekanntes Schema {{.Scheme}}"}); err != nil {
+		t.Fatalf("SetMessageCatalog failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMessageCatalog(&buf); err != nil {
+		t.Fatalf("WriteMessageCatalog failed: %v", err)
+	}
+	messages, err := ReadMessageCatalog(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessageCatalog failed: %v", err)
+	}
+	if len(messages) != len(DefaultMessages()) {
+		t.Errorf("Expected every message to be written, got %d", len(messages))
+	}
+	if messages[MsgUnknownListenScheme] != "Unbekanntes Schema {{.Scheme}}" {
+		t.Errorf("Expected the active template to be written, got %q", messages[MsgUnknownListenScheme])
+	}
+}
+
+func TestSarifText_UsesCatalog(t *testing.T) {
+	t.Cleanup(ResetMessageCatalog)
+	err := SetMessageCatalog(map[string]string{
+		MsgEgressSummary:     "Verbindet über {{.Protocol}} mit {{.Endpoint}}",
+		MsgAddressUnresolved: "{{.Summary}} (Adresse nicht statisch ermittelt)",
+	})
+	if err != nil {
+		t.Fatalf("SetMessageCatalog failed: %v", err)
+	}
+
+	socket := SocketInfo{Type: TrafficTypeEgress, Protocol: ProtocolTCP, RawValue: "dbHost"}
+	if got := socket.Summary(); got != "Verbindet über tcp mit dbHost (Adresse nicht statisch ermittelt)." {
+		t.Errorf("Unexpected text: %q", got)
+	}
+}
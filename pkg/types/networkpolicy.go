@@ -0,0 +1,243 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Annotations on generated NetworkPolicies for what the policy cannot
+// express.
+const (
+	// AnnotationEgressHosts lists the host names egress is allowed to. A
+	// NetworkPolicy selects peers by IP or label, not by name, so their
+	// ports are allowed to any destination.
+	AnnotationEgressHosts = "staticsocket.io/egress-hosts"
+	// AnnotationUnresolved counts findings left out of the policy because
+	// their port or destination is not known statically.
+	AnnotationUnresolved = "staticsocket.io/unresolved-findings"
+)
+
+// networkPolicy is the subset of the networking.k8s.io/v1 NetworkPolicy
+// schema the export needs.
+type networkPolicy struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   networkPolicyMetadata `yaml:"metadata"`
+	Spec       networkPolicySpec     `yaml:"spec"`
+}
+
+type networkPolicyMetadata struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type networkPolicySpec struct {
+	PodSelector labelSelector       `yaml:"podSelector"`
+	PolicyTypes []string            `yaml:"policyTypes"`
+	Ingress     []networkPolicyRule `yaml:"ingress"`
+	Egress      []networkPolicyRule `yaml:"egress"`
+}
+
+type labelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+// networkPolicyRule is an ingress or egress rule; egress peers go in To.
+type networkPolicyRule struct {
+	To    []networkPolicyPeer `yaml:"to,omitempty"`
+	Ports []networkPolicyPort `yaml:"ports,omitempty"`
+}
+
+type networkPolicyPeer struct {
+	IPBlock *ipBlock `yaml:"ipBlock,omitempty"`
+}
+
+type ipBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+type networkPolicyPort struct {
+	Protocol string `yaml:"protocol"`
+	Port     int    `yaml:"port"`
+	EndPort  int    `yaml:"endPort,omitempty"`
+}
+
+// dnsPorts are allowed whenever egress goes to host names, which the pod
+// has to look up first.
+var dnsPorts = []networkPolicyPort{{Protocol: "UDP", Port: 53}, {Protocol: "TCP", Port: 53}}
+
+// exportNetworkPolicy writes one candidate NetworkPolicy per process,
+// selecting its pods by app.kubernetes.io/name: ingress on the ports it
+// listens on, and egress to the IPs and ports it connects to. Loopback
+// and unix socket traffic never crosses the pod network and is left out.
+func (r *AnalysisResults) exportNetworkPolicy(writer io.Writer) error {
+	type process struct {
+		ingress    map[networkPolicyPort]bool
+		egressIPs  map[string]map[networkPolicyPort]bool
+		hostPorts  map[networkPolicyPort]bool
+		hosts      map[string]bool
+		unresolved int
+	}
+	processes := make(map[string]*process)
+	for _, socket := range r.Sockets {
+		name := threagileID(socket.ProcessName)
+		p, ok := processes[name]
+		if !ok {
+			p = &process{
+				ingress:   make(map[networkPolicyPort]bool),
+				egressIPs: make(map[string]map[networkPolicyPort]bool),
+				hostPorts: make(map[networkPolicyPort]bool),
+				hosts:     make(map[string]bool),
+			}
+			processes[name] = p
+		}
+
+		endpoint := socket.Listen
+		if socket.Type == TrafficTypeEgress {
+			endpoint = socket.Destination
+		}
+		transport := strings.ToUpper(string(socket.Protocol.Transport()))
+		if transport == "UNIX" || (endpoint != nil && endpoint.Path != "") || isLoopback(endpoint) {
+			continue
+		}
+		port, ok := policyPort(transport, endpoint)
+		if !ok || socket.Protocol == ProtocolUnknown || (socket.Type == TrafficTypeEgress && endpoint.Host == "") {
+			p.unresolved++
+			continue
+		}
+
+		switch {
+		case socket.Type == TrafficTypeIngress:
+			p.ingress[port] = true
+		case endpoint.IsIP():
+			cidr := ipCIDR(endpoint.Host)
+			if p.egressIPs[cidr] == nil {
+				p.egressIPs[cidr] = make(map[networkPolicyPort]bool)
+			}
+			p.egressIPs[cidr][port] = true
+		default:
+			p.hostPorts[port] = true
+			p.hosts[endpoint.String()] = true
+		}
+	}
+
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	encoder := yaml.NewEncoder(writer)
+	defer encoder.Close()
+	encoder.SetIndent(2)
+	for _, name := range names {
+		p := processes[name]
+		policy := networkPolicy{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+			Metadata:   networkPolicyMetadata{Name: name},
+			Spec: networkPolicySpec{
+				PodSelector: labelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": name}},
+				PolicyTypes: []string{"Ingress", "Egress"},
+				Ingress:     []networkPolicyRule{},
+				Egress:      []networkPolicyRule{},
+			},
+		}
+		if len(p.ingress) > 0 {
+			policy.Spec.Ingress = append(policy.Spec.Ingress, networkPolicyRule{Ports: sortedPorts(p.ingress)})
+		}
+
+		cidrs := make([]string, 0, len(p.egressIPs))
+		for cidr := range p.egressIPs {
+			cidrs = append(cidrs, cidr)
+		}
+		sort.Strings(cidrs)
+		for _, cidr := range cidrs {
+			policy.Spec.Egress = append(policy.Spec.Egress, networkPolicyRule{
+				To:    []networkPolicyPeer{{IPBlock: &ipBlock{CIDR: cidr}}},
+				Ports: sortedPorts(p.egressIPs[cidr]),
+			})
+		}
+		if len(p.hosts) > 0 {
+			policy.Spec.Egress = append(policy.Spec.Egress,
+				networkPolicyRule{Ports: sortedPorts(p.hostPorts)},
+				networkPolicyRule{Ports: dnsPorts})
+			policy.Metadata.Annotations = map[string]string{AnnotationEgressHosts: strings.Join(sortedKeys(p.hosts), ",")}
+		}
+		if p.unresolved > 0 {
+			if policy.Metadata.Annotations == nil {
+				policy.Metadata.Annotations = make(map[string]string)
+			}
+			policy.Metadata.Annotations[AnnotationUnresolved] = strconv.Itoa(p.unresolved)
+		}
+
+		if err := encoder.Encode(policy); err != nil {
+			return fmt.Errorf("encoding network policy %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// policyPort returns the port or port range of endpoint, reporting false
+// when neither is known.
+func policyPort(transport string, endpoint *Endpoint) (networkPolicyPort, bool) {
+	switch {
+	case endpoint == nil:
+		return networkPolicyPort{}, false
+	case endpoint.Port != nil:
+		return networkPolicyPort{Protocol: transport, Port: *endpoint.Port}, true
+	case endpoint.PortRange != nil:
+		return networkPolicyPort{Protocol: transport, Port: endpoint.PortRange.Start, EndPort: endpoint.PortRange.End}, true
+	}
+	return networkPolicyPort{}, false
+}
+
+func isLoopback(endpoint *Endpoint) bool {
+	if endpoint == nil {
+		return false
+	}
+	if strings.EqualFold(endpoint.Host, "localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(endpoint.Host, "[]"))
+	return err == nil && addr.IsLoopback()
+}
+
+// ipCIDR returns the single-address CIDR of a literal IP.
+func ipCIDR(host string) string {
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return host
+	}
+	addr = addr.WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()).String()
+}
+
+func sortedPorts(set map[networkPolicyPort]bool) []networkPolicyPort {
+	ports := make([]networkPolicyPort, 0, len(set))
+	for port := range set {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].Port < ports[j].Port
+	})
+	return ports
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
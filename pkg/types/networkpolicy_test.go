@@ -0,0 +1,78 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnalysisResults_ExportNetworkPolicy(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", intPtr(8080))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolUDP, ProcessName: "api", Listen: &Endpoint{Host: "0.0.0.0", PortRange: &PortRange{Start: 9000, End: 9010}}},
+			{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "api", Listen: NewEndpoint("127.0.0.1", intPtr(6060))},
+			{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "api"},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("10.0.0.5", intPtr(5432))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, ProcessName: "api", Destination: NewEndpoint("payments.internal", intPtr(443))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolGRPC, ProcessName: "api", Destination: NewEndpoint("ledger", intPtr(50051))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUnix, ProcessName: "api", Destination: NewPathEndpoint("/var/run/docker.sock")},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", RawValue: "cfg.URL"},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "Worker", Destination: NewEndpoint("2001:db8::1", intPtr(6379))},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "networkpolicy"); err != nil {
+		t.Fatalf("Failed to export network policies: %v", err)
+	}
+
+	var policies []networkPolicy
+	decoder := yaml.NewDecoder(&buf)
+	for {
+		var policy networkPolicy
+		err := decoder.Decode(&policy)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to parse network policy: %v", err)
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) != 2 || policies[0].Metadata.Name != "api" || policies[1].Metadata.Name != "worker" {
+		t.Fatalf("Expected policies for api and worker, got %+v", policies)
+	}
+
+	api := policies[0]
+	if api.APIVersion != "networking.k8s.io/v1" || api.Kind != "NetworkPolicy" || api.Spec.PodSelector.MatchLabels["app.kubernetes.io/name"] != "api" {
+		t.Errorf("Unexpected policy header: %+v", api)
+	}
+	wantIngress := []networkPolicyRule{{Ports: []networkPolicyPort{{Protocol: "TCP", Port: 8080}, {Protocol: "UDP", Port: 9000, EndPort: 9010}}}}
+	if !reflect.DeepEqual(api.Spec.Ingress, wantIngress) {
+		t.Errorf("Expected ingress %+v, got %+v", wantIngress, api.Spec.Ingress)
+	}
+	wantEgress := []networkPolicyRule{
+		{To: []networkPolicyPeer{{IPBlock: &ipBlock{CIDR: "10.0.0.5/32"}}}, Ports: []networkPolicyPort{{Protocol: "TCP", Port: 5432}}},
+		{Ports: []networkPolicyPort{{Protocol: "TCP", Port: 443}, {Protocol: "TCP", Port: 50051}}},
+		{Ports: dnsPorts},
+	}
+	if !reflect.DeepEqual(api.Spec.Egress, wantEgress) {
+		t.Errorf("Expected egress %+v, got %+v", wantEgress, api.Spec.Egress)
+	}
+	if hosts := api.Metadata.Annotations[AnnotationEgressHosts]; hosts != "ledger:50051,payments.internal:443" {
+		t.Errorf("Expected the egress hosts annotated, got %q", hosts)
+	}
+	if unresolved := api.Metadata.Annotations[AnnotationUnresolved]; unresolved != "2" {
+		t.Errorf("Expected 2 unresolved findings annotated, got %q", unresolved)
+	}
+
+	worker := policies[1]
+	if len(worker.Spec.Ingress) != 0 || len(worker.Spec.Egress) != 1 || worker.Spec.Egress[0].To[0].IPBlock.CIDR != "2001:db8::1/128" {
+		t.Errorf("Expected worker egress to one IPv6 address only, got %+v", worker.Spec)
+	}
+}
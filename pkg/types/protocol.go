@@ -0,0 +1,121 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProtocolInfo describes a protocol known to the analyzer. Custom patterns
+// register their application protocols (amqp, kafka, redis, ...) so that
+// exporters can reason about them instead of seeing plain tcp.
+type ProtocolInfo struct {
+	Name Protocol
+	// Transport is the protocol carrying Name on the wire: tcp, udp, unix,
+	// or ip for raw IP sockets.
+	Transport Protocol
+	// Secure marks protocols that are encrypted on the wire.
+	Secure bool
+}
+
+var (
+	protocolMu       sync.RWMutex
+	protocolRegistry = map[Protocol]ProtocolInfo{
+		ProtocolTCP:       {Name: ProtocolTCP, Transport: ProtocolTCP},
+		ProtocolUDP:       {Name: ProtocolUDP, Transport: ProtocolUDP},
+		ProtocolUnix:      {Name: ProtocolUnix, Transport: ProtocolUnix},
+		ProtocolHTTP:      {Name: ProtocolHTTP, Transport: ProtocolTCP},
+		ProtocolHTTPS:     {Name: ProtocolHTTPS, Transport: ProtocolTCP, Secure: true},
+		ProtocolGRPC:      {Name: ProtocolGRPC, Transport: ProtocolTCP},
+		ProtocolIP:        {Name: ProtocolIP, Transport: ProtocolIP},
+		ProtocolICMP:      {Name: ProtocolICMP, Transport: ProtocolIP},
+		ProtocolWebSocket: {Name: ProtocolWebSocket, Transport: ProtocolTCP},
+		ProtocolWSS:       {Name: ProtocolWSS, Transport: ProtocolTCP, Secure: true},
+		// Carried by whichever transport the run-time network selects
+		ProtocolUnknown: {Name: ProtocolUnknown, Transport: ProtocolUnknown},
+	}
+)
+
+// RegisterProtocol adds a protocol to the registry. Names are lower-case
+// letters, digits, '.', '+' and '-'; the transport defaults to tcp.
+// Registering an existing name again is allowed only with identical info.
+func RegisterProtocol(info ProtocolInfo) error {
+	if !validProtocolName(string(info.Name)) {
+		return fmt.Errorf("invalid protocol name %q", info.Name)
+	}
+	if info.Transport == "" {
+		info.Transport = ProtocolTCP
+	}
+	switch info.Transport {
+	case ProtocolTCP, ProtocolUDP, ProtocolUnix, ProtocolIP:
+	default:
+		return fmt.Errorf("protocol %s: unsupported transport %q", info.Name, info.Transport)
+	}
+
+	protocolMu.Lock()
+	defer protocolMu.Unlock()
+	if existing, ok := protocolRegistry[info.Name]; ok {
+		if existing != info {
+			return fmt.Errorf("protocol %s is already registered", info.Name)
+		}
+		return nil
+	}
+	protocolRegistry[info.Name] = info
+	return nil
+}
+
+// LookupProtocol returns the registered info for p.
+func LookupProtocol(p Protocol) (ProtocolInfo, bool) {
+	protocolMu.RLock()
+	defer protocolMu.RUnlock()
+	info, ok := protocolRegistry[p]
+	return info, ok
+}
+
+// RegisteredProtocols lists all registered protocol names, sorted.
+func RegisteredProtocols() []Protocol {
+	protocolMu.RLock()
+	defer protocolMu.RUnlock()
+	protocols := make([]Protocol, 0, len(protocolRegistry))
+	for name := range protocolRegistry {
+		protocols = append(protocols, name)
+	}
+	sort.Slice(protocols, func(i, j int) bool { return protocols[i] < protocols[j] })
+	return protocols
+}
+
+// Registered reports whether p is a registered protocol. Results read
+// from files written with other pattern sets may carry unregistered ones.
+func (p Protocol) Registered() bool {
+	_, ok := LookupProtocol(p)
+	return ok
+}
+
+// Transport returns the wire transport of p, assuming tcp for protocols
+// that are not registered. ProtocolUnknown has an unknown transport.
+func (p Protocol) Transport() Protocol {
+	if info, ok := LookupProtocol(p); ok {
+		return info.Transport
+	}
+	return ProtocolTCP
+}
+
+// Secure reports whether p is registered as encrypted on the wire.
+func (p Protocol) Secure() bool {
+	info, ok := LookupProtocol(p)
+	return ok && info.Secure
+}
+
+func validProtocolName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '+', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
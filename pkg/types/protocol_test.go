@@ -0,0 +1,72 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegisterProtocol(t *testing.T) {
+	if err := RegisterProtocol(ProtocolInfo{Name: "kafka"}); err != nil {
+		t.Fatalf("Failed to register kafka: %v", err)
+	}
+	info, ok := LookupProtocol("kafka")
+	if !ok || info.Transport != ProtocolTCP {
+		t.Errorf("Expected kafka over tcp, got %+v (registered: %v)", info, ok)
+	}
+
+	// Identical re-registration is a no-op, conflicting is an error.
+	if err := RegisterProtocol(ProtocolInfo{Name: "kafka", Transport: ProtocolTCP}); err != nil {
+		t.Errorf("Expected identical re-registration to succeed, got %v", err)
+	}
+	if err := RegisterProtocol(ProtocolInfo{Name: "kafka", Secure: true}); err == nil {
+		t.Error("Expected conflicting registration to fail")
+	}
+
+	for _, info := range []ProtocolInfo{
+		{Name: ""},
+		{Name: "AMQP"},
+		{Name: "amqp", Transport: "sctp"},
+	} {
+		if err := RegisterProtocol(info); err == nil {
+			t.Errorf("Expected %+v to be rejected", info)
+		}
+	}
+}
+
+func TestProtocol_UnregisteredDefaults(t *testing.T) {
+	unknown := Protocol("nats")
+	if unknown.Registered() || unknown.Secure() || unknown.Transport() != ProtocolTCP {
+		t.Errorf("Expected unregistered protocol to default to insecure tcp")
+	}
+	if !ProtocolHTTPS.Secure() || ProtocolUnix.Transport() != ProtocolUnix {
+		t.Error("Unexpected built-in protocol info")
+	}
+}
+
+func TestExport_CustomProtocols(t *testing.T) {
+	if err := RegisterProtocol(ProtocolInfo{Name: "amqps", Secure: true}); err != nil {
+		t.Fatalf("Failed to register amqps: %v", err)
+	}
+	port := 5671
+	host := "rabbit"
+	results := &AnalysisResults{Sockets: []SocketInfo{
+		{Type: TrafficTypeEgress, Protocol: "amqps", ProcessName: "worker", Destination: NewEndpoint(host, &port)},
+		{Type: TrafficTypeIngress, Protocol: "mystery", ProcessName: "worker", Listen: &Endpoint{Port: &port}},
+	}}
+
+	for _, format := range ExportFormats {
+		var buf bytes.Buffer
+		if err := results.Export(&buf, format); err != nil {
+			t.Errorf("Export %s failed with custom protocols: %v", format, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "threagile"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "binary-encrypted") {
+		t.Errorf("Expected secure custom protocol to map to binary-encrypted:\n%s", buf.String())
+	}
+}
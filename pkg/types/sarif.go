@@ -0,0 +1,241 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// RuleDocsURL is the page documenting the built-in rules, generated with
+// "staticsocket patterns doc". SARIF rules link to their section of it.
+const RuleDocsURL = "https://github.com/yuvalk/staticsocket/blob/main/docs/rules.md"
+
+// RuleAnchor returns the fragment of the section documenting a rule:
+// its ID in lower case with every run of other characters than letters
+// and digits replaced by a hyphen, e.g. net-listen for net.Listen.
+func RuleAnchor(id string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(id) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log that code scanning services
+// such as GitHub and Azure DevOps read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties sarifProperties `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// sarifProperties carry the finding itself, for consumers that filter on
+// more than the rule.
+type sarifProperties struct {
+	Type        TrafficType `json:"type"`
+	Protocol    Protocol    `json:"protocol"`
+	Endpoint    string      `json:"endpoint"`
+	IsResolved  bool        `json:"is_resolved"`
+	VerifiesTLS *bool       `json:"verifies_tls,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+}
+
+// exportSARIF writes the findings as a SARIF 2.1.0 log for code scanning
+// uploads: one result per finding at its file and line, with a rule per
+// pattern linking to its documentation. Findings are notes, except those
+// worth a reviewer's look, which are warnings: unresolved addresses, which
+// a reviewer has to check by hand, and TLS egress that does not verify
+// certificates. Files that could not be analyzed are reported as tool
+// notifications.
+func (r *AnalysisResults) exportSARIF(writer io.Writer) error {
+	driver := sarifDriver{
+		Name:           "staticsocket",
+		InformationURI: "https://github.com/yuvalk/staticsocket",
+		Rules:          []sarifRule{},
+	}
+	if r.Scan != nil {
+		driver.Version = r.Scan.ToolVersion
+	}
+
+	ruleIndex := make(map[string]int)
+	results := make([]sarifResult, 0, len(r.Sockets))
+	for _, socket := range r.Sockets {
+		index, ok := ruleIndex[socket.PatternMatch]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndex[socket.PatternMatch] = index
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               socket.PatternMatch,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("%s opens %s %s sockets", socket.PatternMatch, socket.Protocol, socket.Type)},
+				HelpURI:          RuleDocsURL + "#" + RuleAnchor(socket.PatternMatch),
+			})
+		}
+		results = append(results, sarifResult{
+			RuleID:     socket.PatternMatch,
+			RuleIndex:  index,
+			Level:      sarifLevel(socket),
+			Message:    sarifMessage{Text: socket.Summary()},
+			Locations:  []sarifLocation{sarifSocketLocation(socket)},
+			Properties: sarifProperties{Type: socket.Type, Protocol: socket.Protocol, Endpoint: socket.EndpointName(), IsResolved: socket.IsResolved, VerifiesTLS: socket.VerifiesTLS, Tags: socket.Tags},
+		})
+	}
+
+	invocation := sarifInvocation{ExecutionSuccessful: true}
+	for _, analysisError := range r.Errors {
+		invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+			Level:     "warning",
+			Message:   sarifMessage{Text: analysisError.Message},
+			Locations: []sarifLocation{sarifFileLocation(analysisError.File, 0)},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Invocations: []sarifInvocation{invocation}, Results: results}},
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func sarifLevel(socket SocketInfo) string {
+	if !socket.IsResolved || socket.Protocol == ProtocolUnknown || (socket.VerifiesTLS != nil && !*socket.VerifiesTLS) {
+		return "warning"
+	}
+	return "note"
+}
+
+// Summary describes the finding in one sentence of the message catalog,
+// as SARIF results and vet diagnostics report it.
+func (s SocketInfo) Summary() string {
+	summary := MsgEgressSummary
+	if s.Type == TrafficTypeIngress {
+		summary = MsgIngressSummary
+	}
+	text := Message(summary, "Protocol", s.Protocol, "Endpoint", s.EndpointName())
+	if !s.IsResolved {
+		text = Message(MsgAddressUnresolved, "Summary", text)
+	}
+	if s.Protocol == ProtocolUnknown {
+		text = Message(MsgProtocolUnknown, "Summary", text)
+	}
+	if s.VerifiesTLS != nil && !*s.VerifiesTLS {
+		text = Message(MsgSkipsTLSVerification, "Summary", text)
+	}
+	return text + "."
+}
+
+// sarifFileLocation locates a file by a relative URI, which code scanning
+// services resolve against the repository root.
+func sarifFileLocation(path string, line int) sarifLocation {
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: strings.TrimPrefix(filepath.ToSlash(path), "./")},
+	}}
+	if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	return location
+}
+
+// sarifSocketLocation locates a finding by its extent, when known, so that
+// code scanning highlights the call rather than the whole line.
+func sarifSocketLocation(socket SocketInfo) sarifLocation {
+	location := sarifFileLocation(socket.SourceFile, socket.SourceLine)
+	region := location.PhysicalLocation.Region
+	if region == nil {
+		return location
+	}
+	region.StartColumn = socket.SourceColumn
+	if socket.EndLine >= socket.SourceLine {
+		region.EndLine, region.EndColumn = socket.EndLine, socket.EndColumn
+	}
+	if socket.CodeSnippet != "" {
+		region.Snippet = &sarifMessage{Text: socket.CodeSnippet}
+	}
+	return location
+}
@@ -0,0 +1,156 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAnalysisResults_ExportSARIF(t *testing.T) {
+	skip := false
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolTCP,
+				SourceFile:   "./cmd/server/main.go",
+				SourceLine:   10,
+				Listen:       NewEndpoint("0.0.0.0", intPtr(8080)),
+				IsResolved:   true,
+				PatternMatch: "net.Listen",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTPS,
+				SourceFile:   "client.go",
+				SourceLine:   20,
+				Destination:  NewEndpoint("api.example.com", intPtr(443)),
+				IsResolved:   true,
+				PatternMatch: "http.Get",
+				VerifiesTLS:  &skip,
+			},
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolTCP,
+				SourceFile:   "worker.go",
+				SourceLine:   5,
+				RawValue:     "addr",
+				PatternMatch: "net.Listen",
+			},
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolUnknown,
+				SourceFile:   "worker.go",
+				SourceLine:   7,
+				Listen:       NewEndpoint("0.0.0.0", intPtr(9090)),
+				IsResolved:   true,
+				PatternMatch: "net.Listen",
+			},
+		},
+		Scan:   &ScanInfo{ToolVersion: "v1.2.0"},
+		Errors: []AnalysisError{{File: "broken.go", Message: "expected 'package'"}},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "sarif"); err != nil {
+		t.Fatalf("Failed to export SARIF: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to parse SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected one SARIF 2.1.0 run, got %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "staticsocket" || run.Tool.Driver.Version != "v1.2.0" {
+		t.Errorf("Unexpected driver: %+v", run.Tool.Driver)
+	}
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "net.Listen" || run.Tool.Driver.Rules[1].ID != "http.Get" {
+		t.Fatalf("Expected a rule per pattern, got %+v", run.Tool.Driver.Rules)
+	}
+
+	if want := RuleDocsURL + "#net-listen"; run.Tool.Driver.Rules[0].HelpURI != want {
+		t.Errorf("Expected rule help at %s, got %s", want, run.Tool.Driver.Rules[0].HelpURI)
+	}
+
+	if len(run.Results) != 4 {
+		t.Fatalf("Expected a result per finding, got %d", len(run.Results))
+	}
+	tests := []struct {
+		rule  string
+		index int
+		level string
+		uri   string
+		line  int
+	}{
+		{"net.Listen", 0, "note", "cmd/server/main.go", 10},
+		{"http.Get", 1, "warning", "client.go", 20},
+		{"net.Listen", 0, "warning", "worker.go", 5},
+		{"net.Listen", 0, "warning", "worker.go", 7},
+	}
+	for i, tt := range tests {
+		result := run.Results[i]
+		location := result.Locations[0].PhysicalLocation
+		if result.RuleID != tt.rule || result.RuleIndex != tt.index || result.Level != tt.level {
+			t.Errorf("Result %d: expected rule %s (%d) at level %s, got %s (%d) at %s", i, tt.rule, tt.index, tt.level, result.RuleID, result.RuleIndex, result.Level)
+		}
+		if location.ArtifactLocation.URI != tt.uri || location.Region == nil || location.Region.StartLine != tt.line {
+			t.Errorf("Result %d: expected %s:%d, got %+v", i, tt.uri, tt.line, location)
+		}
+	}
+	if got := run.Results[1].Message.Text; got != "Connects over https to api.example.com:443 without verifying TLS certificates." {
+		t.Errorf("Unexpected message: %q", got)
+	}
+	if got := run.Results[3].Message.Text; got != "Listens for unknown on 0.0.0.0:9090 (network not known statically)." {
+		t.Errorf("Unexpected message: %q", got)
+	}
+
+	notifications := run.Invocations[0].ToolExecutionNotifications
+	if len(notifications) != 1 || notifications[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "broken.go" {
+		t.Errorf("Expected the analysis error as a notification, got %+v", notifications)
+	}
+}
+
+func TestRuleAnchor(t *testing.T) {
+	tests := map[string]string{
+		"net.Listen":                      "net-listen",
+		"cgo:accept4":                     "cgo-accept4",
+		"testcontainers.ContainerRequest": "testcontainers-containerrequest",
+		"MetricsBindAddress":              "metricsbindaddress",
+		"..a..b..":                        "a-b",
+	}
+	for id, want := range tests {
+		if got := RuleAnchor(id); got != want {
+			t.Errorf("RuleAnchor(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestAnalysisResults_ExportSARIFRegion(t *testing.T) {
+	results := AnalysisResults{Sockets: []SocketInfo{
+		{
+			Type: TrafficTypeIngress, Protocol: ProtocolTCP, SourceFile: "main.go", PatternMatch: "net.Listen",
+			SourceLine: 12, SourceColumn: 9, EndLine: 14, EndColumn: 3,
+			CodeSnippet: "net.Listen(\n\t\"tcp\",\n\t\":8080\")",
+		},
+		{Type: TrafficTypeIngress, Protocol: ProtocolTCP, SourceFile: "main.go", SourceLine: 20, PatternMatch: "net.Listen"},
+	}}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "sarif"); err != nil {
+		t.Fatalf("Failed to export SARIF: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to parse SARIF: %v", err)
+	}
+	region := log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region
+	if region == nil || region.StartLine != 12 || region.StartColumn != 9 || region.EndLine != 14 || region.EndColumn != 3 ||
+		region.Snippet == nil || region.Snippet.Text != results.Sockets[0].CodeSnippet {
+		t.Errorf("Expected the region of the call with its snippet, got %+v", region)
+	}
+	if region := log.Runs[0].Results[1].Locations[0].PhysicalLocation.Region; region == nil || *region != (sarifRegion{StartLine: 20}) {
+		t.Errorf("Expected only the start line of a finding without extent, got %+v", region)
+	}
+}
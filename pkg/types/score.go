@@ -0,0 +1,94 @@
+package types
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// Exposure classes for a listener, ordered from least to most reachable.
+const (
+	ExposureLocal    = 1 // loopback interfaces and unix sockets
+	ExposurePrivate  = 2 // a specific, non-loopback interface
+	ExposureWildcard = 3 // all interfaces, or an interface we could not resolve
+)
+
+const (
+	authHintTLS       = 1.0
+	authHintPlaintext = 2.0
+	unresolvedPenalty = 1.5
+)
+
+// BinaryScore is the composite attack-surface score of one binary.
+type BinaryScore struct {
+	ProcessName string  `json:"process_name" yaml:"process_name"`
+	Listeners   int     `json:"listeners" yaml:"listeners"`
+	Unresolved  int     `json:"unresolved" yaml:"unresolved"`
+	Score       float64 `json:"score" yaml:"score"`
+}
+
+// ComputeAttackSurface scores every binary with at least one listener.
+// Each listener contributes exposure class x auth hint x unresolved penalty,
+// and the binary's score is the sum over its listeners. Results are sorted
+// by descending score so the riskiest services come first.
+func ComputeAttackSurface(sockets []SocketInfo) []BinaryScore {
+	byProcess := make(map[string]*BinaryScore)
+	for _, socket := range sockets {
+		if socket.Type != TrafficTypeIngress {
+			continue
+		}
+
+		score, exists := byProcess[socket.ProcessName]
+		if !exists {
+			score = &BinaryScore{ProcessName: socket.ProcessName}
+			byProcess[socket.ProcessName] = score
+		}
+
+		weight := float64(ListenerExposure(socket)) * listenerAuthHint(socket)
+		if !socket.IsResolved {
+			weight *= unresolvedPenalty
+			score.Unresolved++
+		}
+		score.Listeners++
+		score.Score += weight
+	}
+
+	scores := make([]BinaryScore, 0, len(byProcess))
+	for _, score := range byProcess {
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].ProcessName < scores[j].ProcessName
+	})
+	return scores
+}
+
+// ListenerExposure classifies how reachable an ingress socket is.
+func ListenerExposure(socket SocketInfo) int {
+	if socket.Protocol.Transport() == ProtocolUnix {
+		return ExposureLocal
+	}
+
+	iface := strings.Trim(socket.Listen.address(), "[]")
+	switch iface {
+	case "", "0.0.0.0", "::":
+		return ExposureWildcard
+	case "localhost":
+		return ExposureLocal
+	}
+
+	if ip := net.ParseIP(iface); ip != nil && ip.IsLoopback() {
+		return ExposureLocal
+	}
+	return ExposurePrivate
+}
+
+func listenerAuthHint(socket SocketInfo) float64 {
+	if socket.Protocol.Secure() {
+		return authHintTLS
+	}
+	return authHintPlaintext
+}
@@ -0,0 +1,50 @@
+package types
+
+import "testing"
+
+func TestComputeAttackSurface(t *testing.T) {
+	sockets := []SocketInfo{
+		{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", nil), IsResolved: true},
+		{Type: TrafficTypeIngress, Protocol: ProtocolHTTPS, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", nil), IsResolved: true},
+		{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "worker", Listen: NewEndpoint("127.0.0.1", nil), IsResolved: true},
+		{Type: TrafficTypeIngress, Protocol: ProtocolTCP, ProcessName: "worker"},
+		{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, ProcessName: "client", IsResolved: true},
+	}
+
+	scores := ComputeAttackSurface(sockets)
+	if len(scores) != 2 {
+		t.Fatalf("Expected 2 scored binaries, got %d: %+v", len(scores), scores)
+	}
+
+	// worker: 1*2 (loopback) + 3*2*1.5 (unresolved wildcard) = 11
+	if scores[0].ProcessName != "worker" || scores[0].Score != 11 || scores[0].Unresolved != 1 {
+		t.Errorf("Unexpected worker score: %+v", scores[0])
+	}
+
+	// api: 3*2 (plaintext wildcard) + 3*1 (TLS wildcard) = 9
+	if scores[1].ProcessName != "api" || scores[1].Score != 9 || scores[1].Listeners != 2 {
+		t.Errorf("Unexpected api score: %+v", scores[1])
+	}
+}
+
+func TestListenerExposure(t *testing.T) {
+	tests := []struct {
+		socket   SocketInfo
+		expected int
+	}{
+		{SocketInfo{}, ExposureWildcard},
+		{SocketInfo{Listen: NewEndpoint("0.0.0.0", nil)}, ExposureWildcard},
+		{SocketInfo{Listen: NewEndpoint("::", nil)}, ExposureWildcard},
+		{SocketInfo{Listen: NewEndpoint("localhost", nil)}, ExposureLocal},
+		{SocketInfo{Listen: NewEndpoint("127.0.0.1", nil)}, ExposureLocal},
+		{SocketInfo{Listen: NewEndpoint("[::1]", nil)}, ExposureLocal},
+		{SocketInfo{Listen: NewEndpoint("10.0.0.5", nil)}, ExposurePrivate},
+		{SocketInfo{Protocol: ProtocolUnix, Listen: NewPathEndpoint("/tmp/app.sock")}, ExposureLocal},
+	}
+
+	for _, test := range tests {
+		if got := ListenerExposure(test.socket); got != test.expected {
+			t.Errorf("ListenerExposure(%q) = %d, expected %d", test.socket.Listen.String(), got, test.expected)
+		}
+	}
+}
@@ -1,3 +1,6 @@
+// Package types defines analysis results, the findings in them and their
+// export formats. The JSON and YAML field names are part of the stable
+// API: fields are added, never renamed or removed.
 package types
 
 import (
@@ -6,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,8 +30,36 @@ const (
 	ProtocolHTTPS Protocol = "https"
 	ProtocolGRPC  Protocol = "grpc"
 	ProtocolUnix  Protocol = "unix"
+	// Raw IP sockets, and ICMP carried by them
+	ProtocolIP   Protocol = "ip"
+	ProtocolICMP Protocol = "icmp"
+	// WebSocket connections, and WebSocket over TLS
+	ProtocolWebSocket Protocol = "websocket"
+	ProtocolWSS       Protocol = "wss"
+
+	// ProtocolUnknown marks sockets whose network is only known at run
+	// time, such as net.Dial(network, addr), rather than assuming tcp
+	ProtocolUnknown Protocol = "unknown"
 )
 
+// UnresolvedBudgetExceeded marks findings whose resolution ran out of time.
+const UnresolvedBudgetExceeded = "budget-exceeded"
+
+// UnresolvedInvalidDeclaration marks staticsocket:declare comments that do
+// not parse.
+const UnresolvedInvalidDeclaration = "invalid-declaration"
+
+// UnresolvedTemplateValue marks values in Go source templates that are
+// filled in by a template action.
+const UnresolvedTemplateValue = "template-value"
+
+// TagTemplate marks findings in Go source templates rather than Go files.
+const TagTemplate = "template"
+
+// TagHealthcheck marks egress to health, readiness or liveness endpoints,
+// which is monitoring rather than a business dependency.
+const TagHealthcheck = "healthcheck"
+
 type SocketInfo struct {
 	Type         TrafficType `json:"type" yaml:"type"`
 	Protocol     Protocol    `json:"protocol" yaml:"protocol"`
@@ -35,29 +67,180 @@ type SocketInfo struct {
 	SourceFile   string      `json:"source_file" yaml:"source_file"`
 	SourceLine   int         `json:"source_line" yaml:"source_line"`
 	FunctionName string      `json:"function_name" yaml:"function_name"`
+	// Position in the generated Go file when a //line directive maps the
+	// finding to its original source (e.g. a .proto or template)
+	Generated *SourcePosition `json:"generated,omitempty" yaml:"generated,omitempty"`
+	// Column of the finding on SourceLine, and the line and column just
+	// past the end of the matched call or literal, all 1-based; 0 when
+	// unknown, as after a //line directive without a column
+	SourceColumn int `json:"source_column,omitempty" yaml:"source_column,omitempty"`
+	EndLine      int `json:"end_line,omitempty" yaml:"end_line,omitempty"`
+	EndColumn    int `json:"end_column,omitempty" yaml:"end_column,omitempty"`
+	// Source of the matched call or literal, when snippets are captured
+	// (e.g. `net.Listen("tcp", addr)`)
+	CodeSnippet string `json:"code_snippet,omitempty" yaml:"code_snippet,omitempty"`
 	
-	// Ingress-specific fields
-	ListenPort      *int    `json:"listen_port,omitempty" yaml:"listen_port,omitempty"`
-	ListenInterface string  `json:"listen_interface,omitempty" yaml:"listen_interface,omitempty"`
-	
-	// Egress-specific fields
-	DestinationHost *string `json:"destination_host,omitempty" yaml:"destination_host,omitempty"`
-	DestinationPort *int    `json:"destination_port,omitempty" yaml:"destination_port,omitempty"`
+	// Where an ingress socket listens and where an egress socket connects
+	Listen      *Endpoint `json:"listen,omitempty" yaml:"listen,omitempty"`
+	Destination *Endpoint `json:"destination,omitempty" yaml:"destination,omitempty"`
+	// Ports a listener retries on when binding fails, in the order tried
+	CandidatePorts []int `json:"candidate_ports,omitempty" yaml:"candidate_ports,omitempty"`
 	
 	// Additional metadata
-	IsResolved   bool   `json:"is_resolved" yaml:"is_resolved"`
-	RawValue     string `json:"raw_value" yaml:"raw_value"`
-	PatternMatch string `json:"pattern_match" yaml:"pattern_match"`
+	IsResolved bool   `json:"is_resolved" yaml:"is_resolved"`
+	RawValue   string `json:"raw_value" yaml:"raw_value"`
+	// Values a local variable is assigned before the one reported, which
+	// may reach the call on another path
+	CandidateValues []string `json:"candidate_values,omitempty" yaml:"candidate_values,omitempty"`
+	// Environment variable the address is read from, and the literal it
+	// falls back to when the variable is unset, which RawValue is built from
+	EnvVar       string `json:"env_var,omitempty" yaml:"env_var,omitempty"`
+	DefaultValue string `json:"default_value,omitempty" yaml:"default_value,omitempty"`
+	// Call site passing the address into the helper the finding is in,
+	// when it was resolved from there
+	ResolvedFrom *SourcePosition `json:"resolved_from,omitempty" yaml:"resolved_from,omitempty"`
+	PatternMatch string          `json:"pattern_match" yaml:"pattern_match"`
+	// Why an address could not be resolved, when known
+	UnresolvedReason string `json:"unresolved_reason,omitempty" yaml:"unresolved_reason,omitempty"`
+	// Unresolved findings with the same source expression folded into this
+	// one, when collapsed
+	Occurrences int `json:"occurrences,omitempty" yaml:"occurrences,omitempty"`
+
+	// Free-form classification labels (e.g. "opaque-networking")
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Caveats about the finding a reader should know (e.g. topology fan-out)
+	Notes []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+	// Libraries a locally created listener is handed to (e.g. "grpc.Serve")
+	ConsumedBy []string `json:"consumed_by,omitempty" yaml:"consumed_by,omitempty"`
+	// Protocols served on one multiplexed listener (e.g. cmux gRPC + HTTP)
+	Facets []Protocol `json:"facets,omitempty" yaml:"facets,omitempty"`
+	// Call closing the socket or stopping the server it is handed to
+	// (e.g. "defer lis.Close()", "srv.Shutdown(ctx)")
+	ClosedBy string `json:"closed_by,omitempty" yaml:"closed_by,omitempty"`
+	// Signals whose handler shuts a listener down (e.g. "SIGTERM")
+	ShutdownSignals []string `json:"shutdown_signals,omitempty" yaml:"shutdown_signals,omitempty"`
+	// Whether egress over TLS verifies the server certificate; unset for
+	// plaintext traffic and when it is not known statically
+	VerifiesTLS *bool `json:"verifies_tls,omitempty" yaml:"verifies_tls,omitempty"`
+	// Options the code sets on the socket, in source order; options not
+	// listed are left at their defaults, as far as the file shows
+	SocketOptions []SocketOption `json:"socket_options,omitempty" yaml:"socket_options,omitempty"`
+
+	// Root-relative path with build output prefixes (bazel-bin/) removed
+	LogicalPath string `json:"logical_path,omitempty" yaml:"logical_path,omitempty"`
+	// External dependency the source belongs to (e.g. Bazel external/ repo)
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+
+	// Build-constrained files the finding occurs in (e.g. foo_linux.go and
+	// foo_windows.go), merged into this one finding
+	Variants []BuildVariant `json:"variants,omitempty" yaml:"variants,omitempty"`
+}
+
+// Names of socket options.
+const (
+	SocketOptionKeepAlive       = "keepalive"
+	SocketOptionKeepAlivePeriod = "keepalive_period"
+	SocketOptionKeepAliveConfig = "keepalive_config"
+	SocketOptionNoDelay         = "nodelay"
+	SocketOptionReadBuffer      = "read_buffer"
+	SocketOptionWriteBuffer     = "write_buffer"
+	SocketOptionLinger          = "linger"
+	SocketOptionControl         = "control"
+)
+
+// SocketOption is an option set on a socket, such as nodelay set to false
+// by SetNoDelay, with its value as written in the source.
+type SocketOption struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+func (o SocketOption) String() string {
+	return o.Name + "=" + o.Value
+}
+
+// BuildVariant is one build-constrained occurrence of a finding.
+type BuildVariant struct {
+	// Constraint in //go:build syntax, from the file name and build line
+	Constraint string `json:"constraint" yaml:"constraint"`
+	SourceFile string `json:"source_file" yaml:"source_file"`
+	SourceLine int    `json:"source_line" yaml:"source_line"`
+}
+
+// SourcePosition is a file and line.
+type SourcePosition struct {
+	File string `json:"file" yaml:"file"`
+	Line int    `json:"line" yaml:"line"`
 }
 
 type AnalysisResults struct {
-	Sockets     []SocketInfo `json:"sockets" yaml:"sockets"`
-	TotalCount  int          `json:"total_count" yaml:"total_count"`
-	IngressCount int         `json:"ingress_count" yaml:"ingress_count"`
-	EgressCount  int         `json:"egress_count" yaml:"egress_count"`
-	ProcessName  string      `json:"process_name" yaml:"process_name"`
+	Sockets      []SocketInfo `json:"sockets" yaml:"sockets"`
+	TotalCount   int          `json:"total_count" yaml:"total_count"`
+	IngressCount int          `json:"ingress_count" yaml:"ingress_count"`
+	EgressCount  int          `json:"egress_count" yaml:"egress_count"`
+	ProcessName  string       `json:"process_name" yaml:"process_name"`
+	// Findings of ProtocolUnknown, also counted as ingress or egress
+	UnknownProtocolCount int `json:"unknown_protocol_count" yaml:"unknown_protocol_count"`
+	// Set when the findings limit left findings out of Sockets; the counts
+	// above still cover every finding
+	Truncated  bool               `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+	Truncation *TruncationSummary `json:"truncation,omitempty" yaml:"truncation,omitempty"`
+
+	// Per-binary attack-surface ranking, riskiest first
+	AttackSurface []BinaryScore `json:"attack_surface,omitempty" yaml:"attack_surface,omitempty"`
+
+	// Go version declared by the analyzed module, if any
+	GoVersion string `json:"go_version,omitempty" yaml:"go_version,omitempty"`
+	// Version control state of the analyzed tree, if it is a checkout
+	VCS *VCSInfo `json:"vcs,omitempty" yaml:"vcs,omitempty"`
+	// Tool, configuration and rule set that produced these results
+	Scan *ScanInfo `json:"scan,omitempty" yaml:"scan,omitempty"`
+	// Findings and files whose resolution ran out of time, if any
+	ResolveBudget *BudgetSummary `json:"resolve_budget,omitempty" yaml:"resolve_budget,omitempty"`
+	// Findings grouped by listener or destination, when requested
+	Endpoints []EndpointGroup `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+	// Files that could not be analyzed and why
+	Errors []AnalysisError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type VCSInfo struct {
+	System        string `json:"system" yaml:"system"`
+	RepositoryURL string `json:"repository_url,omitempty" yaml:"repository_url,omitempty"`
+	Commit        string `json:"commit" yaml:"commit"`
+	Branch        string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Dirty         bool   `json:"dirty" yaml:"dirty"`
+}
+
+type ScanInfo struct {
+	ToolVersion    string    `json:"tool_version,omitempty" yaml:"tool_version,omitempty"`
+	Timestamp      time.Time `json:"timestamp" yaml:"timestamp"`
+	ConfigHash     string    `json:"config_hash" yaml:"config_hash"`
+	PatternSetHash string    `json:"pattern_set_hash" yaml:"pattern_set_hash"`
+	// Root is the directory analyzed, or holding the file analyzed, which
+	// source files are under
+	Root string `json:"root,omitempty" yaml:"root,omitempty"`
+}
+
+type BudgetSummary struct {
+	FindingsExceeded int `json:"findings_exceeded" yaml:"findings_exceeded"`
+	FilesExceeded    int `json:"files_exceeded" yaml:"files_exceeded"`
 }
 
+type TruncationSummary struct {
+	MaxFindings int `json:"max_findings" yaml:"max_findings"`
+	Omitted     int `json:"omitted" yaml:"omitted"`
+	// Every finding, listed or not, by protocol
+	ByProtocol map[Protocol]int `json:"by_protocol" yaml:"by_protocol"`
+}
+
+type AnalysisError struct {
+	File    string `json:"file" yaml:"file"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// ExportFormats lists the format names accepted by Export.
+var ExportFormats = []string{"json", "yaml", "csv", "threagile", "endpoints", "sql", "elasticsearch", "networkpolicy", "sarif", "dot", "backstage", "squid", "envoy", "envoy-clusters", "nginx", "html", "heatmap"}
+
 func (r *AnalysisResults) Export(writer io.Writer, format string) error {
 	switch strings.ToLower(format) {
 	case "json":
@@ -70,6 +253,34 @@ func (r *AnalysisResults) Export(writer io.Writer, format string) error {
 		return encoder.Encode(r)
 	case "csv":
 		return r.exportCSV(writer)
+	case "threagile":
+		return r.exportThreagile(writer)
+	case "endpoints":
+		return r.exportEndpoints(writer)
+	case "sql":
+		return r.exportSQL(writer)
+	case "elasticsearch":
+		return r.exportElasticsearch(writer)
+	case "networkpolicy":
+		return r.exportNetworkPolicy(writer)
+	case "sarif":
+		return r.exportSARIF(writer)
+	case "dot":
+		return r.exportDOT(writer)
+	case "backstage":
+		return r.exportBackstage(writer)
+	case "squid":
+		return r.exportSquid(writer)
+	case "envoy":
+		return r.exportEnvoy(writer)
+	case "envoy-clusters":
+		return r.exportEnvoyClusters(writer)
+	case "nginx":
+		return r.exportNginx(writer)
+	case "html":
+		return r.exportHTML(writer)
+	case "heatmap":
+		return r.exportHeatmap(writer)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
@@ -82,7 +293,10 @@ func (r *AnalysisResults) exportCSV(writer io.Writer) error {
 	headers := []string{
 		"Type", "Protocol", "ProcessName", "SourceFile", "SourceLine", "FunctionName",
 		"ListenPort", "ListenInterface", "DestinationHost", "DestinationPort",
-		"IsResolved", "RawValue", "PatternMatch",
+		"IsResolved", "RawValue", "PatternMatch", "UnresolvedReason", "Tags", "Notes", "ConsumedBy", "Facets",
+		"LogicalPath", "Module", "Variants", "CandidatePorts", "DestinationFQDNCandidates",
+		"Occurrences", "Generated", "CandidateValues", "ResolvedFrom", "VerifiesTLS", "ClosedBy", "ShutdownSignals",
+		"EnvVar", "DefaultValue", "SocketOptions", "DestinationIsIP",
 	}
 
 	if err := csvWriter.Write(headers); err != nil {
@@ -90,6 +304,7 @@ func (r *AnalysisResults) exportCSV(writer io.Writer) error {
 	}
 
 	for _, socket := range r.Sockets {
+		legacy := socket.legacy()
 		record := []string{
 			string(socket.Type),
 			string(socket.Protocol),
@@ -97,13 +312,34 @@ func (r *AnalysisResults) exportCSV(writer io.Writer) error {
 			socket.SourceFile,
 			fmt.Sprintf("%d", socket.SourceLine),
 			socket.FunctionName,
-			formatIntPtr(socket.ListenPort),
-			socket.ListenInterface,
-			formatStringPtr(socket.DestinationHost),
-			formatIntPtr(socket.DestinationPort),
+			formatIntPtr(legacy.ListenPort),
+			legacy.ListenInterface,
+			formatStringPtr(legacy.DestinationHost),
+			formatIntPtr(legacy.DestinationPort),
 			fmt.Sprintf("%t", socket.IsResolved),
 			socket.RawValue,
 			socket.PatternMatch,
+			socket.UnresolvedReason,
+			strings.Join(socket.Tags, ";"),
+			strings.Join(socket.Notes, ";"),
+			strings.Join(socket.ConsumedBy, ";"),
+			formatProtocols(socket.Facets),
+			socket.LogicalPath,
+			socket.Module,
+			formatVariants(socket.Variants),
+			formatInts(socket.CandidatePorts),
+			strings.Join(socket.Destination.fqdnCandidates(), ";"),
+			formatOccurrences(socket.Occurrences),
+			formatPosition(socket.Generated),
+			strings.Join(socket.CandidateValues, ";"),
+			formatPosition(socket.ResolvedFrom),
+			formatBoolPtr(socket.VerifiesTLS),
+			socket.ClosedBy,
+			strings.Join(socket.ShutdownSignals, ";"),
+			socket.EnvVar,
+			socket.DefaultValue,
+			formatSocketOptions(socket.SocketOptions),
+			fmt.Sprintf("%t", legacy.DestinationIsIP),
 		}
 		if err := csvWriter.Write(record); err != nil {
 			return err
@@ -113,6 +349,52 @@ func (r *AnalysisResults) exportCSV(writer io.Writer) error {
 	return nil
 }
 
+func formatSocketOptions(options []SocketOption) string {
+	values := make([]string, len(options))
+	for i, option := range options {
+		values[i] = option.String()
+	}
+	return strings.Join(values, ";")
+}
+
+func formatVariants(variants []BuildVariant) string {
+	constraints := make([]string, len(variants))
+	for i, variant := range variants {
+		constraints[i] = variant.Constraint
+	}
+	return strings.Join(constraints, ";")
+}
+
+func formatInts(values []int) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = fmt.Sprintf("%d", value)
+	}
+	return strings.Join(formatted, ";")
+}
+
+func formatOccurrences(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", count)
+}
+
+func formatPosition(position *SourcePosition) string {
+	if position == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", position.File, position.Line)
+}
+
+func formatProtocols(protocols []Protocol) string {
+	names := make([]string, len(protocols))
+	for i, protocol := range protocols {
+		names[i] = string(protocol)
+	}
+	return strings.Join(names, ";")
+}
+
 func formatIntPtr(ptr *int) string {
 	if ptr == nil {
 		return ""
@@ -120,6 +402,13 @@ func formatIntPtr(ptr *int) string {
 	return fmt.Sprintf("%d", *ptr)
 }
 
+func formatBoolPtr(ptr *bool) string {
+	if ptr == nil {
+		return ""
+	}
+	return fmt.Sprintf("%t", *ptr)
+}
+
 func formatStringPtr(ptr *string) string {
 	if ptr == nil {
 		return ""
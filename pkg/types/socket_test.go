@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -12,18 +13,17 @@ func TestSocketInfo_JSONExport(t *testing.T) {
 	host := "example.com"
 	
 	socket := SocketInfo{
-		Type:            TrafficTypeIngress,
-		Protocol:        ProtocolHTTP,
-		ProcessName:     "test-service",
-		SourceFile:      "/path/to/file.go",
-		SourceLine:      42,
-		FunctionName:    "main",
-		ListenPort:      &port,
-		ListenInterface: "0.0.0.0",
-		DestinationHost: &host,
-		IsResolved:      true,
-		RawValue:        ":8080",
-		PatternMatch:    "http.ListenAndServe",
+		Type:         TrafficTypeIngress,
+		Protocol:     ProtocolHTTP,
+		ProcessName:  "test-service",
+		SourceFile:   "/path/to/file.go",
+		SourceLine:   42,
+		FunctionName: "main",
+		Listen:       NewEndpoint("0.0.0.0", &port),
+		Destination:  NewEndpoint(host, nil),
+		IsResolved:   true,
+		RawValue:     ":8080",
+		PatternMatch: "http.ListenAndServe",
 	}
 
 	data, err := json.Marshal(socket)
@@ -39,8 +39,11 @@ func TestSocketInfo_JSONExport(t *testing.T) {
 	if unmarshaled.Type != socket.Type {
 		t.Errorf("Expected type %s, got %s", socket.Type, unmarshaled.Type)
 	}
-	if *unmarshaled.ListenPort != *socket.ListenPort {
-		t.Errorf("Expected port %d, got %d", *socket.ListenPort, *unmarshaled.ListenPort)
+	if *unmarshaled.Listen.Port != *socket.Listen.Port {
+		t.Errorf("Expected port %d, got %d", *socket.Listen.Port, *unmarshaled.Listen.Port)
+	}
+	if unmarshaled.Destination.Host != host || unmarshaled.Destination.Kind != EndpointKindHostname {
+		t.Errorf("Expected destination %s, got %+v", host, unmarshaled.Destination)
 	}
 }
 
@@ -49,16 +52,15 @@ func TestAnalysisResults_ExportJSON(t *testing.T) {
 	results := AnalysisResults{
 		Sockets: []SocketInfo{
 			{
-				Type:            TrafficTypeIngress,
-				Protocol:        ProtocolHTTP,
-				ProcessName:     "web-server",
-				SourceFile:      "main.go",
-				SourceLine:      10,
-				ListenPort:      &port,
-				ListenInterface: "0.0.0.0",
-				IsResolved:      true,
-				RawValue:        ":3000",
-				PatternMatch:    "http.ListenAndServe",
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolHTTP,
+				ProcessName:  "web-server",
+				SourceFile:   "main.go",
+				SourceLine:   10,
+				Listen:       NewEndpoint("0.0.0.0", &port),
+				IsResolved:   true,
+				RawValue:     ":3000",
+				PatternMatch: "http.ListenAndServe",
 			},
 		},
 		TotalCount:   1,
@@ -92,16 +94,15 @@ func TestAnalysisResults_ExportCSV(t *testing.T) {
 	results := AnalysisResults{
 		Sockets: []SocketInfo{
 			{
-				Type:            TrafficTypeEgress,
-				Protocol:        ProtocolHTTPS,
-				ProcessName:     "client",
-				SourceFile:      "client.go",
-				SourceLine:      25,
-				DestinationHost: &host,
-				DestinationPort: &port,
-				IsResolved:      true,
-				RawValue:        "https://api.example.com:8080",
-				PatternMatch:    "http.Get",
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTPS,
+				ProcessName:  "client",
+				SourceFile:   "client.go",
+				SourceLine:   25,
+				Destination:  NewEndpoint(host, &port),
+				IsResolved:   true,
+				RawValue:     "https://api.example.com:8080",
+				PatternMatch: "http.Get",
 			},
 		},
 		TotalCount:  1,
@@ -129,6 +130,20 @@ func TestAnalysisResults_ExportCSV(t *testing.T) {
 	if !strings.Contains(data, "egress,https,client") {
 		t.Error("CSV data missing expected values")
 	}
+
+	// Columns are only ever added at the end, so positional consumers
+	// keep working
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	original := "Type,Protocol,ProcessName,SourceFile,SourceLine,FunctionName,ListenPort,ListenInterface,DestinationHost,DestinationPort,IsResolved,RawValue,PatternMatch"
+	if got := strings.Join(records[0][:13], ","); got != original {
+		t.Errorf("Expected the original columns first, got %s", got)
+	}
+	if last := len(records[0]) - 1; records[0][last] != "DestinationIsIP" || records[1][last] != "false" {
+		t.Errorf("Expected DestinationIsIP last, got %s=%s", records[0][last], records[1][last])
+	}
 }
 
 func TestAnalysisResults_ExportYAML(t *testing.T) {
@@ -136,16 +151,15 @@ func TestAnalysisResults_ExportYAML(t *testing.T) {
 	results := AnalysisResults{
 		Sockets: []SocketInfo{
 			{
-				Type:            TrafficTypeIngress,
-				Protocol:        ProtocolTCP,
-				ProcessName:     "tcp-server",
-				SourceFile:      "server.go",
-				SourceLine:      15,
-				ListenPort:      &port,
-				ListenInterface: "127.0.0.1",
-				IsResolved:      true,
-				RawValue:        "127.0.0.1:9090",
-				PatternMatch:    "net.Listen",
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolTCP,
+				ProcessName:  "tcp-server",
+				SourceFile:   "server.go",
+				SourceLine:   15,
+				Listen:       NewEndpoint("127.0.0.1", &port),
+				IsResolved:   true,
+				RawValue:     "127.0.0.1:9090",
+				PatternMatch: "net.Listen",
 			},
 		},
 		TotalCount:   1,
@@ -0,0 +1,149 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// sqlSchema creates the tables the sql export inserts into. The statements
+// are valid in both SQLite and PostgreSQL, so repeated runs append to the
+// same database.
+const sqlSchema = `CREATE TABLE IF NOT EXISTS runs (
+  id TEXT PRIMARY KEY,
+  scanned_at TEXT,
+  tool_version TEXT,
+  config_hash TEXT,
+  pattern_set_hash TEXT,
+  vcs_commit TEXT,
+  vcs_branch TEXT,
+  process_name TEXT,
+  total_count INTEGER,
+  ingress_count INTEGER,
+  egress_count INTEGER
+);
+CREATE TABLE IF NOT EXISTS findings (
+  run_id TEXT NOT NULL REFERENCES runs (id),
+  seq INTEGER NOT NULL,
+  type TEXT NOT NULL,
+  protocol TEXT NOT NULL,
+  process_name TEXT,
+  source_file TEXT,
+  source_line INTEGER,
+  function_name TEXT,
+  listen_host TEXT,
+  listen_port INTEGER,
+  destination_host TEXT,
+  destination_port INTEGER,
+  endpoint TEXT,
+  is_resolved BOOLEAN,
+  raw_value TEXT,
+  pattern_match TEXT,
+  unresolved_reason TEXT,
+  tags TEXT,
+  PRIMARY KEY (run_id, seq)
+);
+CREATE TABLE IF NOT EXISTS endpoints (
+  run_id TEXT NOT NULL REFERENCES runs (id),
+  type TEXT NOT NULL,
+  protocol TEXT NOT NULL,
+  endpoint TEXT NOT NULL,
+  occurrences INTEGER NOT NULL,
+  PRIMARY KEY (run_id, type, protocol, endpoint)
+);
+`
+
+// exportSQL writes a script that creates the runs, findings and endpoints
+// tables if needed and inserts these results as one run, in a single
+// transaction. Pipe it into sqlite3 or psql.
+func (r *AnalysisResults) exportSQL(writer io.Writer) error {
+	runID, err := r.runID()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	b.WriteString(sqlSchema)
+
+	var scannedAt, toolVersion, configHash, patternSetHash, commit, branch any
+	if r.Scan != nil {
+		scannedAt = r.Scan.Timestamp.UTC().Format(time.RFC3339)
+		toolVersion, configHash, patternSetHash = r.Scan.ToolVersion, r.Scan.ConfigHash, r.Scan.PatternSetHash
+	}
+	if r.VCS != nil {
+		commit, branch = r.VCS.Commit, r.VCS.Branch
+	}
+	writeInsert(&b, "runs", runID, scannedAt, toolVersion, configHash, patternSetHash, commit, branch,
+		r.ProcessName, r.TotalCount, r.IngressCount, r.EgressCount)
+
+	for i, socket := range r.Sockets {
+		writeInsert(&b, "findings", runID, i+1, string(socket.Type), string(socket.Protocol),
+			socket.ProcessName, socket.SourceFile, socket.SourceLine, socket.FunctionName,
+			nullString(socket.Listen.address()), nullInt(socket.Listen.port()),
+			nullString(socket.Destination.address()), nullInt(socket.Destination.port()), socket.EndpointName(),
+			socket.IsResolved, socket.RawValue, socket.PatternMatch, socket.UnresolvedReason,
+			strings.Join(socket.Tags, ";"))
+	}
+
+	for _, group := range GroupByEndpoint(r.Sockets) {
+		writeInsert(&b, "endpoints", runID, string(group.Type), string(group.Protocol), group.Endpoint, group.Count)
+	}
+
+	b.WriteString("COMMIT;\n")
+	_, err = io.WriteString(writer, b.String())
+	return err
+}
+
+// runID identifies a run by the digest of its results, so exporting the
+// same results twice is rejected by the primary key instead of duplicated.
+func (r *AnalysisResults) runID() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func writeInsert(b *strings.Builder, table string, values ...any) {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = sqlLiteral(value)
+	}
+	fmt.Fprintf(b, "INSERT INTO %s VALUES (%s);\n", table, strings.Join(literals, ", "))
+}
+
+func nullString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nullInt(value *int) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func sqlLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
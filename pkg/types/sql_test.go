@@ -0,0 +1,61 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnalysisResults_ExportSQL(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolTCP,
+				SourceFile:   "main.go",
+				SourceLine:   10,
+				Listen:       NewEndpoint("0.0.0.0", intPtr(8080)),
+				IsResolved:   true,
+				PatternMatch: "net.Listen",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTP,
+				SourceFile:   "client.go",
+				SourceLine:   20,
+				RawValue:     "cfg['upstream']",
+				PatternMatch: "http.Get",
+			},
+		},
+		TotalCount: 2,
+	}
+
+	var first, second bytes.Buffer
+	if err := results.Export(&first, "sql"); err != nil {
+		t.Fatalf("Failed to export SQL: %v", err)
+	}
+	if err := results.Export(&second, "sql"); err != nil {
+		t.Fatalf("Failed to export SQL: %v", err)
+	}
+	script := first.String()
+	if script != second.String() {
+		t.Error("Expected the same results to export the same script and run ID")
+	}
+
+	for _, want := range []string{
+		"BEGIN;\n",
+		"CREATE TABLE IF NOT EXISTS findings (",
+		"'net.Listen', '', ''",
+		"'0.0.0.0', 8080, NULL, NULL, '0.0.0.0:8080', TRUE",
+		"'cfg[''upstream'']', FALSE, 'cfg[''upstream'']', 'http.Get'",
+		"INSERT INTO endpoints VALUES (",
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+	if n := strings.Count(script, "INSERT INTO findings"); n != 2 {
+		t.Errorf("Expected 2 finding rows, got %d", n)
+	}
+}
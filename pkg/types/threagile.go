@@ -0,0 +1,215 @@
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// threagileModel is the subset of the Threagile model schema needed to seed
+// a threat-modeling session: one technical asset per binary, one per egress
+// destination, and a communication link per distinct egress flow. Binaries
+// and destinations get asset IDs in separate namespaces, so a binary named
+// after a host it calls stays two assets.
+type threagileModel struct {
+	ThreagileVersion string                        `yaml:"threagile_version"`
+	Title            string                        `yaml:"title"`
+	Description      string                        `yaml:"description"`
+	TechnicalAssets  map[string]threagileTechAsset `yaml:"technical_assets"`
+	TrustBoundaries  map[string]threagileBoundary  `yaml:"trust_boundaries,omitempty"`
+}
+
+type threagileTechAsset struct {
+	ID                 string                       `yaml:"id"`
+	Description        string                       `yaml:"description"`
+	Type               string                       `yaml:"type"`
+	Usage              string                       `yaml:"usage"`
+	OutOfScope         bool                         `yaml:"out_of_scope"`
+	Size               string                       `yaml:"size"`
+	Technology         string                       `yaml:"technology"`
+	Internet           bool                         `yaml:"internet"`
+	Machine            string                       `yaml:"machine"`
+	Encryption         string                       `yaml:"encryption"`
+	Confidentiality    string                       `yaml:"confidentiality"`
+	Integrity          string                       `yaml:"integrity"`
+	Availability       string                       `yaml:"availability"`
+	Tags               []string                     `yaml:"tags"`
+	CommunicationLinks map[string]threagileCommLink `yaml:"communication_links,omitempty"`
+}
+
+type threagileCommLink struct {
+	Target         string   `yaml:"target"`
+	Description    string   `yaml:"description"`
+	Protocol       string   `yaml:"protocol"`
+	Authentication string   `yaml:"authentication"`
+	Authorization  string   `yaml:"authorization"`
+	Usage          string   `yaml:"usage"`
+	Tags           []string `yaml:"tags"`
+}
+
+type threagileBoundary struct {
+	ID                    string   `yaml:"id"`
+	Description           string   `yaml:"description"`
+	Type                  string   `yaml:"type"`
+	TechnicalAssetsInside []string `yaml:"technical_assets_inside"`
+}
+
+func (r *AnalysisResults) exportThreagile(writer io.Writer) error {
+	model := threagileModel{
+		ThreagileVersion: "1.0.0",
+		Title:            "StaticSocket generated model",
+		Description:      "Technical assets and communication links discovered by static analysis.",
+		TechnicalAssets:  make(map[string]threagileTechAsset),
+	}
+
+	var internal []string
+	for _, socket := range r.Sockets {
+		processID := "process-" + threagileID(socket.ProcessName)
+		asset, exists := model.TechnicalAssets[processID]
+		if !exists {
+			asset = threagileTechAsset{
+				ID:          processID,
+				Description: fmt.Sprintf("Binary %s", socket.ProcessName),
+				Type:        "process",
+				Usage:       "business",
+				Size:        "service",
+				Technology:  "unknown-technology",
+				Machine:     "virtual",
+				Encryption:  "none",
+				// static analysis knows nothing of the data handled, so the
+				// ratings are Threagile's middle of the road
+				Confidentiality: "internal",
+				Integrity:       "operational",
+				Availability:    "operational",
+				Tags:            []string{"staticsocket"},
+			}
+			internal = append(internal, processID)
+		}
+
+		switch socket.Type {
+		case TrafficTypeIngress:
+			tag := fmt.Sprintf("listens:%s/%s", socket.Protocol, formatIntPtr(socket.Listen.port()))
+			if !containsString(asset.Tags, tag) {
+				asset.Tags = append(asset.Tags, tag)
+			}
+			if isWebProtocol(socket.Protocol) {
+				asset.Technology = "web-service-rest"
+			}
+		case TrafficTypeEgress:
+			if containsString(socket.Tags, TagHealthcheck) {
+				break
+			}
+			targetID := threagileDestinationAsset(&model, socket)
+			linkID := fmt.Sprintf("%s %s", socket.Protocol, targetID)
+			if asset.CommunicationLinks == nil {
+				asset.CommunicationLinks = make(map[string]threagileCommLink)
+			}
+			if _, seen := asset.CommunicationLinks[linkID]; !seen {
+				asset.CommunicationLinks[linkID] = threagileCommLink{
+					Target:         targetID,
+					Description:    fmt.Sprintf("%s at %s:%d", socket.PatternMatch, socket.SourceFile, socket.SourceLine),
+					Protocol:       threagileProtocol(socket.Protocol),
+					Authentication: "none",
+					Authorization:  "none",
+					Usage:          "business",
+					Tags:           []string{},
+				}
+			}
+		}
+		model.TechnicalAssets[processID] = asset
+	}
+
+	if len(internal) > 0 {
+		sort.Strings(internal)
+		model.TrustBoundaries = map[string]threagileBoundary{
+			"analyzed-code": {
+				ID:                    "analyzed-code",
+				Description:           "Binaries found in the analyzed source tree",
+				Type:                  "network-cloud-security-group",
+				TechnicalAssetsInside: internal,
+			},
+		}
+	}
+
+	encoder := yaml.NewEncoder(writer)
+	defer encoder.Close()
+	return encoder.Encode(model)
+}
+
+func threagileDestinationAsset(model *threagileModel, socket SocketInfo) string {
+	host := socket.Destination.address()
+	if host == "" {
+		host = "unresolved-destination"
+	}
+	targetID := "destination-" + threagileID(host)
+	if _, exists := model.TechnicalAssets[targetID]; !exists {
+		model.TechnicalAssets[targetID] = threagileTechAsset{
+			ID:          targetID,
+			Description: fmt.Sprintf("Egress destination %s", host),
+			Type:        "external-entity",
+			Usage:       "business",
+			OutOfScope:  true,
+			Size:        "system",
+			Technology:  "unknown-technology",
+			Internet:    true,
+			Machine:     "virtual",
+			Encryption:  "none",
+			// the destination is out of scope, but Threagile still wants
+			// ratings for it
+			Confidentiality: "internal",
+			Integrity:       "operational",
+			Availability:    "operational",
+			Tags:            []string{"staticsocket"},
+		}
+	}
+	return targetID
+}
+
+func threagileProtocol(protocol Protocol) string {
+	switch protocol {
+	case ProtocolHTTP:
+		return "http"
+	case ProtocolHTTPS:
+		return "https"
+	}
+	if protocol.Secure() {
+		return "binary-encrypted"
+	}
+	return "binary"
+}
+
+func threagileID(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	id := strings.ToLower(name)
+	id = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, id)
+	if id = strings.Trim(id, "-"); id == "" {
+		// nothing of the name is usable, but different names must still
+		// get different IDs
+		return fmt.Sprintf("id-%x", sha256.Sum256([]byte(name)))[:11]
+	}
+	return id
+}
+
+func isWebProtocol(protocol Protocol) bool {
+	return protocol == ProtocolHTTP || protocol == ProtocolHTTPS
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
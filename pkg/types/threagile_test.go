@@ -0,0 +1,172 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAnalysisResults_ExportThreagile(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{
+				Type:         TrafficTypeIngress,
+				Protocol:     ProtocolHTTP,
+				ProcessName:  "web-server",
+				SourceFile:   "main.go",
+				SourceLine:   10,
+				Listen:       NewEndpoint("0.0.0.0", intPtr(8080)),
+				PatternMatch: "http.ListenAndServe",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTPS,
+				ProcessName:  "web-server",
+				SourceFile:   "client.go",
+				SourceLine:   20,
+				Destination:  NewEndpoint("api.example.com", intPtr(443)),
+				PatternMatch: "http.Get",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTPS,
+				ProcessName:  "web-server",
+				SourceFile:   "client.go",
+				SourceLine:   30,
+				Destination:  NewEndpoint("api.example.com", intPtr(443)),
+				PatternMatch: "http.Post",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolHTTP,
+				ProcessName:  "web-server",
+				SourceFile:   "probe.go",
+				SourceLine:   5,
+				Destination:  NewEndpoint("sidecar", intPtr(15021)),
+				PatternMatch: "http.Get",
+				Tags:         []string{TagHealthcheck},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "threagile"); err != nil {
+		t.Fatalf("Failed to export Threagile model: %v", err)
+	}
+
+	var model threagileModel
+	if err := yaml.Unmarshal(buf.Bytes(), &model); err != nil {
+		t.Fatalf("Failed to parse Threagile output: %v", err)
+	}
+
+	server, ok := model.TechnicalAssets["process-web-server"]
+	if !ok {
+		t.Fatalf("Expected technical asset for web-server, got %v", model.TechnicalAssets)
+	}
+	if server.Technology != "web-service-rest" {
+		t.Errorf("Expected web-service-rest technology, got %s", server.Technology)
+	}
+	if !containsString(server.Tags, "listens:http/8080") {
+		t.Errorf("Expected listener tag, got %v", server.Tags)
+	}
+	if len(server.CommunicationLinks) != 1 {
+		t.Fatalf("Expected 1 deduplicated communication link, got %d", len(server.CommunicationLinks))
+	}
+	for _, link := range server.CommunicationLinks {
+		if link.Target != "destination-api-example-com" {
+			t.Errorf("Expected link target destination-api-example-com, got %s", link.Target)
+		}
+		if link.Protocol != "https" || link.Usage != "business" {
+			t.Errorf("Expected an https business link, got %+v", link)
+		}
+	}
+
+	destination, ok := model.TechnicalAssets["destination-api-example-com"]
+	if !ok {
+		t.Fatal("Expected technical asset for egress destination")
+	}
+	if !destination.OutOfScope || destination.Type != "external-entity" {
+		t.Errorf("Expected out-of-scope external entity, got %+v", destination)
+	}
+	if _, ok := model.TechnicalAssets["destination-sidecar"]; ok {
+		t.Error("Expected health check egress to be left out of the model")
+	}
+	for id, asset := range model.TechnicalAssets {
+		if asset.Confidentiality == "" || asset.Integrity == "" || asset.Availability == "" {
+			t.Errorf("Expected CIA ratings on %s, got %+v", id, asset)
+		}
+	}
+}
+
+func TestAnalysisResults_ExportThreagileIDs(t *testing.T) {
+	results := AnalysisResults{
+		Sockets: []SocketInfo{
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolTCP,
+				ProcessName:  "cache",
+				Destination:  NewEndpoint("cache", intPtr(6379)),
+				PatternMatch: "net.Dial",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolTCP,
+				ProcessName:  "cache",
+				Destination:  NewEndpoint("***", intPtr(6380)),
+				PatternMatch: "net.Dial",
+			},
+			{
+				Type:         TrafficTypeEgress,
+				Protocol:     ProtocolTCP,
+				ProcessName:  "cache",
+				Destination:  NewEndpoint("+++", intPtr(6381)),
+				PatternMatch: "net.Dial",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "threagile"); err != nil {
+		t.Fatalf("Failed to export Threagile model: %v", err)
+	}
+	var model threagileModel
+	if err := yaml.Unmarshal(buf.Bytes(), &model); err != nil {
+		t.Fatalf("Failed to parse Threagile output: %v", err)
+	}
+
+	process, ok := model.TechnicalAssets["process-cache"]
+	if !ok || process.Type != "process" {
+		t.Fatalf("Expected the binary as a process asset, got %v", model.TechnicalAssets)
+	}
+	if destination, ok := model.TechnicalAssets["destination-cache"]; !ok || destination.Type != "external-entity" {
+		t.Errorf("Expected the host of the same name as a separate asset, got %v", model.TechnicalAssets)
+	}
+	if len(model.TechnicalAssets) != 4 || len(process.CommunicationLinks) != 3 {
+		t.Errorf("Expected a distinct asset and link per punctuation-only host, got %v", model.TechnicalAssets)
+	}
+	for id := range model.TechnicalAssets {
+		if strings.HasSuffix(id, "-") {
+			t.Errorf("Expected no asset ID with an empty name part, got %q", id)
+		}
+	}
+}
+
+func TestThreagileID(t *testing.T) {
+	tests := map[string]string{
+		"":                "unknown",
+		"web-server":      "web-server",
+		"API.example.com": "api-example-com",
+		"my_service":      "my-service",
+	}
+	if id := threagileID("***"); id == "" || id == threagileID("+++") {
+		t.Errorf("Expected distinct non-empty IDs for punctuation-only names, got %q and %q", id, threagileID("+++"))
+	}
+
+	for input, expected := range tests {
+		if got := threagileID(input); got != expected {
+			t.Errorf("threagileID(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
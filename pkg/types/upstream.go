@@ -0,0 +1,259 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// upstream is one destination a process sends egress to, as a sidecar
+// would need to reach it.
+type upstream struct {
+	name  string
+	host  string
+	port  int
+	tls   bool
+	http2 bool
+}
+
+// processUpstreams is the upstreams of one process, sorted by name, and
+// the number of its egress findings without a known host and port, or of
+// unknown protocol.
+type processUpstreams struct {
+	process         string
+	upstreams       []upstream
+	unresolved      int
+	unknownProtocol int
+}
+
+// upstreams collects the TCP egress destinations of each process, sorted
+// by process. Loopback and unix socket traffic, which a sidecar does not
+// carry, is left out, and egress of unknown protocol is counted. Each
+// destination is named <process>-<host>-<port> and uses TLS when any
+// finding for it does.
+func (r *AnalysisResults) upstreams() []processUpstreams {
+	byProcess := make(map[string]map[string]*upstream)
+	unresolved := make(map[string]int)
+	unknownProtocol := make(map[string]int)
+	for _, socket := range r.Sockets {
+		unknown := socket.Protocol == ProtocolUnknown
+		if socket.Type != TrafficTypeEgress || (socket.Protocol.Transport() != ProtocolTCP && !unknown) {
+			continue
+		}
+		endpoint := socket.Destination
+		if (endpoint != nil && endpoint.Path != "") || isLoopback(endpoint) {
+			continue
+		}
+		process := threagileID(socket.ProcessName)
+		if byProcess[process] == nil {
+			byProcess[process] = make(map[string]*upstream)
+		}
+		if unknown {
+			unknownProtocol[process]++
+			continue
+		}
+		if endpoint == nil || endpoint.Host == "" || endpoint.Port == nil {
+			unresolved[process]++
+			continue
+		}
+
+		host := strings.Trim(endpoint.Host, "[]")
+		name := threagileID(process + "-" + host + "-" + strconv.Itoa(*endpoint.Port))
+		u, ok := byProcess[process][name]
+		if !ok {
+			u = &upstream{name: name, host: host, port: *endpoint.Port}
+			byProcess[process][name] = u
+		}
+		u.tls = u.tls || socket.Protocol.Secure() || socket.VerifiesTLS != nil
+		u.http2 = u.http2 || socket.Protocol == ProtocolGRPC
+	}
+
+	processes := make([]processUpstreams, 0, len(byProcess))
+	for process, upstreams := range byProcess {
+		p := processUpstreams{process: process, unresolved: unresolved[process], unknownProtocol: unknownProtocol[process]}
+		for _, u := range upstreams {
+			p.upstreams = append(p.upstreams, *u)
+		}
+		sort.Slice(p.upstreams, func(i, j int) bool { return p.upstreams[i].name < p.upstreams[j].name })
+		processes = append(processes, p)
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].process < processes[j].process })
+	return processes
+}
+
+// envoyBootstrap is the subset of the Envoy v3 bootstrap schema the
+// cluster export needs.
+type envoyBootstrap struct {
+	StaticResources envoyStaticResources `yaml:"static_resources"`
+}
+
+type envoyStaticResources struct {
+	Clusters []envoyCluster `yaml:"clusters"`
+}
+
+type envoyCluster struct {
+	Name                          string                          `yaml:"name"`
+	Type                          string                          `yaml:"type"`
+	ConnectTimeout                string                          `yaml:"connect_timeout"`
+	LoadAssignment                envoyLoadAssignment             `yaml:"load_assignment"`
+	TransportSocket               *envoyTransportSocket           `yaml:"transport_socket,omitempty"`
+	TypedExtensionProtocolOptions map[string]envoyProtocolOptions `yaml:"typed_extension_protocol_options,omitempty"`
+}
+
+type envoyLoadAssignment struct {
+	ClusterName string                   `yaml:"cluster_name"`
+	Endpoints   []envoyLocalityEndpoints `yaml:"endpoints"`
+}
+
+type envoyLocalityEndpoints struct {
+	LbEndpoints []envoyLbEndpoint `yaml:"lb_endpoints"`
+}
+
+type envoyLbEndpoint struct {
+	Endpoint envoyEndpoint `yaml:"endpoint"`
+}
+
+type envoyEndpoint struct {
+	Address envoyAddress `yaml:"address"`
+}
+
+type envoyAddress struct {
+	SocketAddress envoySocketAddress `yaml:"socket_address"`
+}
+
+type envoySocketAddress struct {
+	Address   string `yaml:"address"`
+	PortValue int    `yaml:"port_value"`
+}
+
+type envoyTransportSocket struct {
+	Name        string         `yaml:"name"`
+	TypedConfig envoyTLSConfig `yaml:"typed_config"`
+}
+
+type envoyTLSConfig struct {
+	Type string `yaml:"@type"`
+	SNI  string `yaml:"sni,omitempty"`
+}
+
+type envoyProtocolOptions struct {
+	Type               string                  `yaml:"@type"`
+	ExplicitHTTPConfig envoyExplicitHTTPConfig `yaml:"explicit_http_config"`
+}
+
+type envoyExplicitHTTPConfig struct {
+	HTTP2ProtocolOptions struct{} `yaml:"http2_protocol_options"`
+}
+
+// exportEnvoyClusters writes, per process, the static clusters of an
+// Envoy sidecar reaching its egress destinations: DNS names are resolved
+// with STRICT_DNS and IPs are STATIC, TLS destinations get an upstream TLS
+// context with the host as SNI, and gRPC destinations speak HTTP/2.
+func (r *AnalysisResults) exportEnvoyClusters(writer io.Writer) error {
+	for i, p := range r.upstreams() {
+		if i > 0 {
+			if _, err := io.WriteString(writer, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(writer, "# process: %s\n", p.process); err != nil {
+			return err
+		}
+		if p.unresolved > 0 {
+			if _, err := fmt.Fprintf(writer, "# %d egress findings have no statically known destination\n", p.unresolved); err != nil {
+				return err
+			}
+		}
+		if p.unknownProtocol > 0 {
+			if _, err := fmt.Fprintf(writer, "# %d egress findings of unknown protocol are left out\n", p.unknownProtocol); err != nil {
+				return err
+			}
+		}
+
+		bootstrap := envoyBootstrap{StaticResources: envoyStaticResources{Clusters: []envoyCluster{}}}
+		for _, u := range p.upstreams {
+			cluster := envoyCluster{
+				Name:           u.name,
+				Type:           "STRICT_DNS",
+				ConnectTimeout: "5s",
+				LoadAssignment: envoyLoadAssignment{
+					ClusterName: u.name,
+					Endpoints: []envoyLocalityEndpoints{{LbEndpoints: []envoyLbEndpoint{{
+						Endpoint: envoyEndpoint{Address: envoyAddress{SocketAddress: envoySocketAddress{Address: u.host, PortValue: u.port}}},
+					}}}},
+				},
+			}
+			if IsIPLiteral(u.host) {
+				cluster.Type = "STATIC"
+			}
+			if u.tls {
+				cluster.TransportSocket = &envoyTransportSocket{
+					Name:        "envoy.transport_sockets.tls",
+					TypedConfig: envoyTLSConfig{Type: "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext"},
+				}
+				if !IsIPLiteral(u.host) {
+					cluster.TransportSocket.TypedConfig.SNI = u.host
+				}
+			}
+			if u.http2 {
+				cluster.TypedExtensionProtocolOptions = map[string]envoyProtocolOptions{
+					"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": {Type: "type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions"},
+				}
+			}
+			bootstrap.StaticResources.Clusters = append(bootstrap.StaticResources.Clusters, cluster)
+		}
+
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(bootstrap); err != nil {
+			return fmt.Errorf("encoding envoy clusters of %s: %w", p.process, err)
+		}
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportNginx writes an Nginx upstream block per egress destination of
+// each process, with the directive that proxies to it as a comment:
+// proxy_pass, or grpc_pass for gRPC, over https or grpcs with the host as
+// TLS server name where the destination uses TLS.
+func (r *AnalysisResults) exportNginx(writer io.Writer) error {
+	var b strings.Builder
+	for i, p := range r.upstreams() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# process: %s\n", p.process)
+		if p.unresolved > 0 {
+			fmt.Fprintf(&b, "# %d egress findings have no statically known destination\n", p.unresolved)
+		}
+		if p.unknownProtocol > 0 {
+			fmt.Fprintf(&b, "# %d egress findings of unknown protocol are left out\n", p.unknownProtocol)
+		}
+		for _, u := range p.upstreams {
+			pass, scheme := "proxy_pass", "http"
+			if u.http2 {
+				pass, scheme = "grpc_pass", "grpc"
+			}
+			if u.tls {
+				scheme += "s"
+			}
+			fmt.Fprintf(&b, "# %s %s://%s;", pass, scheme, u.name)
+			if u.tls && !IsIPLiteral(u.host) {
+				prefix := strings.TrimSuffix(pass, "_pass")
+				fmt.Fprintf(&b, " %s_ssl_server_name on; %s_ssl_name %s;", prefix, prefix, u.host)
+			}
+			b.WriteString("\n")
+			fmt.Fprintf(&b, "upstream %s {\n    server %s;\n}\n", u.name, net.JoinHostPort(u.host, strconv.Itoa(u.port)))
+		}
+	}
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func upstreamResults() AnalysisResults {
+	verifies := false
+	return AnalysisResults{
+		Sockets: []SocketInfo{
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTPS, ProcessName: "api", Destination: NewEndpoint("payments.internal", intPtr(443))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", Destination: NewEndpoint("10.0.0.9", intPtr(8080))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolGRPC, ProcessName: "api", Destination: NewEndpoint("ledger", intPtr(50051))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("ledger", intPtr(50051)), VerifiesTLS: &verifies},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "api", Destination: NewEndpoint("localhost", intPtr(6379))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolUnix, ProcessName: "api", Destination: NewPathEndpoint("/var/run/docker.sock")},
+			{Type: TrafficTypeEgress, Protocol: ProtocolHTTP, ProcessName: "api", RawValue: "cfg.URL"},
+			{Type: TrafficTypeIngress, Protocol: ProtocolHTTP, ProcessName: "api", Listen: NewEndpoint("0.0.0.0", intPtr(8080))},
+			{Type: TrafficTypeEgress, Protocol: ProtocolTCP, ProcessName: "Worker", Destination: NewEndpoint("redis", intPtr(6379))},
+		},
+	}
+}
+
+func TestAnalysisResults_ExportEnvoyClusters(t *testing.T) {
+	results := upstreamResults()
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "envoy-clusters"); err != nil {
+		t.Fatalf("Failed to export envoy clusters: %v", err)
+	}
+
+	documents := strings.Split(buf.String(), "---\n")
+	if len(documents) != 2 || !strings.HasPrefix(documents[0], "# process: api\n# 1 egress findings") || !strings.HasPrefix(documents[1], "# process: worker\n") {
+		t.Fatalf("Expected a document per process, got:\n%s", buf.String())
+	}
+	var api envoyBootstrap
+	if err := yaml.Unmarshal([]byte(documents[0]), &api); err != nil {
+		t.Fatalf("Failed to parse clusters: %v", err)
+	}
+	clusters := api.StaticResources.Clusters
+	if len(clusters) != 3 {
+		t.Fatalf("Expected 3 clusters, got %+v", clusters)
+	}
+
+	ip, ledger, payments := clusters[0], clusters[1], clusters[2]
+	if ip.Name != "api-10-0-0-9-8080" || ip.Type != "STATIC" || ip.TransportSocket != nil {
+		t.Errorf("Unexpected IP cluster: %+v", ip)
+	}
+	if ledger.Name != "api-ledger-50051" || ledger.Type != "STRICT_DNS" || ledger.TransportSocket == nil || len(ledger.TypedExtensionProtocolOptions) != 1 {
+		t.Errorf("Expected an HTTP/2 TLS cluster for ledger, got %+v", ledger)
+	}
+	address := payments.LoadAssignment.Endpoints[0].LbEndpoints[0].Endpoint.Address.SocketAddress
+	if address.Address != "payments.internal" || address.PortValue != 443 || payments.TransportSocket.TypedConfig.SNI != "payments.internal" {
+		t.Errorf("Unexpected payments cluster: %+v", payments)
+	}
+}
+
+func TestAnalysisResults_ExportNginx(t *testing.T) {
+	results := upstreamResults()
+	var buf bytes.Buffer
+	if err := results.Export(&buf, "nginx"); err != nil {
+		t.Fatalf("Failed to export nginx upstreams: %v", err)
+	}
+
+	expected := `# process: api
+# 1 egress findings have no statically known destination
+# proxy_pass http://api-10-0-0-9-8080;
+upstream api-10-0-0-9-8080 {
+    server 10.0.0.9:8080;
+}
+# grpc_pass grpcs://api-ledger-50051; grpc_ssl_server_name on; grpc_ssl_name ledger;
+upstream api-ledger-50051 {
+    server ledger:50051;
+}
+# proxy_pass https://api-payments-internal-443; proxy_ssl_server_name on; proxy_ssl_name payments.internal;
+upstream api-payments-internal-443 {
+    server payments.internal:443;
+}
+
+# process: worker
+# proxy_pass http://worker-redis-6379;
+upstream worker-redis-6379 {
+    server redis:6379;
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected nginx upstreams:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yuvalk/staticsocket/pkg/policy"
+	"github.com/yuvalk/staticsocket/pkg/types"
+)
+
+// readPolicy reads the policy file at path.
+func readPolicy(path string) (*policy.Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy: %w", err)
+	}
+	defer file.Close()
+	p, err := policy.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -policy file %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// checkPolicy writes the findings of results that p does not allow to
+// output and returns how many there are.
+func checkPolicy(p *policy.Policy, results *types.AnalysisResults, output io.Writer) (int, error) {
+	if results.Truncated {
+		return 0, fmt.Errorf("results are truncated to %d findings; rerun with a higher -max-findings to check them against the policy", results.Truncation.MaxFindings)
+	}
+	violations := p.Check(results)
+	for _, violation := range violations {
+		fmt.Fprintf(output, "policy violation: %s\n", violation)
+	}
+	return len(violations), nil
+}
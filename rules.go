@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/yuvalk/staticsocket/pkg/patterns"
+)
+
+// runPatterns handles "patterns doc", which writes the documentation of
+// every rule, including those of a -patterns file, as Markdown or HTML.
+func runPatterns(args []string, output io.Writer) error {
+	if len(args) == 0 || args[0] != "doc" {
+		return errors.New("usage: staticsocket patterns doc [-format markdown|html] [-patterns FILE] [-output FILE]")
+	}
+	fs := flag.NewFlagSet("staticsocket patterns doc", flag.ExitOnError)
+	format := fs.String("format", "markdown", "Documentation format: markdown, html")
+	patternFile := fs.String("patterns", "", "YAML or JSON file of additional patterns to document")
+	outputFile := fs.String("output", "", "Output file (default: stdout)")
+	_ = fs.Parse(args[1:])
+
+	pm := patterns.NewPatternMatcher()
+	if *patternFile != "" {
+		custom, err := readPatterns(*patternFile)
+		if err != nil {
+			return err
+		}
+		for _, p := range custom {
+			if err := pm.AddCustomPattern(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *outputFile != "" {
+		file, err := os.Create(*outputFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+	return patterns.WriteRuleDocs(output, pm.RuleDocs(), *format)
+}
@@ -0,0 +1,39 @@
+package main
+
+// Functions and methods that share their names with socket APIs but are
+// declared locally.
+
+type cache struct{}
+
+func (c *cache) Get(key string) string     { return key }
+func (c *cache) Listen(channel string)     {}
+func (c *cache) Dial(network, addr string) {}
+
+func Dial(network, address string) error   { return nil }
+func Listen(network, address string) error { return nil }
+func Get(url string) string                { return url }
+
+type fakeHTTP struct{}
+
+func (fakeHTTP) Get(url string) (string, error)                        { return url, nil }
+func (fakeHTTP) ListenAndServe(addr string, handler interface{}) error { return nil }
+
+func main() {
+	Dial("tcp", "db.internal:5432")
+	Listen("tcp", ":8080")
+	Get("http://example.com")
+
+	c := &cache{}
+	c.Get("http://example.com")
+	c.Listen(":8080")
+	c.Dial("tcp", "db.internal:5432")
+
+	// A local variable shadowing a package name is not the package.
+	http := fakeHTTP{}
+	http.Get("http://example.com")
+	http.ListenAndServe(":8080", nil)
+
+	net := &cache{}
+	net.Dial("tcp", "db.internal:5432")
+	net.Listen(":9090")
+}
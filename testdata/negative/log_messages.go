@@ -0,0 +1,36 @@
+package main
+
+// Addresses that only ever appear in strings, comments and unused values.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Call net.Listen("tcp", ":8080") to start serving, or
+// http.ListenAndServe(":8080", nil) for plain HTTP.
+const defaultAddr = ":8080"
+
+var docs = `net.Dial("tcp", "db.internal:5432")`
+
+type settings struct {
+	Addr               string
+	Port               int
+	MetricsBindAddress string
+	Endpoints          []string
+}
+
+func main() {
+	log.Printf("listening on %s", defaultAddr)
+	log.Println("connecting to db.internal:5432")
+	fmt.Printf("dial tcp %s:%d: connection refused\n", "db.internal", 5432)
+	fmt.Println("see http://localhost:8080/debug/pprof")
+
+	err := errors.New("Get \"http://example.com\": dial tcp: lookup example.com: no such host")
+	log.Print(err)
+
+	s := settings{Addr: ":8080", Port: 9443, Endpoints: []string{"etcd:2379"}}
+	s.MetricsBindAddress = ":8080"
+	fmt.Println(s, docs)
+}
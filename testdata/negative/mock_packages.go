@@ -0,0 +1,30 @@
+package main
+
+// Test doubles and in-house packages whose names collide with the
+// standard library or well-known clients.
+
+import (
+	"example.com/app/internal/http"
+	"example.com/app/internal/net/v2"
+	client "example.com/app/internal/storage/client"
+	rest "example.com/app/testing/fakerest"
+	"example.com/app/testing/mocks/clientv3"
+)
+
+func main() {
+	http.ListenAndServe(":8080", nil)
+	http.Get("http://example.com")
+	http.Post("http://example.com", "application/json", nil)
+
+	net.Listen("tcp", ":8080")
+	net.Dial("tcp", "db.internal:5432")
+	net.DialTimeout("tcp", "db.internal:5432", 0)
+
+	client.NewClientWithOpts(client.FromEnv)
+	client.NewEnvClient()
+
+	rest.InClusterConfig()
+	_ = rest.Config{Host: "https://10.0.0.1:6443"}
+
+	_ = clientv3.Config{Endpoints: []string{"etcd:2379"}}
+}